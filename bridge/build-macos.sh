@@ -29,8 +29,8 @@ cat <<EOF > "$CONTENTS_DIR/Info.plist"
 EOF
 
 echo "Building Universal macOS Binary..."
-CGO_ENABLED=1 GOOS=darwin GOARCH=amd64 go build -o graham-bridge-amd64 .
-CGO_ENABLED=1 GOOS=darwin GOARCH=arm64 go build -o graham-bridge-arm64 .
+CGO_ENABLED=1 GOOS=darwin GOARCH=amd64 go build -o graham-bridge-amd64 ./cmd/bridge
+CGO_ENABLED=1 GOOS=darwin GOARCH=arm64 go build -o graham-bridge-arm64 ./cmd/bridge
 
 # Use lipo to create a universal binary if available, otherwise fallback to arm64
 if command -v lipo >/dev/null 2>&1; then
@@ -4,22 +4,22 @@ Write-Host "Building for Windows (amd64)..."
 $env:GOOS = "windows"
 $env:GOARCH = "amd64"
 # -H windowsgui suppresses the console window so only the systray icon appears
-go build -ldflags="-H windowsgui" -o graham-bridge-windows-amd64.exe .
+go build -ldflags="-H windowsgui" -o graham-bridge-windows-amd64.exe ./cmd/bridge
 
 Write-Host "Building for Linux (amd64)..."
 $env:GOOS = "linux"
 $env:GOARCH = "amd64"
-go build -o graham-bridge-linux-amd64 .
+go build -o graham-bridge-linux-amd64 ./cmd/bridge
 
 Write-Host "Building for macOS (amd64)..."
 $env:GOOS = "darwin"
 $env:GOARCH = "amd64"
-go build -o graham-bridge-darwin-amd64 .
+go build -o graham-bridge-darwin-amd64 ./cmd/bridge
 
 Write-Host "Building for macOS (arm64)..."
 $env:GOOS = "darwin"
 $env:GOARCH = "arm64"
-go build -o graham-bridge-darwin-arm64 .
+go build -o graham-bridge-darwin-arm64 ./cmd/bridge
 
 Write-Host "Done!"
 # Reset env vars to not pollute
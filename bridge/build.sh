@@ -5,17 +5,17 @@ echo "Building Graham Bridge..."
 
 # Build for Windows
 echo "Building for Windows (amd64)..."
-GOOS=windows GOARCH=amd64 go build -ldflags -H=windowsgui -o graham-bridge-windows-amd64.exe .
+GOOS=windows GOARCH=amd64 go build -ldflags -H=windowsgui -o graham-bridge-windows-amd64.exe ./cmd/bridge
 
 # Build for Linux
 echo "Building for Linux (amd64)..."
-GOOS=linux GOARCH=amd64 go build -o graham-bridge-linux-amd64 .
+GOOS=linux GOARCH=amd64 go build -o graham-bridge-linux-amd64 ./cmd/bridge
 
 # Build for macOS (Intel and Apple Silicon)
 echo "Building for macOS (amd64)..."
-GOOS=darwin GOARCH=amd64 go build -o graham-bridge-darwin-amd64 .
+GOOS=darwin GOARCH=amd64 go build -o graham-bridge-darwin-amd64 ./cmd/bridge
 
 echo "Building for macOS (arm64)..."
-GOOS=darwin GOARCH=arm64 go build -o graham-bridge-darwin-arm64 .
+GOOS=darwin GOARCH=arm64 go build -o graham-bridge-darwin-arm64 ./cmd/bridge
 
 echo "Done!"
@@ -0,0 +1,358 @@
+// Package client talks to a Graham Braille Writer bridge over its HTTP API.
+//
+// District tools written in Go use it to list embossers, submit BRF jobs
+// and follow them as they print, instead of each reimplementing the
+// protocol:
+//
+//	c := client.New("", os.Getenv("BRIDGE_TOKEN"))
+//	res, err := c.Print(ctx, client.PrintRequest{Printer: "Tiger", Data: brf})
+//	var perr *client.Error
+//	if errors.As(err, &perr) && perr.Code == client.CodePrinterNotFound { … }
+//
+// Calls are retried while the bridge is unreachable (still starting, or
+// restarting after a config change) and on responses that say nothing was
+// done yet, such as 503 from a print service that is down. A job the
+// bridge accepted is never submitted twice. Every attempt of one call
+// carries the same X-Request-ID, so the bridge's log ties them together.
+//
+// The client needs no packages outside the standard library and does not
+// import the bridge, so it can be vendored on its own.
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultURL is where a bridge listens unless station mode moves it.
+const DefaultURL = "http://127.0.0.1:8080"
+
+// Client calls one bridge. The zero value is not usable; use New.
+type Client struct {
+	BaseURL string       // bridge address, e.g. DefaultURL
+	Token   string       // API token sent as a bearer token, if any
+	HTTP    *http.Client // transport; http.DefaultClient if nil
+
+	// Retries is how many more times a call is tried after a transient
+	// failure, waiting Backoff, then twice as long, and so on.
+	Retries int
+	Backoff time.Duration
+}
+
+// New returns a client for the bridge at baseURL (DefaultURL if empty)
+// that authenticates with token, if not empty.
+func New(baseURL, token string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultURL
+	}
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Token:   token,
+		Retries: 3,
+		Backoff: 500 * time.Millisecond,
+	}
+}
+
+// Status is the GET /status response.
+type Status struct {
+	Status         string `json:"status"` // "ok"
+	App            string `json:"app"`
+	Version        string `json:"version"`
+	JobSchema      int    `json:"job_schema"`
+	JobSchemas     []int  `json:"job_schemas"`
+	MaxUploadBytes int    `json:"max_upload_bytes"`
+}
+
+// Status checks that the bridge is running and returns its version.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	var s Status
+	err := c.do(ctx, http.MethodGet, "/status", nil, &s, retryRead)
+	return s, err
+}
+
+// Printers lists the destination names the bridge can print to: print
+// queues, direct destinations and pools.
+func (c *Client) Printers(ctx context.Context) ([]string, error) {
+	var names []string
+	err := c.do(ctx, http.MethodGet, "/printers", nil, &names, retryRead)
+	return names, err
+}
+
+// Features is the GET /features response: the optional parts of the
+// bridge, overall and per configured printer.
+type Features struct {
+	Version  string              `json:"version"`
+	Features map[string]bool     `json:"features"`
+	Printers map[string][]string `json:"printers"`
+}
+
+// Features reports which optional parts the bridge has, such as "pef" or
+// "eight_dot". Bridges older than the endpoint answer with a 404 *Error.
+func (c *Client) Features(ctx context.Context) (Features, error) {
+	var f Features
+	err := c.do(ctx, http.MethodGet, "/features", nil, &f, retryRead)
+	return f, err
+}
+
+// DefaultPrinter returns the printer used for requests that name none, or
+// "" if none is set.
+func (c *Client) DefaultPrinter(ctx context.Context) (string, error) {
+	var resp struct {
+		Printer string `json:"printer"`
+	}
+	err := c.do(ctx, http.MethodGet, "/printers/default", nil, &resp, retryRead)
+	return resp.Printer, err
+}
+
+// Companion is an extra output made from the same print request, such as
+// a SimBraille proof or an ink copy.
+type Companion struct {
+	Printer string `json:"printer"`
+	Copy    string `json:"copy,omitempty"` // "print_text" to send the ink text instead
+}
+
+// PrintRequest is a job to submit. Only Data is required; an empty
+// Printer uses the bridge's default printer.
+type PrintRequest struct {
+	Printer     string
+	Data        []byte // BRF
+	Student     string // student identifier for reports
+	Urgent      bool   // print even during quiet hours
+	Media       string // media setting from the printer's profile
+	Dots        int    // 6 (default) or 8
+	LineSpacing string // "single" (default), "double" or "interline"
+	PrintText   string // ink-print version, line for line
+	Also        []Companion
+	Confirm     string // Estimate.Confirm, to print a job the bridge asked to confirm
+}
+
+// MarshalJSON encodes r as the /print request body.
+func (r PrintRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Printer     string      `json:"printer,omitempty"`
+		Data        []byte      `json:"data"` // base64, as /print expects
+		Student     string      `json:"student,omitempty"`
+		Urgent      bool        `json:"urgent,omitempty"`
+		Media       string      `json:"media,omitempty"`
+		Dots        int         `json:"dots,omitempty"`
+		LineSpacing string      `json:"line_spacing,omitempty"`
+		PrintText   string      `json:"print_text,omitempty"`
+		Also        []Companion `json:"also,omitempty"`
+		Confirm     string      `json:"confirm,omitempty"`
+	}{r.Printer, r.Data, r.Student, r.Urgent, r.Media, r.Dots, r.LineSpacing, r.PrintText, r.Also, r.Confirm})
+}
+
+// Print job outcomes reported in PrintResult.Status.
+const (
+	StatusQueued   = "queued"   // sent to the printer
+	StatusAccepted = "accepted" // still queued or printing; follow it with Watch
+	StatusHeld     = "held"     // waiting for a teacher's release or the end of quiet hours
+	StatusWaiting  = "waiting"  // the printer is unreachable; sent when it comes back
+	StatusStuck    = "stuck"    // the print service has it but the embosser has not started
+)
+
+// PrintResult is the bridge's answer to a job it took.
+type PrintResult struct {
+	Status   string    `json:"status"`
+	ID       int       `json:"id"`     // job ID, 0 for a job sent at once
+	Reason   string    `json:"reason"` // why a job is held or waiting
+	Until    time.Time `json:"until"`  // end of quiet hours for a held job
+	Error    string    `json:"error"`  // for a stuck job
+	Code     string    `json:"error_code"`
+	Guidance string    `json:"guidance"`
+}
+
+// Print submits a job and waits while the bridge does. Refused and failed
+// jobs are returned as an *Error.
+func (c *Client) Print(ctx context.Context, req PrintRequest) (PrintResult, error) {
+	if len(req.Data) == 0 {
+		return PrintResult{}, errors.New("client: print request has no data")
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return PrintResult{}, err
+	}
+	var res PrintResult
+	err = c.do(ctx, http.MethodPost, "/print", body, &res, retryUnsent)
+	return res, err
+}
+
+// Job is a job record as the bridge reports it. Fields the bridge adds
+// later are ignored.
+type Job struct {
+	ID          int       `json:"id"`
+	Time        time.Time `json:"time"`
+	Printer     string    `json:"printer"`
+	Status      string    `json:"status"` // queued, printing, done, failed, held, cancelled, stuck, waiting
+	Bytes       int       `json:"bytes"`
+	Pages       int       `json:"pages"`
+	PagesSent   int       `json:"pages_sent"`
+	Student     string    `json:"student"`
+	App         string    `json:"app"` // application whose token sent it
+	Error       string    `json:"error"`
+	Code        string    `json:"error_code"`
+	Guidance    string    `json:"guidance"`
+	Attempts    int       `json:"attempts"`
+	RequestID   string    `json:"request_id"`
+	HoldReason  string    `json:"hold_reason"`
+	LinkedTo    int       `json:"linked_to"`
+	Linked      []int     `json:"linked"`
+	Estimate    int       `json:"estimated_seconds"`
+	ResentFrom  int       `json:"resent_from"`
+	PageRange   string    `json:"page_range"`
+	Interlined  bool      `json:"interlined"`
+	LineSpacing string    `json:"line_spacing"`
+
+	Annotations []Annotation  `json:"annotations"`
+	Verified    *Verification `json:"verified"` // nil until someone checks the output
+}
+
+// Verification records who checked a job's embossed output, and when.
+type Verification struct {
+	Time time.Time `json:"time"`
+	By   string    `json:"by"`
+}
+
+// Annotation is a note added to a finished job.
+type Annotation struct {
+	Time time.Time `json:"time"`
+	By   string    `json:"by"`
+	Text string    `json:"text"`
+}
+
+// Finished reports whether j has reached a final state.
+func (j Job) Finished() bool {
+	switch j.Status {
+	case "done", "failed", "cancelled":
+		return true
+	}
+	return false
+}
+
+// Jobs returns the job history, oldest first.
+func (c *Client) Jobs(ctx context.Context) ([]Job, error) {
+	var resp struct {
+		Jobs []Job `json:"jobs"`
+	}
+	err := c.do(ctx, http.MethodGet, "/jobs", nil, &resp, retryRead)
+	return resp.Jobs, err
+}
+
+// Annotate adds a note to a finished job, such as "delivered to room 12".
+// A job still printing is refused with a 409 *Error.
+func (c *Client) Annotate(ctx context.Context, id int, text string) (Annotation, error) {
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return Annotation{}, err
+	}
+	var a Annotation
+	err = c.do(ctx, http.MethodPost, fmt.Sprintf("/jobs/%d/annotations", id), body, &a, retryUnsent)
+	return a, err
+}
+
+// Verify records that someone checked job id's embossed pages. Only jobs
+// that embossed can be verified; others are refused with a 409 *Error.
+func (c *Client) Verify(ctx context.Context, id int) (Job, error) {
+	var j Job
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/jobs/%d/verify", id), nil, &j, retryRead)
+	return j, err
+}
+
+// Retry policies for do.
+const (
+	retryRead   = iota // any transport failure or 5xx: the call changes nothing
+	retryUnsent        // only failures that show the call was not acted on
+)
+
+// do sends a request, retrying transient failures, and decodes a 2xx JSON
+// response into out.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, out any, policy int) error {
+	reqID := newRequestID()
+	wait := c.Backoff
+	for attempt := 0; ; attempt++ {
+		err := c.once(ctx, method, path, body, out, reqID)
+		if err == nil || attempt >= c.Retries || !retryable(err, policy) {
+			return err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+		wait *= 2
+	}
+}
+
+func (c *Client) once(ctx context.Context, method, path string, body []byte, out any, reqID string) error {
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := c.newRequest(ctx, method, path, rd, reqID)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return &netError{err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return &netError{err}
+	}
+	if resp.StatusCode/100 != 2 {
+		return responseError(resp, data)
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("client: %s %s: bad response: %w", method, path, err)
+	}
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader, reqID string) (*http.Request, error) {
+	u, err := url.Parse(c.BaseURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Request-ID", reqID)
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTP != nil {
+		return c.HTTP
+	}
+	return http.DefaultClient
+}
+
+// newRequestID returns an ID in the form the bridge accepts.
+func newRequestID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "go-" + hex.EncodeToString(b)
+}
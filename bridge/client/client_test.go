@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPrintRetriesAndErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "token required", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-ID", r.Header.Get("X-Request-ID"))
+		switch n := calls.Add(1); {
+		case n == 1: // the print service is still starting
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"status":"rejected","error":"cupsd not running","error_code":"spooler_unavailable"}`)
+		case n == 2:
+			w.WriteHeader(http.StatusAccepted)
+			fmt.Fprint(w, `{"status":"accepted","id":7}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"status":"rejected","error":"no such printer","error_code":"printer_not_found","guidance":"Choose again."}`)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "tok")
+	c.Backoff = time.Millisecond
+	res, err := c.Print(context.Background(), PrintRequest{Printer: "Tiger", Data: []byte("ABC\f")})
+	if err != nil || res.Status != StatusAccepted || res.ID != 7 || calls.Load() != 2 {
+		t.Fatalf("Print = %+v, %v after %d calls", res, err, calls.Load())
+	}
+
+	_, err = c.Print(context.Background(), PrintRequest{Printer: "Gone", Data: []byte("A")})
+	var perr *Error
+	if !errors.As(err, &perr) || perr.Code != CodePrinterNotFound || perr.StatusCode != http.StatusNotFound ||
+		perr.Guidance == "" || perr.RequestID == "" {
+		t.Fatalf("Print to a missing printer: %#v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("a refused job was retried: %d calls", calls.Load())
+	}
+
+	_, err = New(srv.URL, "").Printers(context.Background())
+	if !errors.As(err, &perr) || !perr.Unauthorized() {
+		t.Errorf("Printers without a token: %v", err)
+	}
+}
+
+func TestWatchResumes(t *testing.T) {
+	var conns atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch conns.Add(1) {
+		case 1:
+			fmt.Fprint(w, "retry: 1\n\nid: 1-1\nevent: reset\ndata: {}\n\nid: 1-2\ndata: {\"id\":3,\"status\":\"printing\"}\n\n")
+		default: // the first connection dropped
+			if got := r.Header.Get("Last-Event-ID"); got != "1-2" {
+				t.Errorf("resumed from %q, want 1-2", got)
+			}
+			fmt.Fprint(w, "id: 1-3\nevent: progress\ndata: {\"job_id\":3,\"sent\":5,\"total\":10}\n\n"+
+				"id: 1-4\ndata: {\"id\":3,\"status\":\"done\"}\n\n")
+		}
+	}))
+	defer srv.Close()
+
+	var names []string
+	err := New(srv.URL, "").Watch(context.Background(), func(ev Event) error {
+		names = append(names, ev.Name)
+		if p, ok := ev.Progress(); ok && p.Sent != 5 {
+			t.Errorf("progress = %+v", p)
+		}
+		if j, ok := ev.Job(); ok && j.Finished() {
+			return ErrStopWatching
+		}
+		return nil
+	})
+	if err != nil || fmt.Sprint(names) != "[reset  progress ]" {
+		t.Errorf("Watch = %v, events %q", err, names)
+	}
+}
+
+func TestPrintersAndQueuedPrint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/printers":
+			fmt.Fprint(w, `["Mock Everest", "Office Laser"]`)
+		case "/print":
+			var req struct {
+				Printer string `json:"printer"`
+				Data    []byte `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Printer != "Mock Everest" || string(req.Data) != "ABC\f" {
+				t.Errorf("print request %+v, %v", req, err)
+			}
+			fmt.Fprint(w, `{"status":"queued"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "")
+	ctx := context.Background()
+	if names, err := c.Printers(ctx); err != nil || !slices.Contains(names, "Mock Everest") {
+		t.Fatalf("Printers = %v, %v", names, err)
+	}
+	if res, err := c.Print(ctx, PrintRequest{Printer: "Mock Everest", Data: []byte("ABC\f")}); err != nil || res.Status != StatusQueued {
+		t.Errorf("Print = %+v, %v", res, err)
+	}
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Failure classes the bridge reports in Error.Code.
+const (
+	CodeTimeout         = "timeout"                 // a stage took longer than its configured timeout
+	CodePrinterNotFound = "printer_not_found"       // no such printer or queue
+	CodeNotAccepting    = "printer_not_accepting"   // the queue is paused or rejecting jobs
+	CodeSpooler         = "spooler_unavailable"     // CUPS or the Windows spooler is not running
+	CodePermission      = "permission_denied"       // the bridge may not use the printer or device
+	CodeDevice          = "device_unavailable"      // a serial or USB device is missing or busy
+	CodeStuck           = "job_stuck"               // accepted by the print service but not started
+	CodeHookRejected    = "hook_rejected"           // a configured pre hook refused the job
+	CodeAttention       = "printer_needs_attention" // offline, out of paper, jammed or open
+	CodeQuotaExceeded   = "quota_exceeded"          // the application's daily cap is used up
+	CodeConfirm         = "confirmation_required"   // a large job: see Error.Estimate
+	CodePrintFailed     = "print_failed"            // anything else
+)
+
+// Error is a request the bridge refused or a job that failed.
+type Error struct {
+	StatusCode int    // HTTP status
+	Status     string // "rejected" or "failed" for print jobs, else ""
+	Code       string // failure class (the Code constants), "" if the bridge gave none
+	Message    string // the bridge's message
+	Guidance   string // plain-language advice for Code
+	JobID      int    // the job, if one was recorded
+	RequestID  string // X-Request-ID to quote when reporting the problem
+
+	// Estimate is set for CodeConfirm: what the job would take. Send the
+	// request again with PrintRequest.Confirm = Estimate.Confirm to print it.
+	Estimate *Estimate
+}
+
+// Estimate describes a job over the bridge's confirm_pages.
+type Estimate struct {
+	Printer    string `json:"printer"`
+	Pages      int    `json:"pages"`
+	Sheets     int    `json:"sheets"`
+	Interpoint bool   `json:"interpoint"`
+	Seconds    int    `json:"estimated_seconds"` // 0 if unknown
+	Confirm    string `json:"confirm"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("bridge: %s (%s)", msg, e.Code)
+	}
+	return fmt.Sprintf("bridge: %d %s", e.StatusCode, msg)
+}
+
+// Unauthorized reports whether the call needs a token, or a token with a
+// wider scope.
+func (e *Error) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden && e.Code == ""
+}
+
+// responseError builds an *Error from a non-2xx response. Classified
+// failures are JSON; other refusals are plain text.
+func responseError(resp *http.Response, body []byte) *Error {
+	e := &Error{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+	var j struct {
+		Status   string `json:"status"`
+		Error    string `json:"error"`
+		Code     string `json:"error_code"`
+		Guidance string `json:"guidance"`
+		ID       int    `json:"id"`
+		Estimate
+	}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") && json.Unmarshal(body, &j) == nil {
+		e.Status, e.Message, e.Code, e.Guidance, e.JobID = j.Status, j.Error, j.Code, j.Guidance, j.ID
+		if j.Code == CodeConfirm {
+			e.Estimate = &j.Estimate
+		}
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	return e
+}
+
+// netError is a request that got no response.
+type netError struct{ err error }
+
+func (e *netError) Error() string { return "bridge unreachable: " + e.err.Error() }
+func (e *netError) Unwrap() error { return e.err }
+
+// retryable reports whether a call that failed with err may be tried
+// again under policy.
+func retryable(err error, policy int) bool {
+	var ne *netError
+	if errors.As(err, &ne) {
+		// A read changes nothing. A print may have reached the bridge
+		// unless the connection was never made.
+		return policy == retryRead || isDialError(ne.err)
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusServiceUnavailable, http.StatusTooManyRequests:
+		// Refused before a job was recorded (print service down).
+		return e.JobID == 0
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return policy == retryRead
+	}
+	return false
+}
+
+// isDialError reports whether err happened while connecting, before any
+// of the request was sent.
+func isDialError(err error) bool {
+	var op *net.OpError
+	return errors.As(err, &op) && op.Op == "dial"
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is one message from the bridge's live event stream
+// (GET /log-stream).
+type Event struct {
+	ID   string          // position in the stream, for resuming
+	Name string          // "" for a job record; progress, retry, printer, paper, purge, reset, ui
+	Data json.RawMessage // the event's JSON
+}
+
+// Job decodes a job record event. It reports false for named events.
+func (e Event) Job() (Job, bool) {
+	var j Job
+	if e.Name != "" || json.Unmarshal(e.Data, &j) != nil {
+		return Job{}, false
+	}
+	return j, true
+}
+
+// Progress is the "progress" event: how much of a job has been written.
+type Progress struct {
+	JobID            int    `json:"job_id"`
+	Printer          string `json:"printer"`
+	Sent             int    `json:"sent"`
+	Total            int    `json:"total"`
+	Page             int    `json:"page"`
+	Pages            int    `json:"pages"`
+	RemainingSeconds int    `json:"remaining_seconds"`
+}
+
+// Progress decodes a "progress" event.
+func (e Event) Progress() (Progress, bool) {
+	var p Progress
+	if e.Name != "progress" || json.Unmarshal(e.Data, &p) != nil {
+		return Progress{}, false
+	}
+	return p, true
+}
+
+// ErrStopWatching may be returned by a Watch callback to end the watch
+// without an error.
+var ErrStopWatching = errors.New("client: stop watching")
+
+// Watch follows the event stream, calling fn for every event in order
+// until ctx is done or fn returns an error. On connecting it first gets a
+// "reset" event and every job in the history. When the connection drops
+// it reconnects and resumes after the last event, or, if the bridge has
+// restarted, gets "reset" and the history again. Watch returns nil if fn
+// returns ErrStopWatching, and otherwise fn's error, ctx's error or an
+// *Error the bridge answered with.
+func (c *Client) Watch(ctx context.Context, fn func(Event) error) error {
+	lastID := ""
+	delay := 3 * time.Second
+	for failures := 0; ; {
+		got, err := c.stream(ctx, lastID, &delay, func(ev Event) error {
+			if ev.ID != "" {
+				lastID = ev.ID
+			}
+			return fn(ev)
+		})
+		switch {
+		case errors.Is(err, ErrStopWatching):
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && !retryable(err, retryRead):
+			return err
+		}
+		if got {
+			failures = 0
+		} else if failures++; failures > c.Retries {
+			return err
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// stream reads one connection of the event stream. It reports whether any
+// event arrived, and returns nil when the bridge closed the stream.
+func (c *Client) stream(ctx context.Context, lastID string, delay *time.Duration, fn func(Event) error) (bool, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/log-stream?schema=2", nil, newRequestID())
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return false, &netError{err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body := make([]byte, 4096)
+		n, _ := resp.Body.Read(body)
+		return false, responseError(resp, body[:n])
+	}
+
+	got := false
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64<<10), 16<<20) // job records carry the BRF text
+	var ev Event
+	var data []string
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if data != nil {
+				ev.Data = json.RawMessage(strings.Join(data, "\n"))
+				got = true
+				if err := fn(ev); err != nil {
+					return got, err
+				}
+			}
+			ev, data = Event{}, nil
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			ev.ID = value
+		case "event":
+			ev.Name = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms > 0 {
+				*delay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := sc.Err(); err != nil && ctx.Err() == nil {
+		return got, &netError{err}
+	}
+	return got, nil
+}
@@ -0,0 +1,158 @@
+// Command bridge is the Graham Bridge: a small HTTP server that runs on
+// the user's machine and gives web apps raw print access to Braille
+// embossers. It loads the config, opens the job store, starts the
+// background tasks of pkg/core and serves pkg/httpapi's routes, with an
+// icon in the system tray.
+//
+// Started by Chrome or Edge with an extension origin as its argument, it
+// serves the web-app endpoints over native messaging instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"fyne.io/systray"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/httpapi"
+)
+
+// ---------------------------------------------------------------------------
+// Main
+// ---------------------------------------------------------------------------
+
+func main() {
+	// A browser starting the bridge as its native-messaging host passes the
+	// calling extension's origin instead of flags.
+	if origin, ok := httpapi.NativeHostOrigin(os.Args[1:]); ok {
+		if err := core.LoadConfig(core.DefaultConfigPath()); err != nil {
+			log.Fatalf("config: %v", err)
+		}
+		if err := httpapi.RunNativeHost(origin, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("native host: %v", err)
+		}
+		return
+	}
+
+	flag.StringVar(&core.CfgPath, "config", core.DefaultConfigPath(), "path to the JSON config file")
+	simulate := flag.Bool("simulate", false, "add fake embossers for demos (see simulate.go)")
+	simScript := flag.String("simulate-script", core.SimDefaultScript, "comma-separated job outcomes for --simulate")
+	uiDir := flag.String("ui-dir", "", "directory of dashboard files to serve over the built-in ones")
+	installHost := flag.Bool("install-native-host", false, "register the bridge as a browser native-messaging host and exit")
+	uninstallHost := flag.Bool("uninstall-native-host", false, "remove the native-messaging host registration and exit")
+	profiling := flag.Bool("debug-profiling", false, "serve /debug/pprof/ and /debug/runtime (admin scope; see profiling.go)")
+	flag.Parse()
+	if err := core.LoadConfig(core.CfgPath); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	if ok, err := httpapi.RunBackupCommand(flag.Args()); ok { // pkg/httpapi/backup.go
+		if err != nil {
+			log.Fatalf("%s: %v", flag.Arg(0), err)
+		}
+		return
+	}
+	if *installHost || *uninstallHost {
+		if err := httpapi.RegisterNativeHost(*installHost); err != nil {
+			log.Fatalf("native host: %v", err)
+		}
+		return
+	}
+	if *uiDir != "" {
+		if err := httpapi.SetUIDir(*uiDir); err != nil {
+			log.Fatalf("ui-dir: %v", err)
+		}
+	}
+	s, err := core.OpenStore(core.CurrentConfig().Store) // pkg/core/storedb.go
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	core.Store = s
+	if *simulate {
+		if err := core.EnableSimulation(*simScript); err != nil {
+			log.Fatalf("simulate: %v", err)
+		}
+	}
+	go core.RunNotifier()
+	go core.RunFleet()
+	go core.RunWatchdog()
+	go core.RunQuietHours()
+
+	go func() {
+		mux := http.NewServeMux()
+		httpapi.APIRoutes(mux, core.Store)
+		httpapi.DashboardRoutes(mux, core.Store)
+		if *profiling {
+			httpapi.ProfilingRoutes(mux)
+			log.Printf("profiling enabled at /debug/pprof/ and /debug/runtime")
+		}
+
+		addr := httpapi.ServerAddr()
+		httpapi.StartStation()
+		log.Printf("Graham Bridge listening on http://%s", addr)
+		srv := httpapi.NewServer(addr, httpapi.WithRequestLog(httpapi.WithAudit(httpapi.WithHostControl(httpapi.WithGzip(mux)))))
+		if err := srv.ListenAndServe(); err != nil {
+			log.Fatalf("server error: %v", err)
+		}
+	}()
+
+	systray.Run(onReady, onExit)
+}
+
+func onReady() {
+	systray.SetIcon(iconData)
+	systray.SetTitle("Graham Bridge")
+	systray.SetTooltip("Graham Bridge – HTTP Print Server")
+
+	status := "Status: Running on port 8080"
+	if core.Simulating() {
+		status += " (simulation)"
+	}
+	mStatus := systray.AddMenuItem(status, "Bridge is running")
+	mStatus.Disable()
+
+	systray.AddSeparator()
+	mDebug := systray.AddMenuItem("Open Debug Page", "View print logs and test the embosser")
+	mOpen := systray.AddMenuItem("Open Graham Bridge Editor", "Launch the web app")
+	mQuit := systray.AddMenuItem("Quit", "Quit the bridge")
+
+	go func() {
+		for {
+			select {
+			case <-mDebug.ClickedCh:
+				openBrowser("http://" + core.ListenAddr + "/debug")
+			case <-mOpen.ClickedCh:
+				openBrowser("https://grahambrailleeditor.com/")
+			case <-mQuit.ClickedCh:
+				systray.Quit()
+			}
+		}
+	}()
+}
+
+func onExit() {
+	// cleanup if necessary
+	log.Println("Shutting down Graham Bridge...")
+}
+
+func openBrowser(url string) {
+	var err error
+	switch runtime.GOOS {
+	case "linux":
+		err = exec.Command("xdg-open", url).Start()
+	case "windows":
+		err = exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		err = exec.Command("open", url).Start()
+	default:
+		err = fmt.Errorf("unsupported platform")
+	}
+	if err != nil {
+		log.Printf("Failed to open browser: %v", err)
+	}
+}
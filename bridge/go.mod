@@ -2,9 +2,23 @@ module github.com/grahamthetvi/GrahamBrailleWriter/bridge
 
 go 1.25.0
 
-require fyne.io/systray v1.12.0
+require (
+	fyne.io/systray v1.12.0
+	go.etcd.io/bbolt v1.4.3
+	golang.org/x/net v0.51.0
+	golang.org/x/sys v0.42.0
+	modernc.org/sqlite v1.38.2
+)
 
 require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
 	github.com/godbus/dbus/v5 v5.2.2 // indirect
-	golang.org/x/sys v0.42.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	modernc.org/libc v1.66.3 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
 )
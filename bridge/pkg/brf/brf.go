@@ -0,0 +1,130 @@
+// Package brf reads and checks Braille Ready Format files: the ASCII
+// braille text that embossers print, with a form feed between pages.
+//
+// It is the format code of the Graham Braille Writer bridge, kept free of
+// the bridge's printers, queue and HTTP API so other Go tools can use it:
+//
+//   - Check and Normalize find payloads that are not braille and clean up
+//     text saved from word processors.
+//   - Pages, PageEnds and CountPages lay a file out the way an embosser
+//     does, and ParseRange and ParsePages read page ranges such as "5-10"
+//     and page lists such as "3, 7, 9-12".
+//   - Cell, ToUnicode and FromUnicode convert between ASCII braille and
+//     Unicode braille patterns (U+2800–U+28FF), six- or eight-dot.
+//   - IsPEF and FromPEF convert Portable Embosser Format documents.
+//
+// The API is stable: existing functions keep their signatures and
+// behaviour, and new ones are only added.
+package brf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dot modes for FromUnicode and FromPEF.
+const (
+	Dots6 = 6 // literary braille, North American ASCII braille
+	Dots8 = 8 // computer braille, with dots 7 and 8
+
+	dot7 = 1 << 6
+	dot8 = 1 << 7
+)
+
+// ASCII maps a six-dot pattern (dot 1 = bit 0 … dot 6 = bit 5) to its
+// North American ASCII braille character.
+const ASCII = " A1B'K2L@CIF/MSP\"E3H9O6R^DJG>NTQ,*5<-U8V.%[$+X!&;:4\\0Z7(_?W]#Y)="
+
+// computerBraille maps a seven-dot pattern (dot 1 = bit 0 … dot 7 = bit 6)
+// to its North American computer braille character, or 0 if it has none.
+var computerBraille = func() (t [128]byte) {
+	for c := 0x20; c < 0x80; c++ {
+		plain := c
+		if c >= 0x60 {
+			plain -= 0x20 // lower case is the plain six-dot cell
+		}
+		dots := strings.IndexByte(ASCII, byte(plain))
+		if c >= 0x40 && c < 0x60 {
+			dots |= dot7 // upper case adds dot 7
+		}
+		t[dots] = byte(c)
+	}
+	return t
+}()
+
+// EightDotByte returns the byte that embosses an eight-dot pattern (dot 1 =
+// bit 0 … dot 8 = bit 7) in eight-dot mode: its computer braille character,
+// with the high bit set for dot 8.
+func EightDotByte(dots int) (byte, bool) {
+	c := computerBraille[dots&^dot8]
+	if c == 0 {
+		return 0, false
+	}
+	if dots&dot8 != 0 {
+		c |= 0x80
+	}
+	return c, true
+}
+
+// Cell returns the six-dot pattern of an ASCII braille character (dot 1 =
+// bit 0 … dot 6 = bit 5). Lower-case forms map to upper case, as
+// embossers treat them.
+func Cell(c byte) (byte, bool) {
+	if c >= 0x60 && c <= 0x7E {
+		c -= 0x20
+	}
+	i := strings.IndexByte(ASCII, c)
+	if i < 0 {
+		return 0, false
+	}
+	return byte(i), true
+}
+
+// ToUnicode converts a line of ASCII braille to Unicode braille patterns,
+// dropping control bytes such as escape sequences and returning how many
+// it dropped. Characters with no ASCII braille meaning are kept as they
+// are.
+func ToUnicode(line string) (string, int) {
+	var b strings.Builder
+	skipped := 0
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c < 0x20 || c == 0x7f {
+			skipped++
+			continue
+		}
+		if dots, ok := Cell(c); ok {
+			b.WriteRune(0x2800 + rune(dots))
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), skipped
+}
+
+// FromUnicode converts a row of Unicode braille to ASCII braille, or to
+// computer braille bytes for Dots8. Six-dot rows drop dots 7 and 8, which
+// ASCII braille has no room for. Spaces and no-break spaces are blank
+// cells and line breaks and tabs are ignored; any other character is an
+// error.
+func FromUnicode(s string, dots int) (string, error) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 0x2800 && r <= 0x28FF && dots == Dots8:
+			c, ok := EightDotByte(int(r - 0x2800))
+			if !ok {
+				return "", fmt.Errorf("cell %c has no computer braille code", r)
+			}
+			b.WriteByte(c)
+		case r >= 0x2800 && r <= 0x28FF:
+			b.WriteByte(ASCII[(r-0x2800)&0x3F])
+		case r == ' ' || r == '\u00A0':
+			b.WriteByte(' ')
+		case r == '\n' || r == '\r' || r == '\t':
+		default:
+			return "", fmt.Errorf("non-braille character %q in row", r)
+		}
+	}
+	return b.String(), nil
+}
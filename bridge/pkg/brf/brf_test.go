@@ -0,0 +1,170 @@
+package brf
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	bmp := append([]byte("BM\x10\x00\x00\x00"), make([]byte, 10)...)
+	for _, c := range []struct {
+		name string
+		data string
+		ok   bool
+	}{
+		{"brf", ",! QUICK BR{N FOX\r\n\f", true},
+		{"empty", "", true},
+		{"escape codes", "\x1b@A\n", true},
+		{"pdf", "%PDF-1.7\n", false},
+		{"docx", "PK\x03\x04rest", false},
+		{"png", "\x89PNG\r\n\x1a\n....", false},
+		{"text starting BM", "BMW\n", true},
+		{"bmp", string(bmp), false},
+		{"binary", "\x00\x01\x02\x03A", false},
+	} {
+		if err := Check([]byte(c.data)); (err == nil) != c.ok {
+			t.Errorf("%s: Check = %v, want ok %v", c.name, err, c.ok)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	got, rep := Normalize([]byte("\xEF\xBB\xBF“Hi”—there… é"))
+	if string(got) != "\"Hi\"--there... é" {
+		t.Errorf("Normalize = %q", got)
+	}
+	if rep == nil || !rep.BOM || rep.Replaced["—"] != 1 || rep.Unknown["é"] != 1 {
+		t.Errorf("report = %+v", rep)
+	}
+	if _, rep := Normalize([]byte("ABC\n")); rep != nil {
+		t.Errorf("plain ASCII report = %+v, want nil", rep)
+	}
+	if got, _ := Normalize([]byte("A\xe9B")); string(got) != "A\xe9B" {
+		t.Errorf("8-bit data changed to %q", got)
+	}
+}
+
+func TestPages(t *testing.T) {
+	for _, c := range []struct {
+		data string
+		want [][]string
+	}{
+		{"A\r\nB\r\nC\r\n", [][]string{{"A", "B"}, {"C"}}},
+		{"A\n\fB\n\f", [][]string{{"A"}, {"B"}}},
+		{"A\f\fB", [][]string{{"A"}, {}, {"B"}}},
+		{"", [][]string{{}}},
+	} {
+		got := Pages([]byte(c.data), 2)
+		if !slices.EqualFunc(got, c.want, slices.Equal) {
+			t.Errorf("Pages(%q) = %q, want %q", c.data, got, c.want)
+		}
+	}
+}
+
+func TestPageEnds(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"A\nB\n",
+		"A\nB\n\f",
+		"A\nB\nC\nD\n\f",
+		"A\nB\nC\nD\nE\n",
+		"A\f\fB",
+		"A\nB\nC\nD\n\fE\n\f",
+		"\f",
+	} {
+		ends := PageEnds([]byte(data), 2)
+		if want := CountPages([]byte(data), 2); len(ends) != want {
+			t.Errorf("%q: %d pages %v, CountPages says %d", data, len(ends), ends, want)
+		}
+	}
+	if got := PageEnds([]byte("A\nB\nC\n\f"), 2); !slices.Equal(got, []int{4, 7}) {
+		t.Errorf("page ends = %v, want [4 7]", got)
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	for _, c := range []struct {
+		in          string
+		first, last int
+		ok          bool
+	}{
+		{"7", 7, 7, true}, {"5-10", 5, 10, true}, {"5-", 5, 0, true}, {" 2 - 3 ", 2, 3, true},
+		{"", 0, 0, false}, {"0", 0, 0, false}, {"6-5", 0, 0, false}, {"a-b", 0, 0, false},
+	} {
+		first, last, err := ParseRange(c.in)
+		if (err == nil) != c.ok || first != c.first || last != c.last {
+			t.Errorf("ParseRange(%q) = %d, %d, %v", c.in, first, last, err)
+		}
+	}
+}
+
+func TestParsePages(t *testing.T) {
+	got, err := ParsePages("3, 7,9-12,40-")
+	want := []PageRange{{3, 3}, {7, 7}, {9, 12}, {40, 0}}
+	if err != nil || !slices.Equal(got, want) {
+		t.Errorf("ParsePages = %v, %v, want %v", got, err, want)
+	}
+	for _, bad := range []string{"", "3,", "3,,4", "3,x"} {
+		if _, err := ParsePages(bad); err == nil {
+			t.Errorf("ParsePages(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	// Every ASCII braille character survives the round trip.
+	uni, skipped := ToUnicode(ASCII)
+	if skipped != 0 {
+		t.Errorf("ToUnicode skipped %d bytes", skipped)
+	}
+	if back, err := FromUnicode(uni, Dots6); err != nil || back != ASCII {
+		t.Errorf("round trip = %q, %v", back, err)
+	}
+	if got, skipped := ToUnicode("\x1bab~\x7f"); got != "⠁⠃⠘" || skipped != 2 {
+		t.Errorf("ToUnicode = %q, %d skipped", got, skipped)
+	}
+	if got, _ := ToUnicode("{}"); got != "⠪⠻" {
+		t.Errorf("lower-case forms = %q", got)
+	}
+}
+
+func TestFromUnicodeEightDot(t *testing.T) {
+	// ⠁ a, ⡁ A (dot 7), ⠼ # (a six-dot symbol), ⢁ a with dot 8.
+	got, err := FromUnicode("⠁⡁⠼ ⢁", Dots8)
+	if err != nil || got != "aA# \xe1" {
+		t.Errorf("eight-dot row = %q, %v", got, err)
+	}
+	if got, _ := FromUnicode("⠁⡁", Dots6); got != "AA" {
+		t.Errorf("six-dot row = %q, want dot 7 dropped", got)
+	}
+	// Dot 7 on a digit-row symbol has no computer braille code.
+	if _, err := FromUnicode("⡼", Dots8); err == nil {
+		t.Error("⡼ converted, want an error")
+	}
+	if _, err := FromUnicode("⠁x", Dots6); err == nil {
+		t.Error("x converted, want an error")
+	}
+}
+
+func TestFromPEF(t *testing.T) {
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<pef version="2008-1" xmlns="http://www.daisy.org/ns/2008/pef">
+ <body><volume cols="40" rows="25" rowgap="0" duplex="false"><section>
+  <page><row>⠠⠓⠑⠇⠇⠕⠀⠀</row><row/></page>
+  <page><row>⠼⠁</row></page>
+ </section></volume></body>
+</pef>`
+	if !IsPEF([]byte(doc)) || IsPEF([]byte("<html>")) {
+		t.Fatal("IsPEF wrong")
+	}
+	got, err := FromPEF([]byte(doc), Dots6)
+	if want := ",HELLO\r\n\r\n\f#A\r\n\f"; err != nil || string(got) != want {
+		t.Errorf("FromPEF = %q, %v; want %q", got, err, want)
+	}
+	for _, bad := range []string{`<pef></pef>`, `<pef><page>`, `<pef><page><row>abc</row></page></pef>`} {
+		if _, err := FromPEF([]byte(bad), Dots6); err == nil || !strings.HasPrefix(err.Error(), "invalid PEF") {
+			t.Errorf("FromPEF(%q) error = %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package brf
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// binarySignatures are leading magic bytes of formats that are never BRF.
+var binarySignatures = []struct {
+	magic []byte
+	kind  string
+}{
+	{[]byte("%PDF-"), "a PDF document"},
+	{[]byte("PK\x03\x04"), "a ZIP archive (e.g. a Word .docx file)"},
+	{[]byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1"), "a legacy Office document (.doc)"},
+	{[]byte(`{\rtf`), "an RTF document"},
+	{[]byte("\x89PNG\r\n\x1a\n"), "a PNG image"},
+	{[]byte("\xFF\xD8\xFF"), "a JPEG image"},
+	{[]byte("GIF87a"), "a GIF image"},
+	{[]byte("GIF89a"), "a GIF image"},
+	{[]byte("BM"), "a BMP image"},
+	{[]byte("II*\x00"), "a TIFF image"},
+	{[]byte("MM\x00*"), "a TIFF image"},
+	{[]byte("%!PS"), "a PostScript document"},
+}
+
+// sniffSample is how much of the data the control-byte heuristic looks at.
+const sniffSample = 4096
+
+// Check returns a descriptive error if data is clearly not braille: a
+// document or image format, or binary data. Embossers print whatever they
+// are sent, so these would waste paper.
+func Check(data []byte) error {
+	for _, sig := range binarySignatures {
+		if bytes.HasPrefix(data, sig.magic) {
+			if sig.kind == "a BMP image" && !looksLikeBMP(data) {
+				continue
+			}
+			return fmt.Errorf("payload looks like %s, not braille (BRF) text; translate it to braille before printing", sig.kind)
+		}
+	}
+
+	// BRF is printable ASCII plus CR, LF and FF (plus ESC sequences for some
+	// models); anything with many other control bytes is binary.
+	sample := data[:min(len(data), sniffSample)]
+	odd := 0
+	for _, b := range sample {
+		if b < 0x20 && b != '\r' && b != '\n' && b != '\f' && b != '\t' && b != 0x1b {
+			odd++
+		}
+	}
+	if len(sample) > 0 && odd*10 > len(sample) {
+		return fmt.Errorf("payload looks like binary data (%d of the first %d bytes are control characters), not braille (BRF) text", odd, len(sample))
+	}
+	return nil
+}
+
+// looksLikeBMP guards the short "BM" signature against BRF text that
+// happens to start with those letters: a real bitmap records its own size.
+func looksLikeBMP(data []byte) bool {
+	if len(data) < 14 {
+		return false
+	}
+	size := int(data[2]) | int(data[3])<<8 | int(data[4])<<16 | int(data[5])<<24
+	return size == len(data)
+}
+
+// NormalizeReport records what Normalize changed or flagged.
+type NormalizeReport struct {
+	BOM      bool           `json:"bom,omitempty"`      // a UTF-8 byte-order mark was removed
+	Replaced map[string]int `json:"replaced,omitempty"` // character → times transliterated
+	Unknown  map[string]int `json:"unknown,omitempty"`  // non-ASCII characters left in place
+}
+
+var utf8BOM = []byte("\xEF\xBB\xBF")
+
+// asciiFor maps typographic characters to their plain-ASCII equivalents.
+var asciiFor = map[rune]string{
+	'\u00A0': " ",   // no-break space
+	'\u2007': " ",   // figure space
+	'\u202F': " ",   // narrow no-break space
+	'\u2018': "'",   // left single quote
+	'\u2019': "'",   // right single quote / apostrophe
+	'\u201A': "'",   // single low-9 quote
+	'\u201C': "\"",  // left double quote
+	'\u201D': "\"",  // right double quote
+	'\u201E': "\"",  // double low-9 quote
+	'\u2010': "-",   // hyphen
+	'\u2011': "-",   // non-breaking hyphen
+	'\u2013': "-",   // en dash
+	'\u2014': "--",  // em dash
+	'\u2026': "...", // ellipsis
+	'\u00AD': "",    // soft hyphen
+	'\u200B': "",    // zero-width space
+	'\uFEFF': "",    // stray BOM / zero-width no-break space
+}
+
+// Normalize strips a leading UTF-8 byte-order mark and transliterates the
+// curly quotes, dashes and special spaces that word processors save, each
+// of which would emboss as garbage cells. Other non-ASCII characters are
+// left alone but reported. Data that is not valid UTF-8 (legacy 8-bit
+// BRF) is returned unchanged apart from the BOM check. The report is nil
+// if nothing was found.
+func Normalize(data []byte) ([]byte, *NormalizeReport) {
+	rep := &NormalizeReport{}
+	if bytes.HasPrefix(data, utf8BOM) {
+		data = data[len(utf8BOM):]
+		rep.BOM = true
+	}
+	if !hasNonASCII(data) || !utf8.Valid(data) {
+		if rep.BOM {
+			return data, rep
+		}
+		return data, nil
+	}
+
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); {
+		if data[i] < utf8.RuneSelf {
+			out = append(out, data[i])
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if repl, ok := asciiFor[r]; ok {
+			out = append(out, repl...)
+			rep.Replaced = countRune(rep.Replaced, r)
+		} else {
+			out = append(out, data[i:i+size]...)
+			rep.Unknown = countRune(rep.Unknown, r)
+		}
+		i += size
+	}
+	return out, rep
+}
+
+func hasNonASCII(data []byte) bool {
+	for _, b := range data {
+		if b >= utf8.RuneSelf {
+			return true
+		}
+	}
+	return false
+}
+
+func countRune(m map[string]int, r rune) map[string]int {
+	if m == nil {
+		m = make(map[string]int)
+	}
+	m[string(r)]++
+	return m
+}
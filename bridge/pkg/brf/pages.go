@@ -0,0 +1,124 @@
+package brf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Embossers start a new page at every form feed and after linesPerPage
+// lines. A form feed straight after a full page closes that page rather
+// than adding a blank one, and an empty last page after a final form feed
+// does not count.
+
+// Pages splits data into pages of lines, the way an embosser printing
+// linesPerPage lines per page lays it out. Carriage returns are dropped.
+func Pages(data []byte, linesPerPage int) [][]string {
+	var pages [][]string
+	for _, ff := range strings.Split(strings.ReplaceAll(string(data), "\r", ""), "\f") {
+		lines := strings.Split(ff, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		for len(lines) > linesPerPage {
+			pages = append(pages, lines[:linesPerPage])
+			lines = lines[linesPerPage:]
+		}
+		pages = append(pages, lines)
+	}
+	// Drop the empty page after a final form feed.
+	if n := len(pages); n > 1 && len(pages[n-1]) == 0 {
+		pages = pages[:n-1]
+	}
+	return pages
+}
+
+// CountPages counts braille pages: one per form feed, plus the pages an
+// embosser starts by itself when a page runs past linesPerPage lines.
+func CountPages(data []byte, linesPerPage int) int {
+	segments := bytes.Split(data, []byte{'\f'})
+	pages := 0
+	for i, seg := range segments {
+		if i == len(segments)-1 && len(bytes.TrimSpace(seg)) == 0 {
+			break
+		}
+		lines := bytes.Count(seg, []byte{'\n'})
+		if len(seg) > 0 && seg[len(seg)-1] != '\n' {
+			lines++
+		}
+		pages += max(1, (lines+linesPerPage-1)/linesPerPage)
+	}
+	return pages
+}
+
+// PageEnds returns the byte offset just past each page of data, paging
+// the same way CountPages does, so data[ends[i-1]:ends[i]] is page i+1.
+func PageEnds(data []byte, linesPerPage int) []int {
+	var ends []int
+	start, lines := 0, 0
+	full := false // the last page ended on its line count, not a form feed
+	for i, c := range data {
+		switch c {
+		case '\n':
+			lines++
+			if lines == linesPerPage {
+				ends = append(ends, i+1)
+				start, lines, full = i+1, 0, true
+			}
+		case '\f':
+			if full && start == i {
+				ends[len(ends)-1] = i + 1 // the form feed closes the full page
+			} else {
+				ends = append(ends, i+1)
+			}
+			start, lines, full = i+1, 0, false
+		}
+	}
+	if len(bytes.TrimSpace(data[start:])) > 0 {
+		ends = append(ends, len(data))
+	}
+	return ends
+}
+
+// ParseRange parses a page range — one page ("7"), a range ("5-10") or a
+// range to the end ("5-") — into first and last page numbers, with last 0
+// for "to the end".
+func ParseRange(s string) (first, last int, err error) {
+	from, to, isRange := strings.Cut(s, "-")
+	first, err = strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || first < 1 {
+		return 0, 0, fmt.Errorf("invalid page range %q; use e.g. 7, 5-10 or 5-", s)
+	}
+	switch {
+	case !isRange:
+		return first, first, nil
+	case strings.TrimSpace(to) == "":
+		return first, 0, nil
+	}
+	last, err = strconv.Atoi(strings.TrimSpace(to))
+	if err != nil || last < first {
+		return 0, 0, fmt.Errorf("invalid page range %q; use e.g. 7, 5-10 or 5-", s)
+	}
+	return first, last, nil
+}
+
+// PageRange is one entry of a page list: pages First to Last, with Last 0
+// for "to the end".
+type PageRange struct {
+	First, Last int
+}
+
+// ParsePages parses a comma-separated list of page ranges, each as
+// ParseRange takes them, such as "3, 7, 9-12" or "2, 40-".
+func ParsePages(s string) ([]PageRange, error) {
+	var list []PageRange
+	for part := range strings.SplitSeq(s, ",") {
+		first, last, err := ParseRange(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid page list %q; use e.g. 7, 5-10, 5- or 3,7,9-12", s)
+		}
+		list = append(list, PageRange{first, last})
+	}
+	return list, nil
+}
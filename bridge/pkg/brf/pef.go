@@ -0,0 +1,67 @@
+package brf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PEF (Portable Embosser Format) is XML holding volumes → sections → pages
+// → rows of Unicode braille. FromPEF converts it to BRF one row per line
+// with a form feed after every page.
+
+// IsPEF reports whether data looks like a PEF document.
+func IsPEF(data []byte) bool {
+	head := data[:min(len(data), 1024)]
+	return bytes.Contains(head, []byte("<pef")) || bytes.Contains(head, []byte("daisy.org/ns/2008/pef"))
+}
+
+// FromPEF converts a PEF document to BRF for a six- or eight-dot job (see
+// FromUnicode). Rows end in CR LF with trailing blank cells removed.
+func FromPEF(data []byte, dots int) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	inRow, pages := false, 0
+	var row strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid PEF: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "row" {
+				inRow = true
+				row.Reset()
+			}
+		case xml.CharData:
+			if inRow {
+				row.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "row":
+				inRow = false
+				line, err := FromUnicode(row.String(), dots)
+				if err != nil {
+					return nil, fmt.Errorf("invalid PEF: %w", err)
+				}
+				out.WriteString(strings.TrimRight(line, " "))
+				out.WriteString("\r\n")
+			case "page":
+				out.WriteByte('\f')
+				pages++
+			}
+		}
+	}
+	if pages == 0 {
+		return nil, errors.New("invalid PEF: no pages")
+	}
+	return out.Bytes(), nil
+}
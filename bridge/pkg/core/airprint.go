@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// AirPrint queues
+//
+// Some embossers advertise themselves over AirPrint, and macOS, CUPS and
+// Windows add a queue for them with a driverless driver (AirPrint, IPP
+// Everywhere, the Microsoft IPP Class Driver). Those drivers treat the job
+// as a document to render, so raw BRF comes out as garbage or as a printed
+// page of text.
+//
+// When a job goes to such a queue the bridge looks for a raw path to the
+// same embosser at the host in the queue's device URI: its JetDirect port
+// (socket:host:9100) first, then its own IPP service (ipp:ipp://host/…),
+// which takes the BRF as application/octet-stream. The job is sent there
+// instead, keeping the queue's printer profile, and the job records the
+// path in "route". "airprint": "queue" in the profile sends through the
+// queue anyway.
+//
+// A queue whose device URI gives no host (dnssd:// URIs, WSD ports) or
+// whose embosser answers on neither port has no raw path. GET
+// /printers?details=1 then carries a "warning" for it, which the dashboard
+// shows in the printer list, suggesting a socket: destination or a raw
+// queue instead.
+// ---------------------------------------------------------------------------
+
+// Values of PrinterProfile.AirPrint.
+const (
+	airPrintRaw   = "raw" // default: send around the driver when possible
+	AirPrintQueue = "queue"
+)
+
+// driverlessDrivers are matched against a queue's driver name to find
+// queues that render jobs.
+var driverlessDrivers = []string{"airprint", "ipp everywhere", "driverless", "ipp class driver", "mopria"}
+
+// isDriverless reports whether driver is one that renders jobs itself.
+func isDriverless(driver string) bool {
+	lower := strings.ToLower(driver)
+	for _, d := range driverlessDrivers {
+		if strings.Contains(lower, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// airPrintRouteTTL is how long a queue's route is remembered.
+const airPrintRouteTTL = 5 * time.Minute
+
+// airPrintProbeTimeout bounds each raw-path probe.
+const airPrintProbeTimeout = 2 * time.Second
+
+// queueRoute is what the bridge found out about a print queue.
+type queueRoute struct {
+	Driverless bool
+	Driver     string
+	Raw        string // raw destination to use instead, if found
+	checked    time.Time
+}
+
+var queueRoutes = struct {
+	sync.Mutex
+	m map[string]queueRoute
+}{m: make(map[string]queueRoute)}
+
+// routeFor returns what is known about a queue's driver and raw path,
+// checking again once the remembered answer is old.
+func routeFor(ctx context.Context, printer string) queueRoute {
+	queueRoutes.Lock()
+	r, ok := queueRoutes.m[printer]
+	queueRoutes.Unlock()
+	if ok && time.Since(r.checked) < airPrintRouteTTL {
+		return r
+	}
+
+	driver, uri := ActiveSpooler.Driver(ctx, printer)
+	r = queueRoute{Driver: driver, Driverless: isDriverless(driver), checked: time.Now()}
+	if r.Driverless {
+		r.Raw = findRawPath(ctx, uri)
+		if r.Raw != "" {
+			log.Printf("printer %q uses the %q driver; sending its jobs raw to %s", printer, driver, r.Raw)
+		} else {
+			log.Printf("printer %q uses the %q driver and no raw path to it was found", printer, driver)
+		}
+	}
+	queueRoutes.Lock()
+	queueRoutes.m[printer] = r
+	queueRoutes.Unlock()
+	return r
+}
+
+// rawRoute returns the raw destination to send a queue's jobs to instead,
+// or "" to use the queue.
+func rawRoute(ctx context.Context, printer string) string {
+	if ProfileFor(printer).AirPrint == AirPrintQueue {
+		return ""
+	}
+	return routeFor(ctx, printer).Raw
+}
+
+// findRawPath probes the host in a device URI for a JetDirect port, then
+// an IPP service.
+func findRawPath(ctx context.Context, uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Hostname() == "" || strings.HasPrefix(u.Scheme, "dnssd") {
+		return ""
+	}
+	host := u.Hostname()
+
+	probe, cancel := context.WithTimeout(ctx, airPrintProbeTimeout)
+	defer cancel()
+	addr := net.JoinHostPort(host, "9100")
+	if checkSocket(probe, addr) == nil {
+		return socketPrefix + addr
+	}
+
+	ipp := &url.URL{Scheme: "ipp", Host: net.JoinHostPort(host, "631"), Path: "/ipp/print"}
+	if u.Scheme == "ipp" && u.Path != "" {
+		ipp.Host, ipp.Path = u.Host, u.Path
+	}
+	probe, cancel = context.WithTimeout(ctx, airPrintProbeTimeout)
+	defer cancel()
+	if _, _, err := ippState(probe, ipp); err == nil {
+		return ippPrefix + ipp.String()
+	}
+	return ""
+}
+
+// sendRaw sends a queue's job to its raw path and records the route.
+func sendRaw(ctx context.Context, job *PrintJob, raw string) error {
+	Store.Update(job.ID, func(e *JobEvent) { e.Route = raw })
+	if addr, ok := strings.CutPrefix(raw, socketPrefix); ok {
+		return sendSocket(ctx, job, addr)
+	}
+	return sendIPP(ctx, job, strings.TrimPrefix(raw, ippPrefix))
+}
+
+// QueueWarning is the dashboard warning for a print queue, or "".
+func QueueWarning(ctx context.Context, printer string) string {
+	if isDirect(printer) || strings.HasPrefix(printer, simPrefix) || strings.HasPrefix(printer, driverPrefix) {
+		return ""
+	}
+	r := routeFor(ctx, printer)
+	switch {
+	case !r.Driverless:
+		return ""
+	case r.Raw != "" && ProfileFor(printer).AirPrint != AirPrintQueue:
+		return ""
+	}
+	return "This queue uses the " + r.Driver + " driver, which re-renders jobs, so braille may come out garbled. " +
+		"Add the embosser as a raw queue, or as a socket:<address>:9100 destination in the bridge config."
+}
@@ -0,0 +1,47 @@
+package core
+
+import (
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Job annotations
+//
+// Production rooms keep notes on what happened to a job after it came out
+// of the embosser: "page 3 had weak dots, re-ran", "delivered to Maya's
+// classroom". Rather than a separate spreadsheet, they are added to the
+// job's record:
+//
+//	POST /jobs/{id}/annotations ← {"text": "page 3 had weak dots, re-ran"}
+//	                            → 201 {"time", "by", "text"}
+//
+// and come back with the job in GET /jobs and on /log-stream as
+// "annotations", oldest first. Each records when it was added and by whom
+// (the caller's name from the audit log). Annotations are kept with the
+// history, so they last as long as the job does, travel in backups and
+// go when a student's records are purged. Only finished jobs take them,
+// and they cannot be edited: a correction is another annotation. The
+// bridge adds its own, by "bridge", to jobs it held for a printer that
+// needed attention (printerhold.go).
+// ---------------------------------------------------------------------------
+
+const (
+	MaxAnnotationLen = 500 // characters
+	MaxAnnotations   = 50  // per job
+)
+
+// Annotation is a note added to a finished job.
+type Annotation struct {
+	Time time.Time `json:"time"`
+	By   string    `json:"by"` // caller, as named in the audit log
+	Text string    `json:"text"`
+}
+
+// FinishedStatus reports whether a job in status has stopped changing.
+func FinishedStatus(status string) bool {
+	switch status {
+	case StatusQueued, StatusPrinting, StatusHeld, StatusWaiting:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Anonymized logging
+//
+// Districts with strict data policies can set "anonymize_students": true.
+// Student identifiers are then replaced by a pseudonym such as
+// "s-3f9a0c12be" everywhere they leave the bridge's memory: the page
+// ledger, the job history database and backups (storedb.go), the
+// dashboard and every JSON job record (GET /jobs, the SSE stream, post
+// hooks), the shared dashboard filter in ui-state.json and failure
+// emails. Jobs carry no other title; spoolers are sent "Job N".
+//
+// The in-memory history keeps the real identifier for the current run, so
+// GET /jobs?student=, reports and DELETE /jobs still take either the real
+// identifier or its pseudonym, and pre hooks still get the real one. The
+// pseudonym is an HMAC of the identifier with a random key kept in
+// student.key in the data directory, so it stays the same across restarts
+// (page reports still group by student) but cannot be reversed by trying
+// every student number.
+// ---------------------------------------------------------------------------
+
+// pseudonymPrefix starts every pseudonym, so one is never hashed again.
+const pseudonymPrefix = "s-"
+
+var studentKey = struct {
+	sync.Once
+	key []byte
+}{}
+
+// anonymizing reports whether student identifiers are being hidden.
+func anonymizing() bool {
+	return CurrentConfig().AnonymizeStudents
+}
+
+// Pseudonym returns the stable pseudonym for a student identifier.
+func Pseudonym(student string) string {
+	if student == "" || isPseudonym(student) {
+		return student
+	}
+	studentKey.Do(func() {
+		key, err := SecretFile("student.key", func() ([]byte, error) {
+			key := make([]byte, 32)
+			_, err := rand.Read(key)
+			return key, err
+		})
+		if err != nil {
+			log.Printf("anonymize: %v; pseudonyms will change on restart", err)
+			key = make([]byte, 32)
+			rand.Read(key)
+		}
+		studentKey.key = key
+	})
+	mac := hmac.New(sha256.New, studentKey.key)
+	mac.Write([]byte(strings.ToLower(student)))
+	return pseudonymPrefix + hex.EncodeToString(mac.Sum(nil))[:10]
+}
+
+func isPseudonym(s string) bool {
+	hexPart, ok := strings.CutPrefix(s, pseudonymPrefix)
+	if !ok || len(hexPart) != 10 {
+		return false
+	}
+	_, err := hex.DecodeString(hexPart)
+	return err == nil
+}
+
+// ShownStudent is the identifier to write out: a pseudonym when
+// anonymizing.
+func ShownStudent(student string) string {
+	if anonymizing() {
+		return Pseudonym(student)
+	}
+	return student
+}
+
+// SameStudent reports whether a stored identifier (real or pseudonym) is
+// the one asked for (real or pseudonym).
+func SameStudent(stored, query string) bool {
+	if strings.EqualFold(stored, query) {
+		return true
+	}
+	if !anonymizing() || stored == "" || query == "" {
+		return false
+	}
+	return Pseudonym(stored) == Pseudonym(query)
+}
+
+// MarshalJSON writes a job record with its student shown as ShownStudent.
+// It also adds the record's schema version (schema.go).
+func (e JobEvent) MarshalJSON() ([]byte, error) {
+	type plain JobEvent
+	e.Student = ShownStudent(e.Student)
+	return json.Marshal(struct {
+		Schema int `json:"schema"`
+		plain
+	}{JobSchema, plain(e)})
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeStudents(t *testing.T) {
+	studentKey.Do(func() { studentKey.key = bytes.Repeat([]byte{1}, 32) })
+	old := Cfg.Load()
+	Cfg.Store(&Config{AnonymizeStudents: true})
+	defer Cfg.Store(old)
+
+	p := Pseudonym("Ana Lopez")
+	if !isPseudonym(p) || p != Pseudonym("ana lopez") || Pseudonym(p) != p {
+		t.Fatalf("pseudonym %q", p)
+	}
+	out, _ := json.Marshal(JobEvent{ID: 1, Student: "Ana Lopez"})
+	if strings.Contains(string(out), "Ana") || !strings.Contains(string(out), p) {
+		t.Errorf("job record %s", out)
+	}
+	for _, q := range []string{"ana lopez", p} {
+		if !(JobFilter{Student: q}).Match(JobEvent{Student: "Ana Lopez"}) || !(JobFilter{Student: q}).Match(JobEvent{Student: p}) {
+			t.Errorf("filter %q does not match", q)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Append-only logs
+//
+// The audit log (pkg/httpapi/audit.go) and the page ledger (reports.go)
+// are JSON Lines files that only ever grow, except when a purge drops
+// lines or a restore replaces them.
+// ---------------------------------------------------------------------------
+
+// AppendLog is an append-only JSON Lines file, opened on first write.
+type AppendLog struct {
+	Name string        // prefix for log messages
+	Path func() string // resolved when the file is first opened
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// Record appends one entry, opening the log on first use.
+func (a *AppendLog) Record(v any) {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		path := a.Path()
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			log.Printf("%s: %v", a.Name, err)
+			return
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			log.Printf("%s: %v", a.Name, err)
+			return
+		}
+		a.f = f
+	}
+	if _, err := a.f.Write(append(line, '\n')); err != nil {
+		log.Printf("%s: %v", a.Name, err)
+	}
+}
+
+// Drop rewrites the log without the lines fn selects and returns how many
+// were removed. Writers wait until it is done.
+func (a *AppendLog) Drop(fn func(line []byte) bool) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	path := a.Path()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var kept []byte
+	removed := 0
+	for line := range bytes.Lines(data) {
+		if fn(bytes.TrimRight(line, "\n")) {
+			removed++
+		} else {
+			kept = append(kept, line...)
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, kept, 0o600); err != nil {
+		return 0, err
+	}
+	if a.f != nil {
+		a.f.Close()
+		a.f = nil // reopened by the next record
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// Replace swaps the log's contents for data. Writers wait until it is done.
+func (a *AppendLog) Replace(data []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f != nil {
+		a.f.Close()
+		a.f = nil // reopened by the next record
+	}
+	return WriteFileAtomic(a.Path(), data)
+}
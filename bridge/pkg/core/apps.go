@@ -0,0 +1,167 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Client applications
+//
+// Several programs may print through one bridge: the web app, a Duxbury
+// macro, a district script. Each can be given its own application entry
+// with its own tokens:
+//
+//	"apps": {
+//	  "duxbury": {"tokens": [{"name": "lab-pc", "token": "…", "scope": "print"}],
+//	              "daily_jobs": 50, "daily_pages": 400},
+//	  "district-sync": {"tokens": [ … ], "revoked": true}
+//	}
+//
+// Token names only need to be unique within an application, and appear in
+// the audit log as "token:duxbury/lab-pc". Every job an application's
+// token submits records the application ("app" in the job record), and
+// that token sees only its application's jobs: GET /jobs, the job
+// endpoints and the job records on /log-stream leave out everyone
+// else's. Dashboard logins and the top-level "tokens" see everything, and
+// can narrow GET /jobs with ?app=.
+//
+// "daily_jobs" and "daily_pages" cap what an application prints per
+// calendar day (counted from the job history, so they need a history of
+// at least that many jobs); a job over the cap is refused with
+// quota_exceeded. "revoked": true refuses all of an application's tokens
+// after a config reload without touching the others; its jobs stay in the
+// history. GET /apps (admin) lists each application with today's usage.
+// ---------------------------------------------------------------------------
+
+// AppConfig is a client application's entry in the config's "apps".
+type AppConfig struct {
+	Tokens     []APIToken `json:"tokens"`
+	DailyJobs  int        `json:"daily_jobs,omitempty"`  // 0: no cap
+	DailyPages int        `json:"daily_pages,omitempty"` // 0: no cap
+	Revoked    bool       `json:"revoked,omitempty"`     // tokens refused; jobs kept
+}
+
+// APITokensConfigured reports whether any API token is configured, in
+// "tokens" or in an application.
+func APITokensConfigured(c *Config) bool {
+	if len(c.Tokens) > 0 {
+		return true
+	}
+	for _, a := range c.Apps {
+		if len(a.Tokens) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validApps checks the "apps" section; seen holds the token secrets
+// already used elsewhere in the config.
+func validApps(c *Config, seen map[string]bool) error {
+	for name, a := range c.Apps {
+		if name == "" || strings.ContainsAny(name, "/ ") {
+			return fmt.Errorf("apps: %q is not a valid application name (no spaces or slashes)", name)
+		}
+		if a.DailyJobs < 0 || a.DailyPages < 0 {
+			return fmt.Errorf("app %q: daily_jobs and daily_pages must not be negative", name)
+		}
+		names := make(map[string]bool)
+		for _, t := range a.Tokens {
+			if err := validToken(t, seen); err != nil {
+				return fmt.Errorf("app %q: %w", name, err)
+			}
+			if names[t.Name] {
+				return fmt.Errorf("app %q: two tokens named %q", name, t.Name)
+			}
+			names[t.Name] = true
+		}
+	}
+	return nil
+}
+
+// VisibleTo reports whether a caller of application app may see e.
+func VisibleTo(e JobEvent, app string) bool {
+	return app == "" || e.App == app
+}
+
+// errQuota is returned for a job over its application's daily cap.
+func errQuota(format string, args ...any) error {
+	return Classified(ErrCodeQuota, fmt.Errorf(format, args...))
+}
+
+// AppUsage is an application's jobs and pages so far today.
+type AppUsage struct {
+	Jobs  int `json:"jobs"`
+	Pages int `json:"pages"`
+}
+
+// AppUsageToday counts the jobs an application submitted today, including
+// those still waiting, but not refused ones.
+func AppUsageToday(s JobStore, app string, now time.Time) AppUsage {
+	y, m, d := now.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	var u AppUsage
+	for _, e := range s.List() {
+		if e.App != app || e.Time.Before(start) || e.Status == StatusRejected || e.LinkedTo != 0 {
+			continue
+		}
+		u.Jobs++
+		u.Pages += e.Pages
+	}
+	return u
+}
+
+// appQuotas counts, per application, the jobs that have passed the quota
+// check but are not in the job store yet. The check and the count happen
+// under one lock, so concurrent requests cannot all fit under the same
+// remaining quota.
+var appQuotas = struct {
+	sync.Mutex
+	pending map[string]AppUsage
+}{pending: map[string]AppUsage{}}
+
+// ReserveAppQuota refuses a job of pages pages if it would take app over
+// its daily caps, counting jobs other requests have reserved. A job that
+// fits stays reserved until release is called, which the caller does once
+// the job is recorded in the store or is not going to be; calling it again
+// does nothing.
+func ReserveAppQuota(app string, pages int) (release func(), err error) {
+	release = func() {}
+	if app == "" {
+		return release, nil
+	}
+	a := CurrentConfig().Apps[app]
+	if a.DailyJobs == 0 && a.DailyPages == 0 {
+		return release, nil
+	}
+	appQuotas.Lock()
+	defer appQuotas.Unlock()
+	u, held := AppUsageToday(Store, app, time.Now()), appQuotas.pending[app]
+	u.Jobs += held.Jobs
+	u.Pages += held.Pages
+	if a.DailyJobs > 0 && u.Jobs+1 > a.DailyJobs {
+		return release, errQuota("%s has sent its %d jobs for today", app, a.DailyJobs)
+	}
+	if a.DailyPages > 0 && u.Pages+pages > a.DailyPages {
+		return release, errQuota("%s has %d of its %d pages left for today; this job has %d",
+			app, max(a.DailyPages-u.Pages, 0), a.DailyPages, pages)
+	}
+	appQuotas.pending[app] = AppUsage{Jobs: held.Jobs + 1, Pages: held.Pages + pages}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			appQuotas.Lock()
+			defer appQuotas.Unlock()
+			p := appQuotas.pending[app]
+			if p.Jobs--; p.Jobs == 0 {
+				delete(appQuotas.pending, app)
+				return
+			}
+			p.Pages -= pages
+			appQuotas.pending[app] = p
+		})
+	}, nil
+}
@@ -0,0 +1,10 @@
+package core
+
+// Token scopes, in increasing order of privilege.
+const (
+	ScopeRead  = "read"
+	ScopePrint = "print"
+	ScopeAdmin = "admin"
+)
+
+var ScopeRank = map[string]int{ScopeRead: 1, ScopePrint: 2, ScopeAdmin: 3}
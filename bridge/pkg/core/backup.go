@@ -0,0 +1,76 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// ---------------------------------------------------------------------------
+// Restoring a backup
+//
+// The bundle format and the /backup and /restore endpoints are in
+// pkg/httpapi/backup.go; these are the pieces that touch the data
+// directory and the job store directly.
+// ---------------------------------------------------------------------------
+
+// WriteFileAtomic writes a file via a temporary one, so a crash never
+// leaves it truncated.
+func WriteFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// errJobsActive refuses a restore while jobs are still being handled.
+var errJobsActive = errors.New("jobs are still queued, printing or held; wait for them or cancel them first")
+
+// CheckHistoryIdle returns errJobsActive if any job in s is unfinished.
+func CheckHistoryIdle(s JobStore) error {
+	for _, e := range s.List() {
+		if !FinishedStatus(e.Status) {
+			return errJobsActive
+		}
+	}
+	return nil
+}
+
+// ReplaceHistory swaps the jobs in s for restored ones. Open dashboards
+// get a "purge" event for the old jobs and then each restored one.
+func ReplaceHistory(s JobStore, jobs []JobEvent) error {
+	if err := CheckHistoryIdle(s); err != nil {
+		return err
+	}
+	switch s := s.(type) {
+	case *PersistentStore:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.MemoryStore.Purge(func(JobEvent) bool { return true })
+		if err := s.db.pruneBefore(math.MaxInt); err != nil {
+			return err
+		}
+		s.MemoryStore.load(jobs)
+		for _, e := range s.MemoryStore.List() {
+			if err := s.db.save(e); err != nil {
+				return err
+			}
+			s.events.Publish(StreamEvent{Data: e})
+		}
+	case *MemoryStore:
+		s.Purge(func(JobEvent) bool { return true })
+		s.load(jobs)
+		for _, e := range s.List() {
+			s.events.Publish(StreamEvent{Data: e})
+		}
+	default:
+		return fmt.Errorf("the %T job store cannot be restored", s)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Event fan-out
+//
+// Every published event gets a sequence number and is kept in a short
+// backlog. Each subscriber has its own buffer; one that falls a full buffer
+// behind is disconnected rather than silently missing events, and can
+// subscribe again with the ID of the last event it read to replay just
+// what it missed (the bridge's /log-stream does this with Last-Event-ID).
+// ---------------------------------------------------------------------------
+
+const (
+	SubscriberBuffer = 256  // events a subscriber may lag behind
+	BacklogSize      = 1024 // events kept for resuming
+)
+
+// Event is one message to subscribers. The bridge sends job records with
+// no Name and auxiliary updates such as progress with one.
+type Event struct {
+	Name string
+	Data any
+	Seq  uint64 // assigned by Publish; 0 for un-numbered snapshot events
+}
+
+// Subscription is one subscriber.
+type Subscription struct {
+	C    chan Event    // published events, in order
+	Gone chan struct{} // closed when dropped for falling behind
+
+	// Set by Subscribe. When Resumed is true, Replay holds the events
+	// missed since the subscriber's last event ID; otherwise the caller
+	// must send a full snapshot, tagged with Seq (the sequence number
+	// current at subscription time).
+	Replay  []Event
+	Seq     uint64
+	Resumed bool
+}
+
+// Broadcaster fans events out to subscribers. The zero value is not
+// usable; call NewBroadcaster.
+type Broadcaster struct {
+	mu      sync.Mutex
+	seq     uint64
+	subs    map[*Subscription]struct{}
+	backlog []Event
+}
+
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subs: make(map[*Subscription]struct{})}
+}
+
+// bootID distinguishes event IDs from different runs, so a subscriber
+// reconnecting after a restart gets a full replay instead of a bogus
+// resume.
+var bootID = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+// Publish numbers an event, records it in the backlog and fans it out.
+// Subscribers whose buffer is full are disconnected.
+func (b *Broadcaster) Publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	ev.Seq = b.seq
+	b.backlog = append(b.backlog, ev)
+	if len(b.backlog) > BacklogSize {
+		b.backlog = b.backlog[len(b.backlog)-BacklogSize:]
+	}
+	for s := range b.subs {
+		select {
+		case s.C <- ev:
+		default:
+			delete(b.subs, s)
+			close(s.Gone)
+		}
+	}
+}
+
+// Forget empties the backlog, so subscribers reconnecting get a full
+// snapshot, as after records were deleted.
+func (b *Broadcaster) Forget() {
+	b.mu.Lock()
+	b.backlog = nil
+	b.mu.Unlock()
+}
+
+// Subscribe registers a new subscription, resuming after lastID when that
+// event is still in the backlog.
+func (b *Broadcaster) Subscribe(lastID string) *Subscription {
+	s := &Subscription{
+		C:    make(chan Event, SubscriberBuffer),
+		Gone: make(chan struct{}),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[s] = struct{}{}
+	s.Seq = b.seq
+	if last, ok := ParseEventID(lastID); ok && last <= b.seq {
+		if last == b.seq {
+			s.Resumed = true
+		} else if len(b.backlog) > 0 && b.backlog[0].Seq <= last+1 {
+			for _, ev := range b.backlog {
+				if ev.Seq > last {
+					s.Replay = append(s.Replay, ev)
+				}
+			}
+			s.Resumed = true
+		}
+	}
+	return s
+}
+
+func (b *Broadcaster) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	delete(b.subs, s)
+	b.mu.Unlock()
+}
+
+// EventID formats a sequence number as an event ID, such as an SSE id.
+func EventID(seq uint64) string {
+	return fmt.Sprintf("%s-%d", bootID, seq)
+}
+
+// ParseEventID extracts the sequence number from an ID produced by this
+// run.
+func ParseEventID(id string) (uint64, bool) {
+	boot, num, ok := strings.Cut(id, "-")
+	if !ok || boot != bootID {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(num, 10, 64)
+	return seq, err == nil
+}
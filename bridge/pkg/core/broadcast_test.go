@@ -0,0 +1,92 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+// seqs lists the sequence numbers of events.
+func seqs(evs []Event) []uint64 {
+	var out []uint64
+	for _, ev := range evs {
+		out = append(out, ev.Seq)
+	}
+	return out
+}
+
+func TestBroadcasterResume(t *testing.T) {
+	b := NewBroadcaster()
+	for i := range 5 {
+		b.Publish(Event{Data: i})
+	}
+
+	s := b.Subscribe(EventID(2))
+	if !s.Resumed || !slices.Equal(seqs(s.Replay), []uint64{3, 4, 5}) {
+		t.Errorf("resume after 2: resumed %v, replay %v", s.Resumed, seqs(s.Replay))
+	}
+	if s := b.Subscribe(EventID(5)); !s.Resumed || len(s.Replay) != 0 || s.Seq != 5 {
+		t.Errorf("resume when up to date: %+v", s)
+	}
+	for _, id := range []string{"", "other-run-3", EventID(9)} {
+		if s := b.Subscribe(id); s.Resumed || s.Seq != 5 {
+			t.Errorf("subscribe(%q) resumed; the client needs a snapshot", id)
+		}
+	}
+
+	// Live events follow the replay without a gap.
+	b.Publish(Event{Data: 5})
+	if ev := <-s.C; ev.Seq != 6 || EventID(ev.Seq) != EventID(6) {
+		t.Errorf("live event %d after the replay", ev.Seq)
+	}
+
+	// Once the backlog has moved past an ID, or been forgotten, it can no
+	// longer be resumed.
+	for range BacklogSize {
+		b.Publish(Event{})
+	}
+	if s := b.Subscribe(EventID(2)); s.Resumed {
+		t.Error("resumed from an event no longer in the backlog")
+	}
+	b.Forget()
+	if s := b.Subscribe(EventID(b.seq - 1)); s.Resumed {
+		t.Error("resumed after the backlog was forgotten")
+	}
+}
+
+func TestBroadcasterDropsSlowSubscribers(t *testing.T) {
+	b := NewBroadcaster()
+	slow, fast := b.Subscribe(""), b.Subscribe("")
+	for i := range SubscriberBuffer + 1 {
+		b.Publish(Event{Data: i})
+		<-fast.C
+	}
+	select {
+	case <-slow.Gone:
+	default:
+		t.Fatal("a subscriber a full buffer behind was kept")
+	}
+	select {
+	case <-fast.Gone:
+		t.Error("a subscriber keeping up was dropped")
+	default:
+	}
+	// The dropped subscriber gets nothing more; it reconnects and resumes
+	// from the last event it read.
+	b.Publish(Event{})
+	if len(slow.C) != SubscriberBuffer {
+		t.Errorf("dropped subscriber holds %d events", len(slow.C))
+	}
+	var last Event
+	for len(slow.C) > 0 {
+		last = <-slow.C
+	}
+	s := b.Subscribe(EventID(last.Seq))
+	if !s.Resumed || !slices.Equal(seqs(s.Replay), []uint64{last.Seq + 1, last.Seq + 2}) {
+		t.Errorf("reconnect after %d: resumed %v, replay %v", last.Seq, s.Resumed, seqs(s.Replay))
+	}
+	b.Unsubscribe(fast)
+	b.Unsubscribe(s)
+	if len(b.subs) != 0 {
+		t.Errorf("%d subscribers left", len(b.subs))
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Companion outputs
+//
+// A teacher embossing a worksheet often wants a SimBraille proof to check
+// it against, or an ink copy for the classroom teacher. POST /print takes
+// them in the same request:
+//
+//	"also": [{"printer": "virtual:Proofs"},
+//	         {"printer": "Room 12 LaserJet", "copy": "print_text"}]
+//
+// Each companion is recorded and queued as a job of its own, linked to the
+// main job: it carries "linked_to" with the main job's ID, and the main job
+// lists its companions in "linked". A companion gets the same BRF, checked
+// and adjusted for its own printer's profile, or with "copy":
+// "print_text" the request's print_text, sent as it is (the ink printer's
+// queue must take plain text). Companions are sent alongside the main job
+// rather than after it, so a virtual: proof is ready while the embosser is
+// still running; the request only waits for the main job.
+//
+// Companions follow the same rules as the main job: they are held when the
+// main job needs a teacher's approval and wait out their own printer's
+// quiet hours. One whose printer fails the pre-flight check is recorded as
+// rejected without affecting the main job.
+// ---------------------------------------------------------------------------
+
+// Companion is an extra output of a print request.
+type Companion struct {
+	Printer string `json:"printer"`
+	Copy    string `json:"copy,omitempty"` // copyBRF (default) or copyPrintText
+}
+
+const (
+	copyBRF       = "brf"
+	copyPrintText = "print_text"
+
+	maxCompanions = 4
+)
+
+// ValidCompanions checks the "also" list of a request for printer.
+func ValidCompanions(printer string, also []Companion, printText string) error {
+	if len(also) > maxCompanions {
+		return fmt.Errorf("also: at most %d companion outputs", maxCompanions)
+	}
+	for _, c := range also {
+		switch {
+		case c.Printer == "":
+			return errors.New("also: each companion needs a printer")
+		case c.Printer == printer:
+			return errors.New("also: a companion must go to a different printer")
+		case c.Copy != "" && c.Copy != copyBRF && c.Copy != copyPrintText:
+			return errors.New("also: copy must be brf or print_text")
+		case c.Copy == copyPrintText && printText == "":
+			return errors.New("also: a print_text copy needs print_text in the request")
+		}
+	}
+	return nil
+}
+
+// SendCompanions records and queues the companions of primary, linking
+// them to it. approval holds them like a main job held for a teacher.
+func SendCompanions(ctx context.Context, primary JobEvent, data []byte, opts JobOptions, approval bool) {
+	if len(opts.Also) == 0 {
+		return
+	}
+	ids := make([]int, 0, len(opts.Also))
+	for _, c := range opts.Also {
+		e := companionJob(ctx, primary, c, data, opts, approval)
+		log.Printf("job %d: companion job %d to %q is %s", primary.ID, e.ID, c.Printer, e.Status)
+		ids = append(ids, e.ID)
+	}
+	Store.Update(primary.ID, func(e *JobEvent) { e.Linked = ids })
+}
+
+// companionJob records one companion job and queues or holds it.
+func companionJob(ctx context.Context, primary JobEvent, c Companion, data []byte, opts JobOptions, approval bool) JobEvent {
+	var job JobEvent
+	if c.Copy == copyPrintText {
+		job = NewJobEvent(c.Printer, []byte(opts.PrintText))
+	} else {
+		var perr *PayloadError
+		if job, perr = PrepareJob(c.Printer, data); perr != nil {
+			job = NewJobEvent(c.Printer, data)
+			job.LinkedTo, job.Student, job.RequestID = primary.ID, primary.Student, primary.RequestID
+			job.App = primary.App
+			return RejectJob(job, errors.New(perr.Message))
+		}
+	}
+	job.LinkedTo, job.Student, job.Urgent = primary.ID, primary.Student, opts.Urgent
+	job.RequestID, job.App = primary.RequestID, primary.App
+
+	if err := Preflight(ctx, c.Printer); err != nil {
+		return RejectJob(job, err)
+	}
+	if approval {
+		job.HoldReason = HoldApproval
+		return HoldJob(job)
+	}
+	if until, quiet := QuietUntil(c.Printer, time.Now()); quiet && !opts.Urgent {
+		job.HoldReason, job.HeldUntil = HoldQuietHours, until
+		return HoldJob(job)
+	}
+	e, _ := EnqueueJob(job)
+	return e
+}
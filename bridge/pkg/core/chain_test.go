@@ -0,0 +1,25 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestValidCompanions(t *testing.T) {
+	ok := []Companion{{Printer: "virtual:Proofs"}, {Printer: "LaserJet", Copy: copyPrintText}}
+	if err := ValidCompanions("Index", ok, "Name: ____"); err != nil {
+		t.Errorf("valid companions refused: %v", err)
+	}
+	for _, bad := range [][]Companion{
+		{{Printer: "Index"}},
+		{{Printer: ""}},
+		{{Printer: "LaserJet", Copy: "pdf"}},
+		{{Printer: "a"}, {Printer: "b"}, {Printer: "c"}, {Printer: "d"}, {Printer: "e"}},
+	} {
+		if ValidCompanions("Index", bad, "text") == nil {
+			t.Errorf("%v accepted", bad)
+		}
+	}
+	if ValidCompanions("Index", ok, "") == nil {
+		t.Error("print_text copy accepted without print_text")
+	}
+}
@@ -0,0 +1,567 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Configuration
+//
+// The bridge reads an optional JSON file (default:
+// <user config dir>/graham-bridge/config.json, override with --config).
+// A missing file is not an error; every setting has a working default.
+// ---------------------------------------------------------------------------
+
+// Config is the on-disk bridge configuration.
+type Config struct {
+	// Printers holds per-destination profiles keyed by printer name
+	// (e.g. "Index Everest-D V5" or "serial:/dev/ttyUSB0").
+	Printers map[string]PrinterProfile `json:"printers,omitempty"`
+
+	// Pools share jobs between identical printers, keyed by pool name
+	// (see pool.go).
+	Pools map[string][]string `json:"pools,omitempty"`
+
+	// SpoolTempFile makes the CUPS path hand jobs to lp via a temporary
+	// file instead of stdin (Linux/macOS only).
+	SpoolTempFile bool `json:"spool_temp_file,omitempty"`
+
+	Timeouts Timeouts `json:"timeouts,omitempty"`
+
+	// Store keeps the job history in a database file instead of memory
+	// (see storedb.go). Read at startup only.
+	Store *StoreConfig `json:"store,omitempty"`
+
+	// AllowedOrigins lists extra web-app origins (e.g. a district-hosted
+	// copy of the editor) trusted in addition to the built-in ones.
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+
+	// DashboardPassword and AdminToken protect the debug dashboard and
+	// admin endpoints (see auth.go). Both empty leaves them open.
+	DashboardPassword string `json:"dashboard_password,omitempty"`
+	AdminToken        string `json:"admin_token,omitempty"`
+
+	// PayloadPassphrase, if set, derives the key that encrypts payloads
+	// spilled to disk (payloadcrypt.go) instead of the machine key.
+	PayloadPassphrase string `json:"payload_passphrase,omitempty"`
+
+	// AnonymizeStudents replaces student identifiers with pseudonyms in
+	// everything the bridge writes out (see anonymize.go).
+	AnonymizeStudents bool `json:"anonymize_students,omitempty"`
+
+	// Tokens are scoped API tokens. Configuring any also requires a print
+	// token for /print and a read token for /printers.
+	Tokens []APIToken `json:"tokens,omitempty"`
+
+	// Apps gives each client application its own tokens, daily caps and
+	// off switch (apps.go).
+	Apps map[string]AppConfig `json:"apps,omitempty"`
+
+	// AuditLog overrides the audit log location (default: audit.log in
+	// the bridge data directory).
+	AuditLog string `json:"audit_log,omitempty"`
+
+	// Email sends failure summaries to staff (see notify.go).
+	Email *EmailConfig `json:"email,omitempty"`
+
+	// Webhooks post failures and printer outages to Slack or Microsoft
+	// Teams (see webhook.go).
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+
+	// Push sends the same notifications to phones through ntfy or
+	// Gotify (see push.go).
+	Push []PushConfig `json:"push,omitempty"`
+
+	// Station serves other machines on the LAN (see station.go).
+	Station *StationConfig `json:"station,omitempty"`
+
+	// Fleet reports this bridge to a district management server (see
+	// fleet.go).
+	Fleet *FleetConfig `json:"fleet,omitempty"`
+
+	// Retry tunes how transient send failures are retried (see
+	// retry.go).
+	Retry *RetryConfig `json:"retry,omitempty"`
+
+	// Watchdog tunes the background printer health checks (see
+	// health.go).
+	Watchdog *WatchdogConfig `json:"watchdog,omitempty"`
+
+	// NativeHost lets a companion browser extension print through native
+	// messaging instead of HTTP (see nativehost.go).
+	NativeHost *NativeHostConfig `json:"native_host,omitempty"`
+
+	// Drivers are external programs that print to embossers the bridge
+	// cannot reach itself, keyed by driver name (see drivers.go).
+	Drivers map[string]DriverConfig `json:"drivers,omitempty"`
+
+	// Hooks run external commands on each job before it is queued or
+	// after it finishes (see hooks.go).
+	Hooks []HookConfig `json:"hooks,omitempty"`
+
+	// ConfirmPages makes jobs over this many pages wait for a second,
+	// confirming request (see pkg/httpapi/confirm.go); 0 prints them at once.
+	ConfirmPages int `json:"confirm_pages,omitempty"`
+}
+
+// FleetConfig points the bridge at a central management server.
+type FleetConfig struct {
+	URL             string `json:"url"`                        // https endpoint reports are POSTed to
+	Token           string `json:"token,omitempty"`            // sent as a bearer token
+	Name            string `json:"name,omitempty"`             // shown to the coordinator; default the host name
+	IntervalSeconds int    `json:"interval_seconds,omitempty"` // default 60
+}
+
+// StationConfig turns the bridge into a shared print station for a room.
+type StationConfig struct {
+	Enabled bool   `json:"enabled"`
+	Listen  string `json:"listen,omitempty"` // default ":8080" (all interfaces)
+	Name    string `json:"name,omitempty"`   // advertised name; default the host name
+
+	// AllowedHosts limits clients to these IPs or CIDR ranges; empty
+	// allows any private-network address. BlockedHosts always wins.
+	AllowedHosts []string `json:"allowed_hosts,omitempty"`
+	BlockedHosts []string `json:"blocked_hosts,omitempty"`
+}
+
+// EmailConfig holds SMTP settings for failure notifications.
+type EmailConfig struct {
+	SMTPHost string   `json:"smtp_host"`
+	SMTPPort int      `json:"smtp_port,omitempty"` // default 587 (STARTTLS); 465 uses implicit TLS
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+
+	// PrinterErrorMinutes is how long a printer must keep failing before
+	// an outage email is sent; default 10.
+	PrinterErrorMinutes int `json:"printer_error_minutes,omitempty"`
+}
+
+// Timeouts bounds each stage of handling a job. Zero means the default.
+type Timeouts struct {
+	// SendSeconds limits one delivery attempt (lp, the Windows spooler or
+	// a direct transport). Default 120.
+	SendSeconds int `json:"send_seconds,omitempty"`
+	// ListSeconds limits printer enumeration (lpstat, Get-Printer).
+	// Default 10.
+	ListSeconds int `json:"list_seconds,omitempty"`
+	// ResponseSeconds is how long /print waits for a queued job before
+	// answering 202 Accepted and letting it finish in the background.
+	// Default 300.
+	ResponseSeconds int `json:"response_seconds,omitempty"`
+	// VerifySeconds is how long a CUPS job may wait in the queue after lp
+	// accepts it before it is reported as stuck. Default 60.
+	VerifySeconds int `json:"verify_seconds,omitempty"`
+
+	// Per request (pkg/httpapi/timeouts.go). UploadSeconds limits reading
+	// a request body, such as a large BRF over slow Wi-Fi (default 120);
+	// RequestSeconds limits ordinary API calls (default 30) and
+	// ExportSeconds job history, reports and audit exports (default 300).
+	UploadSeconds  int `json:"upload_seconds,omitempty"`
+	RequestSeconds int `json:"request_seconds,omitempty"`
+	ExportSeconds  int `json:"export_seconds,omitempty"`
+}
+
+// PrinterProfile describes how to talk to one destination.
+type PrinterProfile struct {
+	// Direct transports (serial:, usb:) only.
+	ChunkSize    int    `json:"chunk_size,omitempty"`     // bytes per write; default 1024
+	ChunkDelayMS int    `json:"chunk_delay_ms,omitempty"` // pause after each chunk
+	FlowControl  string `json:"flow_control,omitempty"`   // serial: "none", "xonxoff" or "rtscts"
+	BaudRate     int    `json:"baud_rate,omitempty"`      // serial: default 9600
+	FlowCheck    bool   `json:"flow_check,omitempty"`     // serial: report on handshaking per job
+
+	// Rated embossing speed for time estimates (see progress.go); default
+	// from the model name where known.
+	CharsPerSecond int `json:"chars_per_second,omitempty"`
+
+	// Text checks, all transports.
+	CellsPerLine int    `json:"cells_per_line,omitempty"` // default 40
+	LinesPerPage int    `json:"lines_per_page,omitempty"` // default 25; for page counts
+	LongLines    string `json:"long_lines,omitempty"`     // "warn" (default), "wrap" or "reject"
+
+	// Dot layout for tactile graphics (see geometry.go).
+	DotPitchMM  float64 `json:"dot_pitch_mm,omitempty"`  // within a cell; default 2.5
+	CellPitchMM float64 `json:"cell_pitch_mm,omitempty"` // cell to cell; default 6.0
+	LinePitchMM float64 `json:"line_pitch_mm,omitempty"` // line to line; default 10.0
+	GraphicsDPI int     `json:"graphics_dpi,omitempty"`  // graphics mode resolution, if any
+
+	// Interline merges a job's print_text into the payload as ink lines
+	// (ViewPlus ink-capable embossers; see interline.go).
+	Interline *InterlineMode `json:"interline,omitempty"`
+
+	// Media maps per-job media names to the escape codes that set impact
+	// strength or speed for them (see media.go).
+	Media map[string]MediaCodes `json:"media,omitempty"`
+
+	// EightDot holds the codes that switch the embosser to eight-dot cells
+	// and back; without it eight-dot jobs are refused (see dots.go).
+	EightDot *MediaCodes `json:"eight_dot,omitempty"`
+
+	// LineSpacing maps "double" and "interline" to the codes that set that
+	// line pitch; without them double spacing inserts blank lines (see
+	// spacing.go).
+	LineSpacing map[string]MediaCodes `json:"line_spacing,omitempty"`
+
+	// QuietHours are windows when jobs are held (see quiet.go).
+	QuietHours []QuietWindow `json:"quiet_hours,omitempty"`
+
+	// Paper tracking (see consumables.go); off unless PaperSheets is set.
+	PaperSheets   int `json:"paper_sheets,omitempty"`    // sheets in a full load
+	PaperLow      int `json:"paper_low,omitempty"`       // warn at this many sheets left; default 50
+	PagesPerSheet int `json:"pages_per_sheet,omitempty"` // 2 for interpoint; default 1
+
+	// DriverOptions are passed to an external driver with each job.
+	DriverOptions map[string]any `json:"driver_options,omitempty"`
+
+	// EjectSequence ends every job exactly once; default form feed ("\f").
+	// Use "none" to send payloads unchanged.
+	EjectSequence string `json:"eject_sequence,omitempty"`
+
+	// TopOfForm is "auto", "form_feed" or a top-of-form command; empty
+	// leaves the start of jobs unchanged (see content.go).
+	TopOfForm string `json:"top_of_form,omitempty"`
+
+	// ReversePages sends pages last first, for embossers that stack their
+	// output face-down (see reverse.go).
+	ReversePages bool `json:"reverse_pages,omitempty"`
+
+	// IdentityQuery is sent to a serial embosser to ask its model and
+	// firmware (see identity.go).
+	IdentityQuery string `json:"identity_query,omitempty"`
+
+	// AirPrint is "queue" to send through a driverless queue even when a
+	// raw path to the embosser was found (see airprint.go).
+	AirPrint string `json:"airprint,omitempty"`
+}
+
+// APIToken is a named bearer token limited to one scope.
+type APIToken struct {
+	Name  string `json:"name"`
+	Token string `json:"token"`
+	Scope string `json:"scope"` // "read", "print" or "admin"
+
+	// Hold puts this token's print jobs on hold until a teacher releases
+	// them from the dashboard (e.g. for student accounts).
+	Hold bool `json:"hold,omitempty"`
+}
+
+// Serial flow-control modes accepted in PrinterProfile.FlowControl.
+const (
+	flowNone    = "none"
+	flowXonXoff = "xonxoff"
+	flowRtsCts  = "rtscts"
+)
+
+const (
+	defaultChunkSize    = 1024
+	defaultBaudRate     = 9600
+	defaultCellsPerLine = 40
+	defaultLinesPerPage = 25
+
+	defaultSendTimeout     = 120 * time.Second
+	defaultListTimeout     = 10 * time.Second
+	defaultResponseTimeout = 300 * time.Second
+	defaultVerifyTimeout   = 60 * time.Second
+
+	defaultUploadTimeout  = 120 * time.Second
+	DefaultRequestTimeout = 30 * time.Second
+	defaultExportTimeout  = 300 * time.Second
+)
+
+var (
+	CfgPath string
+	Cfg     atomic.Pointer[Config]
+)
+
+func init() {
+	Cfg.Store(&Config{})
+}
+
+// CurrentConfig returns the active configuration. Callers must not modify it.
+func CurrentConfig() *Config {
+	return Cfg.Load()
+}
+
+// DefaultConfigPath returns the per-user config file location.
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "graham-bridge.json"
+	}
+	return filepath.Join(dir, "graham-bridge", "config.json")
+}
+
+// DataDir is where the bridge keeps its own files (audit log, history,
+// student key). It is the directory holding the config file in use, so
+// two bridges started with --config in different directories keep their
+// records apart.
+func DataDir() string {
+	if CfgPath == "" {
+		return filepath.Dir(DefaultConfigPath())
+	}
+	return filepath.Dir(CfgPath)
+}
+
+// LoadConfig reads and validates the config file at path and makes it the
+// active configuration.
+func LoadConfig(path string) error {
+	CfgPath = path
+	c := &Config{}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read config: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, c); err != nil {
+			return fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	Cfg.Store(c)
+	return nil
+}
+
+// validToken checks one API token; seen holds the secrets already used.
+func validToken(t APIToken, seen map[string]bool) error {
+	if t.Name == "" || len(t.Token) < 16 {
+		return fmt.Errorf("tokens: each token needs a name and a secret of at least 16 characters")
+	}
+	if ScopeRank[t.Scope] == 0 {
+		return fmt.Errorf("token %q: scope must be read, print or admin", t.Name)
+	}
+	if seen[t.Token] {
+		return fmt.Errorf("token %q: secret reused by another token", t.Name)
+	}
+	seen[t.Token] = true
+	return nil
+}
+
+func (c *Config) Validate() error {
+	for name, p := range c.Printers {
+		switch p.FlowControl {
+		case "", flowNone, flowXonXoff, flowRtsCts:
+		default:
+			return fmt.Errorf("printer %q: unknown flow_control %q", name, p.FlowControl)
+		}
+		if p.ChunkSize < 0 || p.ChunkDelayMS < 0 || p.BaudRate < 0 || p.CellsPerLine < 0 || p.LinesPerPage < 0 {
+			return fmt.Errorf("printer %q: negative chunk_size, chunk_delay_ms, baud_rate, cells_per_line or lines_per_page", name)
+		}
+		if p.CharsPerSecond < 0 {
+			return fmt.Errorf("printer %q: chars_per_second must not be negative", name)
+		}
+		if p.PaperSheets < 0 || p.PaperLow < 0 || p.PagesPerSheet < 0 || p.PagesPerSheet > 2 {
+			return fmt.Errorf("printer %q: paper_sheets and paper_low must not be negative; pages_per_sheet must be 1 or 2", name)
+		}
+		if p.DotPitchMM < 0 || p.CellPitchMM < 0 || p.LinePitchMM < 0 || p.GraphicsDPI < 0 {
+			return fmt.Errorf("printer %q: dot_pitch_mm, cell_pitch_mm, line_pitch_mm and graphics_dpi must not be negative", name)
+		}
+		if p.Interline != nil && p.Interline.InkStart == "" {
+			return fmt.Errorf("printer %q: interline needs an ink_start sequence", name)
+		}
+		for m, codes := range p.Media {
+			if m == "" || codes.Start == "" {
+				return fmt.Errorf("printer %q: each media setting needs a name and a start sequence", name)
+			}
+		}
+		for sp, codes := range p.LineSpacing {
+			if sp != spacingDouble && sp != spacingInterline || codes.Start == "" {
+				return fmt.Errorf("printer %q: line_spacing entries must be double or interline, each with a start sequence", name)
+			}
+		}
+		for _, q := range p.QuietHours {
+			if err := q.validate(); err != nil {
+				return fmt.Errorf("printer %q: quiet_hours: %v", name, err)
+			}
+		}
+		switch p.LongLines {
+		case "", longLinesWarn, longLinesWrap, longLinesReject:
+		default:
+			return fmt.Errorf("printer %q: long_lines must be warn, wrap or reject", name)
+		}
+		switch p.AirPrint {
+		case "", airPrintRaw, AirPrintQueue:
+		default:
+			return fmt.Errorf("printer %q: airprint must be raw or queue", name)
+		}
+	}
+	if c.ConfirmPages < 0 {
+		return errors.New("confirm_pages must not be negative")
+	}
+	seen := make(map[string]bool)
+	for _, t := range c.Tokens {
+		if err := validToken(t, seen); err != nil {
+			return err
+		}
+	}
+	if err := validApps(c, seen); err != nil {
+		return err
+	}
+	for _, o := range c.AllowedOrigins {
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("allowed_origins: %q is not an origin like https://example.org", o)
+		}
+	}
+	if e := c.Email; e != nil {
+		if e.SMTPHost == "" || e.From == "" || len(e.To) == 0 {
+			return errors.New("email: smtp_host, from and to are required")
+		}
+		if e.SMTPPort < 0 || e.PrinterErrorMinutes < 0 {
+			return errors.New("email: smtp_port and printer_error_minutes must not be negative")
+		}
+	}
+	for _, h := range c.Webhooks {
+		if err := h.validate(); err != nil {
+			return err
+		}
+	}
+	if err := validPools(c); err != nil {
+		return err
+	}
+	for _, p := range c.Push {
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+	if c.Store != nil {
+		if err := c.Store.validate(); err != nil {
+			return err
+		}
+	}
+	if st := c.Station; st != nil && st.Enabled {
+		if !APITokensConfigured(c) {
+			return errors.New("station: station mode requires at least one API token")
+		}
+		if _, _, err := net.SplitHostPort(StationListen(st)); err != nil {
+			return fmt.Errorf("station: listen: %v", err)
+		}
+		for _, h := range append(slices.Clone(st.AllowedHosts), st.BlockedHosts...) {
+			if ParseHostRule(h) == nil {
+				return fmt.Errorf("station: %q is not an IP address or CIDR range", h)
+			}
+		}
+	}
+	if f := c.Fleet; f != nil {
+		u, err := url.Parse(f.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return errors.New("fleet: url must be an https:// address")
+		}
+		if f.IntervalSeconds < 0 {
+			return errors.New("fleet: interval_seconds must not be negative")
+		}
+	}
+	if r := c.Retry; r != nil && (r.Retries < 0 || r.Retries > 10 || r.BackoffSeconds < 0) {
+		return errors.New("retry: retries must be 0 to 10 and backoff_seconds must not be negative")
+	}
+	if w := c.Watchdog; w != nil && w.IntervalSeconds < 0 {
+		return errors.New("watchdog: interval_seconds must not be negative")
+	}
+	for _, h := range c.Hooks {
+		if err := h.validate(); err != nil {
+			return err
+		}
+	}
+	for name, d := range c.Drivers {
+		if err := d.validate(name); err != nil {
+			return err
+		}
+	}
+	if h := c.NativeHost; h != nil {
+		for _, id := range h.Extensions {
+			if !extensionIDRE.MatchString(id) {
+				return fmt.Errorf("native_host: %q is not an extension ID (32 letters a-p)", id)
+			}
+		}
+	}
+	t := c.Timeouts
+	if t.SendSeconds < 0 || t.ListSeconds < 0 || t.ResponseSeconds < 0 || t.VerifySeconds < 0 ||
+		t.UploadSeconds < 0 || t.RequestSeconds < 0 || t.ExportSeconds < 0 {
+		return errors.New("timeouts must not be negative")
+	}
+	return nil
+}
+
+// sendTimeout, ListTimeout, ResponseTimeout and verifyTimeout return the
+// configured stage timeouts, falling back to the defaults.
+func sendTimeout() time.Duration {
+	return secondsOr(CurrentConfig().Timeouts.SendSeconds, defaultSendTimeout)
+}
+
+func ListTimeout() time.Duration {
+	return secondsOr(CurrentConfig().Timeouts.ListSeconds, defaultListTimeout)
+}
+
+func ResponseTimeout() time.Duration {
+	return secondsOr(CurrentConfig().Timeouts.ResponseSeconds, defaultResponseTimeout)
+}
+
+func verifyTimeout() time.Duration {
+	return secondsOr(CurrentConfig().Timeouts.VerifySeconds, defaultVerifyTimeout)
+}
+
+// UploadTimeout, RequestTimeout and ExportTimeout return the per-request
+// timeouts (pkg/httpapi/timeouts.go).
+func UploadTimeout() time.Duration {
+	return secondsOr(CurrentConfig().Timeouts.UploadSeconds, defaultUploadTimeout)
+}
+
+func RequestTimeout() time.Duration {
+	return secondsOr(CurrentConfig().Timeouts.RequestSeconds, DefaultRequestTimeout)
+}
+
+func ExportTimeout() time.Duration {
+	return secondsOr(CurrentConfig().Timeouts.ExportSeconds, defaultExportTimeout)
+}
+
+func secondsOr(n int, def time.Duration) time.Duration {
+	if n == 0 {
+		return def
+	}
+	return time.Duration(n) * time.Second
+}
+
+// ProfileFor returns the profile for a destination, with defaults applied.
+func ProfileFor(printer string) PrinterProfile {
+	p := CurrentConfig().Printers[printer]
+	if p.ChunkSize == 0 {
+		p.ChunkSize = defaultChunkSize
+	}
+	if p.FlowControl == "" {
+		p.FlowControl = flowNone
+	}
+	if p.BaudRate == 0 {
+		p.BaudRate = defaultBaudRate
+	}
+	if p.CellsPerLine == 0 {
+		p.CellsPerLine = defaultCellsPerLine
+	}
+	if p.LinesPerPage == 0 {
+		p.LinesPerPage = defaultLinesPerPage
+	}
+	if p.LongLines == "" {
+		p.LongLines = longLinesWarn
+	}
+	if p.EjectSequence == "" {
+		p.EjectSequence = "\f"
+	}
+	if p.PaperLow == 0 {
+		p.PaperLow = defaultPaperLow
+	}
+	if p.PagesPerSheet == 0 {
+		p.PagesPerSheet = 1
+	}
+	return p
+}
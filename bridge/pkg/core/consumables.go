@@ -0,0 +1,194 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Consumables
+//
+// Printers whose profile sets "paper_sheets" (a full load of tractor-feed
+// paper) have their sheet use estimated from embossed page counts. Staff
+// press "Refilled" on the dashboard (POST /paper/{printer}/reset) after
+// loading paper; once the estimate falls to "paper_low" sheets the
+// dashboard shows a warning and, if email is configured, staff are mailed
+// once per load. Counts live in paper.json in the data directory so they
+// survive restarts.
+// ---------------------------------------------------------------------------
+
+const defaultPaperLow = 50
+
+// PaperLevel is the paper estimate for one printer.
+type PaperLevel struct {
+	Printer    string    `json:"printer"`
+	Loaded     int       `json:"loaded"` // sheets at the last refill
+	Used       int       `json:"used"`
+	Remaining  int       `json:"remaining"`
+	LowAt      int       `json:"low_at"`
+	Low        bool      `json:"low"`
+	RefilledAt time.Time `json:"refilled_at,omitzero"`
+
+	alerted bool
+}
+
+// paperFile is the on-disk form of the paper counts.
+type paperFile map[string]struct {
+	Loaded     int       `json:"loaded"`
+	Used       int       `json:"used"`
+	RefilledAt time.Time `json:"refilled_at,omitzero"`
+	Alerted    bool      `json:"alerted,omitempty"`
+}
+
+var paper = struct {
+	sync.Mutex
+	levels map[string]*PaperLevel
+	loaded bool
+}{}
+
+func PaperPath() string { return filepath.Join(DataDir(), "paper.json") }
+
+// paperLevelLocked returns the tracked level for printer, or nil when the
+// printer has no paper_sheets setting. paper must be locked.
+func paperLevelLocked(printer string) *PaperLevel {
+	if !paper.loaded {
+		loadPaper()
+	}
+	p := ProfileFor(printer)
+	if p.PaperSheets == 0 {
+		return nil
+	}
+	l := paper.levels[printer]
+	if l == nil {
+		l = &PaperLevel{Printer: printer, Loaded: p.PaperSheets}
+		paper.levels[printer] = l
+	}
+	l.LowAt = p.PaperLow
+	l.Remaining = max(0, l.Loaded-l.Used)
+	l.Low = l.Remaining <= l.LowAt
+	return l
+}
+
+func loadPaper() {
+	paper.loaded = true
+	paper.levels = make(map[string]*PaperLevel)
+	data, err := os.ReadFile(PaperPath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("paper: %v", err)
+		}
+		return
+	}
+	var f paperFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		log.Printf("paper: %s: %v", PaperPath(), err)
+		return
+	}
+	for name, v := range f {
+		paper.levels[name] = &PaperLevel{Printer: name, Loaded: v.Loaded, Used: v.Used, RefilledAt: v.RefilledAt, alerted: v.Alerted}
+	}
+}
+
+// savePaperLocked writes the counts via a temporary file so a crash never
+// leaves a truncated file. paper must be locked.
+func savePaperLocked() {
+	f := make(paperFile, len(paper.levels))
+	for name, l := range paper.levels {
+		v := f[name]
+		v.Loaded, v.Used, v.RefilledAt, v.Alerted = l.Loaded, l.Used, l.RefilledAt, l.alerted
+		f[name] = v
+	}
+	data, _ := json.MarshalIndent(f, "", "  ")
+	path := PaperPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		log.Printf("paper: %v", err)
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		log.Printf("paper: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Printf("paper: %v", err)
+	}
+}
+
+// RefillPaper restarts printer's count after a refill with loaded sheets,
+// or with its profile's paper_sheets when loaded is 0. It reports false
+// when the printer has no paper_sheets setting.
+func RefillPaper(printer string, loaded int) (PaperLevel, bool) {
+	paper.Lock()
+	defer paper.Unlock()
+	l := paperLevelLocked(printer)
+	if l == nil {
+		return PaperLevel{}, false
+	}
+	l.Loaded = ProfileFor(printer).PaperSheets
+	if loaded > 0 {
+		l.Loaded = loaded
+	}
+	l.Used, l.alerted, l.RefilledAt = 0, false, time.Now()
+	l = paperLevelLocked(printer)
+	savePaperLocked()
+	return *l, true
+}
+
+// ReloadPaper makes the next use reread paper.json, after a restore has
+// replaced it.
+func ReloadPaper() {
+	paper.Lock()
+	paper.loaded = false
+	paper.Unlock()
+}
+
+// useSheets charges an embossed job's sheets to its printer.
+func useSheets(e JobEvent) {
+	paper.Lock()
+	l := paperLevelLocked(e.Printer)
+	if l == nil {
+		paper.Unlock()
+		return
+	}
+	p := ProfileFor(e.Printer)
+	l.Used += (e.Pages + p.PagesPerSheet - 1) / p.PagesPerSheet
+	l = paperLevelLocked(e.Printer)
+	alert := l.Low && !l.alerted
+	if alert {
+		l.alerted = true
+	}
+	snapshot := *l
+	savePaperLocked()
+	paper.Unlock()
+
+	Store.Publish(StreamEvent{Name: "paper", Data: snapshot})
+	if alert {
+		log.Printf("paper low on %q: about %d sheets left", e.Printer, snapshot.Remaining)
+		go sendEmail("Paper low on "+e.Printer,
+			fmt.Sprintf("Printer %q has about %d sheets of paper left (of %d loaded).\n"+
+				"Refill the tractor feed, then press Refilled on the bridge dashboard.\n",
+				e.Printer, snapshot.Remaining, snapshot.Loaded))
+	}
+}
+
+// PaperLevels lists every printer with paper tracking.
+func PaperLevels() []PaperLevel {
+	paper.Lock()
+	defer paper.Unlock()
+	var out []PaperLevel
+	for name := range CurrentConfig().Printers {
+		if l := paperLevelLocked(name); l != nil {
+			out = append(out, *l)
+		}
+	}
+	slices.SortFunc(out, func(a, b PaperLevel) int { return strings.Compare(a.Printer, b.Printer) })
+	return out
+}
@@ -0,0 +1,267 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Payload checks
+//
+// /print sends bytes to the embosser untouched, so a PDF, Word document or
+// image posted by mistake would be embossed byte-for-byte — wasting a ream
+// of paper and risking a jam. Payloads are sniffed before they are queued
+// and obvious non-braille formats are refused.
+//
+// Accepted payloads then pass through the printer profile's text checks
+// (see PrepareJob); what each check found or changed is reported on the
+// JobEvent.
+// ---------------------------------------------------------------------------
+
+// PayloadError is a payload refused before queuing, with the HTTP status to
+// answer.
+type PayloadError struct {
+	Status  int
+	Message string
+}
+
+func (e *PayloadError) Error() string { return e.Message }
+
+// PrepareJob checks and adjusts a payload for its printer and builds the
+// job event to queue.
+func PrepareJob(printer string, data []byte) (JobEvent, *PayloadError) {
+	if err := brf.Check(data); err != nil {
+		return JobEvent{}, &PayloadError{http.StatusUnsupportedMediaType, err.Error()}
+	}
+	p := ProfileFor(printer)
+	data, norm := brf.Normalize(data)
+	data, lines := enforceLineLength(data, p)
+	if lines != nil && lines.Action == longLinesReject {
+		return JobEvent{}, &PayloadError{http.StatusUnprocessableEntity, fmt.Sprintf(
+			"%d line(s) exceed %d cells: %s", len(lines.Lines), lines.Limit, lineList(lines.Lines))}
+	}
+	data, tof := ensureTopOfForm(data, p)
+	data, fixed := ensureEject(data, p)
+	e := NewJobEvent(printer, data)
+	e.Pages = brf.CountPages(data, p.LinesPerPage)
+	e.Normalized = norm
+	e.LineCheck = lines
+	e.EjectFixed = fixed
+	e.TOFFixed = tof
+	return e, nil
+}
+
+// ---------------------------------------------------------------------------
+// Text normalization
+//
+// Files saved from Word arrive as UTF-8 with a byte-order mark, curly quotes
+// and non-breaking spaces, each of which embosses as one or more garbage
+// cells. These are transliterated to their ASCII equivalents; any other
+// non-ASCII characters are left alone but reported.
+// ---------------------------------------------------------------------------
+
+// NormalizeReport records what the normalization pass changed or flagged.
+type NormalizeReport = brf.NormalizeReport
+
+// ---------------------------------------------------------------------------
+// Line length
+// ---------------------------------------------------------------------------
+
+// Over-length line handling accepted in PrinterProfile.LongLines.
+const (
+	longLinesWarn   = "warn"   // print as-is and report the lines
+	longLinesWrap   = "wrap"   // break at the last space within the limit
+	longLinesReject = "reject" // refuse the job
+)
+
+// LineReport lists lines longer than the printer's cell width.
+type LineReport struct {
+	Limit  int    `json:"limit"`
+	Action string `json:"action"`
+	Lines  []int  `json:"lines"` // 1-based line numbers in the submitted payload
+}
+
+// enforceLineLength finds lines over p.CellsPerLine and, for the wrap
+// action, breaks them. Form feeds and carriage returns take no cells.
+func enforceLineLength(data []byte, p PrinterProfile) ([]byte, *LineReport) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	var long []int
+	for i, ln := range lines {
+		if cellCount(ln) > p.CellsPerLine {
+			long = append(long, i+1)
+		}
+	}
+	if len(long) == 0 {
+		return data, nil
+	}
+	rep := &LineReport{Limit: p.CellsPerLine, Action: p.LongLines, Lines: long}
+	if p.LongLines != longLinesWrap {
+		return data, rep
+	}
+
+	newline := []byte("\n")
+	if bytes.Contains(data, []byte("\r\n")) {
+		newline = []byte("\r\n")
+	}
+	var out bytes.Buffer
+	out.Grow(len(data) + len(data)/p.CellsPerLine*2)
+	for _, ln := range lines {
+		for cellCount(ln) > p.CellsPerLine {
+			cut := wrapPoint(ln, p.CellsPerLine)
+			out.Write(bytes.TrimRight(ln[:cut], " "))
+			out.Write(newline)
+			ln = bytes.TrimLeft(ln[cut:], " ")
+		}
+		out.Write(ln)
+	}
+	return out.Bytes(), rep
+}
+
+// cellCount is the number of braille cells a line occupies.
+func cellCount(line []byte) int {
+	n := 0
+	for _, b := range line {
+		if b != '\r' && b != '\n' && b != '\f' {
+			n++
+		}
+	}
+	return n
+}
+
+// wrapPoint returns the byte offset at which to break line so the first
+// part fits in limit cells, preferring the last space.
+func wrapPoint(line []byte, limit int) int {
+	cells, hard, space := 0, len(line), -1
+	for i, b := range line {
+		if b == '\r' || b == '\n' || b == '\f' {
+			continue
+		}
+		if cells == limit {
+			hard = i
+			break
+		}
+		if b == ' ' {
+			space = i
+		}
+		cells++
+	}
+	if b := line[hard]; b == ' ' {
+		return hard
+	}
+	if space > 0 {
+		return space
+	}
+	return hard
+}
+
+// lineList formats line numbers for an error message, eliding long lists.
+func lineList(lines []int) string {
+	const show = 10
+	parts := make([]string, 0, show+1)
+	for i, n := range lines {
+		if i == show {
+			parts = append(parts, fmt.Sprintf("and %d more", len(lines)-show))
+			break
+		}
+		parts = append(parts, strconv.Itoa(n))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ---------------------------------------------------------------------------
+// Top of form
+//
+// Translators often start a file with a form feed. An embosser that feeds
+// to the top of a fresh sheet by itself then wastes that sheet, while one
+// that carries on where the last job left the paper needs the form feed.
+// The profile's "top_of_form" says which kind it is:
+//
+//	"" (default)  send the payload's start unchanged
+//	"auto"        the embosser finds the top of form; leading form feeds
+//	              are removed
+//	"form_feed"   start every job with exactly one form feed
+//	anything else a model-specific top-of-form command, sent in place of
+//	              any leading form feeds
+// ---------------------------------------------------------------------------
+
+const (
+	tofAuto     = "auto"
+	tofFormFeed = "form_feed"
+)
+
+// ensureTopOfForm rewrites the start of data for the profile's top_of_form
+// setting. It reports whether the start was changed.
+func ensureTopOfForm(data []byte, p PrinterProfile) ([]byte, bool) {
+	var seq []byte
+	switch p.TopOfForm {
+	case "":
+		return data, false
+	case tofAuto:
+	case tofFormFeed:
+		seq = []byte{'\f'}
+	default:
+		seq = []byte(p.TopOfForm)
+	}
+	// Leading form feeds, and blank lines around them, are one top of form.
+	body := data
+	for len(body) > 0 {
+		trimmed := bytes.TrimLeft(body, "\r\n")
+		if len(trimmed) == 0 || trimmed[0] != '\f' {
+			break
+		}
+		body = trimmed[1:]
+	}
+	out := make([]byte, 0, len(seq)+len(body))
+	out = append(out, seq...)
+	out = append(out, body...)
+	if bytes.Equal(out, data) {
+		return data, false
+	}
+	return out, true
+}
+
+// ---------------------------------------------------------------------------
+// End of job
+// ---------------------------------------------------------------------------
+
+// ejectNone in PrinterProfile.EjectSequence disables the end-of-job pass.
+const ejectNone = "none"
+
+// ensureEject makes data end with the profile's eject sequence exactly once,
+// so the last page always leaves the embosser. Trailing line breaks and
+// repeated ejects are collapsed into a single line break plus the sequence.
+// It reports whether the ending was changed.
+func ensureEject(data []byte, p PrinterProfile) ([]byte, bool) {
+	if p.EjectSequence == ejectNone {
+		return data, false
+	}
+	seq := []byte(p.EjectSequence)
+	body := data
+	for {
+		body = bytes.TrimRight(body, "\r\n")
+		if !bytes.HasSuffix(body, seq) {
+			break
+		}
+		body = body[:len(body)-len(seq)]
+	}
+
+	out := make([]byte, 0, len(body)+len(seq)+2)
+	out = append(out, body...)
+	if len(body) > 0 {
+		if bytes.Contains(data, []byte("\r\n")) {
+			out = append(out, '\r', '\n')
+		} else {
+			out = append(out, '\n')
+		}
+	}
+	out = append(out, seq...)
+	if bytes.Equal(out, data) {
+		return data, false
+	}
+	return out, true
+}
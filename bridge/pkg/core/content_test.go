@@ -0,0 +1,22 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestEnsureTopOfForm(t *testing.T) {
+	for _, c := range []struct{ tof, data, want string }{
+		{"", "\fA\n", "\fA\n"},
+		{tofAuto, "\fA\n", "A\n"},
+		{tofAuto, "\r\n\f\r\n\fA\n", "A\n"},
+		{tofAuto, "A\n\fB\n", "A\n\fB\n"},
+		{tofFormFeed, "A\n", "\fA\n"},
+		{tofFormFeed, "\f\fA\n", "\fA\n"},
+		{"\x1bT", "\fA\n", "\x1bTA\n"},
+	} {
+		got, changed := ensureTopOfForm([]byte(c.data), PrinterProfile{TopOfForm: c.tof})
+		if string(got) != c.want || changed != (c.data != c.want) {
+			t.Errorf("%q: ensureTopOfForm(%q) = %q, %v; want %q", c.tof, c.data, got, changed, c.want)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+// Package core is the printing core of the Graham Braille Writer bridge:
+// everything it does with a job short of the HTTP server, so a kiosk app
+// or other Go program can queue, send and follow jobs the same way the
+// bridge does. It holds:
+//
+//   - the config (config.go) and the job pipeline: PrepareJob and the
+//     fix-ups, pre hooks, holds, quiet hours, EnqueueJob and AwaitJob;
+//   - the queue (queue.go): Dispatcher sends jobs one at a time per
+//     destination, and different destinations in parallel, with retries,
+//     pre-flight checks and waiting for offline printers;
+//   - the drivers: the OS spooler, AirPrint/IPP, IPP-over-USB, direct USB
+//     and serial devices, virtual and loopback printers and simulation;
+//   - the job stores (store.go, storedb.go): History keeps the latest
+//     records in a ring, indexed by ID, without dropping records that are
+//     still in progress, and the bolt and SQLite stores keep them on disk;
+//   - events: Broadcaster numbers them and fans them out to subscribers,
+//     who can resume after the last event they saw, plus notifications
+//     and webhooks.
+//
+// The bridge's endpoints are in pkg/httpapi and its executable, with the
+// tray icon, in cmd/bridge; see ARCHITECTURE.md.
+package core
+
+const (
+	ListenAddr    = "127.0.0.1:8080"
+	BridgeVersion = "3.3.0"
+)
@@ -0,0 +1,185 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Job event model
+// ---------------------------------------------------------------------------
+
+// JobEvent captures everything about a single print attempt.
+type JobEvent struct {
+	ID       int       `json:"id"`
+	Time     time.Time `json:"time"`
+	Printer  string    `json:"printer"`
+	Bytes    int       `json:"bytes"`
+	BRFText  string    `json:"brf_text"`             // first 4 KB of BRF as plain text
+	HexDump  string    `json:"hex_dump"`             // first 256 bytes formatted as hex
+	ErrMsg   string    `json:"error"`                // empty on success
+	Status   string    `json:"status"`               // see the status constants below
+	ErrCode  string    `json:"error_code,omitempty"` // machine-readable failure class (failures.go)
+	Guidance string    `json:"guidance,omitempty"`   // plain-language advice for ErrCode
+
+	ResentFrom int    `json:"resent_from,omitempty"` // source job ID for dashboard resends
+	PageRange  string `json:"page_range,omitempty"`  // pages of ResentFrom reprinted (pagerange.go)
+	Route      string `json:"route,omitempty"`       // raw path used instead of an AirPrint queue
+	PagesSent  int    `json:"pages_sent,omitempty"`  // whole pages written before the job failed
+	Attempts   int    `json:"attempts,omitempty"`    // sends tried, if retried (retry.go)
+	LinkedTo   int    `json:"linked_to,omitempty"`   // main job of a companion output (chain.go)
+	Linked     []int  `json:"linked,omitempty"`      // companion jobs sent with this one
+	Pool       string `json:"pool,omitempty"`        // pool the job was sent to (pool.go)
+	Reversed   bool   `json:"reversed,omitempty"`    // pages sent last first (reverse.go)
+	Student    string `json:"student,omitempty"`     // student identifier supplied with the job
+	App        string `json:"app,omitempty"`         // application whose token sent the job (apps.go)
+	Pages      int    `json:"pages"`                 // braille pages in the payload
+	Urgent     bool   `json:"urgent,omitempty"`      // bypasses quiet hours
+	PrintText  string `json:"print_text,omitempty"`  // first 4 KB of the ink-print text, if supplied
+	Interlined bool   `json:"interlined,omitempty"`  // print text merged into the payload as ink lines
+	Rendered   bool   `json:"rendered,omitempty"`    // a virtual printer's PDF is at /jobs/{id}/pdf
+	Media      string `json:"media,omitempty"`       // media setting applied (media.go)
+	Dots       int    `json:"dots,omitempty"`        // 8 for eight-dot jobs (dots.go)
+
+	// Line spacing applied, if not single (spacing.go).
+	LineSpacing string `json:"line_spacing,omitempty"`
+
+	// ID of the HTTP request that submitted the job
+	// (pkg/httpapi/requestid.go).
+	RequestID string `json:"request_id,omitempty"`
+
+	// Expected embossing time in seconds (progress.go); 0 if unknown.
+	Estimate int `json:"estimated_seconds,omitempty"`
+
+	// Set while Status is StatusHeld.
+	HoldReason string    `json:"hold_reason,omitempty"` // HoldApproval or HoldQuietHours
+	HeldUntil  time.Time `json:"held_until,omitzero"`   // end of quiet hours
+
+	Normalized *NormalizeReport `json:"normalized,omitempty"`  // characters transliterated or flagged
+	LineCheck  *LineReport      `json:"line_check,omitempty"`  // over-length lines found
+	Flow       *FlowReport      `json:"flow,omitempty"`        // serial flow-control check (flowcheck.go)
+	EjectFixed bool             `json:"eject_fixed,omitempty"` // end-of-job eject added or de-duplicated
+	TOFFixed   bool             `json:"tof_fixed,omitempty"`   // start rewritten for the profile's top_of_form
+	Hooks      []HookResult     `json:"hooks,omitempty"`       // hooks run on the job (hooks.go)
+
+	// Notes added after the job finished (annotations.go).
+	Annotations []Annotation `json:"annotations,omitempty"`
+
+	// Set once someone has checked the embossed output (verify.go).
+	Verified *Verification `json:"verified,omitempty"`
+
+	Payload []byte `json:"-"` // full payload until the JobStore hands it to the payload store
+}
+
+// Job lifecycle states reported in JobEvent.Status.
+const (
+	StatusQueued   = "queued"
+	StatusPrinting = "printing"
+	StatusDone     = "done"
+	StatusFailed   = "failed"
+
+	StatusHeld      = "held"      // waiting for a teacher to release it
+	StatusCancelled = "cancelled" // discarded before it was sent
+	StatusStuck     = "stuck"     // accepted by the spooler but not started (see spoolcheck_unix.go)
+	StatusWaiting   = "waiting"   // printer unreachable; sent when it comes back (offline.go)
+)
+
+// ProgressEvent reports how much of a job has been written to a direct
+// transport. It is published as the "progress" SSE event.
+type ProgressEvent struct {
+	JobID   int    `json:"job_id"`
+	Printer string `json:"printer"`
+	Sent    int    `json:"sent"`  // bytes written so far
+	Total   int    `json:"total"` // job size in bytes
+
+	Page             int `json:"page"`                        // page being embossed, from 1
+	Pages            int `json:"pages"`                       // pages in the job
+	RemainingSeconds int `json:"remaining_seconds,omitempty"` // estimate; 0 if unknown (progress.go)
+}
+
+// NewJobEvent builds the JobEvent for a print attempt.
+func NewJobEvent(printer string, data []byte) JobEvent {
+	brfText := string(data)
+	if len(brfText) > 4096 {
+		brfText = brfText[:4096]
+	}
+	e := JobEvent{
+		Time:    time.Now(),
+		Printer: printer,
+		Bytes:   len(data),
+		BRFText: brfText,
+		HexDump: hexDump(data),
+		Payload: data,
+	}
+	e.Estimate = estimateSeconds(printer, len(data))
+	return e
+}
+
+// ---------------------------------------------------------------------------
+// Hex dump helper
+// ---------------------------------------------------------------------------
+
+func hexDump(data []byte) string {
+	if len(data) > 256 {
+		data = data[:256]
+	}
+	var sb strings.Builder
+	for i := 0; i < len(data); i += 16 {
+		end := i + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		row := data[i:end]
+		sb.WriteString(fmt.Sprintf("%04x  ", i))
+		for j, b := range row {
+			sb.WriteString(fmt.Sprintf("%02x ", b))
+			if j == 7 {
+				sb.WriteByte(' ')
+			}
+		}
+		// Pad short rows
+		if len(row) < 16 {
+			pad := (16 - len(row)) * 3
+			if len(row) <= 8 {
+				pad++
+			}
+			sb.WriteString(strings.Repeat(" ", pad))
+		}
+		sb.WriteString(" |")
+		for _, b := range row {
+			if b >= 0x20 && b < 0x7f {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	return sb.String()
+}
+
+// AllDestinations lists every destination the bridge can print to: print
+// queues, simulated embossers, direct destinations from the config, driver
+// printers and attached USB and IPP-over-USB embossers with no queue.
+func AllDestinations(ctx context.Context) []PrinterInfo {
+	printers := []PrinterInfo{}
+	for _, name := range ActiveSpooler.Printers(ctx) {
+		printers = append(printers, PrinterInfo{Name: name})
+	}
+	if Simulating() {
+		for _, name := range simPrinters {
+			printers = append(printers, PrinterInfo{Name: name})
+		}
+	}
+	for name := range CurrentConfig().Printers {
+		if isDirect(name) {
+			printers = append(printers, PrinterInfo{Name: name})
+		}
+	}
+	printers = append(printers, driverPrinters(ctx)...)
+	printers = append(printers, usbPrinters(ctx)...)
+	printers = append(printers, ippUSBPrinters(ctx)...)
+	return printers
+}
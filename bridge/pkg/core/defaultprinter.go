@@ -0,0 +1,39 @@
+package core
+
+import (
+	"context"
+	"slices"
+)
+
+// ---------------------------------------------------------------------------
+// Default printer
+//
+// Most classrooms have a single embosser, so the web app should not have to
+// ask which one to use. A print request without "printer" (POST /print,
+// POST /print-url) goes to the default printer:
+//
+//	GET /printers/default → {"printer": "Index Everest"}   ("" if none is set)
+//	PUT /printers/default → {"printer": "Index Everest"}   ({"printer": ""} clears it)
+//
+// The default is kept with the shared dashboard state (uistate.go), so it
+// survives a restart and every open dashboard marks it at once. Setting it
+// needs the admin scope and a printer the bridge lists; reading it is a
+// web-app endpoint. A request without a printer is still refused when no
+// default is set.
+// ---------------------------------------------------------------------------
+
+// DefaultPrinter returns the printer for requests that name none.
+func DefaultPrinter() string {
+	return CurrentUIState().DefaultPrinter
+}
+
+// KnownDestination reports whether name is a destination the bridge lists
+// or a pool.
+func KnownDestination(ctx context.Context, name string) bool {
+	if _, ok := poolMembers(name); ok {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(ctx, ListTimeout())
+	defer cancel()
+	return slices.ContainsFunc(AllDestinations(ctx), func(p PrinterInfo) bool { return p.Name == name })
+}
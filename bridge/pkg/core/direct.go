@@ -0,0 +1,185 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Direct transports
+//
+// Destinations with a transport prefix bypass the OS spooler and are written
+// straight to the device:
+//
+//	serial:/dev/ttyUSB0, serial:COM3  — RS-232 embossers
+//	usb:/dev/usb/lp0                  — USB printer-class device nodes
+//	usb:usb://ViewPlus/Columbia?…     — macOS: a USB printer reached through
+//	                                    the CUPS usb backend (usb_darwin.go)
+//	virtual:Training                  — no device: the job is rendered to
+//	                                    a PDF kept with the job (render.go)
+//	driver:tiger/Tiger Max            — piped to an external driver
+//	                                    program (drivers.go)
+//	ipp:ipp://localhost:60000/…       — sent with IPP Print-Job, as to
+//	                                    IPP-over-USB embossers (ippusb.go)
+//	socket:192.168.1.50:9100          — raw TCP to a network embosser's
+//	                                    JetDirect port (9100 by default)
+//	loopback:Test                     — no device: the bytes are kept in
+//	                                    memory for tests (loopback.go)
+//
+// Data goes out in profile-sized chunks so small embosser buffers are not
+// overrun; serial ports additionally use the profile's flow control and are
+// drained after every chunk so progress reflects bytes actually on the wire.
+// ---------------------------------------------------------------------------
+
+const (
+	serialPrefix  = "serial:"
+	usbPrefix     = "usb:"
+	virtualPrefix = "virtual:"
+	socketPrefix  = "socket:"
+)
+
+// isDirect reports whether a destination uses a direct transport.
+func isDirect(printer string) bool {
+	return strings.HasPrefix(printer, serialPrefix) || strings.HasPrefix(printer, usbPrefix) ||
+		strings.HasPrefix(printer, virtualPrefix) || strings.HasPrefix(printer, ippPrefix) ||
+		strings.HasPrefix(printer, socketPrefix) || strings.HasPrefix(printer, LoopbackPrefix)
+}
+
+// sendJob delivers a job through the transport its destination names.
+func sendJob(ctx context.Context, job *PrintJob) error {
+	switch {
+	case strings.HasPrefix(job.Printer, serialPrefix):
+		return sendSerial(ctx, job, strings.TrimPrefix(job.Printer, serialPrefix))
+	case strings.HasPrefix(job.Printer, usbPrefix):
+		return sendUSB(ctx, job, strings.TrimPrefix(job.Printer, usbPrefix))
+	case strings.HasPrefix(job.Printer, ippPrefix):
+		return sendIPP(ctx, job, strings.TrimPrefix(job.Printer, ippPrefix))
+	case strings.HasPrefix(job.Printer, socketPrefix):
+		return sendSocket(ctx, job, strings.TrimPrefix(job.Printer, socketPrefix))
+	case strings.HasPrefix(job.Printer, virtualPrefix):
+		return sendVirtual(job)
+	case strings.HasPrefix(job.Printer, simPrefix):
+		return sendSimulated(ctx, job)
+	case strings.HasPrefix(job.Printer, LoopbackPrefix):
+		return sendLoopback(ctx, job)
+	case strings.HasPrefix(job.Printer, driverPrefix):
+		return sendDriver(ctx, job)
+	default:
+		if raw := rawRoute(ctx, job.Printer); raw != "" { // AirPrint queues (airprint.go)
+			return sendRaw(ctx, job, raw)
+		}
+		traceDump(fmt.Sprintf("%s to spooler queue %q", job.Label(), job.Printer), job.Data)
+		return ActiveSpooler.Send(ctx, job.Printer, job.Data)
+	}
+}
+
+func sendSerial(ctx context.Context, job *PrintJob, port string) error {
+	p := ProfileFor(job.Printer)
+	f, err := openSerial(port, p)
+	if err != nil {
+		return fmt.Errorf("open serial port %s: %w", port, err)
+	}
+	defer f.Close()
+	check := startFlowCheck(job.Printer, f, p) // nil unless the profile has flow_check
+	if err := writeChunked(ctx, job, f, p, func() error { return drainSerial(f) }); err != nil {
+		return err
+	}
+	check.finish(job, f)
+	return nil
+}
+
+// sendSocket writes a job to a network embosser's raw TCP port.
+func sendSocket(ctx context.Context, job *PrintJob, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", socketAddr(addr))
+	if err != nil {
+		return Classified(ErrCodeDevice, fmt.Errorf("connect to %s: %w", addr, err))
+	}
+	defer conn.Close()
+	return writeChunked(ctx, job, conn, ProfileFor(job.Printer), nil)
+}
+
+// checkSocket confirms a socket: destination accepts connections.
+func checkSocket(ctx context.Context, addr string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", socketAddr(addr))
+	if err != nil {
+		return Classified(ErrCodeDevice, fmt.Errorf("no answer from %s: %w", addr, err))
+	}
+	return conn.Close()
+}
+
+// socketAddr adds the default JetDirect port to an address without one.
+func socketAddr(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return net.JoinHostPort(strings.Trim(addr, "[]"), "9100")
+	}
+	return addr
+}
+
+// sendVirtual renders the job to a PDF instead of embossing it.
+func sendVirtual(job *PrintJob) error {
+	pdf := renderPDF(job.Data, GeometryFor(job.Printer))
+	Renders.put(job.ID, pdf)
+	Store.Update(job.ID, func(e *JobEvent) { e.Rendered = true })
+	log.Printf("%s: rendered %d bytes of PDF for %q", job.Label(), len(pdf), job.Printer)
+	return nil
+}
+
+func sendDevice(ctx context.Context, job *PrintJob, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("open device %s: %w", path, err)
+	}
+	defer f.Close()
+	return writeChunked(ctx, job, f, ProfileFor(job.Printer), nil)
+}
+
+// writeChunked writes the job in p.ChunkSize pieces, calling drain (if set)
+// after each one and publishing a progress event per chunk. A device held
+// off by flow control can block a write indefinitely, so the file is closed
+// when ctx ends to unblock it.
+func writeChunked(ctx context.Context, job *PrintJob, f io.WriteCloser, p PrinterProfile, drain func() error) error {
+	stop := context.AfterFunc(ctx, func() { f.Close() })
+	defer stop()
+
+	total := len(job.Data)
+	prog := newProgress(job)
+	for sent := 0; sent < total; {
+		if ctx.Err() != nil {
+			return fmt.Errorf("stopped after %d of %d bytes: %w", sent, total, ctx.Err())
+		}
+		end := min(sent+p.ChunkSize, total)
+		traceDump(fmt.Sprintf("%s to %q, bytes %d-%d", job.Label(), job.Printer, sent, end), job.Data[sent:end])
+		n, err := f.Write(job.Data[sent:end])
+		sent += n
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			return fmt.Errorf("write failed after %d of %d bytes: %w", sent, total, err)
+		}
+		if drain != nil {
+			if err := drain(); err != nil {
+				if ctx.Err() != nil {
+					err = ctx.Err()
+				}
+				return fmt.Errorf("drain failed after %d of %d bytes: %w", sent, total, err)
+			}
+		}
+		prog.publish(sent)
+		if p.ChunkDelayMS > 0 && sent < total {
+			select {
+			case <-time.After(time.Duration(p.ChunkDelayMS) * time.Millisecond):
+			case <-ctx.Done():
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package core
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Job dispatcher
+//
+// Every job is routed to a worker goroutine owned by its destination. A
+// worker sends one job at a time, so concurrent submissions can never
+// interleave bytes on the same embosser, while jobs for different
+// destinations still run in parallel. A worker with nothing to do retires
+// after IdleTimeout, so one-off destinations don't leave goroutines
+// behind.
+// ---------------------------------------------------------------------------
+
+// DefaultIdleTimeout is how long a worker with an empty queue waits before
+// retiring when the Dispatcher does not say.
+const DefaultIdleTimeout = 5 * time.Minute
+
+// Dispatcher queues jobs of type J per destination. Set the fields before
+// the first Submit.
+type Dispatcher[J any] struct {
+	// Send delivers one job to dest. It is never called for a destination
+	// while an earlier call for it is still running.
+	Send func(dest string, job J)
+	// Ready, if set, reports whether dest may be sent its next job now;
+	// jobs stay queued while it is false. Call WakeAll when it may have
+	// become true.
+	Ready func(dest string) bool
+	// Drained, if set, is called when dest's queue empties after sending
+	// one or more jobs.
+	Drained func(dest string)
+	// IdleTimeout is how long an idle worker waits before retiring;
+	// DefaultIdleTimeout if zero.
+	IdleTimeout time.Duration
+
+	mu      sync.Mutex
+	workers map[string]*worker[J]
+}
+
+// worker serializes all sends to one destination.
+type worker[J any] struct {
+	dest string
+
+	mu    sync.Mutex
+	queue []J
+	wake  chan struct{} // buffered(1); signalled when queue grows
+}
+
+// Submit appends a job to dest's queue, starting a worker if none is
+// running, and returns how many jobs are queued ahead of it.
+func (d *Dispatcher[J]) Submit(dest string, job J) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w, ok := d.workers[dest]
+	if !ok {
+		if d.workers == nil {
+			d.workers = make(map[string]*worker[J])
+		}
+		w = &worker[J]{dest: dest, wake: make(chan struct{}, 1)}
+		d.workers[dest] = w
+		go d.run(w)
+	}
+	return w.push(job)
+}
+
+// Promote moves the first queued job for dest that match selects to the
+// front of its queue. It reports false if there is no such job.
+func (d *Dispatcher[J]) Promote(dest string, match func(J) bool) bool {
+	d.mu.Lock()
+	w := d.workers[dest]
+	d.mu.Unlock()
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	i := slices.IndexFunc(w.queue, match)
+	if i < 0 {
+		return false
+	}
+	job := w.queue[i]
+	copy(w.queue[1:i+1], w.queue[:i])
+	w.queue[0] = job
+	return true
+}
+
+// WakeAll wakes every worker, as when Ready may have changed.
+func (d *Dispatcher[J]) WakeAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, w := range d.workers {
+		w.signal()
+	}
+}
+
+// Workers counts the destinations with a running worker.
+func (d *Dispatcher[J]) Workers() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.workers)
+}
+
+// retire removes an idle worker. It reports false if work arrived meanwhile.
+func (d *Dispatcher[J]) retire(w *worker[J]) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) > 0 {
+		return false
+	}
+	delete(d.workers, w.dest)
+	return true
+}
+
+// run is the worker loop for one destination.
+func (d *Dispatcher[J]) run(w *worker[J]) {
+	timeout := d.IdleTimeout
+	if timeout <= 0 {
+		timeout = DefaultIdleTimeout
+	}
+	idle := time.NewTimer(timeout)
+	defer idle.Stop()
+	sent := 0 // jobs sent since the queue was last empty
+	for {
+		var job J
+		ok := false
+		if d.Ready == nil || d.Ready(w.dest) {
+			job, ok = w.pop()
+		}
+		if !ok {
+			if sent > 0 && w.pending() == 0 {
+				if d.Drained != nil {
+					d.Drained(w.dest)
+				}
+				sent = 0
+			}
+			select {
+			case <-w.wake:
+			case <-idle.C:
+				if d.retire(w) {
+					return
+				}
+			}
+			idle.Reset(timeout)
+			continue
+		}
+		d.Send(w.dest, job)
+		sent++
+	}
+}
+
+// pending counts the jobs waiting in the worker's queue.
+func (w *worker[J]) pending() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.queue)
+}
+
+func (w *worker[J]) push(job J) int {
+	w.mu.Lock()
+	ahead := len(w.queue)
+	w.queue = append(w.queue, job)
+	w.mu.Unlock()
+	w.signal()
+	return ahead
+}
+
+func (w *worker[J]) pop() (J, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.queue) == 0 {
+		var zero J
+		return zero, false
+	}
+	job := w.queue[0]
+	w.queue = w.queue[1:]
+	return job, true
+}
+
+func (w *worker[J]) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sendLog records the sends a Dispatcher makes, counting how many run at
+// once on each destination and in all.
+type sendLog struct {
+	hold time.Duration
+
+	mu       sync.Mutex
+	running  map[string]int
+	overlap  bool // two sends ran at once on one destination
+	together int  // most sends running at once on any destinations
+	now      int
+	sent     map[string][]int
+}
+
+func (l *sendLog) send(dest string, job int) {
+	l.mu.Lock()
+	l.running[dest]++
+	l.now++
+	l.overlap = l.overlap || l.running[dest] > 1
+	l.together = max(l.together, l.now)
+	l.mu.Unlock()
+
+	time.Sleep(l.hold)
+
+	l.mu.Lock()
+	l.running[dest]--
+	l.now--
+	l.sent[dest] = append(l.sent[dest], job)
+	l.mu.Unlock()
+}
+
+// submitAll submits n jobs for each destination at once and waits until
+// all have been sent.
+func submitAll(t *testing.T, d *Dispatcher[int], l *sendLog, n int, dests ...string) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for _, dest := range dests {
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.Submit(dest, i)
+			}()
+		}
+	}
+	wg.Wait()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		l.mu.Lock()
+		done := 0
+		for _, jobs := range l.sent {
+			done += len(jobs)
+		}
+		l.mu.Unlock()
+		if done == n*len(dests) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d jobs sent", done, n*len(dests))
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func newSendLog(hold time.Duration) *sendLog {
+	return &sendLog{hold: hold, running: map[string]int{}, sent: map[string][]int{}}
+}
+
+func TestDispatcherSerializesOneDestination(t *testing.T) {
+	l := newSendLog(2 * time.Millisecond)
+	d := &Dispatcher[int]{Send: l.send}
+	submitAll(t, d, l, 20, "Everest")
+	if l.overlap {
+		t.Error("two jobs were sent to one destination at once")
+	}
+	got := slices.Sorted(slices.Values(l.sent["Everest"]))
+	if !slices.Equal(got, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19}) {
+		t.Errorf("sent %v", got)
+	}
+}
+
+func TestDispatcherRunsDestinationsInParallel(t *testing.T) {
+	l := newSendLog(50 * time.Millisecond)
+	d := &Dispatcher[int]{Send: l.send}
+	submitAll(t, d, l, 3, "A", "B")
+	if l.overlap {
+		t.Error("two jobs were sent to one destination at once")
+	}
+	if l.together < 2 {
+		t.Error("the two destinations never sent at the same time")
+	}
+}
+
+func TestDispatcherReadyAndDrained(t *testing.T) {
+	l := newSendLog(0)
+	var mu sync.Mutex
+	paused, drained := true, 0
+	d := &Dispatcher[int]{
+		Send: l.send,
+		Ready: func(string) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return !paused
+		},
+		Drained: func(string) {
+			mu.Lock()
+			drained++
+			mu.Unlock()
+		},
+	}
+	for i := range 4 {
+		d.Submit("Everest", i)
+	}
+	// The jobs wait while the destination is not ready, and can be
+	// reordered meanwhile.
+	is := func(id int) func(int) bool { return func(j int) bool { return j == id } }
+	if !d.Promote("Everest", is(2)) || d.Promote("Everest", is(9)) || d.Promote("Other", is(2)) {
+		t.Fatal("Promote reported the wrong result")
+	}
+	time.Sleep(20 * time.Millisecond)
+	l.mu.Lock()
+	early := len(l.sent["Everest"])
+	l.mu.Unlock()
+	if early != 0 {
+		t.Fatalf("%d jobs sent while not ready", early)
+	}
+	mu.Lock()
+	paused = false
+	mu.Unlock()
+	d.WakeAll()
+	// Drained is called once the queue is empty, so after all four sends.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := drained
+		mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Drained called %d times", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if got := l.sent["Everest"]; !slices.Equal(got, []int{2, 0, 1, 3}) {
+		t.Errorf("sent in order %v", got)
+	}
+	if d.Workers() != 1 {
+		t.Errorf("%d workers", d.Workers())
+	}
+}
@@ -0,0 +1,58 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Eight-dot mode
+//
+// Literary braille uses six-dot cells, but computer braille and some
+// foreign codes need dots 7 and 8. A print request asks for them with
+// "dots": 8; the default is 6. The printer profile says how to switch the
+// embosser over, in the same form as a media setting:
+//
+//	"eight_dot": {"start": "\u001bD8", "end": "\u001bD6"}
+//
+// The codes wrap the payload inside any media codes. A profile with an
+// empty start is for embossers left in eight-dot mode from the panel; a
+// profile without "eight_dot" refuses eight-dot jobs.
+//
+// Text payloads are sent as they are: in eight-dot mode the embosser reads
+// them as North American computer braille, where upper-case letters carry
+// dot 7. PEF documents fetched by /print-url are converted with that table
+// instead of six-dot ASCII braille, and cells with dot 8 are sent with the
+// high bit set.
+// ---------------------------------------------------------------------------
+
+const (
+	dots6 = brf.Dots6
+	dots8 = brf.Dots8
+)
+
+// ValidDots reports whether n is a dot mode a print request may ask for.
+func ValidDots(n int) bool {
+	return n == 0 || n == dots6 || n == dots8
+}
+
+// ApplyDots wraps a prepared eight-dot job's payload in its printer's mode
+// codes. Six-dot jobs are left alone.
+func ApplyDots(e *JobEvent, dots int) *PayloadError {
+	if dots != dots8 {
+		return nil
+	}
+	codes := ProfileFor(e.Printer).EightDot
+	if codes == nil {
+		return &PayloadError{http.StatusUnprocessableEntity, fmt.Sprintf(
+			"printer %q has no eight-dot mode in its profile", e.Printer)}
+	}
+	e.Payload = codes.wrap(e.Payload)
+	e.Bytes = len(e.Payload)
+	e.HexDump = hexDump(e.Payload)
+	e.Estimate = estimateSeconds(e.Printer, e.Bytes)
+	e.Dots = dots8
+	return nil
+}
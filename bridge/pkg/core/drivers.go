@@ -0,0 +1,263 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// External drivers
+//
+// Embossers the bridge cannot reach itself can be supported by a driver: any
+// executable named under "drivers" in the config. Its printers appear as
+// driver:<driver>/<printer> destinations. The bridge runs the command (with
+// its configured args) in one of two ways:
+//
+//	<command> describe
+//	    Print JSON listing the printers the driver can reach:
+//	    {"printers": [{"name": "Tiger Max", "accepting": true,
+//	                   "capabilities": {...}}]}
+//	    "accepting" (default true) false refuses jobs at pre-flight;
+//	    "capabilities" is any JSON object and is passed through to
+//	    GET /printers?details=1.
+//
+//	<command> print <printer>
+//	    Stdin holds one line of JSON job options, then the job bytes:
+//	    {"job_id": 7, "printer": "Tiger Max", "bytes": 1834,
+//	     "cells_per_line": 40, "lines_per_page": 25, "options": {...}}
+//	    "options" is the printer profile's "driver_options". Lines written
+//	    to stdout as {"sent": <bytes>} update the job's progress. Exit 0
+//	    once the job is printed; otherwise the last line of stderr is the
+//	    error, either plain text or {"error_code": "...", "error": "..."}
+//	    using the bridge's failure classes.
+//
+// describe runs within the list timeout and print within the send timeout.
+// ---------------------------------------------------------------------------
+
+const driverPrefix = "driver:"
+
+// DriverConfig names an external driver program.
+type DriverConfig struct {
+	Command string   `json:"command"`        // absolute path to the executable
+	Args    []string `json:"args,omitempty"` // placed before describe/print
+}
+
+var driverNameRE = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// driverPrinter is one printer a driver's describe reports.
+type driverPrinter struct {
+	Name         string          `json:"name"`
+	Accepting    *bool           `json:"accepting,omitempty"`
+	Capabilities json.RawMessage `json:"capabilities,omitempty"`
+}
+
+// PrinterInfo is one entry of GET /printers?details=1.
+type PrinterInfo struct {
+	Name         string          `json:"name"`
+	Driver       string          `json:"driver,omitempty"`
+	Capabilities json.RawMessage `json:"capabilities,omitempty"`
+	Model        string          `json:"model,omitempty"` // attached USB embosser with no queue (usbdetect.go)
+	Setup        string          `json:"setup,omitempty"` // how to add a print queue for it
+	Media        []string        `json:"media,omitempty"` // media settings in the profile (media.go)
+
+	// Warning is set for a queue whose driver re-renders jobs (airprint.go).
+	Warning string `json:"warning,omitempty"`
+
+	// Members lists the printers of a pool (pool.go).
+	Members []string `json:"members,omitempty"`
+}
+
+// driverJob is the options line sent ahead of the job bytes.
+type driverJob struct {
+	JobID        int            `json:"job_id"`
+	Printer      string         `json:"printer"`
+	Bytes        int            `json:"bytes"`
+	CellsPerLine int            `json:"cells_per_line"`
+	LinesPerPage int            `json:"lines_per_page"`
+	Options      map[string]any `json:"options,omitempty"`
+}
+
+// validate checks a driver entry from the config.
+func (d DriverConfig) validate(name string) error {
+	if !driverNameRE.MatchString(name) {
+		return fmt.Errorf("driver %q: names may only use letters, digits, '.', '_' and '-'", name)
+	}
+	if !filepath.IsAbs(d.Command) {
+		return fmt.Errorf("driver %q: command must be an absolute path", name)
+	}
+	return nil
+}
+
+// splitDriverDest splits driver:<driver>/<printer>.
+func splitDriverDest(dest string) (driver, printer string, ok bool) {
+	rest, ok := strings.CutPrefix(dest, driverPrefix)
+	if !ok {
+		return "", "", false
+	}
+	return strings.Cut(rest, "/")
+}
+
+// driverCommand builds the command for one driver operation.
+func driverCommand(ctx context.Context, name string, op ...string) (*exec.Cmd, error) {
+	d, ok := CurrentConfig().Drivers[name]
+	if !ok {
+		return nil, Classified(ErrCodeNotFound, fmt.Errorf("no driver named %q in the config", name))
+	}
+	cmd := exec.CommandContext(ctx, d.Command, append(slices.Clone(d.Args), op...)...)
+	cmd.WaitDelay = 5 * time.Second
+	return cmd, nil
+}
+
+// describeDriver runs a driver's describe operation.
+func describeDriver(ctx context.Context, name string) ([]driverPrinter, error) {
+	cmd, err := driverCommand(ctx, name, "describe")
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, driverFailure(name, stderr.Bytes(), err)
+	}
+	var desc struct {
+		Printers []driverPrinter `json:"printers"`
+	}
+	if err := json.Unmarshal(out, &desc); err != nil {
+		return nil, fmt.Errorf("driver %s: describe output: %w", name, err)
+	}
+	return desc.Printers, nil
+}
+
+// driverPrinters lists every configured driver's printers. Drivers that
+// fail to describe themselves are logged and skipped.
+func driverPrinters(ctx context.Context) []PrinterInfo {
+	names := make([]string, 0, len(CurrentConfig().Drivers))
+	for name := range CurrentConfig().Drivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []PrinterInfo
+	for _, name := range names {
+		printers, err := describeDriver(ctx, name)
+		if err != nil {
+			log.Printf("driver %s: %v", name, err)
+			continue
+		}
+		for _, p := range printers {
+			out = append(out, PrinterInfo{
+				Name:         driverPrefix + name + "/" + p.Name,
+				Driver:       name,
+				Capabilities: p.Capabilities,
+			})
+		}
+	}
+	return out
+}
+
+// checkDriver is the pre-flight check for driver: destinations.
+func checkDriver(ctx context.Context, dest string) error {
+	name, printer, ok := splitDriverDest(dest)
+	if !ok {
+		return Classified(ErrCodeNotFound, fmt.Errorf("%q is not of the form driver:<driver>/<printer>", dest))
+	}
+	printers, err := describeDriver(ctx, name)
+	if err != nil {
+		return err
+	}
+	for _, p := range printers {
+		if p.Name != printer {
+			continue
+		}
+		if p.Accepting != nil && !*p.Accepting {
+			return Classified(ErrCodeNotAccepting, fmt.Errorf("driver %s reports %q is not accepting jobs", name, printer))
+		}
+		return nil
+	}
+	return Classified(ErrCodeNotFound, fmt.Errorf("driver %s has no printer named %q", name, printer))
+}
+
+// sendDriver pipes a job to its driver's print operation.
+func sendDriver(ctx context.Context, job *PrintJob) error {
+	name, printer, ok := splitDriverDest(job.Printer)
+	if !ok {
+		return Classified(ErrCodeNotFound, fmt.Errorf("%q is not of the form driver:<driver>/<printer>", job.Printer))
+	}
+	cmd, err := driverCommand(ctx, name, "print", printer)
+	if err != nil {
+		return err
+	}
+	p := ProfileFor(job.Printer)
+	header, err := json.Marshal(driverJob{
+		JobID:        job.ID,
+		Printer:      printer,
+		Bytes:        len(job.Data),
+		CellsPerLine: p.CellsPerLine,
+		LinesPerPage: p.LinesPerPage,
+		Options:      p.DriverOptions,
+	})
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = io.MultiReader(bytes.NewReader(append(header, '\n')), bytes.NewReader(job.Data))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("driver %s: %w", name, err)
+	}
+	prog := newProgress(job)
+	sc := bufio.NewScanner(stdout)
+	for sc.Scan() {
+		var msg struct {
+			Sent *int `json:"sent"`
+		}
+		if json.Unmarshal(sc.Bytes(), &msg) == nil && msg.Sent != nil {
+			prog.publish(min(*msg.Sent, len(job.Data)))
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("driver %s: %w", name, ctx.Err())
+		}
+		return driverFailure(name, stderr.Bytes(), err)
+	}
+	return nil
+}
+
+// driverFailure turns a failed driver run into an error, using the last
+// line of its stderr as the message and, if it is JSON, its error code.
+func driverFailure(name string, stderr []byte, runErr error) error {
+	lines := strings.Split(strings.TrimSpace(string(stderr)), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last == "" {
+		return fmt.Errorf("driver %s: %w", name, runErr)
+	}
+	var msg struct {
+		Code  string `json:"error_code"`
+		Error string `json:"error"`
+	}
+	if json.Unmarshal([]byte(last), &msg) == nil && msg.Error != "" {
+		err := fmt.Errorf("driver %s: %s", name, msg.Error)
+		if ErrorGuidance[msg.Code] != "" {
+			return Classified(msg.Code, err)
+		}
+		return err
+	}
+	return fmt.Errorf("driver %s: %s", name, last)
+}
@@ -0,0 +1,76 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+)
+
+// ---------------------------------------------------------------------------
+// Failure classes
+//
+// "lp command failed: exit status 1" tells a teacher nothing, so transport
+// errors are classified into a small set of codes. Each code carries
+// plain-language guidance that the API returns and the dashboard shows.
+// ---------------------------------------------------------------------------
+
+// Failure classes reported in JobEvent.ErrCode and API error responses.
+const (
+	errCodeTimeout      = "timeout"                 // a stage exceeded its configured timeout
+	ErrCodeNotFound     = "printer_not_found"       // no such printer or queue
+	ErrCodeNotAccepting = "printer_not_accepting"   // queue exists but is paused or rejecting jobs
+	ErrCodeSpooler      = "spooler_unavailable"     // CUPS or the Windows spooler is not running
+	ErrCodePermission   = "permission_denied"       // the bridge may not use this printer or device
+	ErrCodeDevice       = "device_unavailable"      // a serial/USB device is missing or busy
+	ErrCodeStuck        = "job_stuck"               // the spooler accepted the job but the printer has not started it
+	errCodeHook         = "hook_rejected"           // a configured pre hook refused the job (hooks.go)
+	errCodeAttention    = "printer_needs_attention" // the printer reports offline, out of paper, jammed or open
+	ErrCodeQuota        = "quota_exceeded"          // the application's daily cap is used up (apps.go)
+	ErrCodeConfirm      = "confirmation_required"   // a large job waits for a second request (pkg/httpapi/confirm.go)
+	errCodeUnknown      = "print_failed"            // anything else
+)
+
+var ErrorGuidance = map[string]string{
+	errCodeTimeout:      "The printer did not finish in time. Check that it is switched on, online and has paper, then try again.",
+	ErrCodeNotFound:     "This computer has no printer with that name. Choose the embosser again from the printer list; it may have been renamed or removed.",
+	ErrCodeNotAccepting: "The printer is paused or is refusing jobs. Resume it in the computer's printer settings, then try again.",
+	ErrCodeSpooler:      "The computer's printing service is not running. Restart the computer, or ask IT to start the print service (CUPS or Print Spooler).",
+	ErrCodePermission:   "This computer account is not allowed to use the printer. Ask IT to grant access to the printer or device.",
+	ErrCodeDevice:       "The embosser's cable connection was not found or is in use by another program. Check the cable and close other embossing software.",
+	ErrCodeStuck:        "The print service accepted the job, but the embosser has not started it. Check that it is switched on, online and has paper; the job stays in the computer's print queue and prints once the embosser is ready.",
+	errCodeHook:         "A print rule set up for this computer stopped the job. Fix the problem described below and print again, or ask IT about the rule.",
+	errCodeAttention:    "The embosser reports a problem: it is offline, out of paper, jammed or has a cover open. Check it; jobs in the computer's print queue print once it is ready.",
+	ErrCodeQuota:        "This program has printed as much as it may today. Try again tomorrow, or ask the person who runs the bridge to raise its daily limit.",
+	ErrCodeConfirm:      "This is a long job. Check the number of pages and sheets, then confirm to print it.",
+	errCodeUnknown:      "The print job failed. The technical details below may help IT diagnose it.",
+}
+
+// jobError attaches a failure class to a transport error.
+type jobError struct {
+	code string
+	err  error
+}
+
+func (e *jobError) Error() string { return e.err.Error() }
+func (e *jobError) Unwrap() error { return e.err }
+
+// Classified wraps err with a failure class.
+func Classified(code string, err error) error {
+	return &jobError{code: code, err: err}
+}
+
+// ErrorCode returns the failure class for err.
+func ErrorCode(err error) string {
+	var je *jobError
+	switch {
+	case errors.As(err, &je):
+		return je.code
+	case errors.Is(err, context.DeadlineExceeded):
+		return errCodeTimeout
+	case errors.Is(err, fs.ErrPermission):
+		return ErrCodePermission
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrCodeDevice
+	}
+	return errCodeUnknown
+}
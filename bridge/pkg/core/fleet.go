@@ -0,0 +1,243 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Fleet registration
+//
+// With "fleet" configured, the bridge POSTs a report to a district-hosted
+// endpoint at startup and every interval_seconds: a stable bridge ID, its
+// version, printers with their current state, queue health, and summaries
+// of the jobs finished since the last successful report. An AT coordinator
+// can then watch every embosser station from one place.
+//
+// Job summaries carry no document content or student IDs. Summaries that
+// could not be delivered are kept (up to fleetMaxPending) and retried with
+// the next report.
+// ---------------------------------------------------------------------------
+
+const (
+	defaultFleetInterval = 60 * time.Second
+	fleetMaxPending      = 1000
+)
+
+// FleetReport is the JSON body sent to the management server.
+type FleetReport struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	Version  string         `json:"version"`
+	OS       string         `json:"os"`
+	Arch     string         `json:"arch"`
+	Station  bool           `json:"station"`
+	Started  time.Time      `json:"started"`
+	Time     time.Time      `json:"time"`
+	Printers []FleetPrinter `json:"printers"`
+	Health   FleetHealth    `json:"health"`
+	Jobs     []JobSummary   `json:"jobs"`
+}
+
+// FleetPrinter is one destination and whether it can take a job now.
+type FleetPrinter struct {
+	Name  string `json:"name"`
+	State string `json:"state"` // "ready" or a failure code (see failures.go)
+}
+
+// FleetHealth summarises the local queue.
+type FleetHealth struct {
+	Queued         int `json:"queued"`
+	Printing       int `json:"printing"`
+	Held           int `json:"held"`
+	FailedLastHour int `json:"failed_last_hour"`
+}
+
+// JobSummary is a finished job without its content.
+type JobSummary struct {
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Printer string    `json:"printer"`
+	Status  string    `json:"status"`
+	Pages   int       `json:"pages"`
+	Bytes   int       `json:"bytes"`
+	ErrCode string    `json:"error_code,omitempty"`
+}
+
+var (
+	fleetStarted = time.Now()
+	fleetPending = struct {
+		sync.Mutex
+		jobs []JobSummary
+	}{}
+)
+
+// fleetJobFinished queues a finished job for the next fleet report.
+func fleetJobFinished(e JobEvent) {
+	if CurrentConfig().Fleet == nil {
+		return
+	}
+	fleetPending.Lock()
+	defer fleetPending.Unlock()
+	fleetPending.jobs = append(fleetPending.jobs, JobSummary{
+		ID: e.ID, Time: e.Time, Printer: e.Printer, Status: e.Status,
+		Pages: e.Pages, Bytes: e.Bytes, ErrCode: e.ErrCode,
+	})
+	if n := len(fleetPending.jobs); n > fleetMaxPending {
+		fleetPending.jobs = fleetPending.jobs[n-fleetMaxPending:]
+	}
+}
+
+// RunFleet sends a report at startup and then on every interval. The
+// config is re-read each time, so fleet reporting can be enabled by a
+// config reload.
+func RunFleet() {
+	for {
+		interval := defaultFleetInterval
+		if f := CurrentConfig().Fleet; f != nil {
+			if f.IntervalSeconds > 0 {
+				interval = time.Duration(f.IntervalSeconds) * time.Second
+			}
+			if err := sendFleetReport(f); err != nil {
+				log.Printf("fleet: %v", err)
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+func sendFleetReport(f *FleetConfig) error {
+	fleetPending.Lock()
+	jobs := append([]JobSummary(nil), fleetPending.jobs...)
+	fleetPending.Unlock()
+
+	report := buildFleetReport(f, jobs)
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "graham-bridge/"+BridgeVersion)
+	if f.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+f.Token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("server answered %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	// Drop the summaries the server now has; newer ones stay queued.
+	fleetPending.Lock()
+	sent := make(map[int]bool, len(jobs))
+	for _, j := range jobs {
+		sent[j.ID] = true
+	}
+	kept := fleetPending.jobs[:0]
+	for _, j := range fleetPending.jobs {
+		if !sent[j.ID] {
+			kept = append(kept, j)
+		}
+	}
+	fleetPending.jobs = kept
+	fleetPending.Unlock()
+	return nil
+}
+
+func buildFleetReport(f *FleetConfig, jobs []JobSummary) FleetReport {
+	name := f.Name
+	if name == "" {
+		name = HostnameOr("unknown")
+	}
+	r := FleetReport{
+		ID:      bridgeID(),
+		Name:    name,
+		Version: BridgeVersion,
+		OS:      runtime.GOOS,
+		Arch:    runtime.GOARCH,
+		Station: StationEnabled(),
+		Started: fleetStarted,
+		Time:    time.Now(),
+		Jobs:    jobs,
+	}
+	if r.Jobs == nil {
+		r.Jobs = []JobSummary{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ListTimeout())
+	printers := AllDestinations(ctx)
+	cancel()
+	for _, p := range printers {
+		ctx, cancel := context.WithTimeout(context.Background(), ListTimeout())
+		state := "ready"
+		if err := Preflight(ctx, p.Name); err != nil {
+			state = ErrorCode(err)
+		}
+		cancel()
+		r.Printers = append(r.Printers, FleetPrinter{Name: p.Name, State: state})
+	}
+
+	hourAgo := time.Now().Add(-time.Hour)
+	for _, e := range Store.List() {
+		switch e.Status {
+		case StatusQueued:
+			r.Health.Queued++
+		case StatusPrinting:
+			r.Health.Printing++
+		case StatusHeld:
+			r.Health.Held++
+		case StatusFailed:
+			if e.Time.After(hourAgo) {
+				r.Health.FailedLastHour++
+			}
+		}
+	}
+	return r
+}
+
+var bridgeIDOnce = sync.OnceValue(loadBridgeID)
+
+// bridgeID returns this installation's stable identifier, created on
+// first use and kept in the data directory.
+func bridgeID() string { return bridgeIDOnce() }
+
+func loadBridgeID() string {
+	path := filepath.Join(DataDir(), "bridge-id")
+	if b, err := os.ReadFile(path); err == nil {
+		if id := strings.TrimSpace(string(b)); id != "" {
+			return id
+		}
+	}
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+		if err := os.WriteFile(path, []byte(id+"\n"), 0o600); err != nil {
+			log.Printf("fleet: save bridge ID: %v", err)
+		}
+	}
+	return id
+}
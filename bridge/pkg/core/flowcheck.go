@@ -0,0 +1,135 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Serial flow-control check
+//
+// Serial embossers differ in their handshaking: some send XON/XOFF, some
+// drop CTS, some do neither. When flow_control does not match the
+// embosser it keeps accepting bytes past its buffer and drops them, so
+// text goes missing mid-page with no error. Set "flow_control" in every
+// serial profile to what the embosser's serial menu says; a profile
+// without it sends with no handshaking.
+//
+// "flow_check": true turns on a diagnostic for a serial printer. Each job
+// it sends records a "flow" report: how long the write took, how long it
+// would take at the baud rate alone, and so how long the embosser held the
+// bridge off. It warns when
+//
+//   - flow_control is not set in the profile;
+//   - RTS/CTS is chosen but CTS was low when the job started, as with a
+//     cable that lacks the handshake lines;
+//   - the port's driver counted receive overruns (Linux only), so XOFF
+//     characters from the embosser may have been missed;
+//   - the embosser was sent well over what it can emboss in the time at
+//     its rated speed (progress.go) and never held the bridge off, the
+//     usual sign that handshaking is not working.
+//
+// The dashboard lists the report with the job's notes.
+// ---------------------------------------------------------------------------
+
+// FlowReport is a serial job's flow-control check.
+type FlowReport struct {
+	FlowControl string   `json:"flow_control"`
+	Seconds     float64  `json:"seconds"`            // time spent writing the job
+	LineSeconds float64  `json:"line_seconds"`       // the same bytes at the baud rate
+	HeldSeconds float64  `json:"held_seconds"`       // time the embosser held the bridge off
+	Overruns    int      `json:"overruns,omitempty"` // received bytes the port lost
+	Warnings    []string `json:"warnings,omitempty"`
+}
+
+// serialLine is a reading of a serial port's status lines and error
+// counters (readSerialLine, in the serial_*.go files).
+type serialLine struct {
+	CTS      bool // clear to send is asserted
+	Overruns int  // receive overruns counted by the driver
+	Counted  bool // the driver reports overruns
+}
+
+// flowBuffer is how far the bridge may get ahead of an embosser's rated
+// speed before a check without any hold-off warns. Embosser buffers are
+// usually a few kilobytes.
+const flowBuffer = 8 << 10
+
+// flowCheck measures one serial job.
+type flowCheck struct {
+	profile  PrinterProfile
+	explicit bool // flow_control is set in the config
+	cps      int  // rated embossing speed, 0 if unknown
+	before   serialLine
+	lines    bool // before was read
+	start    time.Time
+}
+
+// startFlowCheck begins a check of a job about to be written to f, or
+// returns nil if the printer's profile does not ask for one.
+func startFlowCheck(printer string, f *os.File, p PrinterProfile) *flowCheck {
+	if !p.FlowCheck {
+		return nil
+	}
+	c := &flowCheck{
+		profile:  p,
+		explicit: CurrentConfig().Printers[printer].FlowControl != "",
+		cps:      charsPerSecond(printer),
+		start:    time.Now(),
+	}
+	before, err := readSerialLine(f)
+	c.before, c.lines = before, err == nil
+	return c
+}
+
+// finish records the check on a job written successfully to f.
+func (c *flowCheck) finish(job *PrintJob, f *os.File) {
+	if c == nil {
+		return
+	}
+	elapsed := time.Since(c.start)
+	after, _ := readSerialLine(f) // zero if the port was closed
+	r := c.report(len(job.Data), elapsed, after)
+	for _, w := range r.Warnings {
+		log.Printf("job %d: flow check: %s", job.ID, w)
+	}
+	Store.Update(job.ID, func(e *JobEvent) { e.Flow = &r })
+}
+
+// report works out the check for n bytes written in elapsed.
+func (c *flowCheck) report(n int, elapsed time.Duration, after serialLine) FlowReport {
+	p := c.profile
+	line := time.Duration(n) * 10 * time.Second / time.Duration(p.BaudRate) // 8N1: 10 bits a byte
+	chunks := (n + p.ChunkSize - 1) / p.ChunkSize
+	delays := time.Duration(max(0, chunks-1)*p.ChunkDelayMS) * time.Millisecond
+	held := max(0, elapsed-line-delays)
+
+	r := FlowReport{
+		FlowControl: p.FlowControl,
+		Seconds:     elapsed.Round(10 * time.Millisecond).Seconds(),
+		LineSeconds: line.Round(10 * time.Millisecond).Seconds(),
+		HeldSeconds: held.Round(10 * time.Millisecond).Seconds(),
+	}
+	if !c.explicit {
+		r.Warnings = append(r.Warnings, "flow_control is not set in the profile, so the bridge sends without handshaking; "+
+			"set it to what the embosser's serial menu says")
+	}
+	if p.FlowControl == flowRtsCts && c.lines && !c.before.CTS {
+		r.Warnings = append(r.Warnings, "CTS was low when the job started: the cable may not carry the RTS/CTS lines, "+
+			"or the embosser was offline")
+	}
+	if after.Counted && c.before.Counted && after.Overruns > c.before.Overruns {
+		r.Overruns = after.Overruns - c.before.Overruns
+		r.Warnings = append(r.Warnings, fmt.Sprintf("the serial port lost %d received byte(s), so XOFF signals from the embosser may have been missed", r.Overruns))
+	}
+	if c.cps > 0 && held < time.Second {
+		ahead := n - int(elapsed.Seconds()*float64(c.cps))
+		if ahead > flowBuffer {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("the embosser took %d bytes more than it can emboss in %.0fs and never held the bridge off; "+
+				"if text goes missing mid-page, flow_control %q does not match the embosser", ahead, elapsed.Seconds(), p.FlowControl))
+		}
+	}
+	return r
+}
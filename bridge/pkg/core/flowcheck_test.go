@@ -0,0 +1,31 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlowCheckReport(t *testing.T) {
+	p := PrinterProfile{BaudRate: 9600, ChunkSize: 1024, FlowControl: flowXonXoff}
+	c := &flowCheck{profile: p, explicit: true, cps: 100}
+
+	// 20 KB at 9600 baud takes about 21 s on the wire; an embosser at 100
+	// characters a second that never pauses the bridge cannot keep up.
+	r := c.report(20<<10, 22*time.Second, serialLine{})
+	if r.HeldSeconds > 1 || len(r.Warnings) != 1 {
+		t.Errorf("unpaced job: got %+v", r)
+	}
+
+	// The same job held off until the embosser caught up.
+	r = c.report(20<<10, 205*time.Second, serialLine{})
+	if r.HeldSeconds < 180 || len(r.Warnings) != 0 {
+		t.Errorf("paced job: got %+v", r)
+	}
+
+	c.explicit = false
+	c.before = serialLine{Overruns: 3, Counted: true}
+	r = c.report(1000, 2*time.Second, serialLine{Overruns: 5, Counted: true})
+	if r.Overruns != 2 || len(r.Warnings) != 2 {
+		t.Errorf("overruns: got %+v", r)
+	}
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Embosser geometry
+//
+// The web app's tactile-graphics tools draw on a grid of braille dots. To
+// scale an image to real-world size they need each embosser's dot spacing,
+// which varies by model, so profiles may declare it:
+//
+//	dot_pitch_mm   distance between dots within a cell (default 2.5)
+//	cell_pitch_mm  distance between cells on a line (default 6.0)
+//	line_pitch_mm  distance between braille lines (default 10.0)
+//	graphics_dpi   resolution of the embosser's graphics mode, if any
+//
+// GET /printers/{name}/geometry returns them with the page size in cells
+// and dots. POST /printers/{name}/ruler embosses a ruler page whose bars
+// and edge marks can be measured to calibrate the values.
+// ---------------------------------------------------------------------------
+
+const (
+	defaultDotPitchMM  = 2.5
+	defaultCellPitchMM = 6.0
+	defaultLinePitchMM = 10.0
+)
+
+// Geometry describes one embosser's page and dot layout.
+type Geometry struct {
+	Printer      string  `json:"printer"`
+	CellsPerLine int     `json:"cells_per_line"`
+	LinesPerPage int     `json:"lines_per_page"`
+	DotPitchMM   float64 `json:"dot_pitch_mm"`
+	CellPitchMM  float64 `json:"cell_pitch_mm"`
+	LinePitchMM  float64 `json:"line_pitch_mm"`
+	GraphicsDPI  int     `json:"graphics_dpi,omitempty"`
+
+	// Derived: the dot grid the graphics tools draw on (two dot columns
+	// and three dot rows per cell) and the embossed area in millimetres.
+	DotColumns int     `json:"dot_columns"`
+	DotRows    int     `json:"dot_rows"`
+	WidthMM    float64 `json:"width_mm"`
+	HeightMM   float64 `json:"height_mm"`
+}
+
+// GeometryFor returns the geometry of a destination, with defaults.
+func GeometryFor(printer string) Geometry {
+	p := ProfileFor(printer)
+	g := Geometry{
+		Printer:      printer,
+		CellsPerLine: p.CellsPerLine,
+		LinesPerPage: p.LinesPerPage,
+		DotPitchMM:   p.DotPitchMM,
+		CellPitchMM:  p.CellPitchMM,
+		LinePitchMM:  p.LinePitchMM,
+		GraphicsDPI:  p.GraphicsDPI,
+	}
+	if g.DotPitchMM == 0 {
+		g.DotPitchMM = defaultDotPitchMM
+	}
+	if g.CellPitchMM == 0 {
+		g.CellPitchMM = defaultCellPitchMM
+	}
+	if g.LinePitchMM == 0 {
+		g.LinePitchMM = defaultLinePitchMM
+	}
+	g.DotColumns = 2 * g.CellsPerLine
+	g.DotRows = 3 * g.LinesPerPage
+	g.WidthMM = float64(g.CellsPerLine-1)*g.CellPitchMM + g.DotPitchMM
+	g.HeightMM = float64(g.LinesPerPage-1)*g.LinePitchMM + 2*g.DotPitchMM
+	return g
+}
+
+// RulerPage builds a one-page calibration ruler in braille ASCII:
+//
+//   - a heading giving the cell and line counts;
+//   - a full-width bar of full cells: its length divided by
+//     cells_per_line-1 (less one dot pitch) is the cell pitch;
+//   - a tick row marking every fifth and tenth cell;
+//   - full cells at both edges of every line, with the line number every
+//     fifth line: the distance between the first and last bar divided by
+//     lines_per_page-1 is the line pitch.
+func RulerPage(g Geometry) []byte {
+	const full = "=" // dots 1-2-3-4-5-6
+	width, lines := g.CellsPerLine, g.LinesPerPage
+	var out []string
+	out = append(out, strings.Repeat(full, width))
+
+	var tick strings.Builder
+	for c := 0; c < width; c++ {
+		switch {
+		case c%10 == 0:
+			tick.WriteString(full)
+		case c%5 == 0:
+			tick.WriteString("l") // dots 1-2-3: a short vertical mark
+		default:
+			tick.WriteByte(' ')
+		}
+	}
+	out = append(out, strings.TrimRight(tick.String(), " "))
+
+	heading := fmt.Sprintf(",RULER %s CELLS %s L9ES", brailleNumber(width), brailleNumber(lines))
+	if len(heading) > width-2 {
+		heading = ""
+	}
+	for n := 3; n < lines; n++ {
+		mid := ""
+		switch {
+		case n == 3:
+			mid = heading
+		case n%5 == 0:
+			mid = brailleNumber(n)
+		}
+		pad := max(0, width-2-len(mid))
+		out = append(out, full+mid+strings.Repeat(" ", pad)+full)
+	}
+	out = append(out, strings.Repeat(full, width))
+	return []byte(strings.Join(out, "\r\n") + "\r\n")
+}
+
+// brailleNumber writes n in braille ASCII: the number sign followed by the
+// letters a-j for the digits 1-9 and 0.
+func brailleNumber(n int) string {
+	var b strings.Builder
+	b.WriteByte('#')
+	for _, d := range strconv.Itoa(n) {
+		if d == '0' {
+			b.WriteByte('j')
+		} else {
+			b.WriteByte(byte('a' + d - '1'))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,327 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Printer health watchdog
+//
+// A job's result only says how the printer was when the job ran. The
+// watchdog checks every destination the bridge knows about on an interval
+// (default 60 seconds) and pushes a "printer" event over /log-stream
+// whenever one changes state, so the dashboard shows a printer going
+// offline before anyone tries to print to it.
+//
+// Destinations are checked the same way as GET /printers/{name}/status:
+// lpstat or the Windows spooler for print queues, the pre-flight check for
+// direct, driver and simulated destinations. A CUPS queue that looks ready
+// is also checked at its network address, since CUPS only notices a printer
+// has gone when it next tries to send to it:
+//
+//	ipp://   IPP Get-Printer-Attributes (printer-state and its reasons)
+//	ipps://  TCP connect to the IPP port
+//	socket:// and lpd://  TCP connect to the raw (9100) or LPD (515) port
+//
+// "watchdog": {"interval_seconds": 30} changes the interval and
+// "watchdog": {"disabled": true} turns the checks off.
+// ---------------------------------------------------------------------------
+
+const defaultWatchdogInterval = 60 * time.Second
+
+// WatchdogConfig tunes the health watchdog.
+type WatchdogConfig struct {
+	IntervalSeconds int  `json:"interval_seconds,omitempty"` // default 60
+	Disabled        bool `json:"disabled,omitempty"`
+}
+
+// PrinterHealth is the data of a "printer" stream event.
+type PrinterHealth struct {
+	PrinterState
+	Online  bool      `json:"online"`
+	Checked time.Time `json:"checked"`
+	Held    bool      `json:"held,omitempty"` // queue held until it is fixed (printerhold.go)
+}
+
+// health holds the last state seen for each destination.
+var health = struct {
+	sync.Mutex
+	last map[string]PrinterHealth
+}{last: make(map[string]PrinterHealth)}
+
+// RunWatchdog checks every destination on each interval. The config is
+// re-read each time, so a reload can change or disable it.
+func RunWatchdog() {
+	for {
+		interval := defaultWatchdogInterval
+		w := CurrentConfig().Watchdog
+		if w != nil && w.IntervalSeconds > 0 {
+			interval = time.Duration(w.IntervalSeconds) * time.Second
+		}
+		if w == nil || !w.Disabled {
+			checkDestinations()
+		} else {
+			releaseAttentionHolds()
+		}
+		time.Sleep(interval)
+	}
+}
+
+// checkDestinations checks each destination once and publishes the ones
+// whose state changed. Inconclusive checks leave the last state standing.
+func checkDestinations() {
+	ctx, cancel := context.WithTimeout(context.Background(), ListTimeout())
+	printers := AllDestinations(ctx)
+	cancel()
+	for _, name := range waitingPrinters() {
+		if !slices.ContainsFunc(printers, func(p PrinterInfo) bool { return p.Name == name }) {
+			printers = append(printers, PrinterInfo{Name: name})
+		}
+	}
+	for _, p := range printers {
+		s := DestinationState(context.Background(), p.Name)
+		if s.State == stateUnknown {
+			continue
+		}
+		h := PrinterHealth{PrinterState: s, Online: isOnline(s.State), Checked: time.Now()}
+		if h.State == stateReady {
+			flushWaiting(p.Name) // offline.go
+		}
+		notified := updateAttentionHold(p.Name, h)
+		h.Held = attentionHeld(p.Name)
+
+		health.Lock()
+		prev, seen := health.last[p.Name]
+		health.last[p.Name] = h
+		health.Unlock()
+
+		if seen && prev.State == h.State {
+			continue
+		}
+		if seen && prev.Online != h.Online {
+			if h.Online {
+				log.Printf("printer %q is back online (%s)", p.Name, h.State)
+			} else {
+				log.Printf("printer %q went offline (%s)", p.Name, h.State)
+			}
+		}
+		if seen && !notified {
+			notifyPrinterChange(p.Name, prev, h)
+		}
+		Store.Publish(StreamEvent{Name: "printer", Data: h})
+	}
+}
+
+// isOnline reports whether a printer in state can be reached at all.
+func isOnline(state string) bool {
+	return state != stateOffline && state != stateNotFound
+}
+
+// checkNetworkDevice looks past CUPS to the printer at a queue's device
+// URI. URIs it cannot check report ready.
+func checkNetworkDevice(ctx context.Context, uri string) (string, []string) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Hostname() == "" {
+		return stateReady, nil
+	}
+	switch u.Scheme {
+	case "ipp":
+		state, reasons, err := ippState(ctx, u)
+		if err != nil {
+			return stateOffline, []string{err.Error()}
+		}
+		return state, reasons
+	case "ipps":
+		err = tcpPing(ctx, u, "631")
+	case "socket":
+		err = tcpPing(ctx, u, "9100")
+	case "lpd":
+		err = tcpPing(ctx, u, "515")
+	}
+	if err != nil {
+		return stateOffline, []string{err.Error()}
+	}
+	return stateReady, nil
+}
+
+// tcpPing connects to a device's port and hangs up without sending.
+func tcpPing(ctx context.Context, u *url.URL, defaultPort string) error {
+	port := u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return fmt.Errorf("no answer from %s: %w", u.Host, err)
+	}
+	return conn.Close()
+}
+
+// IPP tags used by the bridge's Get-Printer-Attributes requests (RFC 8010).
+const (
+	ippOperationTag   = 0x01
+	ippEndTag         = 0x03
+	ippEnum           = 0x23
+	ippKeyword        = 0x44
+	ippURI            = 0x45
+	ippCharset        = 0x47
+	ippNaturalLang    = 0x48
+	ippGetPrinterOp   = 0x000b
+	ippPrinterBusy    = 4 // printer-state "processing"
+	ippPrinterStopped = 5 // the printer needs attention
+)
+
+// ippState asks an IPP printer for its state with Get-Printer-Attributes.
+func ippState(ctx context.Context, u *url.URL) (string, []string, error) {
+	body, err := ippGetAttributes(ctx, u, "printer-state", "printer-state-reasons")
+	if err != nil {
+		return "", nil, err
+	}
+	return parseIPPState(body)
+}
+
+// ippGetAttributes sends Get-Printer-Attributes for the named attributes
+// and returns the raw response.
+func ippGetAttributes(ctx context.Context, u *url.URL, attrs ...string) ([]byte, error) {
+	req := ippRequest(ippGetPrinterOp, u)
+	for i, a := range attrs {
+		name := "requested-attributes"
+		if i > 0 {
+			name = ""
+		}
+		ippAttr(req, ippKeyword, name, []byte(a))
+	}
+	req.WriteByte(ippEndTag)
+	return ippPost(ctx, u, req)
+}
+
+// ippRequest starts an IPP request for operation op on the printer at u,
+// leaving the operation attributes group open.
+func ippRequest(op byte, u *url.URL) *bytes.Buffer {
+	req := new(bytes.Buffer)
+	req.Write([]byte{2, 0, 0, op, 0, 0, 0, 1, ippOperationTag})
+	ippAttr(req, ippCharset, "attributes-charset", []byte("utf-8"))
+	ippAttr(req, ippNaturalLang, "attributes-natural-language", []byte("en"))
+	ippAttr(req, ippURI, "printer-uri", []byte(u.String()))
+	return req
+}
+
+// ippPost sends an IPP request to the printer at u and returns the
+// response.
+func ippPost(ctx context.Context, u *url.URL, req io.Reader) ([]byte, error) {
+	target := *u
+	target.Scheme = "http"
+	if target.Port() == "" {
+		target.Host = net.JoinHostPort(u.Hostname(), "631")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/ipp")
+	if b, ok := req.(*bytes.Buffer); ok {
+		traceDump("IPP request to "+u.Host, b.Bytes())
+	}
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("no answer from %s: %w", u.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered HTTP %d", u.Host, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	traceDump("IPP response from "+u.Host, body)
+	return body, err
+}
+
+// ippAttr appends one attribute (or, with an empty name, an additional
+// value of the previous one).
+func ippAttr(b *bytes.Buffer, tag byte, name string, value []byte) {
+	b.WriteByte(tag)
+	binary.Write(b, binary.BigEndian, uint16(len(name)))
+	b.WriteString(name)
+	binary.Write(b, binary.BigEndian, uint16(len(value)))
+	b.Write(value)
+}
+
+// parseIPPState reads printer-state and printer-state-reasons from a
+// Get-Printer-Attributes response.
+func parseIPPState(body []byte) (string, []string, error) {
+	state, printerState := stateReady, 0
+	var reasons []string
+	err := walkIPP(body, func(tag byte, name string, value []byte) {
+		switch {
+		case name == "printer-state" && tag == ippEnum && len(value) == 4:
+			printerState = int(binary.BigEndian.Uint32(value))
+		case name == "printer-state-reasons" && string(value) != "none":
+			reasons = append(reasons, string(value))
+			state = worseState(state, reasonState(string(value)))
+		}
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	switch {
+	case printerState == ippPrinterStopped && state == stateReady:
+		state = stateError
+	case printerState == ippPrinterBusy && state == stateReady:
+		state = statePrinting
+	}
+	return state, reasons, nil
+}
+
+// walkIPP calls fn for each attribute value in an IPP response. Additional
+// values of a multi-valued attribute are passed with its name.
+func walkIPP(body []byte, fn func(tag byte, name string, value []byte)) error {
+	if len(body) < 8 {
+		return errors.New("short IPP response")
+	}
+	if status := binary.BigEndian.Uint16(body[2:4]); status >= 0x0100 {
+		return fmt.Errorf("IPP status 0x%04x", status)
+	}
+	var name string
+	for p := 8; p < len(body); {
+		tag := body[p]
+		p++
+		if tag == ippEndTag {
+			break
+		}
+		if tag < 0x10 { // attribute group delimiter
+			continue
+		}
+		if p+2 > len(body) {
+			break
+		}
+		n := int(binary.BigEndian.Uint16(body[p:]))
+		p += 2
+		if p+n+2 > len(body) {
+			break
+		}
+		if n > 0 {
+			name = string(body[p : p+n])
+		}
+		p += n
+		v := int(binary.BigEndian.Uint16(body[p:]))
+		p += 2
+		if p+v > len(body) {
+			break
+		}
+		fn(tag, name, body[p:p+v])
+		p += v
+	}
+	return nil
+}
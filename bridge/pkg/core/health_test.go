@@ -0,0 +1,44 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseIPPState(t *testing.T) {
+	response := func(state byte, reasons ...string) []byte {
+		var b bytes.Buffer
+		b.Write([]byte{2, 0, 0, 0, 0, 0, 0, 1, ippOperationTag})
+		ippAttr(&b, ippCharset, "attributes-charset", []byte("utf-8"))
+		b.WriteByte(0x04) // printer attributes group
+		ippAttr(&b, ippEnum, "printer-state", []byte{0, 0, 0, state})
+		for i, r := range reasons {
+			name := "printer-state-reasons"
+			if i > 0 {
+				name = ""
+			}
+			ippAttr(&b, ippKeyword, name, []byte(r))
+		}
+		b.WriteByte(ippEndTag)
+		return b.Bytes()
+	}
+	tests := []struct {
+		body []byte
+		want string
+	}{
+		{response(3, "none"), stateReady},
+		{response(4, "none"), statePrinting},
+		{response(5, "none"), stateError},
+		{response(5, "media-empty-error", "cover-open-error"), statePaperOut},
+		{response(3, "toner-low-warning"), stateReady},
+	}
+	for i, tt := range tests {
+		got, _, err := parseIPPState(tt.body)
+		if err != nil || got != tt.want {
+			t.Errorf("case %d: got %q, %v; want %q", i, got, err, tt.want)
+		}
+	}
+	if _, _, err := parseIPPState([]byte{2, 0, 0x04, 0x00, 0, 0, 0, 1, ippEndTag}); err == nil {
+		t.Error("client-error-not-found status accepted")
+	}
+}
@@ -0,0 +1,187 @@
+package core
+
+import "sync"
+
+// ---------------------------------------------------------------------------
+// Record history
+//
+// A History keeps the latest records in a ring buffer indexed by ID, and
+// assigns the IDs. When it is full, adding a record evicts the oldest
+// finished one; a record still in progress (a queued or held print job)
+// is never evicted, since whoever is working on it needs it. If every
+// record is unfinished the ring grows by a slot instead.
+// ---------------------------------------------------------------------------
+
+// History is a ring of records of type T. The zero value is not usable;
+// call NewHistory.
+type History[T any] struct {
+	mu     sync.RWMutex
+	ring   []T
+	start  int         // slot of the oldest record
+	count  int         // records currently held
+	index  map[int]int // record ID → slot
+	nextID int
+
+	id       func(T) int
+	finished func(T) bool
+}
+
+// NewHistory returns a history holding size records. id reads a record's
+// ID, and finished reports whether a record may be evicted.
+func NewHistory[T any](size int, id func(T) int, finished func(T) bool) *History[T] {
+	return &History[T]{
+		ring:     make([]T, size),
+		index:    make(map[int]int, size),
+		nextID:   1,
+		id:       id,
+		finished: finished,
+	}
+}
+
+// Add stores the record build returns for the next ID. It returns the
+// record and the ID of the record it evicted to make room, or 0. build
+// runs with the history locked, so IDs are used in order.
+func (h *History[T]) Add(build func(id int) T) (T, int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	rec := build(h.nextID)
+	h.nextID++
+
+	evicted := 0
+	if h.count == len(h.ring) {
+		evicted = h.evictFinished()
+	}
+	if h.count == len(h.ring) {
+		h.grow()
+	}
+	slot := (h.start + h.count) % len(h.ring)
+	h.count++
+	h.ring[slot] = rec
+	h.index[h.id(rec)] = slot
+	return rec, evicted
+}
+
+// evictFinished drops the oldest finished record from a full ring, moving
+// the unfinished records older than it up a slot, and returns its ID, or 0
+// if every record is unfinished. h.mu must be held.
+func (h *History[T]) evictFinished() int {
+	at := func(i int) int { return (h.start + i) % len(h.ring) }
+	k := 0
+	for k < h.count && !h.finished(h.ring[at(k)]) {
+		k++
+	}
+	if k == h.count {
+		return 0
+	}
+	id := h.id(h.ring[at(k)])
+	delete(h.index, id)
+	for i := k; i > 0; i-- {
+		h.ring[at(i)] = h.ring[at(i-1)]
+		h.index[h.id(h.ring[at(i)])] = at(i)
+	}
+	var zero T
+	h.ring[h.start] = zero
+	h.start = at(1)
+	h.count--
+	return id
+}
+
+// grow adds a slot to a full ring. h.mu must be held.
+func (h *History[T]) grow() {
+	ring := make([]T, len(h.ring)+1)
+	for i := range h.count {
+		ring[i] = h.ring[(h.start+i)%len(h.ring)]
+		h.index[h.id(ring[i])] = i
+	}
+	h.ring, h.start = ring, 0
+}
+
+// Update applies fn to a stored record and returns the result.
+func (h *History[T]) Update(id int, fn func(*T)) (T, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	slot, ok := h.index[id]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	fn(&h.ring[slot])
+	return h.ring[slot], true
+}
+
+// Get returns the record with the given ID.
+func (h *History[T]) Get(id int) (T, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	slot, ok := h.index[id]
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return h.ring[slot], true
+}
+
+// List returns all stored records, oldest first.
+func (h *History[T]) List() []T {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	out := make([]T, h.count)
+	for i := range out {
+		out[i] = h.ring[(h.start+i)%len(h.ring)]
+	}
+	return out
+}
+
+// Purge deletes the records match selects and returns them, oldest first.
+func (h *History[T]) Purge(match func(T) bool) []T {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var kept, purged []T
+	for i := range h.count {
+		rec := h.ring[(h.start+i)%len(h.ring)]
+		if match(rec) {
+			purged = append(purged, rec)
+		} else {
+			kept = append(kept, rec)
+		}
+	}
+	if len(purged) == 0 {
+		return nil
+	}
+	clear(h.ring)
+	clear(h.index)
+	for i, rec := range kept {
+		h.ring[i] = rec
+		h.index[h.id(rec)] = i
+	}
+	h.start, h.count = 0, len(kept)
+	return purged
+}
+
+// Load fills an empty history with records read back from storage, oldest
+// first, keeping the newest if there are more than fit. IDs continue
+// after the highest loaded.
+func (h *History[T]) Load(recs []T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(recs) > len(h.ring) {
+		recs = recs[len(recs)-len(h.ring):]
+	}
+	for i, rec := range recs {
+		h.ring[i] = rec
+		h.index[h.id(rec)] = i
+		h.nextID = max(h.nextID, h.id(rec)+1)
+	}
+	h.start, h.count = 0, len(recs)
+}
+
+// OldestID returns the ID of the oldest record held, or the next ID if
+// none.
+func (h *History[T]) OldestID() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.count == 0 {
+		return h.nextID
+	}
+	return h.id(h.ring[h.start])
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"slices"
+	"testing"
+)
+
+type rec struct {
+	id     int
+	status string
+}
+
+func newRecs(size int) *History[rec] {
+	return NewHistory(size, func(r rec) int { return r.id }, func(r rec) bool { return r.status == "done" })
+}
+
+func add(h *History[rec], status string) rec {
+	r, _ := h.Add(func(id int) rec { return rec{id, status} })
+	return r
+}
+
+// ids lists the IDs of the records in h, oldest first.
+func ids(h *History[rec]) []int {
+	var out []int
+	for _, r := range h.List() {
+		out = append(out, r.id)
+	}
+	return out
+}
+
+func TestHistoryEviction(t *testing.T) {
+	h := newRecs(3)
+	for range 5 {
+		add(h, "done")
+	}
+	if got := ids(h); !slices.Equal(got, []int{3, 4, 5}) {
+		t.Fatalf("full ring holds %v", got)
+	}
+
+	// An unfinished record stays put while the finished ones around it go.
+	h.Update(4, func(r *rec) { r.status = "held" })
+	for range 2 {
+		add(h, "done")
+	}
+	if _, evicted := h.Add(func(id int) rec { return rec{id, "done"} }); evicted != 6 {
+		t.Errorf("evicted %d, want 6", evicted)
+	}
+	if got := ids(h); !slices.Equal(got, []int{4, 7, 8}) {
+		t.Errorf("ring with a held record holds %v", got)
+	}
+	if _, ok := h.Get(3); ok {
+		t.Error("evicted record 3 is still indexed")
+	}
+
+	// With nothing finished to evict the ring grows.
+	h.Update(7, func(r *rec) { r.status = "queued" })
+	h.Update(8, func(r *rec) { r.status = "waiting" })
+	add(h, "held")
+	if got := ids(h); !slices.Equal(got, []int{4, 7, 8, 9}) {
+		t.Errorf("ring of unfinished records holds %v", got)
+	}
+	for _, id := range []int{4, 7, 8, 9} {
+		if r, ok := h.Get(id); !ok || r.id != id {
+			t.Errorf("Get(%d) = %d, %v", id, r.id, ok)
+		}
+	}
+	if h.OldestID() != 4 {
+		t.Errorf("OldestID = %d", h.OldestID())
+	}
+}
+
+func TestHistoryHeldRecordOutlivesHistory(t *testing.T) {
+	h := newRecs(200)
+	held := add(h, "held")
+	for range 200 {
+		add(h, "done")
+	}
+	if _, ok := h.Update(held.id, func(r *rec) { r.status = "queued" }); !ok {
+		t.Fatal("the held record was evicted")
+	}
+	if n := len(h.List()); n != 200 {
+		t.Errorf("%d records held, want 200", n)
+	}
+}
+
+func TestHistoryPurgeAndLoad(t *testing.T) {
+	h := newRecs(4)
+	for range 4 {
+		add(h, "done")
+	}
+	purged := h.Purge(func(r rec) bool { return r.id%2 == 0 })
+	if len(purged) != 2 || !slices.Equal(ids(h), []int{1, 3}) {
+		t.Errorf("purged %v, left %v", purged, ids(h))
+	}
+	if r := add(h, "done"); r.id != 5 {
+		t.Errorf("next ID after a purge %d", r.id)
+	}
+
+	h = newRecs(2)
+	h.Load([]rec{{3, "done"}, {5, "done"}, {8, "done"}})
+	if !slices.Equal(ids(h), []int{5, 8}) || h.OldestID() != 5 {
+		t.Errorf("loaded %v", ids(h))
+	}
+	if r := add(h, "done"); r.id != 9 {
+		t.Errorf("next ID after loading %d", r.id)
+	}
+}
@@ -0,0 +1,171 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Job hooks
+//
+// Districts can run their own programs on print jobs without changing the
+// bridge: insert a school header, strip a watermark line, enforce a naming
+// rule. Each entry under "hooks" in the config is an external command run
+// for jobs submitted through /print and /print-url, optionally only for
+// some printers.
+//
+// A "pre" hook runs before the job is checked and queued. Its stdin holds
+// one line of JSON ({"printer", "student", "bytes"}) followed by the
+// payload; whatever it writes to stdout replaces the payload, and writing
+// nothing leaves it unchanged. A non-zero exit fails the hook, with the last
+// line of stderr as the reason. By default that refuses the job (recorded
+// as rejected with error code hook_rejected); "on_error": "continue" sends
+// the unmodified payload instead.
+//
+// A "post" hook runs after the job finishes, with the job record as JSON
+// on stdin; its output is ignored.
+//
+// Hooks run in config order. Each run, with whether it changed the payload
+// or failed, is recorded on the job for the dashboard.
+// ---------------------------------------------------------------------------
+
+const (
+	HookPre  = "pre"
+	hookPost = "post"
+
+	hookReject   = "reject"
+	HookContinue = "continue"
+
+	hookTimeout = 30 * time.Second
+)
+
+// HookConfig is one hook from the config.
+type HookConfig struct {
+	Name     string   `json:"name"`
+	Stage    string   `json:"stage"`   // "pre" or "post"
+	Command  string   `json:"command"` // absolute path to the executable
+	Args     []string `json:"args,omitempty"`
+	Printers []string `json:"printers,omitempty"` // limit to these printers; empty means all
+	OnError  string   `json:"on_error,omitempty"` // pre hooks: "reject" (default) or "continue"
+}
+
+// HookResult records one hook run on a job.
+type HookResult struct {
+	Name    string `json:"name"`
+	Stage   string `json:"stage"`
+	Changed bool   `json:"changed,omitempty"` // pre hook replaced the payload
+	Error   string `json:"error,omitempty"`
+}
+
+// hookInput is the JSON line ahead of the payload for pre hooks.
+type hookInput struct {
+	Printer string `json:"printer"`
+	Student string `json:"student,omitempty"`
+	Bytes   int    `json:"bytes"`
+}
+
+func (h HookConfig) validate() error {
+	if h.Name == "" {
+		return errors.New("hooks: each hook needs a name")
+	}
+	if h.Stage != HookPre && h.Stage != hookPost {
+		return fmt.Errorf("hook %q: stage must be pre or post", h.Name)
+	}
+	if !filepath.IsAbs(h.Command) {
+		return fmt.Errorf("hook %q: command must be an absolute path", h.Name)
+	}
+	switch h.OnError {
+	case "", hookReject, HookContinue:
+	default:
+		return fmt.Errorf("hook %q: on_error must be reject or continue", h.Name)
+	}
+	return nil
+}
+
+// hooksFor returns the hooks of one stage that apply to printer.
+func hooksFor(stage, printer string) []HookConfig {
+	var out []HookConfig
+	for _, h := range CurrentConfig().Hooks {
+		if h.Stage == stage && (len(h.Printers) == 0 || slices.Contains(h.Printers, printer)) {
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// runHook runs one hook command with stdin, returning its stdout.
+func runHook(h HookConfig, stdin []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, h.Command, h.Args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timed out after %s", hookTimeout)
+		}
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if msg := strings.TrimSpace(lines[len(lines)-1]); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// RunPreHooks passes a payload through the pre hooks for its printer. A
+// hook failure that refuses the job is returned as a hook_rejected error
+// alongside the results so far.
+func RunPreHooks(printer, student string, data []byte) ([]byte, []HookResult, error) {
+	var results []HookResult
+	for _, h := range hooksFor(HookPre, printer) {
+		header, _ := json.Marshal(hookInput{Printer: printer, Student: student, Bytes: len(data)})
+		out, err := runHook(h, append(append(header, '\n'), data...))
+		r := HookResult{Name: h.Name, Stage: HookPre}
+		switch {
+		case err != nil:
+			r.Error = err.Error()
+			log.Printf("hook %s: %v", h.Name, err)
+			results = append(results, r)
+			if h.OnError != HookContinue {
+				return data, results, Classified(errCodeHook, fmt.Errorf("hook %s: %v", h.Name, err))
+			}
+			continue
+		case len(out) > 0 && !bytes.Equal(out, data):
+			data, r.Changed = out, true
+		}
+		results = append(results, r)
+	}
+	return data, results, nil
+}
+
+// runPostHooks runs the post hooks for a finished job and records their
+// results on it.
+func runPostHooks(e JobEvent) {
+	hooks := hooksFor(hookPost, e.Printer)
+	if len(hooks) == 0 {
+		return
+	}
+	record, _ := json.Marshal(e)
+	var results []HookResult
+	for _, h := range hooks {
+		r := HookResult{Name: h.Name, Stage: hookPost}
+		if _, err := runHook(h, record); err != nil {
+			r.Error = err.Error()
+			log.Printf("hook %s: job %d: %v", h.Name, e.ID, err)
+		}
+		results = append(results, r)
+	}
+	Store.Update(e.ID, func(e *JobEvent) { e.Hooks = append(e.Hooks, results...) })
+}
@@ -0,0 +1,240 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Printer identity
+//
+// Some embosser faults only happen with one firmware release, so support
+// needs to know exactly what is attached. GET /printers/{name}/identity
+// asks the embosser itself, over whichever channel can answer:
+//
+//   - ipp: destinations and CUPS queues with an ipp:// device: the
+//     printer-make-and-model, printer-firmware-string-version and
+//     printer-device-id attributes;
+//   - USB (usb: destinations and CUPS queues on a USB embosser): the IEEE
+//     1284 device ID the embosser reports when it is plugged in;
+//   - serial: destinations: the profile's identity_query, the escape code
+//     the embosser answers with its model and firmware (Index embossers
+//     list theirs in the manual's ESC command table).
+//
+// The reply is returned as it came, and parsed as a device ID if it looks
+// like one. A serial embosser that is printing cannot be asked, so the
+// request fails with 409 until the job finishes. Destinations without a
+// channel back (virtual:, sim:, driver:, Windows queues) say so in
+// "error". The dashboard shows the model and firmware under the printer
+// list.
+// ---------------------------------------------------------------------------
+
+// PrinterIdentity is the response from GET /printers/{name}/identity.
+type PrinterIdentity struct {
+	Printer  string `json:"printer"`
+	Source   string `json:"source,omitempty"` // "ipp", "ieee1284" or "serial"
+	Model    string `json:"model,omitempty"`
+	Firmware string `json:"firmware,omitempty"`
+	Serial   string `json:"serial_number,omitempty"`
+	DeviceID string `json:"device_id,omitempty"` // IEEE 1284 device ID
+	Reply    string `json:"reply,omitempty"`     // serial query reply as sent
+	Error    string `json:"error,omitempty"`
+}
+
+// IdentityTimeout bounds the whole query, including a serial reply.
+const IdentityTimeout = 5 * time.Second
+
+// identityReplyMax is the longest serial reply read.
+const identityReplyMax = 256
+
+// ErrPrinterBusy is returned when a serial embosser is printing.
+var ErrPrinterBusy = errors.New("the embosser is printing; ask again when the job finishes")
+
+// QueryIdentity asks a destination's embosser what it is.
+func QueryIdentity(ctx context.Context, printer string) (PrinterIdentity, error) {
+	id := PrinterIdentity{Printer: printer}
+	switch {
+	case strings.HasPrefix(printer, serialPrefix):
+		return serialIdentity(ctx, id)
+	case strings.HasPrefix(printer, usbPrefix):
+		return usbIdentity(ctx, id)
+	case strings.HasPrefix(printer, ippPrefix):
+		u, err := ippDestURL(strings.TrimPrefix(printer, ippPrefix))
+		if err != nil {
+			return id, err
+		}
+		return ippIdentity(ctx, id, u)
+	case strings.HasPrefix(printer, virtualPrefix), strings.HasPrefix(printer, simPrefix), strings.HasPrefix(printer, LoopbackPrefix):
+		return id, errors.New("virtual printers have no embosser to ask")
+	case strings.HasPrefix(printer, driverPrefix):
+		return id, errors.New("driver destinations do not report their embosser")
+	case strings.HasPrefix(printer, socketPrefix):
+		return id, errors.New("a raw socket has no channel back from the embosser")
+	}
+
+	uri := deviceURI(ctx, printer)
+	u, err := url.Parse(uri)
+	switch {
+	case uri == "" || err != nil:
+		return id, errors.New("the print system does not say how this printer is connected")
+	case u.Scheme == "ipp":
+		return ippIdentity(ctx, id, u)
+	case u.Scheme == "usb":
+		return usbIdentity(ctx, id)
+	}
+	return id, fmt.Errorf("cannot ask a %s: printer for its identity", u.Scheme)
+}
+
+// ippIdentity reads the identity attributes from an IPP printer.
+func ippIdentity(ctx context.Context, id PrinterIdentity, u *url.URL) (PrinterIdentity, error) {
+	body, err := ippGetAttributes(ctx, u, "printer-make-and-model",
+		"printer-firmware-string-version", "printer-device-id")
+	if err != nil {
+		return id, err
+	}
+	id.Source = "ipp"
+	return id, parseIPPIdentity(body, &id)
+}
+
+// parseIPPIdentity fills id from a Get-Printer-Attributes response. The
+// first firmware version listed is the main firmware's.
+func parseIPPIdentity(body []byte, id *PrinterIdentity) error {
+	err := walkIPP(body, func(_ byte, name string, value []byte) {
+		switch name {
+		case "printer-make-and-model":
+			id.Model = string(value)
+		case "printer-firmware-string-version":
+			if id.Firmware == "" {
+				id.Firmware = string(value)
+			}
+		case "printer-device-id":
+			id.DeviceID = string(value)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if id.DeviceID != "" {
+		fromDeviceID(id, id.DeviceID)
+	}
+	return nil
+}
+
+// usbIdentity reads the device ID of the USB embosser a destination or
+// CUPS queue prints to.
+func usbIdentity(ctx context.Context, id PrinterIdentity) (PrinterIdentity, error) {
+	devices, err := attachedUSB(ctx)
+	if err != nil {
+		return id, err
+	}
+	for _, d := range devices {
+		if d.Dest != id.Printer && d.Queue != id.Printer {
+			continue
+		}
+		if d.DeviceID == "" {
+			return id, errors.New("the USB embosser did not report a device ID")
+		}
+		id.Source = "ieee1284"
+		id.DeviceID = d.DeviceID
+		fromDeviceID(&id, d.DeviceID)
+		return id, nil
+	}
+	return id, errors.New("the USB embosser is not attached")
+}
+
+// serialIdentity sends the profile's identity query and reads the reply.
+func serialIdentity(ctx context.Context, id PrinterIdentity) (PrinterIdentity, error) {
+	p := ProfileFor(id.Printer)
+	if p.IdentityQuery == "" {
+		return id, errors.New("the printer profile has no identity_query for this embosser")
+	}
+	if printerBusy(id.Printer) {
+		return id, ErrPrinterBusy
+	}
+	port := strings.TrimPrefix(id.Printer, serialPrefix)
+	f, err := openSerial(port, p)
+	if err != nil {
+		return id, fmt.Errorf("open serial port %s: %w", port, err)
+	}
+	defer f.Close()
+	reply, err := serialQuery(ctx, f, p.IdentityQuery)
+	if err != nil {
+		return id, err
+	}
+	id.Source = "serial"
+	id.Reply = reply
+	if strings.Contains(reply, ":") {
+		id.DeviceID = strings.TrimSpace(reply)
+		fromDeviceID(&id, id.DeviceID)
+	} else {
+		id.Model = strings.TrimSpace(reply)
+	}
+	return id, nil
+}
+
+// serialQuery writes query and reads the reply until the embosser goes
+// quiet or ctx expires. Where the port has no read deadlines (Windows),
+// openSerial's read timeouts end the read instead.
+func serialQuery(ctx context.Context, f *os.File, query string) (string, error) {
+	traceDump("identity query to "+f.Name(), []byte(query))
+	if _, err := f.WriteString(query); err != nil {
+		return "", err
+	}
+	deadline, _ := ctx.Deadline()
+	var reply []byte
+	buf := make([]byte, identityReplyMax)
+	for len(reply) < identityReplyMax {
+		wait := time.Now().Add(time.Second)
+		if len(reply) > 0 {
+			wait = time.Now().Add(200 * time.Millisecond) // end of the reply
+		}
+		if !deadline.IsZero() && deadline.Before(wait) {
+			wait = deadline
+		}
+		f.SetReadDeadline(wait)
+		n, err := f.Read(buf[:identityReplyMax-len(reply)])
+		reply = append(reply, buf[:n]...)
+		if err != nil || n == 0 {
+			break
+		}
+	}
+	traceDump("identity reply from "+f.Name(), reply)
+	if len(reply) == 0 {
+		return "", errors.New("the embosser did not answer the identity query")
+	}
+	return strings.TrimRight(string(reply), "\x00"), nil
+}
+
+// fromDeviceID fills in model, firmware and serial number from an IEEE
+// 1284 device ID, keeping any already set.
+func fromDeviceID(id *PrinterIdentity, deviceID string) {
+	f := parseDeviceID(deviceID)
+	if mfg, mdl := deviceMakeModel(f); id.Model == "" {
+		id.Model = strings.TrimSpace(mfg + " " + mdl)
+	}
+	for _, k := range []string{"FWVER", "FW", "FIRMWARE", "REV", "VER"} {
+		if id.Firmware == "" {
+			id.Firmware = f[k]
+		}
+	}
+	for _, k := range []string{"SN", "SERN", "SERIALNUMBER"} {
+		if id.Serial == "" {
+			id.Serial = f[k]
+		}
+	}
+}
+
+// printerBusy reports whether a job is being sent to printer.
+func printerBusy(printer string) bool {
+	for _, e := range Store.List() {
+		if e.Printer == printer && e.Status == StatusPrinting {
+			return true
+		}
+	}
+	return false
+}
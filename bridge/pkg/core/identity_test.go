@@ -0,0 +1,27 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseIPPIdentity(t *testing.T) {
+	var b bytes.Buffer
+	b.Write([]byte{2, 0, 0, 0, 0, 0, 0, 1, ippOperationTag})
+	ippAttr(&b, ippCharset, "attributes-charset", []byte("utf-8"))
+	b.WriteByte(0x04) // printer attributes group
+	// 0x41 is textWithoutLanguage.
+	ippAttr(&b, 0x41, "printer-make-and-model", []byte("ViewPlus Columbia"))
+	ippAttr(&b, 0x41, "printer-firmware-string-version", []byte("3.2.1"))
+	ippAttr(&b, 0x41, "", []byte("1.0"))
+	ippAttr(&b, 0x41, "printer-device-id", []byte("MFG:ViewPlus;MDL:Columbia;SN:VP1234;"))
+	b.WriteByte(ippEndTag)
+
+	id := PrinterIdentity{Printer: "Columbia"}
+	if err := parseIPPIdentity(b.Bytes(), &id); err != nil {
+		t.Fatal(err)
+	}
+	if id.Model != "ViewPlus Columbia" || id.Firmware != "3.2.1" || id.Serial != "VP1234" {
+		t.Errorf("got %+v", id)
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Interline print
+//
+// A job may carry "print_text": the ink-print version of the braille, one
+// print line per braille line with form feeds at the same page breaks, so
+// a sighted parent can read along. The text is checked against the braille
+// layout and kept with the job for the dashboard preview.
+//
+// For a printer whose profile has "interline" (ViewPlus embossers with ink
+// capability), the payload is rewritten so each braille line is followed
+// by its print line wrapped in the profile's ink_start/ink_end sequences,
+// which switch the embosser to ink and back (see the embosser's
+// programming manual). Other printers emboss the braille unchanged.
+// ---------------------------------------------------------------------------
+
+// InterlineMode configures ink-plus-braille output for one printer.
+type InterlineMode struct {
+	InkStart string `json:"ink_start"`         // switches the head to ink printing
+	InkEnd   string `json:"ink_end,omitempty"` // switches back to embossing
+}
+
+// splitPages splits text into pages of lines, dropping line-ending CRs and
+// the empty page after a final form feed.
+func splitPages(data []byte) [][]string {
+	pages := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\f")
+	if len(pages) > 1 && strings.TrimSpace(pages[len(pages)-1]) == "" {
+		pages = pages[:len(pages)-1]
+	}
+	out := make([][]string, len(pages))
+	for i, p := range pages {
+		out[i] = strings.Split(strings.TrimSuffix(p, "\n"), "\n")
+	}
+	return out
+}
+
+// AttachPrintText checks ink-print text against a prepared job's braille
+// layout, records it for the preview and, for interline printers, merges
+// it into the payload.
+func AttachPrintText(e *JobEvent, printText string) *PayloadError {
+	ink, _ := brf.Normalize([]byte(printText))
+	brl := splitPages(e.Payload)
+	inkPages := splitPages(ink)
+	if len(inkPages) > len(brl) {
+		return &PayloadError{http.StatusUnprocessableEntity, fmt.Sprintf(
+			"print_text has %d pages but the braille has %d", len(inkPages), len(brl))}
+	}
+	for i, lines := range inkPages {
+		if len(lines) > len(brl[i]) {
+			return &PayloadError{http.StatusUnprocessableEntity, fmt.Sprintf(
+				"print_text page %d has %d lines but the braille page has %d", i+1, len(lines), len(brl[i]))}
+		}
+	}
+
+	e.PrintText = string(ink)
+	if len(e.PrintText) > 4096 {
+		e.PrintText = e.PrintText[:4096]
+	}
+	mode := ProfileFor(e.Printer).Interline
+	if mode == nil {
+		return nil
+	}
+	e.Payload = interleave(e.Payload, inkPages, *mode)
+	e.Bytes = len(e.Payload)
+	e.Estimate = estimateSeconds(e.Printer, e.Bytes)
+	e.HexDump = hexDump(e.Payload)
+	e.Interlined = true
+	return nil
+}
+
+// interleave writes each braille line followed by its print line. Page
+// breaks and the job's line-ending style are preserved.
+func interleave(data []byte, ink [][]string, mode InterlineMode) []byte {
+	eol := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		eol = "\r\n"
+	}
+	pages := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\f")
+	var b strings.Builder
+	for i, page := range pages {
+		if i > 0 {
+			b.WriteByte('\f')
+		}
+		if i >= len(ink) {
+			b.WriteString(strings.ReplaceAll(page, "\n", eol))
+			continue
+		}
+		lines := strings.Split(page, "\n")
+		for j, line := range lines {
+			if j == len(lines)-1 && line == "" {
+				break // page ended with a line break
+			}
+			b.WriteString(line)
+			b.WriteString(eol)
+			if j < len(ink[i]) && strings.TrimSpace(ink[i][j]) != "" {
+				b.WriteString(mode.InkStart)
+				b.WriteString(ink[i][j])
+				b.WriteString(mode.InkEnd)
+				b.WriteString(eol)
+			}
+		}
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,180 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Direct IPP and IPP-over-USB
+//
+// Newer embossers speak IPP over their USB port (the IPP-USB class)
+// instead of taking raw bytes on a printer-class interface. On Linux the
+// ipp-usb daemon claims such devices and serves each one over HTTP on a
+// localhost port, from 60000 up. The bridge finds those ports in ipp-usb's
+// state files (ippusb_linux.go), asks each embosser for its model and
+// lists the ones no CUPS queue uses in GET /printers as
+//
+//	ipp:ipp://localhost:60000/ipp/print
+//
+// An ipp: destination sends each job to the printer at the URI with an IPP
+// Print-Job request, as application/octet-stream so the embosser gets the
+// BRF unchanged. Progress events follow the upload, and the printer's own
+// printer-state and printer-state-reasons answer GET
+// /printers/{name}/status and the pre-flight check, so paper-out and
+// offline are reported without CUPS. Any ipp:// printer on the network can
+// be added to the config as an ipp: destination the same way.
+// ---------------------------------------------------------------------------
+
+const ippPrefix = "ipp:"
+
+// IPP values used by Print-Job.
+const (
+	ippPrintJobOp    = 0x0002
+	ippInteger       = 0x21
+	ippNameNoLang    = 0x42
+	ippMimeMediaType = 0x49
+	ippNotAccepting  = 0x0506 // server-error-not-accepting-jobs
+	ippServerBusy    = 0x0507 // server-error-busy
+)
+
+// ippUSBProbeTimeout bounds the model query to each ipp-usb port; ports of
+// unplugged devices do not answer.
+const ippUSBProbeTimeout = 2 * time.Second
+
+// ippUSBDevice is a device ipp-usb serves on a localhost port.
+type ippUSBDevice struct {
+	Port  int
+	Name  string // ipp-usb's DNS-SD name for it
+	Queue string // CUPS queue already using it, if any
+}
+
+// ippUSBURI is the IPP URI ipp-usb serves a device at.
+func ippUSBURI(port int) string {
+	return "ipp://localhost:" + strconv.Itoa(port) + "/ipp/print"
+}
+
+// ippUSBPrinters lists IPP-over-USB embossers that are plugged in, have
+// no print queue and are not already configured as destinations.
+func ippUSBPrinters(ctx context.Context) []PrinterInfo {
+	devices, err := attachedIPPUSB(ctx)
+	if err != nil {
+		log.Printf("IPP-USB scan: %v", err)
+		return nil
+	}
+	var out []PrinterInfo
+	for _, d := range devices {
+		dest := ippPrefix + ippUSBURI(d.Port)
+		if d.Queue != "" {
+			continue
+		}
+		if _, ok := CurrentConfig().Printers[dest]; ok {
+			continue
+		}
+		u, _ := url.Parse(ippUSBURI(d.Port))
+		probe, cancel := context.WithTimeout(ctx, ippUSBProbeTimeout)
+		body, err := ippGetAttributes(probe, u, "printer-make-and-model")
+		cancel()
+		if err != nil {
+			continue // unplugged: ipp-usb keeps the state file
+		}
+		var model string
+		walkIPP(body, func(_ byte, name string, value []byte) {
+			if name == "printer-make-and-model" {
+				model = string(value)
+			}
+		})
+		if model == "" {
+			model = d.Name
+		}
+		if model != "" && !isEmbosser(model) {
+			continue
+		}
+		out = append(out, PrinterInfo{Name: dest, Model: model, Setup: fmt.Sprintf(
+			"No print queue uses this embosser; ipp-usb shares it on port %d and the bridge prints to it over IPP directly.", d.Port)})
+	}
+	return out
+}
+
+// ippDestURL parses the printer URI of an ipp: destination.
+func ippDestURL(uri string) (*url.URL, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "ipp" || u.Hostname() == "" {
+		return nil, Classified(ErrCodeNotFound, fmt.Errorf("%q is not an ipp://host/path printer URI", uri))
+	}
+	return u, nil
+}
+
+// checkIPP is the pre-flight check for ipp: destinations: the printer
+// must answer.
+func checkIPP(ctx context.Context, uri string) error {
+	u, err := ippDestURL(uri)
+	if err != nil {
+		return err
+	}
+	if _, _, err := ippState(ctx, u); err != nil {
+		return Classified(ErrCodeDevice, err)
+	}
+	return nil
+}
+
+// sendIPP sends a job to the IPP printer at uri with Print-Job.
+func sendIPP(ctx context.Context, job *PrintJob, uri string) error {
+	u, err := ippDestURL(uri)
+	if err != nil {
+		return err
+	}
+	req := ippRequest(ippPrintJobOp, u)
+	ippAttr(req, ippNameNoLang, "requesting-user-name", []byte("graham-bridge"))
+	ippAttr(req, ippNameNoLang, "job-name", []byte("Job "+strconv.Itoa(job.ID)))
+	ippAttr(req, ippMimeMediaType, "document-format", []byte("application/octet-stream"))
+	req.WriteByte(ippEndTag)
+
+	body := &progressReader{r: bytes.NewReader(job.Data), prog: newProgress(job)}
+	resp, err := ippPost(ctx, u, io.MultiReader(req, body))
+	if err != nil {
+		return Classified(ErrCodeDevice, err)
+	}
+	if len(resp) < 8 {
+		return fmt.Errorf("short IPP response from %s", u.Host)
+	}
+	switch status := binary.BigEndian.Uint16(resp[2:4]); {
+	case status == ippNotAccepting:
+		return Classified(ErrCodeNotAccepting, fmt.Errorf("%s is not accepting jobs", u.Host))
+	case status == ippServerBusy:
+		return Classified(ErrCodeDevice, fmt.Errorf("%s is busy", u.Host))
+	case status >= 0x0100:
+		return fmt.Errorf("%s refused the job: IPP status 0x%04x", u.Host, status)
+	}
+	var printerJob int
+	walkIPP(resp, func(tag byte, name string, value []byte) {
+		if name == "job-id" && tag == ippInteger && len(value) == 4 {
+			printerJob = int(binary.BigEndian.Uint32(value))
+		}
+	})
+	log.Printf("job %d: accepted by %s as IPP job %d", job.ID, u.Host, printerJob)
+	return nil
+}
+
+// progressReader publishes progress as a job's bytes are read for upload.
+type progressReader struct {
+	r    io.Reader
+	sent int
+	prog *progress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.sent += n
+		p.prog.publish(p.sent)
+	}
+	return n, err
+}
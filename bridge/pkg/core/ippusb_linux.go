@@ -0,0 +1,83 @@
+//go:build linux
+
+package core
+
+import (
+	"bufio"
+	"context"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ipp-usb keeps a state file per device it has seen, with the localhost
+// port it serves the device on. The files outlive the device, so the
+// caller must check the port answers.
+const ippUSBStateDir = "/var/ipp-usb/dev"
+
+// attachedIPPUSB lists the devices in ipp-usb's state files and the CUPS
+// queues that print to them.
+func attachedIPPUSB(ctx context.Context) ([]ippUSBDevice, error) {
+	paths, err := filepath.Glob(filepath.Join(ippUSBStateDir, "*.state"))
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "lpstat", "-v")
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	out, _ := cmd.Output() // no CUPS, or no queues
+	queues := parseLpstatV(string(out))
+
+	var found []ippUSBDevice
+	for _, path := range paths {
+		d, ok := readIPPUSBState(path)
+		if !ok {
+			continue
+		}
+		d.Queue = queueForIPPUSB(queues, d)
+		found = append(found, d)
+	}
+	return found, nil
+}
+
+// readIPPUSBState reads the http-port and dnssd-name keys of a state file.
+func readIPPUSBState(path string) (ippUSBDevice, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ippUSBDevice{}, false
+	}
+	defer f.Close()
+	var d ippUSBDevice
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), "=")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		switch strings.TrimSpace(k) {
+		case "http-port":
+			d.Port, _ = strconv.Atoi(v)
+		case "dnssd-name":
+			d.Name = v
+		}
+	}
+	return d, d.Port > 0
+}
+
+// queueForIPPUSB finds a CUPS queue using an ipp-usb device, either at its
+// localhost port or by the DNS-SD name ipp-usb advertises it under.
+func queueForIPPUSB(queues map[string]string, d ippUSBDevice) string {
+	port := ":" + strconv.Itoa(d.Port) + "/"
+	for uri, q := range queues {
+		if strings.Contains(uri, "localhost"+port) || strings.Contains(uri, "127.0.0.1"+port) {
+			return q
+		}
+		if name, err := url.PathUnescape(uri); err == nil && d.Name != "" && strings.Contains(name, "//"+d.Name+"._ipp") {
+			return q
+		}
+	}
+	return ""
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package core
+
+import "context"
+
+// attachedIPPUSB finds nothing: macOS and Windows give IPP-over-USB
+// embossers a print queue of their own.
+func attachedIPPUSB(context.Context) ([]ippUSBDevice, error) {
+	return nil, nil
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendIPP(t *testing.T) {
+	var got []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := io.ReadAll(r.Body)
+		if len(req) < 4 || req[3] != ippPrintJobOp {
+			http.Error(w, "not Print-Job", http.StatusBadRequest)
+			return
+		}
+		got = req
+		var resp bytes.Buffer
+		resp.Write([]byte{2, 0, 0, 0, 0, 0, 0, 1, ippOperationTag})
+		ippAttr(&resp, ippCharset, "attributes-charset", []byte("utf-8"))
+		resp.WriteByte(0x02) // job attributes group
+		ippAttr(&resp, ippInteger, "job-id", []byte{0, 0, 0, 42})
+		resp.WriteByte(ippEndTag)
+		w.Write(resp.Bytes())
+	}))
+	defer srv.Close()
+
+	uri := "ipp://" + strings.TrimPrefix(srv.URL, "http://") + "/ipp/print"
+	job := &PrintJob{ID: 1, Printer: ippPrefix + uri, Data: []byte("ABC\f")}
+	if err := sendIPP(t.Context(), job, uri); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(got, []byte("\x03ABC\f")) {
+		t.Errorf("request does not end with the attributes' end tag and the document: %q", got)
+	}
+}
@@ -0,0 +1,48 @@
+package core
+
+import (
+	"time"
+)
+
+// JobFilter selects jobs for /jobs.
+type JobFilter struct {
+	Student, Printer, Status string
+	App                      string
+	Verified                 string // "", "yes" or "no"
+	Since, Until             time.Time
+}
+
+func (f JobFilter) Match(e JobEvent) bool {
+	switch {
+	case f.Student != "" && !SameStudent(e.Student, f.Student):
+		return false
+	case f.Printer != "" && e.Printer != f.Printer:
+		return false
+	case f.Status != "" && e.Status != f.Status:
+		return false
+	case f.App != "" && e.App != f.App:
+		return false
+	case f.Verified == "yes" && e.Verified == nil:
+		return false
+	case f.Verified == "no" && !needsVerification(e):
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// JobOptions are the per-job settings a print request may carry.
+type JobOptions struct {
+	Student    string
+	Urgent     bool
+	Media      string
+	Dots       int
+	Spacing    string
+	PrintText  string
+	Also       []Companion
+	Confirm    string // see pkg/httpapi/confirm.go
+	ResentFrom int    // the job a dashboard resend copies (pkg/httpapi/debug.go)
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// BRF lint
+//
+// POST /lint checks a BRF file for common transcription slips against BANA
+// Braille Formats conventions, without printing it:
+//
+//	running_head         pages after the first lack the running head on line 1
+//	page_number          a page's last line has no braille page number, or
+//	                     it is not at the right margin or out of sequence
+//	blank_before_heading a centered heading follows text with no blank line
+//	extra_blank_lines    a centered heading follows two or more blank lines
+//	hyphen_split         a line ends in a divided word; at a page break this
+//	                     is a warning, since words must not be divided
+//	                     across pages
+//
+// Pages and line widths come from the printer's profile (or the defaults),
+// so the checks match how the file will emboss. The findings are advice for
+// the web app to show; nothing here stops a job from printing.
+// ---------------------------------------------------------------------------
+
+// Lint finding severities.
+const (
+	lintWarning = "warning"
+	lintInfo    = "info"
+)
+
+// LintFinding is one issue found in a BRF file. Page and Line are 1-based;
+// Line counts within the page and is 0 for findings about the whole file.
+type LintFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Page     int    `json:"page,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// LintReport is the response from POST /lint.
+type LintReport struct {
+	Printer      string        `json:"printer,omitempty"`
+	Pages        int           `json:"pages"`
+	CellsPerLine int           `json:"cells_per_line"`
+	LinesPerPage int           `json:"lines_per_page"`
+	Findings     []LintFinding `json:"findings"`
+}
+
+// pageNumberRE matches a braille page number (optionally "p" for
+// preliminary pages) set off from the text at the end of a line.
+var pageNumberRE = regexp.MustCompile(`(?:^|\s{3,})(p?#[a-j]+)\s*$`)
+
+// LintBRF runs every check over data laid out at width cells by length
+// lines.
+func LintBRF(data []byte, width, length int) LintReport {
+	pages := brf.Pages(data, length)
+	found := lintFindings{}
+	head := lintRunningHeads(pages, &found)
+	found = append(found, lintPageNumbers(pages, width)...)
+	for pi, lines := range pages {
+		for li, line := range lines {
+			if isCenteredHeading(line, width) && li > 0 && li < len(lines)-1 {
+				lintHeadingSpacing(lines, li, head != "", width, pi+1, &found)
+			}
+			if t := strings.TrimRight(line, " "); len(t) > 1 && strings.HasSuffix(t, "-") && !strings.HasSuffix(t, "--") {
+				if li == len(lines)-1 || (li == len(lines)-2 && isPageNumberLine(lines[li+1])) {
+					if pi < len(pages)-1 {
+						found.add("hyphen_split", lintWarning, pi+1, li+1, "word divided across a page break")
+					}
+				} else if next := strings.TrimLeft(lines[li+1], " "); next != "" && next == lines[li+1] {
+					found.add("hyphen_split", lintInfo, pi+1, li+1, "word divided at the end of the line")
+				}
+			}
+		}
+	}
+	return LintReport{Pages: len(pages), CellsPerLine: width, LinesPerPage: length, Findings: found}
+}
+
+type lintFindings []LintFinding
+
+func (f *lintFindings) add(rule, severity string, page, line int, format string, args ...any) {
+	*f = append(*f, LintFinding{rule, severity, page, line, fmt.Sprintf(format, args...)})
+}
+
+// lintRunningHeads finds the running head — the text most often on line 1
+// after the first page — and flags pages without it. It returns the head,
+// or "" if the file does not appear to use one.
+func lintRunningHeads(pages [][]string, found *lintFindings) string {
+	if len(pages) < 3 {
+		return ""
+	}
+	counts := map[string]int{}
+	for _, lines := range pages[1:] {
+		counts[headText(lines)]++
+	}
+	head, n := "", 0
+	for text, c := range counts {
+		if text != "" && (c > n || c == n && text < head) {
+			head, n = text, c
+		}
+	}
+	if n*2 < len(pages)-1 {
+		found.add("running_head", lintInfo, 0, 0, "no running head found on line 1 of most pages")
+		return ""
+	}
+	for pi, lines := range pages[1:] {
+		if t := headText(lines); t != head {
+			if t == "" {
+				found.add("running_head", lintWarning, pi+2, 1, "running head missing")
+			} else {
+				found.add("running_head", lintWarning, pi+2, 1, "line 1 differs from the running head %q", head)
+			}
+		}
+	}
+	return head
+}
+
+// headText is a page's line 1 without surrounding space or a trailing
+// print page number.
+func headText(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	line := strings.TrimSpace(lines[0])
+	if i := strings.LastIndex(line, "   "); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	return line
+}
+
+// lintPageNumbers checks that each page's last line carries its braille
+// page number at the right margin, counting up by one. Files that number
+// no pages at all get a single finding.
+func lintPageNumbers(pages [][]string, width int) lintFindings {
+	var found lintFindings
+	numbered, prev := 0, 0
+	for pi, lines := range pages {
+		if len(lines) == 0 {
+			continue
+		}
+		last := lines[len(lines)-1]
+		m := pageNumberRE.FindStringSubmatch(last)
+		if m == nil {
+			found.add("page_number", lintWarning, pi+1, len(lines), "no braille page number on the last line")
+			continue
+		}
+		numbered++
+		if n := cellCount([]byte(strings.TrimRight(last, " "))); n != width {
+			found.add("page_number", lintWarning, pi+1, len(lines),
+				"page number %s ends in cell %d, not at the right margin (cell %d)", m[1], n, width)
+		}
+		if strings.HasPrefix(m[1], "p") {
+			continue
+		}
+		n := brailleValue(m[1])
+		if prev != 0 && n != prev+1 {
+			found.add("page_number", lintWarning, pi+1, len(lines), "page number %d follows %d", n, prev)
+		}
+		prev = n
+	}
+	if numbered == 0 {
+		found = nil
+		if len(pages) > 1 {
+			found.add("page_number", lintWarning, 0, 0, "no braille page numbers found")
+		}
+	}
+	return found
+}
+
+// isPageNumberLine reports whether line holds only a braille page number.
+func isPageNumberLine(line string) bool {
+	m := pageNumberRE.FindStringSubmatch(line)
+	return m != nil && strings.TrimSpace(line) == m[1]
+}
+
+// brailleValue decodes a braille number written by brailleNumber.
+func brailleValue(s string) int {
+	n := 0
+	for _, c := range strings.TrimPrefix(strings.TrimPrefix(s, "p"), "#") {
+		n = n*10 + int(c-'a'+1)%10
+	}
+	return n
+}
+
+// isCenteredHeading reports whether line is indented to sit in the middle
+// of a width-cell line, give or take a cell.
+func isCenteredHeading(line string, width int) bool {
+	text := strings.TrimSpace(line)
+	if text == "" || isPageNumberLine(line) {
+		return false
+	}
+	left := len(line) - len(strings.TrimLeft(line, " "))
+	right := width - left - len(text)
+	return left >= 2 && right >= 0 && left-right <= 1 && right-left <= 1
+}
+
+// lintHeadingSpacing checks the blank lines above the centered heading at
+// lines[i]. A heading directly under the running head, at the top of the
+// page or continuing the heading above needs none.
+func lintHeadingSpacing(lines []string, i int, hasHead bool, width, page int, found *lintFindings) {
+	if i == 1 && hasHead {
+		return
+	}
+	prev := lines[i-1]
+	if isCenteredHeading(prev, width) {
+		return
+	}
+	if strings.TrimSpace(prev) != "" {
+		found.add("blank_before_heading", lintWarning, page, i+1, "centered heading needs a blank line above it")
+		return
+	}
+	if i >= 2 && strings.TrimSpace(lines[i-2]) == "" && strings.TrimSpace(strings.Join(lines[:i-2], "")) != "" {
+		found.add("extra_blank_lines", lintInfo, page, i+1, "centered heading follows more than one blank line")
+	}
+}
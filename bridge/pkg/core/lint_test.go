@@ -0,0 +1,98 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLintBRF(t *testing.T) {
+	const width, length = 20, 6
+	// page lays out one page: its lines, then the braille page number n at
+	// the right margin (none when n is 0) and a form feed.
+	page := func(n int, lines ...string) string {
+		if n > 0 {
+			num := brailleNumber(n)
+			lines = append(lines, strings.Repeat(" ", width-len(num))+num)
+		}
+		return strings.Join(lines, "\n") + "\n\f"
+	}
+	heading := strings.Repeat(" ", 6) + "HEADING" // centered in 20 cells
+
+	for _, c := range []struct {
+		name string
+		data string
+		want []string // "rule page:line severity"
+	}{
+		{
+			name: "clean",
+			data: page(1, "TITLE", "", heading, "TEXT") +
+				page(2, "HEAD", "TEXT") +
+				page(3, "HEAD", "", heading, "TEXT"),
+			want: nil,
+		},
+		{
+			name: "running head mismatch",
+			data: page(1, "TITLE") + page(2, "HEAD") + page(3, "OTHER") + page(4, "HEAD") + page(5, "", "TEXT"),
+			want: []string{
+				"running_head 3:1 warning",
+				"running_head 5:1 warning",
+			},
+		},
+		{
+			name: "page number off the margin",
+			data: page(1, "TEXT") + "TEXT\n" + brailleNumber(2) + "\n\f",
+			want: []string{"page_number 2:2 warning"},
+		},
+		{
+			name: "page number out of sequence",
+			data: page(1, "TEXT") + page(3, "TEXT"),
+			want: []string{"page_number 2:2 warning"},
+		},
+		{
+			name: "missing page number",
+			data: page(1, "TEXT") + page(0, "TEXT"),
+			want: []string{"page_number 2:1 warning"},
+		},
+		{
+			name: "no blank line before a centered heading",
+			data: page(1, "TEXT", "TEXT", heading, "TEXT"),
+			want: []string{"blank_before_heading 1:3 warning"},
+		},
+		{
+			name: "heading under the running head",
+			data: page(1, "TITLE") + page(2, "HEAD", heading, "TEXT") + page(3, "HEAD", "TEXT"),
+			want: nil,
+		},
+		{
+			name: "extra blank lines before a centered heading",
+			data: page(1, "TEXT", "", "", heading, "TEXT"),
+			want: []string{"extra_blank_lines 1:4 info"},
+		},
+		{
+			name: "word divided across a page break",
+			data: page(1, "TEXT", "DIVI-") + page(2, "DED TEXT"),
+			want: []string{"hyphen_split 1:2 warning"},
+		},
+		{
+			name: "word divided within a page",
+			data: page(1, "DIVI-", "DED TEXT"),
+			want: []string{"hyphen_split 1:1 info"},
+		},
+		{
+			name: "hyphen on the last page",
+			data: page(1, "TEXT", "DIVI-"),
+			want: nil,
+		},
+	} {
+		rep := LintBRF([]byte(c.data), width, length)
+		var got []string
+		for _, f := range rep.Findings {
+			got = append(got, fmt.Sprintf("%s %d:%d %s", f.Rule, f.Page, f.Line, f.Severity))
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%s: findings %q, want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package core
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Log level
+//
+// The bridge normally logs one line per job and per state change. While
+// troubleshooting, an admin can raise the level for a while without
+// restarting the bridge (which would lose the queue and the job history):
+//
+//	GET  /log-level → {"level": "info", "until": null}
+//	POST /log-level → {"level": "trace", "minutes": 15}
+//
+//	info   the usual log (default)
+//	debug  also each step of a job: dispatch, pre-flight, spooler commands
+//	trace  also every byte exchanged with an embosser, spooler or IPP
+//	       printer, as hex dumps of up to 4 KB per message
+//
+// A raised level drops back to info after "minutes" (default 30, at most
+// 8 hours), so a forgotten trace does not fill the disk with student work.
+// Setting "info" ends it at once. The level is not saved.
+// ---------------------------------------------------------------------------
+
+const (
+	LevelInfo = iota
+	levelDebug
+	levelTrace
+)
+
+var LogLevelNames = []string{"info", "debug", "trace"}
+
+const (
+	DefaultLogLevelDuration = 30 * time.Minute
+	MaxLogLevelDuration     = 8 * time.Hour
+	traceDumpMax            = 4096
+)
+
+var logLevel atomic.Int32
+
+// LogLevelState tracks when a raised level ends.
+var LogLevelState = struct {
+	sync.Mutex
+	until time.Time
+	reset *time.Timer
+}{}
+
+// LogLevelStatus is the /log-level response.
+type LogLevelStatus struct {
+	Level string     `json:"level"`
+	Until *time.Time `json:"until"` // when it drops back to info; null at info
+}
+
+// debugf logs at the debug level.
+func debugf(format string, args ...any) {
+	if logLevel.Load() >= levelDebug {
+		log.Printf("debug: "+format, args...)
+	}
+}
+
+// tracing reports whether transport traffic is being dumped.
+func tracing() bool {
+	return logLevel.Load() >= levelTrace
+}
+
+// traceDump logs data exchanged with a printer at the trace level.
+func traceDump(label string, data []byte) {
+	if !tracing() {
+		return
+	}
+	more := ""
+	if len(data) > traceDumpMax {
+		more = fmt.Sprintf("… %d more bytes\n", len(data)-traceDumpMax)
+		data = data[:traceDumpMax]
+	}
+	log.Printf("trace: %s (%d bytes)\n%s%s", label, len(data), hex.Dump(data), more)
+}
+
+// SetLogLevel sets the level, dropping back to info after d.
+func SetLogLevel(level int, d time.Duration) LogLevelStatus {
+	LogLevelState.Lock()
+	defer LogLevelState.Unlock()
+	if LogLevelState.reset != nil {
+		LogLevelState.reset.Stop()
+		LogLevelState.reset = nil
+	}
+	logLevel.Store(int32(level))
+	LogLevelState.until = time.Time{}
+	if level != LevelInfo {
+		LogLevelState.until = time.Now().Add(d)
+		LogLevelState.reset = time.AfterFunc(d, func() {
+			SetLogLevel(LevelInfo, 0)
+			log.Printf("log level back to info")
+		})
+	}
+	return LogLevelStatusLocked()
+}
+
+func LogLevelStatusLocked() LogLevelStatus {
+	s := LogLevelStatus{Level: LogLevelNames[logLevel.Load()]}
+	if !LogLevelState.until.IsZero() {
+		until := LogLevelState.until
+		s.Until = &until
+	}
+	return s
+}
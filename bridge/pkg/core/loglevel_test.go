@@ -0,0 +1,22 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetLogLevel(t *testing.T) {
+	t.Cleanup(func() { SetLogLevel(LevelInfo, 0) })
+	s := SetLogLevel(levelTrace, time.Minute)
+	if s.Level != "trace" || s.Until == nil || !tracing() {
+		t.Errorf("trace: %+v", s)
+	}
+	SetLogLevel(levelDebug, 20*time.Millisecond)
+	if tracing() {
+		t.Error("still tracing at debug")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if logLevel.Load() != LevelInfo {
+		t.Errorf("level %d after it expired, want info", logLevel.Load())
+	}
+}
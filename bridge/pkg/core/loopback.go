@@ -0,0 +1,157 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Loopback printer
+//
+// loopback:<name> is a destination that prints to memory: a job goes
+// through the queue, pre-processing, chunked writes and progress events as
+// for a real embosser, and the exact bytes that reached the "device" are
+// kept for inspection. Tests and developers without an embosser use it to
+// check what the bridge would have sent, and to make printing fail or slow
+// down on demand (admin scope):
+//
+//	GET    /loopback/{name} → settings and the jobs received, newest last,
+//	                          with each payload as base64
+//	POST   /loopback/{name} → {"fail": 2, "fail_code": "device_unavailable",
+//	                           "fail_after_bytes": 100, "latency_ms": 500}
+//	DELETE /loopback/{name} → forget its jobs and settings
+//
+// "fail" makes that many of the next send attempts fail with "fail_code"
+// (default print_failed), after writing "fail_after_bytes" of the job
+// (default none). Codes the retry policy treats as transient are retried,
+// and each attempt uses up one failure. "latency_ms" delays the start of
+// every job. A loopback printer needs no setup and is never listed in
+// /printers unless it has a profile in the config.
+// ---------------------------------------------------------------------------
+
+const (
+	LoopbackPrefix  = "loopback:"
+	loopbackMaxJobs = 100 // jobs kept per loopback printer
+)
+
+// LoopbackSettings are the failures and latency a loopback printer injects.
+type LoopbackSettings struct {
+	Fail      int    `json:"fail"`                       // send attempts left to fail
+	FailCode  string `json:"fail_code,omitempty"`        // failure class (failures.go)
+	FailAfter int    `json:"fail_after_bytes,omitempty"` // bytes written before failing
+	LatencyMS int    `json:"latency_ms,omitempty"`       // wait before each job
+}
+
+// LoopbackJob is one send attempt a loopback printer received.
+type LoopbackJob struct {
+	JobID  int       `json:"job_id"`
+	Time   time.Time `json:"time"`
+	Bytes  int       `json:"bytes"`
+	Data   []byte    `json:"data"`            // exactly what was written
+	ErrMsg string    `json:"error,omitempty"` // the injected failure, if any
+}
+
+// LoopbackState is the /loopback/{name} response.
+type LoopbackState struct {
+	Printer  string           `json:"printer"`
+	Settings LoopbackSettings `json:"settings"`
+	Jobs     []LoopbackJob    `json:"jobs"`
+}
+
+var Loopbacks = struct {
+	sync.Mutex
+	m map[string]*LoopbackState
+}{m: map[string]*LoopbackState{}}
+
+// Loopback returns the state of a loopback printer, creating it if needed.
+// Must be called with Loopbacks held.
+func Loopback(printer string) *LoopbackState {
+	lb, ok := Loopbacks.m[printer]
+	if !ok {
+		lb = &LoopbackState{Printer: printer, Jobs: []LoopbackJob{}}
+		Loopbacks.m[printer] = lb
+	}
+	return lb
+}
+
+// ResetLoopback forgets printer's recorded jobs and injected failures.
+// Must be called with Loopbacks held.
+func ResetLoopback(printer string) {
+	delete(Loopbacks.m, printer)
+}
+
+// sendLoopback writes a job to memory, injecting the configured failure.
+func sendLoopback(ctx context.Context, job *PrintJob) error {
+	Loopbacks.Lock()
+	lb := Loopback(job.Printer)
+	set := lb.Settings
+	failing := set.Fail > 0
+	if failing {
+		lb.Settings.Fail--
+	}
+	Loopbacks.Unlock()
+
+	if set.LatencyMS > 0 {
+		select {
+		case <-time.After(time.Duration(set.LatencyMS) * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	w := &loopbackWriter{failAfter: -1}
+	if failing {
+		code := set.FailCode
+		if code == "" {
+			code = errCodeUnknown
+		}
+		w.failAfter = set.FailAfter
+		w.err = Classified(code, fmt.Errorf("loopback: injected %s", code))
+	}
+	err := writeChunked(ctx, job, w, ProfileFor(job.Printer), nil)
+	if err == nil && w.err != nil {
+		err = w.err // failing after more bytes than the job has
+	}
+
+	rec := LoopbackJob{JobID: job.ID, Time: time.Now(), Bytes: w.buf.Len(), Data: w.buf.Bytes()}
+	if err != nil {
+		rec.ErrMsg = err.Error()
+	}
+	Loopbacks.Lock()
+	lb = Loopback(job.Printer)
+	lb.Jobs = append(lb.Jobs, rec)
+	if len(lb.Jobs) > loopbackMaxJobs {
+		lb.Jobs = lb.Jobs[len(lb.Jobs)-loopbackMaxJobs:]
+	}
+	Loopbacks.Unlock()
+	return err
+}
+
+// loopbackWriter records writes, failing with err once failAfter bytes
+// have been written (never if failAfter is negative).
+type loopbackWriter struct {
+	buf       bytes.Buffer
+	failAfter int
+	err       error
+}
+
+func (w *loopbackWriter) Write(p []byte) (int, error) {
+	if w.failAfter < 0 || w.buf.Len()+len(p) <= w.failAfter {
+		return w.buf.Write(p)
+	}
+	n, _ := w.buf.Write(p[:max(w.failAfter-w.buf.Len(), 0)])
+	return n, w.err
+}
+
+func (w *loopbackWriter) Close() error { return nil }
+
+// checkLoopback is the pre-flight check for loopback: destinations.
+func checkLoopback(printer string) error {
+	if printer == LoopbackPrefix {
+		return Classified(ErrCodeNotFound, errors.New("loopback: needs a name, as in loopback:Test"))
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+//go:build !windows
+
+package core
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// CUPS printer enumeration
+//
+// lpstat's human-readable output is translated ("la impresora X está
+// inactiva"), so the commands are run in the C locale and the name-only
+// "lpstat -e" listing is preferred. Older CUPS releases without -e fall back
+// to "lpstat -a" and then "lpstat -p", whose parsers only accept tokens that
+// are valid CUPS queue names and know the localized "printer" prefixes in
+// case the locale override is ignored.
+// ---------------------------------------------------------------------------
+
+// lpstatQueries are tried in order until one succeeds.
+var lpstatQueries = []struct {
+	args  []string
+	parse func(string) []string
+}{
+	{[]string{"-e"}, parseLpstatE},
+	{[]string{"-a"}, parseLpstatA},
+	{[]string{"-p"}, parseLpstatP},
+}
+
+// listPrinters returns printer names visible to CUPS on Linux/macOS.
+func listPrinters(ctx context.Context) []string {
+	for _, q := range lpstatQueries {
+		cmd := exec.CommandContext(ctx, "lpstat", q.args...)
+		cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+		out, err := cmd.Output()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			continue
+		}
+		return q.parse(string(out))
+	}
+	return nil
+}
+
+// parseLpstatE parses "lpstat -e": one destination name per line.
+func parseLpstatE(out string) []string {
+	var names []string
+	eachLine(out, func(line string) {
+		if name := strings.TrimSpace(line); validQueueName(name) {
+			names = append(names, name)
+		}
+	})
+	return dedupe(names)
+}
+
+// parseLpstatA parses "lpstat -a", where every locale puts the queue name
+// first ("Braille accepting requests since …", "Braille aceptando
+// peticiones desde …"). Indented lines carry reasons and are skipped, as
+// are lpstat's own diagnostics.
+func parseLpstatA(out string) []string {
+	var names []string
+	eachLine(out, func(line string) {
+		if line == "" || line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(line, "lpstat:") {
+			return
+		}
+		if name, _, _ := strings.Cut(line, " "); validQueueName(name) && strings.Contains(line, " ") {
+			names = append(names, name)
+		}
+	})
+	return dedupe(names)
+}
+
+// printerPrefixes introduce a queue name in "lpstat -p" output, by locale.
+var printerPrefixes = []string{
+	"printer ",      // en, nl, da
+	"la impresora ", // es
+	"l'imprimante ", // fr
+	"l’imprimante ", // fr (typographic apostrophe)
+	"Drucker ",      // de
+	"la stampante ", // it
+	"a impressora ", // pt
+	"skrivare ",     // sv
+	"skriver ",      // nb
+	"drukarka ",     // pl
+	"принтер ",      // ru
+	"プリンター ",        // ja
+	"打印机 ",          // zh_CN
+}
+
+// parseLpstatP parses "lpstat -p", taking the token that follows a known
+// "printer" prefix at the start of a line.
+func parseLpstatP(out string) []string {
+	var names []string
+	eachLine(out, func(line string) {
+		for _, prefix := range printerPrefixes {
+			if len(line) < len(prefix) || !strings.EqualFold(line[:len(prefix)], prefix) {
+				continue
+			}
+			rest := line[len(prefix):]
+			name, _, _ := strings.Cut(rest, " ")
+			name = strings.TrimRight(name, ".:,")
+			if validQueueName(name) {
+				names = append(names, name)
+			}
+			return
+		}
+	})
+	return dedupe(names)
+}
+
+// validQueueName reports whether s could be a CUPS destination name
+// (optionally with a /instance suffix). CUPS forbids spaces, tabs, '#' and
+// control characters, and limits names to 127 bytes.
+func validQueueName(s string) bool {
+	if s == "" || len(s) > 127 {
+		return false
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '#' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func eachLine(out string, fn func(string)) {
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		fn(strings.TrimRight(sc.Text(), "\r"))
+	}
+}
+
+func dedupe(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	out := names[:0]
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// parseLpstatStatus interprets "lpstat -a NAME -o NAME -l -p NAME" output
+// run in the C locale.
+func parseLpstatStatus(printerName, out string) PrinterState {
+	s := PrinterState{Printer: printerName, State: stateReady}
+	set := func(state string) { s.State = worseState(s.State, state) }
+	eachLine(out, func(line string) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, printerName+" not accepting"):
+			set(statePaused)
+			s.Reasons = append(s.Reasons, "not accepting jobs")
+		case strings.HasPrefix(line, "printer "+printerName+" disabled"):
+			set(statePaused)
+			s.Reasons = append(s.Reasons, "disabled")
+		case strings.HasPrefix(line, "printer "+printerName+" now printing"):
+			set(statePrinting)
+		case strings.HasPrefix(line, printerName+"-") && isJobLine(line[len(printerName)+1:]):
+			s.Jobs++
+		case strings.HasPrefix(trimmed, "Alerts:"):
+			for _, a := range strings.Split(strings.TrimPrefix(trimmed, "Alerts:"), ",") {
+				a = strings.TrimSpace(a)
+				if a == "" || a == "none" {
+					continue
+				}
+				s.Reasons = append(s.Reasons, a)
+				set(reasonState(a))
+			}
+		}
+	})
+	return s
+}
+
+// isJobLine reports whether rest, following "NAME-" on an "lpstat -o"
+// line, starts with a job number.
+func isJobLine(rest string) bool {
+	id, _, _ := strings.Cut(rest, " ")
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// backendDevice is one device a CUPS backend lists when run with no
+// arguments.
+type backendDevice struct {
+	Class     string // "direct" for a locally attached device
+	URI       string // device URI, e.g. usb://ViewPlus/Columbia?serial=VP1
+	MakeModel string
+	Info      string
+	DeviceID  string // IEEE 1284 device ID ("MFG:…;MDL:…;")
+}
+
+// parseBackendDevices parses a backend's device listing: one device per
+// line as
+//
+//	class uri "make-and-model" "info" "device-id" "location"
+func parseBackendDevices(out string) []backendDevice {
+	var devices []backendDevice
+	eachLine(out, func(line string) {
+		f := splitQuoted(line)
+		if len(f) < 3 || !strings.Contains(f[1], "://") {
+			return
+		}
+		d := backendDevice{Class: f[0], URI: f[1], MakeModel: f[2]}
+		if len(f) > 3 {
+			d.Info = f[3]
+		}
+		if len(f) > 4 {
+			d.DeviceID = f[4]
+		}
+		devices = append(devices, d)
+	})
+	return devices
+}
+
+// splitQuoted splits a line on spaces, keeping "double-quoted" fields
+// (which may be empty) together.
+func splitQuoted(line string) []string {
+	var fields []string
+	for line = strings.TrimSpace(line); line != ""; line = strings.TrimSpace(line) {
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				return append(fields, line[1:])
+			}
+			fields = append(fields, line[1:end+1])
+			line = line[end+2:]
+			continue
+		}
+		field, rest, _ := strings.Cut(line, " ")
+		fields = append(fields, field)
+		line = rest
+	}
+	return fields
+}
+
+// parseLpstatV parses "lpstat -v" in the C locale into a map from device
+// URI to queue name.
+func parseLpstatV(out string) map[string]string {
+	queues := make(map[string]string)
+	eachLine(out, func(line string) {
+		rest, ok := strings.CutPrefix(line, "device for ")
+		if !ok {
+			return
+		}
+		name, uri, ok := strings.Cut(rest, ": ")
+		if ok && validQueueName(name) {
+			queues[strings.TrimSpace(uri)] = name
+		}
+	})
+	return queues
+}
+
+// parseLpoptions parses "lpoptions -p <queue>" output, a line of
+// name=value pairs where values with spaces are 'single-quoted' and
+// special characters may be escaped with a backslash.
+func parseLpoptions(out string) map[string]string {
+	opts := make(map[string]string)
+	s := strings.TrimSpace(out)
+	for s != "" {
+		name, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		var value strings.Builder
+		quote := byte(0)
+		i := 0
+	scan:
+		for ; i < len(rest); i++ {
+			c := rest[i]
+			switch {
+			case c == '\\' && i+1 < len(rest):
+				i++
+				value.WriteByte(rest[i])
+			case quote != 0 && c == quote:
+				quote = 0
+			case quote == 0 && (c == '\'' || c == '"'):
+				quote = c
+			case quote == 0 && c == ' ':
+				break scan
+			default:
+				value.WriteByte(c)
+			}
+		}
+		opts[strings.TrimSpace(name)] = value.String()
+		s = strings.TrimSpace(rest[i:])
+	}
+	return opts
+}
@@ -0,0 +1,158 @@
+//go:build !windows
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// The captures in testdata/lpstat were taken from CUPS 2.4 with LANG set
+// to each locale; p_garbage.txt is the plain "lpstat" output the old
+// fallback used to misparse.
+func TestParseLpstat(t *testing.T) {
+	all := []string{"Index_Everest_D_V5", "Office-Laser", "ViewPlus_Columbia"}
+	tests := []struct {
+		file  string
+		parse func(string) []string
+		want  []string
+	}{
+		{"e_en.txt", parseLpstatE, []string{"ViewPlus_Columbia", "Index_Everest_D_V5", "Office-Laser", "Index_Everest_D_V5/draft"}},
+		{"a_en.txt", parseLpstatA, all},
+		{"a_es.txt", parseLpstatA, all},
+		{"a_de.txt", parseLpstatA, all},
+		{"p_en.txt", parseLpstatP, all},
+		{"p_es.txt", parseLpstatP, all},
+		{"p_fr.txt", parseLpstatP, all},
+		{"p_de.txt", parseLpstatP, all},
+		{"p_ja.txt", parseLpstatP, all},
+		{"p_garbage.txt", parseLpstatP, nil},
+		{"p_garbage.txt", parseLpstatE, nil},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join("testdata", "lpstat", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tt.parse(string(data)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestValidQueueName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Braille":                true,
+		"Index_Basic-D":          true,
+		"Braille/draft":          true,
+		"":                       false,
+		"two words":              false,
+		"tab\there":              false,
+		"hash#name":              false,
+		strings.Repeat("a", 128): false,
+	} {
+		if got := validQueueName(name); got != want {
+			t.Errorf("validQueueName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestParseJobState(t *testing.T) {
+	const id = "Everest-42"
+	if got := parseRequestID("request id is Everest-42 (1 file(s))\n"); got != id {
+		t.Errorf("parseRequestID = %q, want %q", got, id)
+	}
+	queued := "Everest-41   teacher   2048   Tue 14 Oct 2026 09:12:01\n" +
+		"Everest-42   teacher   1024   Tue 14 Oct 2026 09:12:05\n"
+	tests := []struct {
+		name, queued, status string
+		want                 cupsJobState
+	}{
+		{"waiting", queued, "printer Everest now printing Everest-41.  enabled since …\n", cupsJobWaiting},
+		{"printing", queued, "printer Everest now printing Everest-42.  enabled since …\n", cupsJobPrinting},
+		{"gone", "", "printer Everest is idle.  enabled since …\n", cupsJobGone},
+		{"prefix is not a match", "Everest-420   teacher   1024   …\n", "printer Everest is idle.\n", cupsJobGone},
+	}
+	for _, tt := range tests {
+		if got := parseJobState(tt.queued, tt.status, id); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseLpstatStatus(t *testing.T) {
+	tests := []struct {
+		out   string
+		state string
+		jobs  int
+	}{
+		{"Braille accepting requests since Thu 01 Oct 2026 09:00:00 AM\n" +
+			"printer Braille is idle.  enabled since Thu 01 Oct 2026 09:00:00 AM\n\tAlerts: none\n", stateReady, 0},
+		{"Braille accepting requests since Thu 01 Oct 2026 09:00:00 AM\n" +
+			"Braille-12              teacher        2048   Thu 01 Oct 2026 09:01:00 AM\n" +
+			"Braille-13              teacher        1024   Thu 01 Oct 2026 09:02:00 AM\n" +
+			"printer Braille now printing Braille-12.  enabled since Thu 01 Oct 2026 09:00:00 AM\n" +
+			"\tAlerts: media-empty-warning\n", statePrinting, 2},
+		{"Braille accepting requests since Thu 01 Oct 2026 09:00:00 AM\n" +
+			"printer Braille is idle.  enabled since Thu 01 Oct 2026 09:00:00 AM\n" +
+			"\tAlerts: media-empty-error, offline-report\n", stateOffline, 0},
+		{"Braille not accepting requests since Thu 01 Oct 2026 09:00:00 AM -\n\tPaused\n" +
+			"printer Braille disabled since Thu 01 Oct 2026 09:00:00 AM -\n\tPaused\n\tAlerts: media-jam-error\n", statePaused, 0},
+		{"Braille accepting requests since Thu 01 Oct 2026 09:00:00 AM\n" +
+			"printer Braille is idle.  enabled since Thu 01 Oct 2026 09:00:00 AM\n\tAlerts: media-jam-error\n", statePaperJam, 0},
+	}
+	for i, tt := range tests {
+		s := parseLpstatStatus("Braille", tt.out)
+		if s.State != tt.state || s.Jobs != tt.jobs {
+			t.Errorf("case %d: got state %q jobs %d, want %q %d", i, s.State, s.Jobs, tt.state, tt.jobs)
+		}
+	}
+}
+
+func TestParseBackendDevices(t *testing.T) {
+	out := `direct usb://ViewPlus/Columbia?serial=VP0042 "ViewPlus Columbia" "ViewPlus Columbia USB" "MFG:ViewPlus;MDL:Columbia;CMD:PJL;" ""
+direct usb://Index/Everest-D%20V5 "Index Everest-D V5" "" "" ""
+network socket "Unknown" "AppSocket/HP JetDirect"
+`
+	got := parseBackendDevices(out)
+	if len(got) != 2 {
+		t.Fatalf("got %d devices, want 2: %+v", len(got), got)
+	}
+	want := backendDevice{
+		Class:     "direct",
+		URI:       "usb://ViewPlus/Columbia?serial=VP0042",
+		MakeModel: "ViewPlus Columbia",
+		Info:      "ViewPlus Columbia USB",
+		DeviceID:  "MFG:ViewPlus;MDL:Columbia;CMD:PJL;",
+	}
+	if got[0] != want {
+		t.Errorf("got %+v, want %+v", got[0], want)
+	}
+	if got[1].URI != "usb://Index/Everest-D%20V5" || got[1].MakeModel != "Index Everest-D V5" || got[1].Info != "" {
+		t.Errorf("got %+v", got[1])
+	}
+}
+
+func TestParseLpstatV(t *testing.T) {
+	out := "device for Everest: usb://Index/Everest-D%20V5?serial=1234\n" +
+		"device for Office_Laser: ipp://10.0.0.5/ipp/print\n"
+	got := parseLpstatV(out)
+	if got["usb://Index/Everest-D%20V5?serial=1234"] != "Everest" || got["ipp://10.0.0.5/ipp/print"] != "Office_Laser" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestParseLpoptions(t *testing.T) {
+	out := "copies=1 device-uri=ipp://192.168.1.50/ipp/print finishings=3 " +
+		"printer-make-and-model='Index Everest-D V5 - IPP Everywhere' printer-info=Room\\ 12\n"
+	opts := parseLpoptions(out)
+	if opts["device-uri"] != "ipp://192.168.1.50/ipp/print" || opts["printer-info"] != "Room 12" {
+		t.Errorf("got %v", opts)
+	}
+	if driver := opts["printer-make-and-model"]; !isDriverless(driver) {
+		t.Errorf("%q not recognised as driverless", driver)
+	}
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain points the user config directory, and so the data directory
+// (audit log, page ledger, student key), at a scratch directory, so a test
+// run never writes to the developer's own bridge files.
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "graham-bridge-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, v := range []string{"HOME", "XDG_CONFIG_HOME", "AppData"} {
+		os.Setenv(v, home)
+	}
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+func TestDataDirFollowsConfig(t *testing.T) {
+	old := CfgPath
+	defer func() { CfgPath = old }()
+	dir := t.TempDir()
+	CfgPath = filepath.Join(dir, "room3", "bridge.json")
+	if got := DataDir(); got != filepath.Join(dir, "room3") {
+		t.Errorf("dataDir = %s", got)
+	}
+	CfgPath = ""
+	if got := DataDir(); got != filepath.Dir(DefaultConfigPath()) {
+		t.Errorf("dataDir without a config = %s", got)
+	}
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Media settings
+//
+// Many embossers take escape codes for impact strength, multiple strikes or
+// speed, traded off against the material: heavy braille paper needs harder
+// strikes than standard paper, and plastic label sheets need slower,
+// lighter ones. A printer profile lists the settings it supports under
+// "media", each with the codes that select it and, optionally, the codes
+// that put the embosser back afterwards:
+//
+//	"media": {
+//	  "heavy":  {"start": "\u001bS3", "end": "\u001bS2"},
+//	  "labels": {"start": "\u001bS1\u001bV1", "end": "\u001bS2\u001bV3"}
+//	}
+//
+// A print request chooses one with "media": "labels". The codes wrap the
+// whole payload, after interline merging, so they are sent exactly once.
+// The sequences come from the embosser's programming manual.
+// ---------------------------------------------------------------------------
+
+// MediaCodes selects one media setting on an embosser.
+type MediaCodes struct {
+	Start string `json:"start"`         // sent before the job
+	End   string `json:"end,omitempty"` // sent after the job to restore the defaults
+}
+
+// wrap returns data between the start and end codes.
+func (c MediaCodes) wrap(data []byte) []byte {
+	out := make([]byte, 0, len(c.Start)+len(data)+len(c.End))
+	out = append(out, c.Start...)
+	out = append(out, data...)
+	return append(out, c.End...)
+}
+
+// ApplyMedia wraps a prepared job's payload in its media codes.
+func ApplyMedia(e *JobEvent, media string) *PayloadError {
+	if media == "" {
+		return nil
+	}
+	codes, ok := ProfileFor(e.Printer).Media[media]
+	if !ok {
+		return &PayloadError{http.StatusUnprocessableEntity, fmt.Sprintf(
+			"printer %q has no media setting %q%s", e.Printer, media, knownMedia(e.Printer))}
+	}
+	e.Payload = codes.wrap(e.Payload)
+	e.Bytes = len(e.Payload)
+	e.HexDump = hexDump(e.Payload)
+	e.Estimate = estimateSeconds(e.Printer, e.Bytes)
+	e.Media = media
+	return nil
+}
+
+// MediaNames returns the media settings in a printer's profile, sorted.
+func MediaNames(printer string) []string {
+	var names []string
+	for name := range ProfileFor(printer).Media {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// knownMedia lists a printer's media settings for an error message.
+func knownMedia(printer string) string {
+	names := MediaNames(printer)
+	if len(names) == 0 {
+		return " (its profile defines none)"
+	}
+	return " (choose " + strings.Join(names, ", ") + ")"
+}
@@ -0,0 +1,13 @@
+package core
+
+import (
+	"regexp"
+)
+
+// NativeHostConfig lists the browser extensions allowed to use the bridge
+// through native messaging.
+type NativeHostConfig struct {
+	Extensions []string `json:"extensions"` // Chrome/Edge extension IDs
+}
+
+var extensionIDRE = regexp.MustCompile(`^[a-p]{32}$`)
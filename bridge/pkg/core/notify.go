@@ -0,0 +1,300 @@
+package core
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Failure notifications
+//
+// The embosser often lives in a different room from the teacher, so when
+// "email" is configured the bridge mails staff about failures (chat
+// webhooks, webhook.go, get the same failure summaries):
+//
+//   - failed jobs, batched into one summary per notifyBatchWindow;
+//   - printers that have failed every job for printer_error_minutes, once
+//     per outage, and again when they recover.
+// ---------------------------------------------------------------------------
+
+const (
+	notifyBatchWindow        = time.Minute
+	notifyCheckInterval      = time.Minute
+	defaultPrinterErrorDelay = 10 * time.Minute
+	defaultSMTPPort          = 587
+)
+
+// printerOutage tracks a printer whose jobs keep failing.
+type printerOutage struct {
+	since    time.Time
+	lastErr  string
+	reported bool
+}
+
+var notifier = struct {
+	sync.Mutex
+	failed  []JobEvent                // failures waiting for the next summary
+	flush   *time.Timer               // pending summary, nil if none
+	outages map[string]*printerOutage // keyed by printer
+}{outages: make(map[string]*printerOutage)}
+
+// notifyJobFinished records a finished job for the notifiers.
+func notifyJobFinished(e JobEvent) {
+	fleetJobFinished(e)
+	go runPostHooks(e)
+	if c := CurrentConfig(); c.Email == nil && len(c.Webhooks) == 0 && len(c.Push) == 0 {
+		return
+	}
+	notifier.Lock()
+	defer notifier.Unlock()
+
+	if e.Status != StatusFailed && e.Status != StatusStuck {
+		if o := notifier.outages[e.Printer]; o != nil {
+			delete(notifier.outages, e.Printer)
+			if o.reported {
+				go sendEmail(fmt.Sprintf("Printer %s is working again", e.Printer),
+					fmt.Sprintf("Printer %q printed job #%d successfully at %s after failing since %s.\n",
+						e.Printer, e.ID, time.Now().Format(time.Kitchen), o.since.Format(time.Kitchen)))
+			}
+		}
+		return
+	}
+
+	if notifier.outages[e.Printer] == nil {
+		notifier.outages[e.Printer] = &printerOutage{since: time.Now()}
+	}
+	notifier.outages[e.Printer].lastErr = e.ErrMsg
+
+	notifier.failed = append(notifier.failed, e)
+	if notifier.flush == nil {
+		notifier.flush = time.AfterFunc(notifyBatchWindow, flushFailures)
+	}
+}
+
+// flushFailures mails the pending failed-job summary.
+func flushFailures() {
+	notifier.Lock()
+	failed := notifier.failed
+	notifier.failed, notifier.flush = nil, nil
+	notifier.Unlock()
+	if len(failed) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	for _, e := range failed {
+		fmt.Fprintf(&b, "Job #%d to %q at %s", e.ID, e.Printer, e.Time.Format(time.Kitchen))
+		if e.Student != "" {
+			fmt.Fprintf(&b, " (student %s)", ShownStudent(e.Student))
+		}
+		fmt.Fprintf(&b, " failed.\n  %s\n  %s\n\n", ErrorGuidance[e.ErrCode], firstLine(e.ErrMsg))
+	}
+	subject := fmt.Sprintf("%d print job(s) failed", len(failed))
+	if len(failed) == 1 {
+		subject = fmt.Sprintf("Print job #%d failed on %s", failed[0].ID, failed[0].Printer)
+	}
+	sendNotification(eventJobFailed, subject, b.String())
+	sendEmail(subject, b.String())
+}
+
+// Events sent to chat webhooks (webhook.go) and push services (push.go).
+const (
+	eventJobFailed        = "job_failed"
+	eventPrinterOffline   = "printer_offline"
+	eventPrinterAttention = "printer_attention"
+	eventBatchDone        = "batch_done"
+)
+
+var notifyEvents = []string{eventJobFailed, eventPrinterOffline, eventPrinterAttention, eventBatchDone}
+
+// sendNotification posts an event to the webhooks and push services that
+// take it.
+func sendNotification(event, title, body string) {
+	postWebhooks(event, title, body)
+	postPush(event, title, body)
+}
+
+// notifyPrinterChange reports a printer the watchdog saw go offline, come
+// back or start needing attention.
+func notifyPrinterChange(printer string, prev, h PrinterHealth) {
+	detail := ""
+	if h.Guidance != "" {
+		detail += h.Guidance + "\n"
+	}
+	if len(h.Reasons) > 0 {
+		detail += firstLine(h.Reasons[0]) + "\n"
+	}
+	switch {
+	case prev.Online != h.Online && h.Online:
+		sendNotification(eventPrinterOffline, "Printer "+printer+" is back online",
+			fmt.Sprintf("Printer %q is %s again at %s.\n", printer, h.State, h.Checked.Format(time.Kitchen)))
+	case prev.Online != h.Online:
+		sendNotification(eventPrinterOffline, "Printer "+printer+" is offline",
+			fmt.Sprintf("Printer %q went offline at %s.\n", printer, h.Checked.Format(time.Kitchen))+detail)
+	case h.ErrorCode == errCodeAttention || h.State == stateError:
+		what := strings.ReplaceAll(h.State, "_", " ")
+		sendNotification(eventPrinterAttention, "Printer "+printer+": "+what,
+			fmt.Sprintf("Printer %q reported %s at %s.\n", printer, what, h.Checked.Format(time.Kitchen))+detail)
+	}
+}
+
+// Batches worth a notification: a queue that ran at least batchMinJobs
+// jobs or for batchMinDuration before emptying.
+const (
+	batchMinJobs     = 3
+	batchMinDuration = 10 * time.Minute
+)
+
+// batchStats counts the jobs a printer's worker sent since its queue was
+// last empty.
+type batchStats struct {
+	jobs, failed, pages int
+	start               time.Time
+}
+
+// add counts a job the worker has finished.
+func (b *batchStats) add(e JobEvent) {
+	if b.jobs == 0 {
+		b.start = e.Time
+	}
+	b.jobs++
+	if e.Status == StatusDone {
+		b.pages += e.Pages
+	} else {
+		b.failed++
+	}
+}
+
+// notifyBatchDone reports a printer's queue emptying after a long batch.
+func notifyBatchDone(printer string, b batchStats) {
+	elapsed := time.Since(b.start)
+	if b.jobs < batchMinJobs && elapsed < batchMinDuration {
+		return
+	}
+	body := fmt.Sprintf("%d job(s), %d page(s) embossed in %s.\n", b.jobs, b.pages, elapsed.Round(time.Minute))
+	if b.failed > 0 {
+		body += fmt.Sprintf("%d job(s) failed; see the dashboard.\n", b.failed)
+	}
+	sendNotification(eventBatchDone, "Printer "+printer+" has finished its queue", body)
+}
+
+// RunNotifier reports printers that stay in an error state.
+func RunNotifier() {
+	for range time.Tick(notifyCheckInterval) {
+		e := CurrentConfig().Email
+		if e == nil {
+			continue
+		}
+		delay := defaultPrinterErrorDelay
+		if e.PrinterErrorMinutes > 0 {
+			delay = time.Duration(e.PrinterErrorMinutes) * time.Minute
+		}
+
+		var due []string
+		var body strings.Builder
+		notifier.Lock()
+		for printer, o := range notifier.outages {
+			if !o.reported && time.Since(o.since) >= delay {
+				o.reported = true
+				due = append(due, printer)
+				fmt.Fprintf(&body, "Printer %q has failed every job since %s.\n  Last error: %s\n\n",
+					printer, o.since.Format(time.Kitchen), firstLine(o.lastErr))
+			}
+		}
+		notifier.Unlock()
+		if len(due) > 0 {
+			slices.Sort(due)
+			go sendEmail("Printer needs attention: "+strings.Join(due, ", "), body.String())
+		}
+	}
+}
+
+// sendEmail delivers a plain-text message to the configured recipients.
+func sendEmail(subject, body string) {
+	e := CurrentConfig().Email
+	if e == nil {
+		return
+	}
+	host := HostnameOr("this computer")
+	msg := "From: " + e.From + "\r\n" +
+		"To: " + strings.Join(e.To, ", ") + "\r\n" +
+		"Subject: [Graham Bridge] " + subject + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		strings.ReplaceAll(body, "\n", "\r\n") +
+		"\r\n-- \r\nGraham Bridge on " + host + "\r\n"
+	if err := smtpSend(e, []byte(msg)); err != nil {
+		log.Printf("email %q: %v", subject, err)
+	}
+}
+
+func smtpSend(e *EmailConfig, msg []byte) error {
+	port := e.SMTPPort
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	addr := net.JoinHostPort(e.SMTPHost, strconv.Itoa(port))
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.SMTPHost)
+	}
+	if port != 465 {
+		// SendMail upgrades to STARTTLS whenever the server offers it.
+		return smtp.SendMail(addr, auth, e.From, e.To, msg)
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: e.SMTPHost})
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, e.SMTPHost)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.From); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func HostnameOr(def string) string {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
+	}
+	return def
+}
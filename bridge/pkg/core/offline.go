@@ -0,0 +1,63 @@
+package core
+
+import (
+	"log"
+)
+
+// ---------------------------------------------------------------------------
+// Waiting for the printer
+//
+// An embosser that is switched off or unplugged fails the pre-flight check
+// with device_unavailable. Rather than refuse the job, POST /print records
+// it as "waiting" (202, with the reason) and the health watchdog
+// (health.go) sends it once it sees the printer ready again, in the order
+// the jobs arrived. The dashboard shows waiting jobs with Send now
+// and Discard buttons, which use /jobs/{id}/release and /jobs/{id}/discard
+// like held jobs.
+//
+// Printers that are missing altogether (printer_not_found) or that refuse
+// jobs are still refused at once, and so is every unreachable printer when
+// the watchdog is disabled, since nothing would notice it come back.
+// ---------------------------------------------------------------------------
+
+// WaitsForPrinter reports whether a job refused by the pre-flight check
+// with err should wait for the printer instead.
+func WaitsForPrinter(err error) bool {
+	w := CurrentConfig().Watchdog
+	return ErrorCode(err) == ErrCodeDevice && (w == nil || !w.Disabled)
+}
+
+// WaitJob records a job as waiting for its printer.
+func WaitJob(e JobEvent, err error) JobEvent {
+	e.Status = StatusWaiting
+	e.ErrMsg, e.ErrCode = err.Error(), ErrorCode(err)
+	e.Guidance = ErrorGuidance[e.ErrCode]
+	return Store.Append(e)
+}
+
+// waitingPrinters lists the printers that have jobs waiting, so the
+// watchdog checks them even when they are not listed any more (an
+// unplugged USB embosser).
+func waitingPrinters() []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, e := range Store.List() {
+		if e.Status == StatusWaiting && !seen[e.Printer] {
+			seen[e.Printer] = true
+			out = append(out, e.Printer)
+		}
+	}
+	return out
+}
+
+// flushWaiting queues the jobs waiting for printer, oldest first.
+func flushWaiting(printer string) {
+	for _, e := range Store.List() {
+		if e.Status != StatusWaiting || e.Printer != printer {
+			continue
+		}
+		if _, ok := ReleaseJob(e.ID); ok {
+			log.Printf("job %d sent: %q is reachable again", e.ID, printer)
+		}
+	}
+}
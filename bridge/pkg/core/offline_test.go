@@ -0,0 +1,27 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestWaitingJobs(t *testing.T) {
+	old := Store
+	Store = NewMemoryStore(10)
+	defer func() { Store = old }()
+
+	down := Classified(ErrCodeDevice, errors.New("device /dev/usb/lp0: no such file"))
+	if !WaitsForPrinter(down) || WaitsForPrinter(Classified(ErrCodeNotFound, errors.New("no such printer"))) {
+		t.Fatal("wrong errors wait for the printer")
+	}
+	a := WaitJob(JobEvent{Printer: "usb:/dev/usb/lp0"}, down)
+	WaitJob(JobEvent{Printer: "usb:/dev/usb/lp0"}, down)
+	Store.Append(JobEvent{Printer: "Index", Status: StatusDone})
+	if got := waitingPrinters(); !slices.Equal(got, []string{"usb:/dev/usb/lp0"}) {
+		t.Errorf("waiting printers %v", got)
+	}
+	if e, ok := DiscardJob(a.ID); !ok || e.Status != StatusCancelled {
+		t.Errorf("discard: %v %v", e.Status, ok)
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Page-range printing
+//
+// When page 7 of a 40-page job jams, re-embossing the whole job wastes
+// paper and time. POST /jobs/{id}/print?pages=5-10 sends only those pages
+// of a recorded job, as a new job on the same printer. "pages" is one page
+// ("7"), a range ("5-10"), a range to the end ("5-") or a list of them
+// ("3,7,9-12"), as the dashboard sends when pages are ticked in a job's
+// page thumbnails. Listed pages go out in document order, each once.
+//
+// Pages are found the way the page counts are: at form feeds and every
+// lines_per_page lines. Media, eight-dot and line spacing codes that
+// wrapped the original job wrap the new one too, and the last page gets
+// the profile's eject sequence if it ended on its line count.
+//
+// A job that failed partway (cable pulled, paper out) records in
+// "pages_sent" how many whole pages were written before it stopped.
+// POST /jobs/{id}/resume reprints it from the page after those. Embossers
+// buffer, so the last page written may not have come out; the dashboard
+// lets the teacher adjust the page before resuming. Pages are numbered as
+// in the document even when the printer reverses them (reverse.go).
+//
+// Either way the new job is checked like one sent to /print: it is held
+// for a token marked "hold" or for quiet hours, counts against the
+// caller's application, and asks for confirmation
+// (pkg/httpapi/confirm.go) if it is over confirm_pages; send the request
+// again with ?confirm=<value>.
+// ---------------------------------------------------------------------------
+
+// jobCodes returns the escape codes that wrapped a recorded job's payload
+// (see submitPrint), in the order they were sent.
+func jobCodes(e JobEvent) MediaCodes {
+	p := ProfileFor(e.Printer)
+	var layers []MediaCodes // innermost first
+	if codes, ok := p.LineSpacing[e.LineSpacing]; ok {
+		layers = append(layers, codes)
+	}
+	if e.Dots == dots8 && p.EightDot != nil {
+		layers = append(layers, *p.EightDot)
+	}
+	if codes, ok := p.Media[e.Media]; ok {
+		layers = append(layers, codes)
+	}
+	var c MediaCodes
+	for _, l := range layers {
+		c.Start = l.Start + c.Start
+		c.End += l.End
+	}
+	return c
+}
+
+// pageRange cuts pages first to last (0 for the end) out of a recorded
+// job's payload, keeping the codes around it.
+func pageRange(e JobEvent, data []byte, first, last int) ([]byte, int, error) {
+	return PickPages(e, data, []brf.PageRange{{First: first, Last: last}})
+}
+
+// PickPages cuts the listed pages out of a recorded job's payload, keeping
+// the codes around them, and returns them with their count.
+func PickPages(e JobEvent, data []byte, list []brf.PageRange) ([]byte, int, error) {
+	p := ProfileFor(e.Printer)
+	codes := jobCodes(e)
+	body := data
+	if bytes.HasPrefix(body, []byte(codes.Start)) && bytes.HasSuffix(body[len(codes.Start):], []byte(codes.End)) {
+		body = body[len(codes.Start) : len(body)-len(codes.End)]
+	} else {
+		codes = MediaCodes{} // the profile changed; cut the payload as it is
+	}
+	ends := brf.PageEnds(body, p.LinesPerPage)
+	picked := make([]bool, len(ends)) // by page as sent
+	for _, r := range list {
+		last := r.Last
+		if last == 0 {
+			last = len(ends)
+		}
+		if r.First > len(ends) || last > len(ends) {
+			return nil, 0, fmt.Errorf("job %d has %d page(s)", e.ID, len(ends))
+		}
+		for page := r.First; page <= last; page++ {
+			if e.Reversed {
+				// Document pages were sent last first.
+				picked[len(ends)-page] = true
+			} else {
+				picked[page-1] = true
+			}
+		}
+	}
+	var part []byte
+	count := 0
+	for i, ok := range picked {
+		if !ok {
+			continue
+		}
+		start := 0
+		if i > 0 {
+			start = ends[i-1]
+		}
+		part = append(part, body[start:ends[i]]...)
+		count++
+	}
+	part, _ = ensureEject(part, p)
+	return codes.wrap(part), count, nil
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+func TestPageRange(t *testing.T) {
+	data := []byte("A\n\fB\n\fC\nD\n\f")
+	e := JobEvent{ID: 1, Printer: "test"}
+	for _, c := range []struct {
+		first, last int
+		want        string
+	}{
+		{1, 1, "A\n\f"}, {2, 3, "B\n\fC\nD\n\f"}, {3, 0, "C\nD\n\f"},
+	} {
+		got, n, err := pageRange(e, data, c.first, c.last)
+		if err != nil || string(got) != c.want || n != c.last-c.first+1 && c.last != 0 {
+			t.Errorf("pages %d-%d = %q, %d, %v; want %q", c.first, c.last, got, n, err, c.want)
+		}
+	}
+	if _, _, err := pageRange(e, data, 4, 4); err == nil {
+		t.Error("page 4 of 3: want an error")
+	}
+}
+
+func TestPickPages(t *testing.T) {
+	data := []byte("A\n\fB\n\fC\n\fD\n\f")
+	e := JobEvent{ID: 1, Printer: "test"}
+	got, n, err := PickPages(e, data, []brf.PageRange{{First: 4, Last: 4}, {First: 1, Last: 2}, {First: 2, Last: 2}})
+	if err != nil || string(got) != "A\n\fB\n\fD\n\f" || n != 3 {
+		t.Errorf("pages 4,1-2,2 = %q, %d, %v", got, n, err)
+	}
+	e.Reversed = true // sent as D C B A
+	got, n, err = PickPages(e, []byte("D\n\fC\n\fB\n\fA\n\f"), []brf.PageRange{{First: 1, Last: 1}, {First: 3, Last: 0}})
+	if err != nil || string(got) != "D\n\fC\n\fA\n\f" || n != 3 {
+		t.Errorf("reversed pages 1,3- = %q, %d, %v", got, n, err)
+	}
+	if _, _, err := PickPages(e, data, []brf.PageRange{{First: 2, Last: 5}}); err == nil {
+		t.Error("pages 2-5 of 4: want an error")
+	}
+}
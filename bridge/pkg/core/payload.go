@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Job payload storage
+//
+// The job history only holds metadata and short previews. Full payloads are
+// kept here, keyed by job ID: small ones in memory, anything over
+// spillThreshold in a private (0700) directory under the user cache dir, so
+// a queue full of textbook volumes doesn't balloon the bridge's footprint.
+// A second store holds PDFs rendered by virtual printers. Spilled files are
+// encrypted (payloadcrypt.go) and decrypted only when read back.
+// ---------------------------------------------------------------------------
+
+// spillThreshold is the payload size above which data is written to disk.
+const spillThreshold = 64 * 1024
+
+type payloadStore struct {
+	name string // spill subdirectory
+	ext  string // spill file extension
+
+	mu  sync.Mutex
+	dir string // empty until first spill; "-" if unavailable
+	mem map[int][]byte
+}
+
+var (
+	Payloads = &payloadStore{name: "payloads", ext: ".brf", mem: make(map[int][]byte)}
+	Renders  = &payloadStore{name: "renders", ext: ".pdf", mem: make(map[int][]byte)}
+)
+
+// put stores the payload for a job. If the spill directory is unusable the
+// payload is kept in memory instead.
+func (s *payloadStore) put(id int, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(data) > spillThreshold {
+		if dir := s.spillDir(); dir != "" {
+			sealed, err := sealPayload(currentPayloadKey(), s.name, id, data)
+			if err == nil {
+				err = os.WriteFile(s.path(id), sealed, 0o600)
+			}
+			if err == nil {
+				return
+			}
+			log.Printf("payload store: spill job %d: %v", id, err)
+		}
+	}
+	s.mem[id] = data
+}
+
+// Get returns the payload for a job.
+func (s *payloadStore) Get(id int) ([]byte, error) {
+	s.mu.Lock()
+	data, ok := s.mem[id]
+	dir := s.dir
+	s.mu.Unlock()
+	if ok {
+		return data, nil
+	}
+	if dir == "" || dir == "-" {
+		return nil, fmt.Errorf("payload for job %d not found", id)
+	}
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("payload for job %d not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return openPayload(currentPayloadKey(), s.name, id, data)
+}
+
+// remove discards the payload for a job that left the history.
+func (s *payloadStore) remove(id int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.mem[id]; ok {
+		delete(s.mem, id)
+		return
+	}
+	if s.dir != "" && s.dir != "-" {
+		os.Remove(s.path(id))
+	}
+}
+
+// spillDir lazily creates the spill directory, clearing payloads left over
+// from a previous run (their job history did not survive the restart).
+// Must be called with s.mu held.
+func (s *payloadStore) spillDir() string {
+	if s.dir == "" {
+		s.dir = "-"
+		base, err := os.UserCacheDir()
+		if err != nil {
+			log.Printf("payload store: %v; keeping payloads in memory", err)
+			return ""
+		}
+		dir := filepath.Join(base, "graham-bridge", s.name)
+		os.RemoveAll(dir)
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			log.Printf("payload store: %v; keeping payloads in memory", err)
+			return ""
+		}
+		s.dir = dir
+	}
+	if s.dir == "-" {
+		return ""
+	}
+	return s.dir
+}
+
+func (s *payloadStore) path(id int) string {
+	return filepath.Join(s.dir, strconv.Itoa(id)+s.ext)
+}
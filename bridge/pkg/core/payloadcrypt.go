@@ -0,0 +1,167 @@
+package core
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Payload encryption at rest
+//
+// Payloads spilled to disk (payload.go) are student work, so they are
+// written encrypted with AES-256-GCM and decrypted only when the bridge
+// reads them back to send, preview or reprint a job. Each file is bound to
+// its store and job ID, so files cannot be swapped between jobs.
+//
+// The key comes from "payload_passphrase" in the config if it is set
+// (PBKDF2-SHA256 with a salt kept in the data directory); otherwise from a
+// random machine key in payload.key in the data directory, readable only
+// by the bridge's account. The key file sits in the config directory, not
+// with the spilled payloads in the cache directory. If neither can be
+// used, a key for this run only is made; spilled payloads do not outlive a
+// restart anyway.
+// ---------------------------------------------------------------------------
+
+const (
+	payloadCryptVersion = 1
+	payloadKDFRounds    = 600_000
+)
+
+var payloadKey = struct {
+	sync.Mutex
+	key        []byte
+	passphrase string // key was derived from this ("" for the machine key)
+}{}
+
+// currentPayloadKey returns the key for the configured passphrase,
+// deriving it again if the passphrase changed.
+func currentPayloadKey() []byte {
+	pass := CurrentConfig().PayloadPassphrase
+	payloadKey.Lock()
+	defer payloadKey.Unlock()
+	if payloadKey.key != nil && payloadKey.passphrase == pass {
+		return payloadKey.key
+	}
+	var key []byte
+	var err error
+	if pass != "" {
+		key, err = passphraseKey(pass)
+	} else {
+		key, err = machineKey()
+	}
+	if err != nil {
+		log.Printf("payload encryption: %v; using a key for this run only", err)
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	payloadKey.key, payloadKey.passphrase = key, pass
+	return key
+}
+
+// machineKey reads the machine key, creating it on first use.
+func machineKey() ([]byte, error) {
+	return SecretFile("payload.key", func() ([]byte, error) {
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		return key, err
+	})
+}
+
+// passphraseKey derives the key from a passphrase and the stored salt.
+func passphraseKey(pass string) ([]byte, error) {
+	salt, err := SecretFile("payload.salt", func() ([]byte, error) {
+		salt := make([]byte, 16)
+		_, err := rand.Read(salt)
+		return salt, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pbkdf2.Key(sha256.New, pass, salt, payloadKDFRounds, 32)
+}
+
+// SecretFile reads a file in the data directory, creating it (0600) with
+// the bytes from create if it does not exist.
+func SecretFile(name string, create func() ([]byte, error)) ([]byte, error) {
+	path := filepath.Join(DataDir(), name)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return data, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if data, err = create(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, os.ErrExist) {
+		return os.ReadFile(path) // another bridge made it first
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return data, f.Close()
+}
+
+// payloadAAD binds an encrypted payload to its store and job.
+func payloadAAD(store string, id int) []byte {
+	return []byte(store + "/" + strconv.Itoa(id))
+}
+
+// sealPayload encrypts a payload for writing to disk.
+func sealPayload(key []byte, store string, id int, data []byte) ([]byte, error) {
+	gcm, err := payloadCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 1+gcm.NonceSize(), 1+gcm.NonceSize()+len(data)+gcm.Overhead())
+	out[0] = payloadCryptVersion
+	rand.Read(out[1:])
+	return gcm.Seal(out, out[1:], data, payloadAAD(store, id)), nil
+}
+
+// openPayload decrypts a payload read from disk.
+func openPayload(key []byte, store string, id int, sealed []byte) ([]byte, error) {
+	gcm, err := payloadCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < 1+gcm.NonceSize() || sealed[0] != payloadCryptVersion {
+		return nil, fmt.Errorf("payload for job %d is not in a known format", id)
+	}
+	nonce, body := sealed[1:1+gcm.NonceSize()], sealed[1+gcm.NonceSize():]
+	data, err := gcm.Open(nil, nonce, body, payloadAAD(store, id))
+	if err != nil {
+		return nil, fmt.Errorf("payload for job %d cannot be decrypted (was payload_passphrase changed?)", id)
+	}
+	return data, nil
+}
+
+func payloadCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("payload key is %d bytes, want 32", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,27 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealPayload(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	data := []byte("⠁⠃⠉ student work")
+	sealed, err := sealPayload(key, "payloads", 3, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, []byte("student work")) {
+		t.Error("payload stored in the clear")
+	}
+	if got, err := openPayload(key, "payloads", 3, sealed); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("round trip: %q, %v", got, err)
+	}
+	if _, err := openPayload(key, "payloads", 4, sealed); err == nil {
+		t.Error("payload opened as another job's")
+	}
+	if _, err := openPayload(bytes.Repeat([]byte{8}, 32), "payloads", 3, sealed); err == nil {
+		t.Error("payload opened with the wrong key")
+	}
+}
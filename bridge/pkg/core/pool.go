@@ -0,0 +1,120 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Printer pools
+//
+// A district production room with two identical embossers can name them as
+// a pool and send print requests to the pool instead of either embosser:
+//
+//	"pools": {"Production": ["Index Everest A", "Index Everest B"]}
+//
+// Each job sent to the pool is given to the member with the fewest jobs
+// queued or printing, skipping members the health watchdog last saw offline,
+// so a big run is shared between them. Ties go to the member listed first.
+// The job is recorded on the member that takes it with "pool" set to the
+// pool's name; from then on it is that printer's job, so a retry, resend or
+// release stays on it. Members use their own profiles, which should match.
+//
+// GET /printers lists each pool after the printers (with ?details=1, as an
+// entry with "members"), and a pool can be the default printer.
+// ---------------------------------------------------------------------------
+
+// validPools checks the "pools" section of the config.
+func validPools(c *Config) error {
+	for name, members := range c.Pools {
+		if name == "" {
+			return errors.New("pools: a pool needs a name")
+		}
+		if len(members) < 2 {
+			return fmt.Errorf("pools: %q needs at least two printers", name)
+		}
+		if _, ok := c.Printers[name]; ok {
+			return fmt.Errorf("pools: %q is also a printer profile", name)
+		}
+		for i, m := range members {
+			if _, ok := c.Pools[m]; ok || m == "" {
+				return fmt.Errorf("pools: %q: members must be printers, not %q", name, m)
+			}
+			if slices.Contains(members[:i], m) {
+				return fmt.Errorf("pools: %q lists %q twice", name, m)
+			}
+		}
+	}
+	return nil
+}
+
+// poolMembers returns the printers of pool name, or false if it is not one.
+func poolMembers(name string) ([]string, bool) {
+	m, ok := CurrentConfig().Pools[name]
+	return m, ok
+}
+
+// ResolvePool picks the member of pool printer that should take the next
+// job. For a printer that is not a pool it returns the printer and "".
+func ResolvePool(printer string) (member, pool string) {
+	members, ok := poolMembers(printer)
+	if !ok {
+		return printer, ""
+	}
+	unavailable := func(m string) bool { return lastSeenOffline(m) || attentionHeld(m) }
+	member = pickMember(members, poolLoad(members), unavailable)
+	log.Printf("pool %q: job goes to %q", printer, member)
+	return member, printer
+}
+
+// pickMember chooses the least loaded member not known to be offline,
+// falling back to the least loaded of all when every member is offline.
+func pickMember(members []string, load map[string]int, offline func(string) bool) string {
+	best := ""
+	for _, skipOffline := range []bool{true, false} {
+		for _, m := range members {
+			if skipOffline && offline(m) {
+				continue
+			}
+			if best == "" || load[m] < load[best] {
+				best = m
+			}
+		}
+		if best != "" {
+			break
+		}
+	}
+	return best
+}
+
+// poolLoad counts the jobs queued or printing on each member.
+func poolLoad(members []string) map[string]int {
+	load := make(map[string]int, len(members))
+	for _, e := range Store.List() {
+		if (e.Status == StatusQueued || e.Status == StatusPrinting) && slices.Contains(members, e.Printer) {
+			load[e.Printer]++
+		}
+	}
+	return load
+}
+
+// lastSeenOffline reports whether the watchdog last saw printer offline.
+func lastSeenOffline(printer string) bool {
+	health.Lock()
+	defer health.Unlock()
+	h, seen := health.last[printer]
+	return seen && !h.Online
+}
+
+// PoolInfos lists the configured pools for GET /printers.
+func PoolInfos() []PrinterInfo {
+	var out []PrinterInfo
+	for name, members := range CurrentConfig().Pools {
+		out = append(out, PrinterInfo{Name: name, Members: members})
+	}
+	slices.SortFunc(out, func(a, b PrinterInfo) int { return strings.Compare(a.Name, b.Name) })
+	return out
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestPickPoolMember(t *testing.T) {
+	members := []string{"A", "B", "C"}
+	none := func(string) bool { return false }
+	if m := pickMember(members, map[string]int{}, none); m != "A" {
+		t.Errorf("idle pool picked %q, want the first member", m)
+	}
+	if m := pickMember(members, map[string]int{"A": 2, "B": 1, "C": 1}, none); m != "B" {
+		t.Errorf("picked %q, want the least loaded", m)
+	}
+	if m := pickMember(members, map[string]int{"A": 2}, func(p string) bool { return p != "A" }); m != "A" {
+		t.Errorf("picked offline %q", m)
+	}
+	if m := pickMember(members, map[string]int{"A": 1, "B": 2, "C": 3}, func(string) bool { return true }); m != "A" {
+		t.Errorf("all offline: picked %q", m)
+	}
+
+	c := &Config{Pools: map[string][]string{"P": {"A"}}}
+	if validPools(c) == nil {
+		t.Error("one-member pool accepted")
+	}
+	c.Pools = map[string][]string{"P": {"A", "Q"}, "Q": {"B", "C"}}
+	if validPools(c) == nil {
+		t.Error("pool of pools accepted")
+	}
+}
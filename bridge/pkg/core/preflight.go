@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Pre-flight check
+//
+// Before a job is queued the destination is checked to exist and accept
+// jobs, so a typo'd or paused printer fails immediately with a specific
+// error rather than after the job has waited its turn. Only definite answers
+// refuse a job; if the check itself cannot be completed the job is queued
+// as before.
+// ---------------------------------------------------------------------------
+
+// StatusRejected is the API status for a job refused by the pre-flight check.
+const StatusRejected = "rejected"
+
+// Preflight returns a classified error if printer cannot take a job now.
+func Preflight(ctx context.Context, printer string) error {
+	ctx, cancel := context.WithTimeout(ctx, ListTimeout())
+	defer cancel()
+
+	var err error
+	switch {
+	case strings.HasPrefix(printer, serialPrefix):
+		err = checkDevice(strings.TrimPrefix(printer, serialPrefix))
+	case strings.HasPrefix(printer, usbPrefix):
+		err = checkUSB(ctx, strings.TrimPrefix(printer, usbPrefix))
+	case strings.HasPrefix(printer, ippPrefix):
+		err = checkIPP(ctx, strings.TrimPrefix(printer, ippPrefix))
+	case strings.HasPrefix(printer, socketPrefix):
+		err = checkSocket(ctx, strings.TrimPrefix(printer, socketPrefix))
+	case strings.HasPrefix(printer, virtualPrefix):
+		return nil
+	case strings.HasPrefix(printer, simPrefix):
+		err = checkSimulated(printer)
+	case strings.HasPrefix(printer, LoopbackPrefix):
+		err = checkLoopback(printer)
+	case strings.HasPrefix(printer, driverPrefix):
+		err = checkDriver(ctx, printer)
+	default:
+		err = ActiveSpooler.Check(ctx, printer)
+	}
+	debugf("pre-flight check for %q: %v", printer, err)
+	if err == nil {
+		return nil
+	}
+	switch ErrorCode(err) {
+	case ErrCodeNotFound, ErrCodeNotAccepting, ErrCodeSpooler, ErrCodeDevice, ErrCodePermission:
+		return err
+	}
+	log.Printf("pre-flight check for %q inconclusive: %v", printer, err)
+	return nil
+}
+
+// checkDevice confirms a direct-transport device node is present. Windows
+// COM ports have no file to stat and are checked when opened.
+func checkDevice(path string) error {
+	if strings.HasPrefix(strings.ToUpper(path), "COM") {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return Classified(ErrCodeDevice, fmt.Errorf("device %s: %w", path, err))
+	}
+	return nil
+}
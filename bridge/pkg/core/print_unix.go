@@ -0,0 +1,202 @@
+//go:build !windows
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// sendToPrinter sends raw BRF bytes to the named printer using CUPS (lp).
+// This implementation is used on macOS and Linux.
+//
+// The job is piped to lp's stdin so student work never touches the disk.
+// Setting "spool_temp_file" in the config restores the older temp-file path
+// for lp wrappers that cannot read from stdin. Once lp accepts the job it
+// is watched until CUPS starts it (see spoolcheck_unix.go).
+func sendToPrinter(ctx context.Context, printerName string, data []byte) error {
+	var out string
+	var err error
+	if CurrentConfig().SpoolTempFile {
+		out, err = lpFromTempFile(ctx, printerName, data)
+	} else {
+		cmd := exec.CommandContext(ctx, "lp", "-d", printerName, "-o", "raw")
+		cmd.Stdin = bytes.NewReader(data)
+		out, err = runLp(ctx, cmd)
+	}
+	if err != nil {
+		return err
+	}
+	id := parseRequestID(out)
+	if id == "" {
+		log.Printf("lp gave no request ID for %q; cannot verify the job: %s", printerName, strings.TrimSpace(out))
+		return nil
+	}
+	return verifySpooled(ctx, printerName, id)
+}
+
+// lpFromTempFile spools the job through a private (0600) temporary file.
+func lpFromTempFile(ctx context.Context, printerName string, data []byte) (string, error) {
+	// Write the BRF content to a temporary file.
+	tmp, err := os.CreateTemp("", "graham-bridge-*.brf")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("close temp file: %w", err)
+	}
+
+	// Use `lp` to send the file to the named printer as a raw job.
+	cmd := exec.CommandContext(ctx, "lp", "-d", printerName, "-o", "raw", tmp.Name())
+	return runLp(ctx, cmd)
+}
+
+// runLp runs an lp command in the C locale, returning its output and
+// classifying its failure.
+func runLp(ctx context.Context, cmd *exec.Cmd) (string, error) {
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	cmd.WaitDelay = time.Second
+	output, err := cmd.CombinedOutput()
+	debugf("%s: %v: %s", strings.Join(cmd.Args, " "), err, bytes.TrimSpace(output))
+	if ctx.Err() != nil {
+		// lp may have handed the job to CUPS before it was stopped, so
+		// this is not retried: sending it again could emboss it twice.
+		return "", Classified(ErrCodeStuck, fmt.Errorf("lp did not finish: %w", ctx.Err()))
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", Classified(ErrCodeSpooler, fmt.Errorf("lp not found; is CUPS installed? %w", err))
+	}
+	if err != nil {
+		return "", Classified(classifyLp(string(output)), fmt.Errorf("lp command failed: %w\noutput: %s", err, output))
+	}
+	return string(output), nil
+}
+
+// lpFailures maps fragments of lp's (C locale) error messages to failure
+// classes, checked in order.
+var lpFailures = []struct {
+	fragment string
+	code     string
+}{
+	{"does not exist", ErrCodeNotFound},
+	{"unknown destination", ErrCodeNotFound},
+	{"no default destination", ErrCodeNotFound},
+	{"not accepting jobs", ErrCodeNotAccepting},
+	{"not accepting requests", ErrCodeNotAccepting},
+	{"unable to connect to server", ErrCodeSpooler},
+	{"scheduler not responding", ErrCodeSpooler},
+	{"scheduler is not running", ErrCodeSpooler},
+	{"connection refused", ErrCodeSpooler},
+	{"forbidden", ErrCodePermission},
+	{"not authorized", ErrCodePermission},
+	{"permission denied", ErrCodePermission},
+}
+
+func classifyLp(output string) string {
+	lower := strings.ToLower(output)
+	for _, f := range lpFailures {
+		if strings.Contains(lower, f.fragment) {
+			return f.code
+		}
+	}
+	return errCodeUnknown
+}
+
+// checkPrinter asks CUPS whether printerName exists, is accepting jobs and
+// is enabled.
+func checkPrinter(ctx context.Context, printerName string) error {
+	cmd := exec.CommandContext(ctx, "lpstat", "-a", printerName, "-p", printerName)
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return Classified(ErrCodeSpooler, fmt.Errorf("lpstat not found; is CUPS installed? %w", err))
+	}
+	return lpstatState(printerName, string(out), err)
+}
+
+// lpstatState interprets "lpstat -a NAME -p NAME" output.
+func lpstatState(printerName, out string, err error) error {
+	if err != nil {
+		lower := strings.ToLower(out)
+		if strings.Contains(lower, "invalid destination") || strings.Contains(lower, "unknown destination") {
+			return Classified(ErrCodeNotFound, fmt.Errorf("printer %q does not exist", printerName))
+		}
+		return Classified(classifyLp(out), fmt.Errorf("lpstat failed: %w\noutput: %s", err, out))
+	}
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, printerName+" not accepting"):
+			return Classified(ErrCodeNotAccepting, fmt.Errorf("printer %q is not accepting jobs", printerName))
+		case strings.HasPrefix(line, "printer "+printerName+" disabled"):
+			return Classified(ErrCodeNotAccepting, fmt.Errorf("printer %q is paused", printerName))
+		}
+	}
+	return nil
+}
+
+// printerState asks CUPS for a printer's state, alerts and queued jobs. The
+// job listing comes before -l so only the printer gets the long format.
+func printerState(ctx context.Context, printerName string) PrinterState {
+	cmd := exec.CommandContext(ctx, "lpstat", "-a", printerName, "-o", printerName, "-l", "-p", printerName)
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		s := PrinterState{Printer: printerName, State: stateUnknown, Reasons: []string{strings.TrimSpace(string(out))}}
+		switch {
+		case ctx.Err() != nil:
+			s.Reasons = []string{ctx.Err().Error()}
+		case errors.Is(err, exec.ErrNotFound):
+			s.Reasons = []string{"lpstat not found; is CUPS installed?"}
+		case ErrorCode(lpstatState(printerName, string(out), err)) == ErrCodeNotFound:
+			s.State = stateNotFound
+		}
+		return s
+	}
+	return parseLpstatStatus(printerName, string(out))
+}
+
+// deviceURI returns the device URI of a CUPS queue, or "" if CUPS does not
+// say.
+func deviceURI(ctx context.Context, printerName string) string {
+	cmd := exec.CommandContext(ctx, "lpstat", "-v", printerName)
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	for uri, q := range parseLpstatV(string(out)) {
+		if q == printerName {
+			return uri
+		}
+	}
+	return ""
+}
+
+// queueDriver returns the driver (make and model) and device URI of a
+// CUPS queue, from lpoptions.
+func queueDriver(ctx context.Context, printerName string) (driver, uri string) {
+	cmd := exec.CommandContext(ctx, "lpoptions", "-p", printerName)
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", ""
+	}
+	opts := parseLpoptions(string(out))
+	return opts["printer-make-and-model"], opts["device-uri"]
+}
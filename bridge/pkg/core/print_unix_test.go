@@ -0,0 +1,48 @@
+//go:build !windows
+
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A send that times out while lp is running may already be in the CUPS
+// queue, so it must not be retried.
+func TestLpTimeoutNotRetried(t *testing.T) {
+	old, oldStore := Cfg.Load(), Store
+	defer func() { Cfg.Store(old); Store = oldStore }()
+	Cfg.Store(&Config{
+		Timeouts: Timeouts{SendSeconds: 1},
+		Retry:    &RetryConfig{Retries: 3, BackoffSeconds: 1},
+		Printers: map[string]PrinterProfile{"Slow_Everest": {AirPrint: AirPrintQueue}},
+	})
+	Store = NewMemoryStore(10)
+	prev := ActiveSpooler
+	ActiveSpooler = OSSpooler{}
+	defer func() { ActiveSpooler = prev }()
+
+	// The fake lp records each run, then hangs as if CUPS never answered.
+	dir := t.TempDir()
+	runs := filepath.Join(dir, "runs")
+	script := "#!/bin/sh\necho run >> " + runs + "\nexec sleep 30\n"
+	if err := os.WriteFile(filepath.Join(dir, "lp"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	e := Store.Append(JobEvent{Printer: "Slow_Everest", Status: StatusQueued})
+	err := SendWithRetry(&PrintJob{ID: e.ID, Printer: "Slow_Everest", Data: []byte("A\n\f")})
+	if ErrorCode(err) != ErrCodeStuck {
+		t.Errorf("lp timeout: %v (%s)", err, ErrorCode(err))
+	}
+	got, _ := os.ReadFile(runs)
+	if n := strings.Count(string(got), "run"); n != 1 {
+		t.Errorf("lp ran %d times", n)
+	}
+	if Transient(&PrintJob{}, err) {
+		t.Error("lp timeout counted as transient")
+	}
+}
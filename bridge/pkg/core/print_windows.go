@@ -0,0 +1,491 @@
+//go:build windows
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Windows spooler API via winspool.drv
+//
+// We use syscall.LoadDLL rather than cgo so the binary can be built
+// with GOOS=windows on Linux (cross-compile) — cgo requires a Windows
+// C toolchain. The tradeoff is that we must manage unsafe.Pointer manually.
+//
+// Required Win32 functions:
+//   OpenPrinterW     — open a handle to the named printer
+//   StartDocPrinterW — begin a raw document in the spooler
+//   StartPagePrinter — (required by some drivers even for raw jobs)
+//   WritePrinter     — write raw bytes into the job
+//   EndPagePrinter   — close the page
+//   EndDocPrinter    — end the document
+//   ClosePrinter     — release the handle
+//   EnumPrintersW    — list local and connected printers
+//   GetPrinterW      — read a printer's status (printerstatus.go)
+//   EnumJobsW        — list the jobs queued on a printer
+//
+// Printer names go to and from the spooler as UTF-16 and are never passed
+// through a shell, so names with spaces, parentheses or non-ASCII
+// characters ("Index Everest-D V5 (Copy 1)") reach it exactly as listed.
+
+var (
+	winspool        = syscall.NewLazyDLL("winspool.drv")
+	procOpenPrinter = winspool.NewProc("OpenPrinterW")
+	procStartDoc    = winspool.NewProc("StartDocPrinterW")
+	procStartPage   = winspool.NewProc("StartPagePrinter")
+	procWrite       = winspool.NewProc("WritePrinter")
+	procEndPage     = winspool.NewProc("EndPagePrinter")
+	procEndDoc      = winspool.NewProc("EndDocPrinter")
+	procClose       = winspool.NewProc("ClosePrinter")
+	procEnum        = winspool.NewProc("EnumPrintersW")
+)
+
+// DOC_INFO_1 corresponds to the Win32 DOC_INFO_1W struct.
+type docInfo1 struct {
+	pDocName    *uint16
+	pOutputFile *uint16
+	pDatatype   *uint16
+}
+
+// sendToPrinter sends raw BRF bytes to the Windows print spooler.
+// This bypasses GDI rendering and is required for ViewPlus embossers.
+//
+// Spooler calls cannot be interrupted, so on timeout the call is abandoned
+// in its goroutine and the caller gets the context error straight away.
+func sendToPrinter(ctx context.Context, printerName string, data []byte) error {
+	done := make(chan error, 1)
+	go func() { done <- spoolRaw(printerName, data) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("spooler did not respond: %w", ctx.Err())
+	}
+}
+
+// spoolRaw writes one RAW document to the named printer.
+func spoolRaw(printerName string, data []byte) error {
+	// Open printer handle.
+	printerNamePtr, err := syscall.UTF16PtrFromString(printerName)
+	if err != nil {
+		return fmt.Errorf("encode printer name: %w", err)
+	}
+
+	var hPrinter uintptr
+	ret, _, lastErr := procOpenPrinter.Call(
+		uintptr(unsafe.Pointer(printerNamePtr)),
+		uintptr(unsafe.Pointer(&hPrinter)),
+		0,
+	)
+	if ret == 0 {
+		return classifySpooler(fmt.Errorf("OpenPrinterW failed: %w", lastErr))
+	}
+	defer procClose.Call(hPrinter) //nolint:errcheck
+
+	// Build DOC_INFO_1 with datatype "RAW".
+	docName, _ := syscall.UTF16PtrFromString("Graham Bridge Job")
+	datatype, _ := syscall.UTF16PtrFromString("RAW")
+	info := &docInfo1{
+		pDocName:    docName,
+		pOutputFile: nil,
+		pDatatype:   datatype,
+	}
+
+	ret, _, lastErr = procStartDoc.Call(
+		hPrinter,
+		1, // level
+		uintptr(unsafe.Pointer(info)),
+	)
+	if ret == 0 {
+		return classifySpooler(fmt.Errorf("StartDocPrinterW failed: %w", lastErr))
+	}
+	defer procEndDoc.Call(hPrinter) //nolint:errcheck
+
+	ret, _, lastErr = procStartPage.Call(hPrinter)
+	if ret == 0 {
+		return classifySpooler(fmt.Errorf("StartPagePrinter failed: %w", lastErr))
+	}
+	defer procEndPage.Call(hPrinter) //nolint:errcheck
+
+	var written uint32
+	ret, _, lastErr = procWrite.Call(
+		hPrinter,
+		uintptr(unsafe.Pointer(&data[0])),
+		uintptr(len(data)),
+		uintptr(unsafe.Pointer(&written)),
+	)
+	if ret == 0 {
+		return classifySpooler(fmt.Errorf("WritePrinter failed: %w", lastErr))
+	}
+	if int(written) != len(data) {
+		return fmt.Errorf("WritePrinter wrote %d of %d bytes", written, len(data))
+	}
+
+	return nil
+}
+
+// Win32 error codes the spooler reports for common failures.
+const (
+	errAccessDenied       syscall.Errno = 5
+	errServiceNotActive   syscall.Errno = 1062
+	errRPCUnavailable     syscall.Errno = 1722
+	errInvalidPrinterName syscall.Errno = 1801
+	errPrinterNotFound    syscall.Errno = 3012
+	errPrinterDeleted     syscall.Errno = 1905
+	errPrintCancelled     syscall.Errno = 63
+)
+
+// classifySpooler attaches a failure class to a spooler API error.
+func classifySpooler(err error) error {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return err
+	}
+	switch errno {
+	case errInvalidPrinterName, errPrinterNotFound, errPrinterDeleted:
+		return Classified(ErrCodeNotFound, err)
+	case errAccessDenied:
+		return Classified(ErrCodePermission, err)
+	case errServiceNotActive, errRPCUnavailable:
+		return Classified(ErrCodeSpooler, err)
+	case errPrintCancelled:
+		return Classified(ErrCodeNotAccepting, err)
+	}
+	return err
+}
+
+// PRINTER_STATUS_* flags and the PRINTER_ATTRIBUTE_WORK_OFFLINE attribute.
+const (
+	printerStatusPaused           = 0x1
+	printerStatusError            = 0x2
+	printerStatusPendingDeletion  = 0x4
+	printerStatusPaperJam         = 0x8
+	printerStatusPaperOut         = 0x10
+	printerStatusPaperProblem     = 0x40
+	printerStatusOffline          = 0x80
+	printerStatusBusy             = 0x200
+	printerStatusPrinting         = 0x400
+	printerStatusNotAvailable     = 0x1000
+	printerStatusProcessing       = 0x4000
+	printerStatusUserIntervention = 0x100000
+	printerStatusDoorOpen         = 0x400000
+
+	printerAttributeWorkOffline = 0x400
+)
+
+// JOB_STATUS_* flags. A port monitor often reports a problem only on the
+// job it is stuck on, leaving the printer's own status word clear.
+const (
+	jobStatusError            = 0x2
+	jobStatusPrinting         = 0x10
+	jobStatusOffline          = 0x20
+	jobStatusPaperOut         = 0x40
+	jobStatusBlockedDevQ      = 0x200
+	jobStatusUserIntervention = 0x400
+)
+
+var (
+	procGetPrinter = winspool.NewProc("GetPrinterW")
+	procEnumJobs   = winspool.NewProc("EnumJobsW")
+)
+
+// printerInfo2 corresponds to the Win32 PRINTER_INFO_2W struct.
+type printerInfo2 struct {
+	pServerName         *uint16
+	pPrinterName        *uint16
+	pShareName          *uint16
+	pPortName           *uint16
+	pDriverName         *uint16
+	pComment            *uint16
+	pLocation           *uint16
+	pDevMode            uintptr
+	pSepFile            *uint16
+	pPrintProcessor     *uint16
+	pDatatype           *uint16
+	pParameters         *uint16
+	pSecurityDescriptor uintptr
+	attributes          uint32
+	priority            uint32
+	defaultPriority     uint32
+	startTime           uint32
+	untilTime           uint32
+	status              uint32
+	cJobs               uint32
+	averagePPM          uint32
+}
+
+// jobInfo1 corresponds to the Win32 JOB_INFO_1W struct.
+type jobInfo1 struct {
+	jobID        uint32
+	pPrinterName *uint16
+	pMachineName *uint16
+	pUserName    *uint16
+	pDocument    *uint16
+	pDatatype    *uint16
+	pStatus      *uint16
+	status       uint32
+	priority     uint32
+	position     uint32
+	totalPages   uint32
+	pagesPrinted uint32
+	submitted    windows.Systemtime
+}
+
+// checkPrinter opens the printer by its exact name and reads its status.
+func checkPrinter(ctx context.Context, printerName string) error {
+	done := make(chan error, 1)
+	go func() { done <- printerStatus(printerName) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// printerStatus refuses printers that are paused or being deleted. Other
+// problems (offline, out of paper) are left to the spooler, which holds
+// the job until the printer is ready.
+func printerStatus(printerName string) error {
+	s, err := spoolerState(printerName)
+	if err != nil {
+		return err
+	}
+	switch s.State {
+	case stateNotFound:
+		return Classified(ErrCodeNotFound, fmt.Errorf("printer %q is being deleted", printerName))
+	case statePaused:
+		return Classified(ErrCodeNotAccepting, fmt.Errorf("printer %q is paused", printerName))
+	}
+	return nil
+}
+
+// printerState asks the spooler for a printer's state and queued jobs.
+func printerState(ctx context.Context, printerName string) PrinterState {
+	type result struct {
+		s   PrinterState
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		s, err := spoolerState(printerName)
+		done <- result{s, err}
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			s := PrinterState{Printer: printerName, State: stateUnknown, Reasons: []string{r.err.Error()}}
+			if ErrorCode(r.err) == ErrCodeNotFound {
+				s.State = stateNotFound
+			}
+			return s
+		}
+		return r.s
+	case <-ctx.Done():
+		return PrinterState{Printer: printerName, State: stateUnknown, Reasons: []string{ctx.Err().Error()}}
+	}
+}
+
+// spoolerState reads PRINTER_INFO_2 and the job list for one printer.
+func spoolerState(printerName string) (PrinterState, error) {
+	namePtr, err := syscall.UTF16PtrFromString(printerName)
+	if err != nil {
+		return PrinterState{}, fmt.Errorf("encode printer name: %w", err)
+	}
+	var h uintptr
+	ret, _, lastErr := procOpenPrinter.Call(uintptr(unsafe.Pointer(namePtr)), uintptr(unsafe.Pointer(&h)), 0)
+	if ret == 0 {
+		return PrinterState{}, classifySpooler(fmt.Errorf("OpenPrinterW failed: %w", lastErr))
+	}
+	defer procClose.Call(h) //nolint:errcheck
+
+	info, err := getPrinterInfo2(h)
+	if err != nil {
+		return PrinterState{}, err
+	}
+	jobs, err := enumJobs(h)
+	if err != nil {
+		log.Printf("EnumJobsW %q: %v", printerName, err)
+	}
+	return windowsPrinterState(printerName, info.status, info.attributes, jobs), nil
+}
+
+// getPrinterInfo2 reads PRINTER_INFO_2 for an open printer.
+func getPrinterInfo2(h uintptr) (*printerInfo2, error) {
+	// The first call only reports the buffer size required.
+	var needed uint32
+	_, _, lastErr := procGetPrinter.Call(h, 2, 0, 0, uintptr(unsafe.Pointer(&needed)))
+	if needed == 0 {
+		return nil, fmt.Errorf("GetPrinterW failed: %w", lastErr)
+	}
+	buf := make([]byte, needed)
+	ret, _, lastErr := procGetPrinter.Call(h, 2,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(needed), uintptr(unsafe.Pointer(&needed)))
+	if ret == 0 {
+		return nil, classifySpooler(fmt.Errorf("GetPrinterW failed: %w", lastErr))
+	}
+	return (*printerInfo2)(unsafe.Pointer(&buf[0])), nil
+}
+
+// enumJobs returns the status word of each job queued on an open printer.
+func enumJobs(h uintptr) ([]uint32, error) {
+	var needed, returned uint32
+	procEnumJobs.Call(h, 0, 0xFFFFFFFF, 1, 0, 0,
+		uintptr(unsafe.Pointer(&needed)), uintptr(unsafe.Pointer(&returned)))
+	if needed == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, needed)
+	ret, _, lastErr := procEnumJobs.Call(h, 0, 0xFFFFFFFF, 1,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(needed),
+		uintptr(unsafe.Pointer(&needed)), uintptr(unsafe.Pointer(&returned)))
+	if ret == 0 {
+		return nil, lastErr
+	}
+	infos := unsafe.Slice((*jobInfo1)(unsafe.Pointer(&buf[0])), returned)
+	statuses := make([]uint32, len(infos))
+	for i, j := range infos {
+		statuses[i] = j.status
+	}
+	return statuses, nil
+}
+
+// windowsPrinterState maps spooler printer and job status flags to a
+// PrinterState, the most serious condition winning.
+func windowsPrinterState(printerName string, status, attributes uint32, jobs []uint32) PrinterState {
+	s := PrinterState{Printer: printerName, State: stateReady, Jobs: len(jobs)}
+	var jobFlags uint32
+	for _, j := range jobs {
+		jobFlags |= j
+	}
+	flag := func(printerBits, jobBits uint32) bool {
+		return status&printerBits != 0 || jobFlags&jobBits != 0
+	}
+	switch {
+	case status&printerStatusPendingDeletion != 0:
+		s.State, s.Reasons = stateNotFound, []string{"being deleted"}
+	case status&printerStatusPaused != 0:
+		s.State, s.Reasons = statePaused, []string{"paused"}
+	case attributes&printerAttributeWorkOffline != 0:
+		s.State, s.Reasons = stateOffline, []string{"use printer offline"}
+	case flag(printerStatusOffline|printerStatusNotAvailable, jobStatusOffline):
+		s.State, s.Reasons = stateOffline, []string{"offline"}
+	case flag(printerStatusPaperJam, 0):
+		s.State, s.Reasons = statePaperJam, []string{"paper jam"}
+	case flag(printerStatusPaperOut|printerStatusPaperProblem, jobStatusPaperOut):
+		s.State, s.Reasons = statePaperOut, []string{"out of paper"}
+	case flag(printerStatusDoorOpen, 0):
+		s.State, s.Reasons = stateDoorOpen, []string{"door open"}
+	case flag(printerStatusError|printerStatusUserIntervention, jobStatusError|jobStatusUserIntervention|jobStatusBlockedDevQ):
+		s.State, s.Reasons = stateError, []string{"needs attention"}
+	case flag(printerStatusPrinting|printerStatusBusy|printerStatusProcessing, jobStatusPrinting):
+		s.State = statePrinting
+	}
+	return s
+}
+
+// PRINTER_ENUM_* flags for EnumPrintersW.
+const (
+	printerEnumLocal       = 0x2
+	printerEnumConnections = 0x4
+)
+
+// printerInfo4 corresponds to the Win32 PRINTER_INFO_4W struct.
+type printerInfo4 struct {
+	pPrinterName *uint16
+	pServerName  *uint16
+	attributes   uint32
+}
+
+// listPrinters returns the names of all printers installed on Windows,
+// including connections to shared network printers.
+func listPrinters(ctx context.Context) []string {
+	done := make(chan []string, 1)
+	go func() {
+		names, err := enumPrinters()
+		if err != nil {
+			log.Printf("EnumPrintersW: %v", err)
+		}
+		done <- names
+	}()
+	select {
+	case names := <-done:
+		return names
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+func enumPrinters() ([]string, error) {
+	const flags = printerEnumLocal | printerEnumConnections
+	var needed, returned uint32
+	// The first call only reports the buffer size required.
+	procEnum.Call(flags, 0, 4, 0, 0,
+		uintptr(unsafe.Pointer(&needed)), uintptr(unsafe.Pointer(&returned)))
+	if needed == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, needed)
+	ret, _, lastErr := procEnum.Call(flags, 0, 4,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(needed),
+		uintptr(unsafe.Pointer(&needed)), uintptr(unsafe.Pointer(&returned)))
+	if ret == 0 {
+		return nil, lastErr
+	}
+	return printerNames(unsafe.Slice((*printerInfo4)(unsafe.Pointer(&buf[0])), returned)), nil
+}
+
+// printerNames decodes the UTF-16 names from PRINTER_INFO_4 records.
+func printerNames(infos []printerInfo4) []string {
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		if info.pPrinterName == nil {
+			continue
+		}
+		if name := windows.UTF16PtrToString(info.pPrinterName); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// deviceURI is not available from the Windows spooler; printers are
+// checked through their spooler status alone.
+func deviceURI(context.Context, string) string {
+	return ""
+}
+
+// queueDriver returns a printer's driver name and, for IPP ports, whose
+// names are the printer's URL, that URL.
+func queueDriver(_ context.Context, printerName string) (driver, uri string) {
+	namePtr, err := syscall.UTF16PtrFromString(printerName)
+	if err != nil {
+		return "", ""
+	}
+	var h uintptr
+	if ret, _, _ := procOpenPrinter.Call(uintptr(unsafe.Pointer(namePtr)), uintptr(unsafe.Pointer(&h)), 0); ret == 0 {
+		return "", ""
+	}
+	defer procClose.Call(h) //nolint:errcheck
+	info, err := getPrinterInfo2(h)
+	if err != nil {
+		return "", ""
+	}
+	if info.pDriverName != nil {
+		driver = windows.UTF16PtrToString(info.pDriverName)
+	}
+	if info.pPortName != nil {
+		if port := windows.UTF16PtrToString(info.pPortName); strings.Contains(port, "://") {
+			uri = port
+		}
+	}
+	return driver, uri
+}
@@ -0,0 +1,86 @@
+//go:build windows
+
+package core
+
+import (
+	"slices"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// Names as they appear in Windows' printer list, including the "(Copy 1)"
+// suffix Windows adds to duplicate queues and non-ASCII names set by
+// districts.
+var awkwardPrinterNames = []string{
+	"Index Everest-D V5 (Copy 1)",
+	"ViewPlus Columbia",
+	"Impresora Braille — Aula 3",
+	"Imprimante braille élève",
+	"点字プリンター",
+	"Braille & Large Print [Room 12] 😀",
+}
+
+func TestPrinterNamesRoundTrip(t *testing.T) {
+	infos := make([]printerInfo4, len(awkwardPrinterNames))
+	for i, name := range awkwardPrinterNames {
+		p, err := syscall.UTF16PtrFromString(name)
+		if err != nil {
+			t.Fatalf("encode %q: %v", name, err)
+		}
+		infos[i].pPrinterName = p
+	}
+	if got := printerNames(infos); !slices.Equal(got, awkwardPrinterNames) {
+		t.Errorf("printerNames = %q, want %q", got, awkwardPrinterNames)
+	}
+}
+
+// TestInstalledPrintersOpen checks that every name the bridge lists can be
+// opened again by that exact name.
+func TestInstalledPrintersOpen(t *testing.T) {
+	names, err := enumPrinters()
+	if err != nil {
+		t.Fatalf("enumPrinters: %v", err)
+	}
+	if len(names) == 0 {
+		t.Skip("no printers installed")
+	}
+	for _, name := range names {
+		p, err := syscall.UTF16PtrFromString(name)
+		if err != nil {
+			t.Fatalf("encode %q: %v", name, err)
+		}
+		var h uintptr
+		ret, _, lastErr := procOpenPrinter.Call(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&h)), 0)
+		if ret == 0 {
+			t.Errorf("OpenPrinterW(%q): %v", name, lastErr)
+			continue
+		}
+		procClose.Call(h)
+	}
+}
+
+func TestWindowsPrinterState(t *testing.T) {
+	tests := []struct {
+		status, attributes uint32
+		jobs               []uint32
+		want               string
+	}{
+		{0, 0, nil, stateReady},
+		{printerStatusPrinting, 0, []uint32{jobStatusPrinting, 0}, statePrinting},
+		{printerStatusPaused | printerStatusPaperOut, 0, nil, statePaused},
+		{0, printerAttributeWorkOffline, []uint32{0}, stateOffline},
+		{0, 0, []uint32{jobStatusPrinting | jobStatusOffline}, stateOffline},
+		{printerStatusPaperJam, 0, nil, statePaperJam},
+		{0, 0, []uint32{jobStatusPaperOut}, statePaperOut},
+		{printerStatusDoorOpen, 0, nil, stateDoorOpen},
+		{0, 0, []uint32{jobStatusBlockedDevQ}, stateError},
+		{printerStatusPendingDeletion, 0, nil, stateNotFound},
+	}
+	for i, tt := range tests {
+		s := windowsPrinterState("Braille", tt.status, tt.attributes, tt.jobs)
+		if s.State != tt.want || s.Jobs != len(tt.jobs) {
+			t.Errorf("case %d: got %q with %d jobs, want %q with %d", i, s.State, s.Jobs, tt.want, len(tt.jobs))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Attention holds
+//
+// When the health watchdog (health.go) sees a printer run out of paper,
+// jam or open its cover, the bridge stops sending that printer more jobs
+// instead of letting them fail one after another. Its queue is held: the
+// jobs stay queued, other printers carry on, and the dashboard's printer
+// badge says "queue held". The job that was printing and those waiting
+// get an annotation (annotations.go) saying what happened, and a
+// "printer_attention" notification goes to the webhooks and push services.
+//
+// Once the watchdog sees the printer ready again the hold lifts by itself,
+// the queue resumes and a second notification says so; nobody has to find
+// the resume button. A hold lasts only as long as the watchdog is checking:
+// "watchdog": {"disabled": true} lifts any that are in place.
+// ---------------------------------------------------------------------------
+
+// attentionHolds maps each held printer to the state that held it.
+var attentionHolds = struct {
+	sync.Mutex
+	held map[string]string
+}{held: make(map[string]string)}
+
+// needsAttention reports whether a printer in state needs someone to fix
+// it before it can emboss anything.
+func needsAttention(state string) bool {
+	return state == statePaperOut || state == statePaperJam || state == stateDoorOpen
+}
+
+// attentionHeld reports whether printer's queue is held.
+func attentionHeld(printer string) bool {
+	attentionHolds.Lock()
+	defer attentionHolds.Unlock()
+	_, ok := attentionHolds.held[printer]
+	return ok
+}
+
+// updateAttentionHold holds or releases printer's queue to match the
+// state the watchdog just saw. It reports whether it notified anyone, so
+// the watchdog does not report the same change twice.
+func updateAttentionHold(printer string, h PrinterHealth) bool {
+	attentionHolds.Lock()
+	prev, held := attentionHolds.held[printer]
+	switch {
+	case needsAttention(h.State):
+		attentionHolds.held[printer] = h.State
+	case h.State == stateReady || h.State == statePrinting:
+		delete(attentionHolds.held, printer)
+	}
+	attentionHolds.Unlock()
+
+	what := strings.ReplaceAll(h.State, "_", " ")
+	switch {
+	case !held && needsAttention(h.State):
+		n := annotateHeldJobs(printer, fmt.Sprintf("%s reported %s at %s; its queue was held until it is fixed",
+			printer, what, h.Checked.Format(time.Kitchen)))
+		log.Printf("printer %q: %s, holding its queue (%d job(s) affected)", printer, what, n)
+		detail := ""
+		if h.Guidance != "" {
+			detail = h.Guidance + "\n"
+		}
+		sendNotification(eventPrinterAttention, "Printer "+printer+": "+what+", queue held",
+			fmt.Sprintf("Printer %q reported %s at %s. %d job(s) are held until it is fixed.\n",
+				printer, what, h.Checked.Format(time.Kitchen), n)+detail)
+		return true
+	case held && !attentionHeld(printer):
+		log.Printf("printer %q is %s again, resuming its queue", printer, h.State)
+		JobQueue.WakeAll()
+		sendNotification(eventPrinterAttention, "Printer "+printer+" is ready, queue resumed",
+			fmt.Sprintf("Printer %q is %s again at %s (it had reported %s). Held jobs are printing.\n",
+				printer, h.State, h.Checked.Format(time.Kitchen), strings.ReplaceAll(prev, "_", " ")))
+		return true
+	}
+	return false
+}
+
+// releaseAttentionHolds lifts every hold, as when the watchdog is turned
+// off and nothing would notice the printers recover.
+func releaseAttentionHolds() {
+	attentionHolds.Lock()
+	n := len(attentionHolds.held)
+	clear(attentionHolds.held)
+	attentionHolds.Unlock()
+	if n > 0 {
+		log.Printf("watchdog disabled: released %d held printer queue(s)", n)
+		JobQueue.WakeAll()
+	}
+}
+
+// annotateHeldJobs notes text on the jobs printing or queued on printer
+// and returns how many there were.
+func annotateHeldJobs(printer, text string) int {
+	a := Annotation{Time: time.Now().UTC(), By: "bridge", Text: text}
+	n := 0
+	for _, e := range Store.List() {
+		if e.Printer != printer || e.Status != StatusPrinting && e.Status != StatusQueued {
+			continue
+		}
+		n++
+		Store.Update(e.ID, func(e *JobEvent) {
+			if len(e.Annotations) < MaxAnnotations {
+				e.Annotations = append(e.Annotations, a)
+			}
+		})
+	}
+	return n
+}
@@ -0,0 +1,43 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAttentionHolds(t *testing.T) {
+	old, oldStore := Cfg.Load(), Store
+	defer func() { Cfg.Store(old); Store = oldStore }()
+	Cfg.Store(&Config{})
+	Store = NewMemoryStore(10)
+	printing := Store.Append(JobEvent{Printer: "Everest", Status: StatusPrinting, Time: time.Now()})
+	queued := Store.Append(JobEvent{Printer: "Everest", Status: StatusQueued, Time: time.Now()})
+	other := Store.Append(JobEvent{Printer: "Tiger", Status: StatusQueued, Time: time.Now()})
+
+	out := PrinterHealth{PrinterState: PrinterState{Printer: "Everest", State: statePaperOut}, Checked: time.Now()}
+	if !updateAttentionHold("Everest", out) || !attentionHeld("Everest") {
+		t.Fatal("paper out did not hold the queue")
+	}
+	if updateAttentionHold("Everest", out) {
+		t.Error("a printer still out of paper was reported again")
+	}
+	for _, id := range []int{printing.ID, queued.ID} {
+		if e, _ := Store.Get(id); len(e.Annotations) != 1 || e.Annotations[0].By != "bridge" {
+			t.Errorf("job %d annotations = %+v", id, e.Annotations)
+		}
+	}
+	if e, _ := Store.Get(other.ID); len(e.Annotations) != 0 || attentionHeld("Tiger") {
+		t.Errorf("another printer's job was affected: %+v", e.Annotations)
+	}
+
+	offline := out
+	offline.State = stateOffline
+	if updateAttentionHold("Everest", offline); !attentionHeld("Everest") {
+		t.Error("going offline released the hold")
+	}
+	ready := out
+	ready.State = stateReady
+	if !updateAttentionHold("Everest", ready) || attentionHeld("Everest") {
+		t.Error("a ready printer is still held")
+	}
+}
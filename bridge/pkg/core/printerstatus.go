@@ -0,0 +1,146 @@
+package core
+
+import (
+	"context"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Printer status
+//
+// GET /printers/{name}/status reports what the print system knows about a
+// destination right now: whether it is ready, paused, offline or needs
+// attention (out of paper, jammed, cover open), and how many jobs are
+// waiting in its queue. CUPS is asked through lpstat; Windows through the
+// spooler's GetPrinter and EnumJobs calls; a ready CUPS queue is also
+// checked at its network address (health.go), as is an ipp: destination
+// (ippusb.go). Other direct, virtual and driver destinations report what
+// their pre-flight check finds.
+// ---------------------------------------------------------------------------
+
+// Printer states reported in PrinterState.State.
+const (
+	stateReady    = "ready"
+	statePrinting = "printing"
+	statePaused   = "paused"
+	stateOffline  = "offline"
+	statePaperOut = "paper_out"
+	statePaperJam = "paper_jam"
+	stateDoorOpen = "door_open"
+	stateError    = "error"
+	stateNotFound = "not_found"
+	stateUnknown  = "unknown" // the print system could not be asked
+)
+
+// PrinterState is the response from GET /printers/{name}/status.
+type PrinterState struct {
+	Printer   string   `json:"printer"`
+	State     string   `json:"state"`
+	Reasons   []string `json:"reasons,omitempty"`    // print-system detail, e.g. CUPS alerts
+	Jobs      int      `json:"jobs"`                 // jobs waiting in the OS queue
+	ErrorCode string   `json:"error_code,omitempty"` // failure class a job would likely get
+	Guidance  string   `json:"guidance,omitempty"`
+}
+
+// stateRank orders printer states so the most serious one found wins.
+var stateRank = map[string]int{
+	stateReady: 0, statePrinting: 1, stateError: 2, stateDoorOpen: 3,
+	statePaperOut: 4, statePaperJam: 5, stateOffline: 6, statePaused: 7,
+}
+
+// worseState returns whichever of a and b is more serious.
+func worseState(a, b string) string {
+	if stateRank[b] > stateRank[a] {
+		return b
+	}
+	return a
+}
+
+// stateReasons maps IPP printer-state-reasons keywords (which CUPS lists
+// on lpstat's "Alerts:" line) to printer states.
+var stateReasons = []struct{ keyword, state string }{
+	{"offline", stateOffline},
+	{"media-jam", statePaperJam},
+	{"media-empty", statePaperOut},
+	{"media-needed", statePaperOut},
+	{"door-open", stateDoorOpen},
+	{"cover-open", stateDoorOpen},
+}
+
+// reasonState returns the state a printer-state-reasons keyword implies.
+// Warnings and reports are informational, except offline-report, which is
+// how CUPS marks a printer it cannot reach.
+func reasonState(keyword string) string {
+	if strings.HasSuffix(keyword, "-warning") || strings.HasSuffix(keyword, "-report") && keyword != "offline-report" {
+		return stateReady
+	}
+	for _, r := range stateReasons {
+		if strings.HasPrefix(keyword, r.keyword) {
+			return r.state
+		}
+	}
+	if strings.HasSuffix(keyword, "-error") {
+		return stateError
+	}
+	return stateReady
+}
+
+// stateErrorCodes maps states that stop jobs printing to failure classes.
+var stateErrorCodes = map[string]string{
+	statePaused:   ErrCodeNotAccepting,
+	stateNotFound: ErrCodeNotFound,
+	stateOffline:  errCodeAttention,
+	statePaperOut: errCodeAttention,
+	statePaperJam: errCodeAttention,
+	stateDoorOpen: errCodeAttention,
+	stateError:    errCodeAttention,
+}
+
+// withGuidance fills in the failure class and guidance for s.State.
+func (s PrinterState) withGuidance() PrinterState {
+	if code := stateErrorCodes[s.State]; code != "" {
+		s.ErrorCode, s.Guidance = code, ErrorGuidance[code]
+	}
+	return s
+}
+
+// DestinationState reports the state of any destination.
+func DestinationState(ctx context.Context, printer string) PrinterState {
+	ctx, cancel := context.WithTimeout(ctx, ListTimeout())
+	defer cancel()
+
+	var err error
+	switch {
+	case strings.HasPrefix(printer, serialPrefix), strings.HasPrefix(printer, usbPrefix),
+		strings.HasPrefix(printer, virtualPrefix), strings.HasPrefix(printer, simPrefix),
+		strings.HasPrefix(printer, driverPrefix), strings.HasPrefix(printer, socketPrefix),
+		strings.HasPrefix(printer, LoopbackPrefix):
+		err = Preflight(ctx, printer)
+	case strings.HasPrefix(printer, ippPrefix):
+		s := PrinterState{Printer: printer}
+		s.State, s.Reasons = checkNetworkDevice(ctx, strings.TrimPrefix(printer, ippPrefix))
+		return s.withGuidance()
+	default:
+		s := printerState(ctx, printer)
+		if s.State == stateReady {
+			state, reasons := checkNetworkDevice(ctx, deviceURI(ctx, printer))
+			s.State = worseState(s.State, state)
+			s.Reasons = append(s.Reasons, reasons...)
+		}
+		return s.withGuidance()
+	}
+	s := PrinterState{Printer: printer, State: stateReady}
+	switch ErrorCode(err) {
+	case ErrCodeNotFound, ErrCodeDevice:
+		s.State = stateNotFound
+	case ErrCodeNotAccepting:
+		s.State = statePaused
+	case errCodeUnknown:
+	default:
+		s.State = stateError
+	}
+	if err != nil {
+		s.Reasons = []string{err.Error()}
+	}
+	return s.withGuidance()
+}
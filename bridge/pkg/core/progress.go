@@ -0,0 +1,109 @@
+package core
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Emboss progress and time estimates
+//
+// Direct transports publish a "progress" event for every chunk they write.
+// Besides bytes sent, each event says which page is being embossed and,
+// when the embosser's speed is known, about how long is left. The speed is
+// the profile's "chars_per_second", or the rated speed of a known model
+// found in the destination name. Once a job has been going for a few
+// seconds the rate actually achieved is used instead, since buffering and
+// flow control rarely let an embosser reach its rated speed.
+//
+// Every job also records an estimated embossing time when it is created,
+// from the same speed figure. A job that fails partway records how many
+// whole pages had been written, so it can be resumed from the next one
+// (see pagerange.go).
+// ---------------------------------------------------------------------------
+
+// measureAfter is how long a job runs before its own rate is trusted.
+const measureAfter = 5 * time.Second
+
+// ratedSpeeds are manufacturers' characters-per-second figures, matched
+// against the lower-cased destination name.
+var ratedSpeeds = []struct {
+	model string
+	cps   int
+}{
+	{"everest", 100},
+	{"basic-d", 100},
+	{"romeo 60", 60},
+	{"juliet 120", 120},
+	{"braillo 200", 200},
+	{"braillo 300", 300},
+	{"braillo 400", 400},
+}
+
+// charsPerSecond returns a destination's embossing speed, or 0 if unknown.
+func charsPerSecond(printer string) int {
+	if cps := ProfileFor(printer).CharsPerSecond; cps > 0 {
+		return cps
+	}
+	lower := strings.ToLower(printer)
+	for _, r := range ratedSpeeds {
+		if strings.Contains(lower, r.model) {
+			return r.cps
+		}
+	}
+	return 0
+}
+
+// estimateSeconds is the expected embossing time for n bytes, or 0.
+func estimateSeconds(printer string, n int) int {
+	cps := charsPerSecond(printer)
+	if cps == 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(n) / float64(cps)))
+}
+
+// progress publishes progress events for one job.
+type progress struct {
+	job      *PrintJob
+	start    time.Time
+	pageEnds []int
+	cps      int
+}
+
+func newProgress(job *PrintJob) *progress {
+	return &progress{
+		job:      job,
+		start:    time.Now(),
+		pageEnds: brf.PageEnds(job.Data, ProfileFor(job.Printer).LinesPerPage),
+		cps:      charsPerSecond(job.Printer),
+	}
+}
+
+// publish reports that the first sent bytes of the job have been written.
+func (p *progress) publish(sent int) {
+	total := len(p.job.Data)
+	ev := ProgressEvent{
+		JobID:   p.job.ID,
+		Printer: p.job.Printer,
+		Sent:    sent,
+		Total:   total,
+		Pages:   max(len(p.pageEnds), 1),
+	}
+	p.job.sent = sent
+	p.job.pagesSent = sort.SearchInts(p.pageEnds, sent+1)
+	ev.Page = min(p.job.pagesSent+1, ev.Pages)
+
+	rate := float64(p.cps)
+	if elapsed := time.Since(p.start); elapsed >= measureAfter && sent > 0 {
+		rate = float64(sent) / elapsed.Seconds()
+	}
+	if rate > 0 {
+		ev.RemainingSeconds = int(math.Ceil(float64(total-sent) / rate))
+	}
+	Store.Publish(StreamEvent{Name: "progress", Data: ev})
+}
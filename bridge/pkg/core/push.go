@@ -0,0 +1,142 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Push notifications
+//
+// A TVI who travels between schools is rarely at a desk when the afternoon
+// batch finishes or the embosser jams. Each entry in "push" sends the same
+// events as the chat webhooks (webhook.go) to a phone through ntfy or a
+// Gotify server:
+//
+//	"push": [
+//	  {"service": "ntfy", "url": "https://ntfy.sh/room12-embosser"},
+//	  {"service": "gotify", "url": "https://gotify.district.org", "token": "<app token>",
+//	   "events": ["printer_attention", "batch_done"]}
+//	]
+//
+// For ntfy the URL is the topic's; "token" is sent as a bearer token for
+// topics that need one. For Gotify the URL is the server's and "token" is
+// the application token. Failures, outages and jams are sent at high
+// priority so they break through a phone's quiet mode; a finished batch at
+// normal priority.
+// ---------------------------------------------------------------------------
+
+// PushConfig is one push notification service.
+type PushConfig struct {
+	Service string   `json:"service"` // "ntfy" or "gotify"
+	URL     string   `json:"url"`
+	Token   string   `json:"token,omitempty"`
+	Events  []string `json:"events,omitempty"` // default all
+}
+
+func (p PushConfig) validate() error {
+	u, err := url.Parse(p.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("push: url must be an http(s):// address")
+	}
+	switch {
+	case p.Service != "ntfy" && p.Service != "gotify":
+		return fmt.Errorf("push: service must be ntfy or gotify, not %q", p.Service)
+	case p.Service == "ntfy" && strings.Trim(u.Path, "/") == "":
+		return errors.New("push: an ntfy url must include the topic, like https://ntfy.sh/my-topic")
+	case p.Service == "gotify" && p.Token == "":
+		return errors.New("push: gotify needs the application token")
+	}
+	for _, ev := range p.Events {
+		if !slices.Contains(notifyEvents, ev) {
+			return fmt.Errorf("push: unknown event %q", ev)
+		}
+	}
+	return nil
+}
+
+// wants reports whether the service takes event.
+func (p PushConfig) wants(event string) bool {
+	return len(p.Events) == 0 || slices.Contains(p.Events, event)
+}
+
+// urgentEvent reports whether event is sent at high priority.
+func urgentEvent(event string) bool {
+	return event != eventBatchDone
+}
+
+// postPush sends a notification to every push service that takes event.
+func postPush(event, title, body string) {
+	for _, p := range CurrentConfig().Push {
+		if !p.wants(event) {
+			continue
+		}
+		go func() {
+			req, err := pushRequest(p, event, title, body)
+			if err == nil {
+				err = doPush(req)
+			}
+			if err != nil {
+				log.Printf("push %s: %v", redactURL(p.URL), err)
+			}
+		}()
+	}
+}
+
+// pushRequest builds the request for one push service.
+func pushRequest(p PushConfig, event, title, body string) (*http.Request, error) {
+	body = strings.TrimSpace(body)
+	if p.Service == "gotify" {
+		priority := 5
+		if urgentEvent(event) {
+			priority = 8
+		}
+		data, _ := json.Marshal(map[string]any{"title": title, "message": body, "priority": priority})
+		req, err := http.NewRequest(http.MethodPost, strings.TrimRight(p.URL, "/")+"/message", bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Gotify-Key", p.Token)
+		return req, nil
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.URL, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Title", title)
+	req.Header.Set("Tags", "graham-bridge,"+event)
+	if urgentEvent(event) {
+		req.Header.Set("Priority", "high")
+	}
+	if p.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.Token)
+	}
+	return req, nil
+}
+
+func doPush(req *http.Request) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req.Header.Set("User-Agent", "graham-bridge/"+BridgeVersion)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("answered %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
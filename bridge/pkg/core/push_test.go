@@ -0,0 +1,33 @@
+package core
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPushRequest(t *testing.T) {
+	req, err := pushRequest(PushConfig{Service: "ntfy", URL: "https://ntfy.sh/room12"}, eventPrinterAttention, "Printer Index: paper jam", "Clear the jam.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if req.Header.Get("Title") != "Printer Index: paper jam" || req.Header.Get("Priority") != "high" || string(body) != "Clear the jam." {
+		t.Errorf("ntfy request %v %q", req.Header, body)
+	}
+	g := PushConfig{Service: "gotify", URL: "https://gotify.example.org/", Token: "abc"}
+	req, err = pushRequest(g, eventBatchDone, "Done", "3 jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(req.Body)
+	if req.URL.String() != "https://gotify.example.org/message" || req.Header.Get("X-Gotify-Key") != "abc" || !strings.Contains(string(body), `"priority":5`) {
+		t.Errorf("gotify request %s %v %s", req.URL, req.Header, body)
+	}
+	if (PushConfig{Service: "ntfy", URL: "https://ntfy.sh/"}).validate() == nil {
+		t.Error("ntfy url without a topic accepted")
+	}
+	if (PushConfig{Service: "gotify", URL: "https://gotify.example.org"}).validate() == nil {
+		t.Error("gotify without a token accepted")
+	}
+}
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Job queue
+//
+// Every print job goes to its destination's worker in the core dispatcher
+// (pkg/core), which sends one job at a time per embosser and runs
+// different embossers in parallel. This file supplies what the bridge
+// adds: holds for the dashboard's pause and printers needing attention,
+// the job record as the job runs, and the batch notification when a
+// printer's queue empties.
+// ---------------------------------------------------------------------------
+
+// PrintJob is a queued unit of work for a single destination.
+type PrintJob struct {
+	ID      int // JobEvent ID
+	Printer string
+	ReqID   string     // request that submitted the job, for log lines
+	Data    []byte     // loaded from the payload store when the job runs
+	done    chan error // receives the send result exactly once
+
+	pagesSent int // whole pages written so far, from progress events
+	sent      int // bytes written so far, from progress events
+}
+
+// Label names the job in log lines, with the request that submitted it.
+func (j *PrintJob) Label() string {
+	if j.ReqID == "" {
+		return fmt.Sprintf("job %d", j.ID)
+	}
+	return fmt.Sprintf("job %d (request %s)", j.ID, j.ReqID)
+}
+
+// JobQueue sends print jobs, one worker per destination.
+var JobQueue = &Dispatcher[*PrintJob]{
+	Send: sendPrintJob,
+	// The dashboard's pause holds jobs in the queue (uistate.go), as does
+	// a printer that needs attention (printerhold.go).
+	Ready:   func(printer string) bool { return !queuePaused() && !attentionHeld(printer) },
+	Drained: batchDone,
+}
+
+// batches holds the jobs each printer sent since its queue was last empty
+// (notify.go).
+var batches = struct {
+	sync.Mutex
+	m map[string]batchStats
+}{m: make(map[string]batchStats)}
+
+// batchDone reports a printer's finished batch once its queue empties.
+func batchDone(printer string) {
+	batches.Lock()
+	b := batches.m[printer]
+	delete(batches.m, printer)
+	batches.Unlock()
+	if b.jobs > 0 {
+		notifyBatchDone(printer, b)
+	}
+}
+
+// EnqueueJob records a job as queued and hands it to its destination's
+// worker. The returned channel yields the send result once the job has run.
+func EnqueueJob(e JobEvent) (JobEvent, <-chan error) {
+	e.Status = StatusQueued
+	e = Store.Append(e)
+	job := &PrintJob{
+		ID:      e.ID,
+		Printer: e.Printer,
+		ReqID:   e.RequestID,
+		done:    make(chan error, 1),
+	}
+	submitJob(job)
+	return e, job.done
+}
+
+// HoldJob records a job as held; it is not queued until ReleaseJob.
+func HoldJob(e JobEvent) JobEvent {
+	e.Status = StatusHeld
+	return Store.Append(e)
+}
+
+// RejectJob records a job refused before queuing, such as by a hook.
+func RejectJob(e JobEvent, err error) JobEvent {
+	e.Status = StatusRejected
+	e.ErrMsg, e.ErrCode = err.Error(), ErrorCode(err)
+	e.Guidance = ErrorGuidance[e.ErrCode]
+	return Store.Append(e)
+}
+
+// ReleaseJob queues a held or waiting job. It reports false if the job is
+// unknown or no longer held or waiting.
+func ReleaseJob(id int) (JobEvent, bool) {
+	released := false
+	e, ok := Store.Update(id, func(e *JobEvent) {
+		if e.Status == StatusHeld || e.Status == StatusWaiting {
+			e.Status = StatusQueued
+			e.HoldReason, e.HeldUntil = "", time.Time{}
+			e.ErrMsg, e.ErrCode, e.Guidance = "", "", ""
+			released = true
+		}
+	})
+	if !ok || !released {
+		return e, false
+	}
+	submitJob(&PrintJob{ID: e.ID, Printer: e.Printer, ReqID: e.RequestID, done: make(chan error, 1)})
+	return e, true
+}
+
+// DiscardJob cancels a held or waiting job. It reports false if the job is
+// unknown or no longer held or waiting.
+func DiscardJob(id int) (JobEvent, bool) {
+	discarded := false
+	e, ok := Store.Update(id, func(e *JobEvent) {
+		if e.Status == StatusHeld || e.Status == StatusWaiting {
+			e.Status = StatusCancelled
+			discarded = true
+		}
+	})
+	return e, ok && discarded
+}
+
+// EmbossNext puts a job at the front of its printer's queue, behind the
+// job being sent, releasing it first if it is held or waiting. It is for
+// the test a student needs in five minutes. It reports false if the job is
+// unknown or already printing or finished.
+func EmbossNext(id int) (JobEvent, bool) {
+	e, ok := Store.Get(id)
+	if !ok {
+		return e, false
+	}
+	switch e.Status {
+	case StatusHeld, StatusWaiting:
+		if e, ok = ReleaseJob(id); !ok {
+			return e, false
+		}
+	case StatusQueued:
+	default:
+		return e, false
+	}
+	if !JobQueue.Promote(e.Printer, func(j *PrintJob) bool { return j.ID == id }) {
+		e, _ = Store.Get(id)
+		return e, false // sent meanwhile
+	}
+	return e, true
+}
+
+// AwaitJob waits for a queued job's result on behalf of an HTTP handler.
+// finished is false if the client went away or the response timeout passed
+// first; the job itself keeps running either way.
+func AwaitJob(ctx context.Context, done <-chan error) (finished bool, err error) {
+	t := time.NewTimer(ResponseTimeout())
+	defer t.Stop()
+	select {
+	case err := <-done:
+		return true, err
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-t.C:
+		return false, nil
+	}
+}
+
+// submitJob hands a job to its destination's worker.
+func submitJob(job *PrintJob) {
+	ahead := JobQueue.Submit(job.Printer, job)
+	debugf("%s queued for %q behind %d job(s)", job.Label(), job.Printer, ahead)
+}
+
+// sendPrintJob delivers one job to the printer and publishes its outcome.
+func sendPrintJob(printer string, job *PrintJob) {
+	Store.Update(job.ID, func(e *JobEvent) { e.Status = StatusPrinting })
+
+	start := time.Now()
+	data, err := Payloads.Get(job.ID)
+	if err == nil {
+		job.Data = data
+		log.Printf("%s: sending %d bytes to %q", job.Label(), len(job.Data), job.Printer)
+		err = SendWithRetry(job)
+		job.Data = nil
+	}
+
+	e, ok := Store.Update(job.ID, func(e *JobEvent) {
+		if err != nil {
+			e.Status = StatusFailed
+			if ErrorCode(err) == ErrCodeStuck {
+				e.Status = StatusStuck
+			}
+			e.ErrMsg = err.Error()
+			e.ErrCode = ErrorCode(err)
+			e.Guidance = ErrorGuidance[e.ErrCode]
+			e.PagesSent = job.pagesSent
+		} else {
+			e.Status = StatusDone
+		}
+	})
+	recordJob(e, time.Since(start))
+	if err == nil {
+		useSheets(e)
+	}
+	notifyJobFinished(e)
+	if ok {
+		batches.Lock()
+		b := batches.m[printer]
+		b.add(e)
+		batches.m[printer] = b
+		batches.Unlock()
+	}
+	job.done <- err
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Quiet hours
+//
+// Embossers are loud. A printer profile can list "quiet_hours" windows
+// during which new jobs are held instead of printed; RunQuietHours releases
+// them when the window ends. A job submitted with "urgent": true prints
+// anyway, and a teacher can release a quiet-held job early from the
+// dashboard like any other held job.
+//
+//	"quiet_hours": [{"days": ["mon","tue","wed","thu","fri"], "start": "09:00", "end": "11:30"}]
+//
+// A window whose end is before its start runs past midnight; its days are
+// the days it starts on. Times are in the bridge machine's local zone.
+// ---------------------------------------------------------------------------
+
+const (
+	HoldApproval   = "approval"    // waiting for a teacher (token "hold")
+	HoldQuietHours = "quiet_hours" // waiting for a quiet window to end
+
+	quietCheckInterval = 30 * time.Second
+)
+
+// QuietWindow is one recurring quiet period.
+type QuietWindow struct {
+	Days  []string `json:"days,omitempty"` // "mon" … "sun"; empty means every day
+	Start string   `json:"start"`          // "HH:MM"
+	End   string   `json:"end"`            // "HH:MM"
+}
+
+var weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a HH:MM time", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (q QuietWindow) validate() error {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return fmt.Errorf("window %s-%s is empty", q.Start, q.End)
+	}
+	for _, d := range q.Days {
+		if !slices.Contains(weekdays, strings.ToLower(d)) {
+			return fmt.Errorf("unknown day %q (use mon, tue, … sun)", d)
+		}
+	}
+	return nil
+}
+
+// onDay reports whether the window starts on t's weekday.
+func (q QuietWindow) onDay(t time.Time) bool {
+	if len(q.Days) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(q.Days, func(d string) bool {
+		return strings.EqualFold(d, weekdays[t.Weekday()])
+	})
+}
+
+// activeUntil returns when the window ends if now falls inside it.
+func (q QuietWindow) activeUntil(now time.Time) (time.Time, bool) {
+	start, err1 := parseClock(q.Start)
+	end, err2 := parseClock(q.End)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, false
+	}
+	y, m, d := now.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	at := func(day time.Time, min int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), min/60, min%60, 0, 0, now.Location())
+	}
+	// The window may have started today, or yesterday if it wraps.
+	for _, day := range []time.Time{midnight, midnight.AddDate(0, 0, -1)} {
+		if !q.onDay(day) {
+			continue
+		}
+		from, to := at(day, start), at(day, end)
+		if end < start {
+			to = at(day.AddDate(0, 0, 1), end)
+		}
+		if !now.Before(from) && now.Before(to) {
+			return to, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// QuietUntil reports whether printer is in quiet hours at now and, if so,
+// when they end (the latest end of any overlapping window).
+func QuietUntil(printer string, now time.Time) (time.Time, bool) {
+	var until time.Time
+	for _, q := range ProfileFor(printer).QuietHours {
+		if t, ok := q.activeUntil(now); ok && t.After(until) {
+			until = t
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// RunQuietHours releases quiet-held jobs once their printer's quiet window
+// has ended.
+func RunQuietHours() {
+	for range time.Tick(quietCheckInterval) {
+		now := time.Now()
+		for _, e := range Store.List() {
+			if e.Status != StatusHeld || e.HoldReason != HoldQuietHours {
+				continue
+			}
+			if _, quiet := QuietUntil(e.Printer, now); quiet {
+				continue
+			}
+			if _, ok := ReleaseJob(e.ID); ok {
+				log.Printf("job %d released: quiet hours ended for %q", e.ID, e.Printer)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Printer reliability
+//
+// A flaky USB cable or a worn feed roller shows up as a creeping failure
+// rate long before it ruins a deadline. GET /printers/{name}/stats reads
+// the page ledger (reports.go) for one printer and returns
+//
+//	window        the printer's last "last" jobs (default 50): jobs, failed,
+//	              success rate, mean seconds to send, most common errors
+//	days          jobs and failures per day for the last 14 days, oldest
+//	              first, for the dashboard's sparkline
+//
+// Send time runs from the start of the first attempt to the result, so
+// retries (retry.go) count against it; ledgers written before it was
+// recorded have no times and are left out of the mean.
+// ---------------------------------------------------------------------------
+
+const (
+	DefaultReliabilityWindow = 50
+	MaxReliabilityWindow     = 1000
+	reliabilityDays          = 14
+)
+
+// ErrorCount is how often one error class occurred.
+type ErrorCount struct {
+	Code  string `json:"code"`
+	Count int    `json:"count"`
+}
+
+// ReliabilityWindow summarizes a printer's most recent jobs.
+type ReliabilityWindow struct {
+	Jobs        int          `json:"jobs"`
+	Failed      int          `json:"failed"`
+	SuccessRate float64      `json:"success_rate"`           // 0 to 1; 0 with no jobs
+	MeanSeconds float64      `json:"mean_seconds,omitempty"` // mean send time of timed jobs
+	Errors      []ErrorCount `json:"errors"`                 // most frequent first
+	Since       time.Time    `json:"since,omitzero"`         // oldest job in the window
+}
+
+// PrinterReliability is the /printers/{name}/stats response.
+type PrinterReliability struct {
+	Printer string            `json:"printer"`
+	Window  ReliabilityWindow `json:"window"`
+	Days    []UsageCount      `json:"days"`
+}
+
+func ReliabilityFor(printer string, n int, now time.Time) PrinterReliability {
+	y, m, d := now.Local().Date()
+	first := time.Date(y, m, d, 0, 0, 0, 0, time.Local).AddDate(0, 0, 1-reliabilityDays)
+	days := make([]UsageCount, reliabilityDays)
+	index := make(map[string]int, reliabilityDays)
+	for i := range days {
+		days[i].Key = first.AddDate(0, 0, i).Format(time.DateOnly)
+		index[days[i].Key] = i
+	}
+
+	var recent []PageRecord
+	EachRecord(JobFilter{Printer: printer}, func(rec PageRecord) {
+		recent = append(recent, rec)
+		if len(recent) > n {
+			recent = recent[1:]
+		}
+		if i, ok := index[rec.Time.Local().Format(time.DateOnly)]; ok {
+			days[i].add(rec)
+		}
+	})
+	return PrinterReliability{Printer: printer, Window: reliabilityWindow(recent), Days: days}
+}
+
+// reliabilityWindow summarizes recs, oldest first.
+func reliabilityWindow(recs []PageRecord) ReliabilityWindow {
+	win := ReliabilityWindow{Jobs: len(recs), Errors: []ErrorCount{}}
+	if len(recs) == 0 {
+		return win
+	}
+	win.Since = recs[0].Time
+	codes := map[string]int{}
+	var total float64
+	timed := 0
+	for _, rec := range recs {
+		if rec.Status != StatusDone {
+			win.Failed++
+			code := rec.ErrCode
+			if code == "" {
+				code = rec.Status
+			}
+			codes[code]++
+		}
+		if rec.Seconds > 0 {
+			total += rec.Seconds
+			timed++
+		}
+	}
+	win.SuccessRate = float64(win.Jobs-win.Failed) / float64(win.Jobs)
+	if timed > 0 {
+		win.MeanSeconds = total / float64(timed)
+	}
+	for code, count := range codes {
+		win.Errors = append(win.Errors, ErrorCount{Code: code, Count: count})
+	}
+	slices.SortFunc(win.Errors, func(a, b ErrorCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Code, b.Code)
+	})
+	return win
+}
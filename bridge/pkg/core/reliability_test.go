@@ -0,0 +1,25 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReliabilityWindow(t *testing.T) {
+	now := time.Now()
+	win := reliabilityWindow([]PageRecord{
+		{Time: now.Add(-3 * time.Hour), Status: StatusDone, Seconds: 30},
+		{Time: now.Add(-2 * time.Hour), Status: StatusFailed, ErrCode: ErrCodeDevice, Seconds: 10},
+		{Time: now.Add(-time.Hour), Status: StatusDone},
+		{Time: now, Status: StatusFailed, ErrCode: ErrCodeDevice, Seconds: 20},
+	})
+	if win.Jobs != 4 || win.Failed != 2 || win.SuccessRate != 0.5 || win.MeanSeconds != 20 {
+		t.Errorf("window %+v", win)
+	}
+	if len(win.Errors) != 1 || win.Errors[0] != (ErrorCount{Code: ErrCodeDevice, Count: 2}) {
+		t.Errorf("errors %+v", win.Errors)
+	}
+	if empty := reliabilityWindow(nil); empty.Jobs != 0 || empty.Errors == nil {
+		t.Errorf("empty window %+v", empty)
+	}
+}
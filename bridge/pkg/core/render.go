@@ -0,0 +1,100 @@
+package core
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Braille rendering
+//
+// renderPDF draws a BRF payload as embossed dots on PDF pages laid out with
+// the destination's geometry (see geometry.go): one PDF page per braille
+// page, a new page after lines_per_page lines or at a form feed. It backs
+// the virtual: printers, so jobs can be checked without an embosser.
+// ---------------------------------------------------------------------------
+
+const (
+	renderMarginMM = 15.0
+	dotRadiusMM    = 0.75
+	mmToPt         = 72 / 25.4
+)
+
+// renderPDF renders data as a PDF of embossed dots.
+func renderPDF(data []byte, g Geometry) []byte {
+	pageW := (g.WidthMM + 2*renderMarginMM) * mmToPt
+	pageH := (g.HeightMM + 2*renderMarginMM) * mmToPt
+
+	var contents [][]byte
+	for _, lines := range brf.Pages(data, g.LinesPerPage) {
+		var c bytes.Buffer
+		c.WriteString("0 g\n")
+		for li, line := range lines {
+			for ci := 0; ci < len(line) && ci < g.CellsPerLine; ci++ {
+				dots, ok := brf.Cell(line[ci])
+				if !ok {
+					continue
+				}
+				for d := 0; d < 6; d++ {
+					if dots&(1<<d) == 0 {
+						continue
+					}
+					x := renderMarginMM + float64(ci)*g.CellPitchMM + float64(d/3)*g.DotPitchMM
+					y := renderMarginMM + float64(li)*g.LinePitchMM + float64(d%3)*g.DotPitchMM
+					writeDisc(&c, x*mmToPt, pageH-y*mmToPt, dotRadiusMM*mmToPt)
+				}
+			}
+		}
+		contents = append(contents, c.Bytes())
+	}
+	return writePDF(contents, pageW, pageH)
+}
+
+// writeDisc appends a filled circle drawn with four Bézier arcs.
+func writeDisc(b *bytes.Buffer, x, y, r float64) {
+	k := 0.5523 * r
+	fmt.Fprintf(b, "%.2f %.2f m ", x+r, y)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x+r, y+k, x+k, y+r, x, y+r)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x-k, y+r, x-r, y+k, x-r, y)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c ", x-r, y-k, x-k, y-r, x, y-r)
+	fmt.Fprintf(b, "%.2f %.2f %.2f %.2f %.2f %.2f c f\n", x+k, y-r, x+r, y-k, x+r, y)
+}
+
+// writePDF assembles a minimal PDF with one page per content stream.
+func writePDF(contents [][]byte, w, h float64) []byte {
+	var out bytes.Buffer
+	var offsets []int
+	obj := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// Objects: 1 catalog, 2 page tree, then a page and its content per page.
+	kids := make([]string, len(contents))
+	for i := range contents {
+		kids[i] = fmt.Sprintf("%d 0 R", 3+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(contents)))
+	for i, c := range contents {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(c)
+		zw.Close()
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R /Resources << >> >>", w, h, 4+2*i))
+		obj(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", z.Len(), z.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
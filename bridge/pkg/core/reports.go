@@ -0,0 +1,96 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Page reports
+//
+// Every finished job adds a line to pages.log in the bridge data directory
+// (failed ones with their error code, for the usage statistics in
+// stats.go). Unlike the in-memory job history it is never trimmed, so
+// GET /reports/pages can total embossed pages per student, printer or
+// month over a whole school year to justify paper budgets:
+//
+//	by=student|printer|month   grouping (default student)
+//	since=, until=<RFC 3339>   time range
+//	format=csv                 CSV instead of JSON
+// ---------------------------------------------------------------------------
+
+// PageRecord is one line of the page ledger.
+type PageRecord struct {
+	Time    time.Time `json:"time"`
+	JobID   int       `json:"job_id"`
+	Printer string    `json:"printer"`
+	Student string    `json:"student,omitempty"`
+	Pages   int       `json:"pages"`
+	Status  string    `json:"status,omitempty"` // done or failed; empty in older ledgers means done
+	ErrCode string    `json:"error_code,omitempty"`
+	Seconds float64   `json:"seconds,omitempty"` // time to send, retries included (reliability.go)
+}
+
+// ReportRow is one group in a page report.
+type ReportRow struct {
+	Key   string `json:"key"`
+	Jobs  int    `json:"jobs"`
+	Pages int    `json:"pages"`
+}
+
+var PageLedger = AppendLog{Name: "page ledger", Path: func() string {
+	return filepath.Join(DataDir(), "pages.log")
+}}
+
+// recordJob adds a finished job to the page ledger.
+func recordJob(e JobEvent, took time.Duration) {
+	PageLedger.Record(PageRecord{
+		Time:    time.Now(),
+		JobID:   e.ID,
+		Printer: e.Printer,
+		Student: ShownStudent(e.Student),
+		Pages:   e.Pages,
+		Status:  e.Status,
+		ErrCode: e.ErrCode,
+		Seconds: took.Round(100 * time.Millisecond).Seconds(),
+	})
+}
+
+// EachRecord calls fn for every ledger record matching f, oldest first.
+func EachRecord(f JobFilter, fn func(PageRecord)) {
+	file, err := os.Open(PageLedger.Path())
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		var rec PageRecord
+		if json.Unmarshal(sc.Bytes(), &rec) != nil {
+			continue
+		}
+		if rec.Status == "" {
+			rec.Status = StatusDone
+		}
+		if f.Match(JobEvent{Time: rec.Time, Printer: rec.Printer, Student: rec.Student, Status: rec.Status}) {
+			fn(rec)
+		}
+	}
+}
+
+// ReportKey returns the group a record falls in.
+func ReportKey(rec PageRecord, by string) string {
+	switch by {
+	case "printer":
+		return rec.Printer
+	case "month":
+		return rec.Time.Local().Format("2006-01")
+	}
+	if rec.Student == "" {
+		return "(untagged)"
+	}
+	return ShownStudent(rec.Student)
+}
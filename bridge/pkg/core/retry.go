@@ -0,0 +1,131 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Retrying transient failures
+//
+// Some failures clear up by themselves within seconds: the print spooler
+// restarting, a USB embosser re-enumerating after a brown-out, a network
+// embosser slow to answer after waking. A job that fails with one of
+//
+//	spooler_unavailable, device_unavailable, timeout
+//
+// before any of it reached the embosser is sent again, up to "retries"
+// more times (default 3), waiting "backoff_seconds" (default 2) before the
+// first retry and twice as long before each one after that, up to a
+// minute. Jobs that had started embossing are never retried, so no page
+// comes out twice, and neither is lp running out of time: CUPS may
+// already hold the job (print_unix.go). Each failed attempt is published as a "retry" event on
+// /log-stream and counted in the job's "attempts"; the dashboard shows the
+// retry countdown in the job log.
+//
+//	"retry": {"retries": 5, "backoff_seconds": 5}
+//	"retry": {"disabled": true}
+// ---------------------------------------------------------------------------
+
+const (
+	defaultRetries = 3
+	defaultBackoff = 2 * time.Second
+	maxBackoff     = time.Minute
+)
+
+// RetryConfig tunes retries of transient failures.
+type RetryConfig struct {
+	Retries        int  `json:"retries,omitempty"`         // default 3
+	BackoffSeconds int  `json:"backoff_seconds,omitempty"` // before the first retry; default 2
+	Disabled       bool `json:"disabled,omitempty"`
+}
+
+// RetryEvent is the data of a "retry" stream event.
+type RetryEvent struct {
+	JobID        int    `json:"job_id"`
+	Printer      string `json:"printer"`
+	Attempt      int    `json:"attempt"`  // the attempt that failed, from 1
+	Attempts     int    `json:"attempts"` // attempts allowed in all
+	Error        string `json:"error"`
+	ErrCode      string `json:"error_code"`
+	DelaySeconds int    `json:"delay_seconds"` // wait before the next attempt
+}
+
+// retryPolicy returns how many retries are allowed and the first delay.
+func retryPolicy() (int, time.Duration) {
+	r := CurrentConfig().Retry
+	if r == nil {
+		return defaultRetries, defaultBackoff
+	}
+	if r.Disabled {
+		return 0, 0
+	}
+	retries, backoff := defaultRetries, defaultBackoff
+	if r.Retries > 0 {
+		retries = r.Retries
+	}
+	if r.BackoffSeconds > 0 {
+		backoff = time.Duration(r.BackoffSeconds) * time.Second
+	}
+	return retries, backoff
+}
+
+// Transient reports whether a send that failed with err may be tried again.
+func Transient(job *PrintJob, err error) bool {
+	if job.sent > 0 {
+		return false
+	}
+	switch ErrorCode(err) {
+	case ErrCodeSpooler, ErrCodeDevice, errCodeTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff is the wait before retry n (from 1).
+func backoff(first time.Duration, n int) time.Duration {
+	d := first
+	for range n - 1 {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// SendWithRetry sends a job, retrying transient failures.
+func SendWithRetry(job *PrintJob) error {
+	retries, first := retryPolicy()
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 {
+			Store.Update(job.ID, func(e *JobEvent) { e.Attempts = attempt })
+		}
+		timeout := sendTimeout()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := sendJob(ctx, job)
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s: %w", timeout, err)
+		}
+		if err == nil || attempt > retries || !Transient(job, err) {
+			return err
+		}
+
+		delay := backoff(first, attempt)
+		log.Printf("%s: attempt %d of %d failed (%v); retrying in %s", job.Label(), attempt, retries+1, err, delay)
+		Store.Publish(StreamEvent{Name: "retry", Data: RetryEvent{
+			JobID:        job.ID,
+			Printer:      job.Printer,
+			Attempt:      attempt,
+			Attempts:     retries + 1,
+			Error:        err.Error(),
+			ErrCode:      ErrorCode(err),
+			DelaySeconds: int(delay.Seconds()),
+		}})
+		time.Sleep(delay)
+	}
+}
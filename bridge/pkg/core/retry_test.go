@@ -0,0 +1,30 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	var got []time.Duration
+	for n := 1; n <= 7; n++ {
+		got = append(got, backoff(2*time.Second, n))
+	}
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second, time.Minute, time.Minute}
+	if !slices.Equal(got, want) {
+		t.Errorf("backoff %v", got)
+	}
+
+	busy := Classified(ErrCodeSpooler, errors.New("cups not running"))
+	if !Transient(&PrintJob{}, busy) {
+		t.Error("spooler failure not retried")
+	}
+	if Transient(&PrintJob{sent: 512}, busy) {
+		t.Error("job retried after bytes reached the embosser")
+	}
+	if Transient(&PrintJob{}, Classified(ErrCodeNotAccepting, errors.New("paused"))) {
+		t.Error("paused queue retried")
+	}
+}
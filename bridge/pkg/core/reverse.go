@@ -0,0 +1,90 @@
+package core
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Reverse page order
+//
+// Some embossers stack their output face-down, so a document comes out
+// last page on top. "reverse_pages": true in the printer profile sends the
+// pages of every job in reverse order so the stack reads from the front.
+//
+// Pages are found the way the page counts are (form feeds and every
+// lines_per_page lines) after interline merging and line spacing, so print
+// lines and added blank lines move with their page. Each page but the last
+// sent ends with a form feed, and the eject sequence is moved to the end.
+//
+// Page ranges and resumes (pagerange.go) number pages as the document
+// does, not in the order they were sent.
+// ---------------------------------------------------------------------------
+
+// ApplyReverse reverses a prepared job's pages if its printer's profile
+// asks for it.
+func ApplyReverse(e *JobEvent) {
+	p := ProfileFor(e.Printer)
+	if !p.ReversePages {
+		return
+	}
+	e.Payload = reversePages(e.Payload, p)
+	e.Bytes = len(e.Payload)
+	e.HexDump = hexDump(e.Payload)
+	e.Reversed = true
+}
+
+// reversePages returns data with its pages in reverse order.
+func reversePages(data []byte, p PrinterProfile) []byte {
+	body := trimEject(data, p)
+	var pages [][]byte
+	start := 0
+	for _, end := range brf.PageEnds(body, p.LinesPerPage) {
+		pages = append(pages, body[start:end])
+		start = end
+	}
+	if len(pages) < 2 {
+		return data
+	}
+	slices.Reverse(pages)
+	out := make([]byte, 0, len(data)+len(pages))
+	for i, page := range pages {
+		if i == len(pages)-1 {
+			out = append(out, bytes.TrimRight(page, "\f")...) // ensureEject ends it
+			break
+		}
+		out = append(out, page...)
+		if !bytes.HasSuffix(page, []byte{'\f'}) {
+			out = append(out, '\f')
+		}
+	}
+	out, _ = ensureEject(out, p)
+	return out
+}
+
+// trimEject strips the eject sequence from the end of data, leaving the
+// last line ended.
+func trimEject(data []byte, p PrinterProfile) []byte {
+	if p.EjectSequence == ejectNone {
+		return data
+	}
+	eol := []byte("\n")
+	if bytes.Contains(data, []byte("\r\n")) {
+		eol = []byte("\r\n")
+	}
+	seq := []byte(p.EjectSequence)
+	body := data
+	for {
+		body = bytes.TrimRight(body, "\r\n")
+		if !bytes.HasSuffix(body, seq) {
+			break
+		}
+		body = body[:len(body)-len(seq)]
+	}
+	if len(body) == 0 {
+		return body
+	}
+	return append(body[:len(body):len(body)], eol...)
+}
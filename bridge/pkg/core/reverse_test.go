@@ -0,0 +1,30 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestReversePages(t *testing.T) {
+	ff := PrinterProfile{LinesPerPage: 2, EjectSequence: "\f"}
+	esc := PrinterProfile{LinesPerPage: 2, EjectSequence: "\x1bE"}
+	for _, c := range []struct {
+		data string
+		p    PrinterProfile
+		want string
+	}{
+		{"A\n\fB\n\fC\n\f", ff, "C\n\fB\n\fA\n\f"},
+		{"A\nB\nC\n\f", ff, "C\n\fA\nB\n\f"},
+		{"A\r\n\fB\r\n\f", ff, "B\r\n\fA\r\n\f"},
+		{"A\n\fB\n\x1bE", esc, "B\n\fA\n\x1bE"},
+		{"A\n\f", ff, "A\n\f"},
+	} {
+		if got := string(reversePages([]byte(c.data), c.p)); got != c.want {
+			t.Errorf("reversePages(%q) = %q, want %q", c.data, got, c.want)
+		}
+	}
+
+	e := JobEvent{ID: 1, Printer: "test", Reversed: true}
+	if got, _, err := pageRange(e, []byte("C\n\fB\n\fA\n\f"), 1, 2); err != nil || string(got) != "B\n\fA\n\f" {
+		t.Errorf("reversed pages 1-2 = %q, %v", got, err)
+	}
+}
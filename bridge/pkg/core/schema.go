@@ -0,0 +1,26 @@
+package core
+
+// ---------------------------------------------------------------------------
+// Job record schema versions
+//
+// Job records keep gaining fields, and web apps deployed to school servers
+// are not always updated with the bridge. Every job record now carries
+// "schema", the version of its layout, and a consumer of /log-stream can
+// ask for the version it was written against:
+//
+//	GET /log-stream?schema=1
+//
+//	1  the original record: id, time, printer, bytes, brf_text, hex_dump
+//	   and error, sent once per job when it has finished, as the bridge
+//	   did when jobs were printed synchronously. Version 1 readers take an
+//	   empty error as success, so jobs that did not print (rejected,
+//	   cancelled, stuck) always have one.
+//	2  the full record with status, pages, student and the rest (default)
+//
+// A version the bridge does not know is refused with 400, and GET /status
+// lists the versions it can send. Named events (progress, retry, ui, …)
+// are not versioned; older consumers ignore names they do not know. The
+// bridge has no WebSocket endpoint, so SSE is the only stream to negotiate.
+// ---------------------------------------------------------------------------
+
+const JobSchema = 2
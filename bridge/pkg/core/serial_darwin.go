@@ -0,0 +1,59 @@
+//go:build darwin
+
+package core
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// openSerial opens a tty in raw 8N1 mode with the profile's baud rate and
+// flow control. XON/XOFF and RTS/CTS are handled by the kernel tty driver.
+// The port is opened for reading too, for identity queries.
+func openSerial(port string, p PrinterProfile) (*os.File, error) {
+	f, err := os.OpenFile(port, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+	fd := int(f.Fd())
+	t, err := unix.IoctlGetTermios(fd, unix.TIOCGETA)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("not a serial port: %w", err)
+	}
+
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF | unix.IXANY
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB | unix.CSTOPB | unix.CRTSCTS
+	t.Cflag |= unix.CS8 | unix.CREAD | unix.CLOCAL
+	t.Ispeed, t.Ospeed = uint64(p.BaudRate), uint64(p.BaudRate)
+	switch p.FlowControl {
+	case flowXonXoff:
+		t.Iflag |= unix.IXON | unix.IXOFF
+	case flowRtsCts:
+		t.Cflag |= unix.CRTSCTS
+	}
+
+	if err := unix.IoctlSetTermios(fd, unix.TIOCSETA, t); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("configure serial port: %w", err)
+	}
+	return f, nil
+}
+
+// drainSerial blocks until all queued output has been transmitted (tcdrain).
+func drainSerial(f *os.File) error {
+	return unix.IoctlSetInt(int(f.Fd()), unix.TIOCDRAIN, 0)
+}
+
+// readSerialLine reads CTS; macOS drivers do not report overrun counts.
+func readSerialLine(f *os.File) (serialLine, error) {
+	bits, err := unix.IoctlGetInt(int(f.Fd()), unix.TIOCMGET)
+	if err != nil {
+		return serialLine{}, err
+	}
+	return serialLine{CTS: bits&unix.TIOCM_CTS != 0}, nil
+}
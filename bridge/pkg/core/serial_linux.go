@@ -0,0 +1,90 @@
+//go:build linux
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+var linuxBaudRates = map[int]uint32{
+	1200:   unix.B1200,
+	2400:   unix.B2400,
+	4800:   unix.B4800,
+	9600:   unix.B9600,
+	19200:  unix.B19200,
+	38400:  unix.B38400,
+	57600:  unix.B57600,
+	115200: unix.B115200,
+}
+
+// openSerial opens a tty in raw 8N1 mode with the profile's baud rate and
+// flow control. XON/XOFF and RTS/CTS are handled by the kernel tty driver.
+// The port is opened for reading too, for identity queries.
+func openSerial(port string, p PrinterProfile) (*os.File, error) {
+	speed, ok := linuxBaudRates[p.BaudRate]
+	if !ok {
+		return nil, fmt.Errorf("unsupported baud rate %d", p.BaudRate)
+	}
+	f, err := os.OpenFile(port, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+	fd := int(f.Fd())
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("not a serial port: %w", err)
+	}
+
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF | unix.IXANY
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB | unix.CSTOPB | unix.CRTSCTS | unix.CBAUD
+	t.Cflag |= unix.CS8 | unix.CREAD | unix.CLOCAL | speed
+	t.Ispeed, t.Ospeed = speed, speed
+	switch p.FlowControl {
+	case flowXonXoff:
+		t.Iflag |= unix.IXON | unix.IXOFF
+	case flowRtsCts:
+		t.Cflag |= unix.CRTSCTS
+	}
+
+	if err := unix.IoctlSetTermios(fd, unix.TCSETS, t); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("configure serial port: %w", err)
+	}
+	return f, nil
+}
+
+// drainSerial blocks until all queued output has been transmitted (tcdrain).
+func drainSerial(f *os.File) error {
+	return unix.IoctlSetInt(int(f.Fd()), unix.TCSBRK, 1)
+}
+
+// serialICounter is the kernel's struct serial_icounter_struct.
+type serialICounter struct {
+	CTS, DSR, RNG, DCD, RX, TX  int32
+	Frame, Overrun, Parity, Brk int32
+	BufOverrun                  int32
+	_                           [9]int32
+}
+
+// readSerialLine reads CTS (TIOCMGET) and the driver's overrun counters
+// (TIOCGICOUNT), which many USB serial adapters do not keep.
+func readSerialLine(f *os.File) (serialLine, error) {
+	fd := int(f.Fd())
+	bits, err := unix.IoctlGetInt(fd, unix.TIOCMGET)
+	if err != nil {
+		return serialLine{}, err
+	}
+	line := serialLine{CTS: bits&unix.TIOCM_CTS != 0}
+	var c serialICounter
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), unix.TIOCGICOUNT, uintptr(unsafe.Pointer(&c))); errno == 0 {
+		line.Overruns, line.Counted = int(c.Overrun+c.BufOverrun), true
+	}
+	return line, nil
+}
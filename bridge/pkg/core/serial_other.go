@@ -0,0 +1,22 @@
+//go:build !linux && !darwin && !windows
+
+package core
+
+import (
+	"errors"
+	"os"
+)
+
+var errSerialUnsupported = errors.New("serial transport is not supported on this platform")
+
+func openSerial(string, PrinterProfile) (*os.File, error) {
+	return nil, errSerialUnsupported
+}
+
+func drainSerial(*os.File) error {
+	return errSerialUnsupported
+}
+
+func readSerialLine(*os.File) (serialLine, error) {
+	return serialLine{}, errSerialUnsupported
+}
@@ -0,0 +1,83 @@
+//go:build windows
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// DCB.Flags bits (see the Win32 DCB documentation).
+const (
+	dcbBinary      = 0x00000001
+	dcbOutxCtsFlow = 0x00000004
+	dcbOutX        = 0x00000100
+	dcbInX         = 0x00000200
+	dcbRtsMask     = 0x00003000
+
+	msCtsOn = 0x0010 // GetCommModemStatus: CTS is asserted
+)
+
+// openSerial opens a COM port in 8N1 mode with the profile's baud rate and
+// flow control. Reads give up after a second, or once the embosser pauses
+// mid-reply, since synchronous handles have no read deadlines.
+func openSerial(port string, p PrinterProfile) (*os.File, error) {
+	// COM10 and above are only reachable through the device namespace.
+	if !strings.HasPrefix(port, `\\.\`) {
+		port = `\\.\` + port
+	}
+	f, err := os.OpenFile(port, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	h := windows.Handle(f.Fd())
+
+	var dcb windows.DCB
+	dcb.DCBlength = uint32(unsafe.Sizeof(dcb))
+	if err := windows.GetCommState(h, &dcb); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("not a serial port: %w", err)
+	}
+	dcb.BaudRate = uint32(p.BaudRate)
+	dcb.ByteSize = 8
+	dcb.Parity = windows.NOPARITY
+	dcb.StopBits = windows.ONESTOPBIT
+	dcb.Flags &^= dcbOutxCtsFlow | dcbOutX | dcbInX | dcbRtsMask
+	dcb.Flags |= dcbBinary | windows.RTS_CONTROL_ENABLE
+	switch p.FlowControl {
+	case flowXonXoff:
+		dcb.Flags |= dcbOutX | dcbInX
+	case flowRtsCts:
+		dcb.Flags &^= dcbRtsMask
+		dcb.Flags |= dcbOutxCtsFlow | windows.RTS_CONTROL_HANDSHAKE
+	}
+	if err := windows.SetCommState(h, &dcb); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("configure serial port: %w", err)
+	}
+	timeouts := windows.CommTimeouts{ReadIntervalTimeout: 200, ReadTotalTimeoutConstant: 1000}
+	if err := windows.SetCommTimeouts(h, &timeouts); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("configure serial port: %w", err)
+	}
+	return f, nil
+}
+
+// drainSerial blocks until the driver has transmitted all queued output.
+func drainSerial(f *os.File) error {
+	return windows.FlushFileBuffers(windows.Handle(f.Fd()))
+}
+
+// readSerialLine reads CTS. Windows reports only whether an overrun has
+// happened since the last ClearCommError, so no count is given.
+func readSerialLine(f *os.File) (serialLine, error) {
+	var status uint32
+	if err := windows.GetCommModemStatus(windows.Handle(f.Fd()), &status); err != nil {
+		return serialLine{}, err
+	}
+	return serialLine{CTS: status&msCtsOn != 0}, nil
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Simulation mode
+//
+// --simulate adds fake embossers (sim:Everest-D V5, sim:ViewPlus Columbia)
+// so the web app can be demoed with no hardware. Jobs sent to them go
+// through the normal queue, progress events and job log; each one takes a
+// few seconds and its outcome comes from the --simulate-script, a
+// comma-separated list of steps used in turn and repeated:
+//
+//	ok          the job succeeds
+//	slow        the job succeeds after three times as long
+//	fail        the job fails with a generic error
+//	fail:<code> the job fails with that failure class, e.g.
+//	            fail:printer_not_accepting or fail:device_unavailable
+//
+// Without --simulate, sim: destinations are rejected as unknown printers.
+// ---------------------------------------------------------------------------
+
+const (
+	simPrefix        = "sim:"
+	SimDefaultScript = "ok,ok,ok,fail:printer_not_accepting"
+	simChunk         = 256 // bytes per progress event
+	simChunkDelay    = 150 * time.Millisecond
+)
+
+var simPrinters = []string{"sim:Everest-D V5", "sim:ViewPlus Columbia"}
+
+var sim = struct {
+	sync.Mutex
+	enabled bool
+	script  []string
+	next    int
+}{}
+
+// EnableSimulation turns simulation mode on with the given script.
+func EnableSimulation(script string) error {
+	var steps []string
+	for _, s := range strings.Split(script, ",") {
+		s = strings.TrimSpace(s)
+		code, isFail := strings.CutPrefix(s, "fail:")
+		switch {
+		case s == "ok", s == "slow", s == "fail":
+		case isFail && ErrorGuidance[code] != "":
+		default:
+			return fmt.Errorf("unknown simulate step %q (use ok, slow, fail or fail:<error code>)", s)
+		}
+		steps = append(steps, s)
+	}
+	sim.Lock()
+	sim.enabled, sim.script, sim.next = true, steps, 0
+	sim.Unlock()
+	log.Printf("simulation mode: fake printers %s, script %s", strings.Join(simPrinters, ", "), script)
+	return nil
+}
+
+func Simulating() bool {
+	sim.Lock()
+	defer sim.Unlock()
+	return sim.enabled
+}
+
+// checkSimulated is the pre-flight check for sim: destinations.
+func checkSimulated(printer string) error {
+	if !Simulating() {
+		return Classified(ErrCodeNotFound, fmt.Errorf("%s: simulation mode is off (start the bridge with --simulate)", printer))
+	}
+	for _, p := range simPrinters {
+		if p == printer {
+			return nil
+		}
+	}
+	return Classified(ErrCodeNotFound, fmt.Errorf("no simulated printer named %q", printer))
+}
+
+// sendSimulated plays out one job: progress in chunks, then the next
+// scripted outcome.
+func sendSimulated(ctx context.Context, job *PrintJob) error {
+	if err := checkSimulated(job.Printer); err != nil {
+		return err
+	}
+	sim.Lock()
+	step := sim.script[sim.next%len(sim.script)]
+	sim.next++
+	sim.Unlock()
+
+	delay := simChunkDelay
+	if step == "slow" {
+		delay *= 3
+	}
+	total := len(job.Data)
+	prog := newProgress(job)
+	for sent := 0; sent < total; {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("stopped after %d of %d bytes: %w", sent, total, ctx.Err())
+		}
+		sent = min(sent+simChunk, total)
+		prog.publish(sent)
+	}
+
+	switch code, _ := strings.CutPrefix(step, "fail:"); {
+	case step == "fail":
+		return errors.New("simulated failure")
+	case code != step:
+		return Classified(code, fmt.Errorf("simulated %s", code))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Line spacing
+//
+// Beginning braille readers often need double-spaced material, and a
+// teacher writing print between the lines needs room for it. A print
+// request chooses "line_spacing": "single" (the default), "double" or
+// "interline".
+//
+// Embossers that can change their line pitch take escape codes for it,
+// listed in the printer profile in the same form as media settings:
+//
+//	"line_spacing": {
+//	  "double":    {"start": "\u001bL2", "end": "\u001bL1"},
+//	  "interline": {"start": "\u001bL5", "end": "\u001bL1"}
+//	}
+//
+// Without codes, double spacing is done by putting a blank line after
+// every braille line and starting new pages so each still holds
+// lines_per_page lines. Interline spacing has no blank-line form, so
+// printers without codes for it refuse it.
+//
+// Spacing is applied after any interline print is merged in; a braille
+// line's print line stays with it, ahead of the blank line.
+// ---------------------------------------------------------------------------
+
+const (
+	spacingSingle    = "single"
+	spacingDouble    = "double"
+	spacingInterline = "interline"
+)
+
+// ValidSpacing reports whether s is a line spacing a print request may ask
+// for.
+func ValidSpacing(s string) bool {
+	switch s {
+	case "", spacingSingle, spacingDouble, spacingInterline:
+		return true
+	}
+	return false
+}
+
+// ApplyLineSpacing spaces a prepared job's lines, with its printer's
+// spacing codes if the profile has them.
+func ApplyLineSpacing(e *JobEvent, spacing string) *PayloadError {
+	if spacing == "" || spacing == spacingSingle {
+		return nil
+	}
+	p := ProfileFor(e.Printer)
+	codes, ok := p.LineSpacing[spacing]
+	switch {
+	case ok:
+		e.Payload = codes.wrap(e.Payload)
+		if spacing == spacingDouble {
+			e.Pages = brf.CountPages(e.Payload, max(1, p.LinesPerPage/2))
+		}
+	case spacing == spacingDouble:
+		inkStart := ""
+		if e.Interlined {
+			inkStart = p.Interline.InkStart
+		}
+		e.Payload = doubleSpace(e.Payload, p.LinesPerPage, inkStart)
+		e.Pages = brf.CountPages(e.Payload, p.LinesPerPage)
+	default:
+		return &PayloadError{http.StatusUnprocessableEntity, fmt.Sprintf(
+			"printer %q has no %s spacing codes in its profile", e.Printer, spacing)}
+	}
+	e.Bytes = len(e.Payload)
+	e.HexDump = hexDump(e.Payload)
+	e.Estimate = estimateSeconds(e.Printer, e.Bytes)
+	e.LineSpacing = spacing
+	return nil
+}
+
+// doubleSpace puts a blank line after each braille line, breaking pages
+// early so none holds more than linesPerPage lines. Lines starting with
+// inkStart are interline print and stay with the line above. Text after a
+// page's last line break (an eject code) is kept where it was.
+func doubleSpace(data []byte, linesPerPage int, inkStart string) []byte {
+	eol := "\n"
+	if bytes.Contains(data, []byte("\r\n")) {
+		eol = "\r\n"
+	}
+	var b strings.Builder
+	for i, page := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\f") {
+		if i > 0 {
+			b.WriteByte('\f')
+		}
+		lines := strings.Split(page, "\n")
+		last := len(lines) - 1 // index of the unterminated tail
+		used := 0
+		for j := 0; j < last; {
+			k := j + 1
+			for inkStart != "" && k < last && strings.HasPrefix(lines[k], inkStart) {
+				k++
+			}
+			row := lines[j:k]
+			j = k
+			if used > 0 && used+len(row) > linesPerPage {
+				b.WriteByte('\f')
+				used = 0
+			}
+			for _, line := range row {
+				b.WriteString(line)
+				b.WriteString(eol)
+			}
+			used += len(row)
+			if j < last && used < linesPerPage {
+				b.WriteString(eol)
+				used++
+			}
+		}
+		b.WriteString(lines[last])
+	}
+	return []byte(b.String())
+}
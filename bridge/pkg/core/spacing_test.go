@@ -0,0 +1,23 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestDoubleSpace(t *testing.T) {
+	for _, c := range []struct {
+		data, ink string
+		lpp       int
+		want      string
+	}{
+		{"A\nB\nC\n\f", "", 4, "A\n\nB\n\n\fC\n\f"},
+		{"A\r\nB\r\n", "", 25, "A\r\n\r\nB\r\n"},
+		{"A\nB\n\fC\n", "", 25, "A\n\nB\n\fC\n"},
+		{"A\n\x1bIa\nB\n", "\x1bI", 3, "A\n\x1bIa\n\n\fB\n"},
+		{"A\nB\n\x1b@", "", 25, "A\n\nB\n\x1b@"},
+	} {
+		if got := string(doubleSpace([]byte(c.data), c.lpp, c.ink)); got != c.want {
+			t.Errorf("doubleSpace(%q, %d) = %q, want %q", c.data, c.lpp, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+//go:build !windows
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Spooler verification (CUPS)
+//
+// lp exiting 0 only means CUPS took the file. After submitting, the bridge
+// reads the request ID lp prints and watches the job: once CUPS starts
+// printing it or it leaves the queue, the job is done. A job still waiting
+// in the queue after timeouts.verify_seconds (default 60) is reported as
+// stuck — usually an embosser that is off, offline or out of paper — and
+// left in the CUPS queue to print when the embosser is ready.
+// ---------------------------------------------------------------------------
+
+const verifyPollInterval = 2 * time.Second
+
+var lpRequestID = regexp.MustCompile(`request id is (\S+)`)
+
+// parseRequestID extracts the CUPS job ID from lp's output.
+func parseRequestID(out string) string {
+	if m := lpRequestID.FindStringSubmatch(out); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// cupsJobState is where a submitted job is in CUPS.
+type cupsJobState int
+
+const (
+	cupsJobWaiting  cupsJobState = iota // queued, not yet sent to the device
+	cupsJobPrinting                     // the printer is processing it
+	cupsJobGone                         // completed, cancelled or purged
+)
+
+// verifySpooled waits until CUPS starts printing job id (or the job has
+// left the queue), or reports it as stuck. The wait has its own timeout,
+// separate from the send timeout lp has already used part of.
+func verifySpooled(ctx context.Context, printer, id string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), verifyTimeout())
+	defer cancel()
+	for {
+		state, err := jobState(ctx, printer, id)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			// lpstat itself failed; lp already accepted the job, so don't
+			// fail it for a check we could not complete.
+			log.Printf("verify %s: %v", id, err)
+			return nil
+		case state == cupsJobPrinting || state == cupsJobGone:
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return Classified(ErrCodeStuck, fmt.Errorf(
+				"CUPS accepted job %s but %q has not started it after %s", id, printer, verifyTimeout()))
+		case <-time.After(verifyPollInterval):
+		}
+	}
+}
+
+// jobState asks lpstat where job id is.
+func jobState(ctx context.Context, printer, id string) (cupsJobState, error) {
+	queued, err := lpstatOutput(ctx, "-o", printer)
+	if err != nil {
+		return cupsJobWaiting, err
+	}
+	status, err := lpstatOutput(ctx, "-p", printer)
+	if err != nil {
+		return cupsJobWaiting, err
+	}
+	return parseJobState(queued, status, id), nil
+}
+
+// parseJobState interprets "lpstat -o NAME" (not-completed jobs, one per
+// line starting with the job ID) and "lpstat -p NAME" ("printer NAME now
+// printing NAME-42.").
+func parseJobState(queued, status, id string) cupsJobState {
+	if strings.Contains(status, "now printing "+id+".") {
+		return cupsJobPrinting
+	}
+	for _, line := range strings.Split(queued, "\n") {
+		if f := strings.Fields(line); len(f) > 0 && f[0] == id {
+			return cupsJobWaiting
+		}
+	}
+	return cupsJobGone
+}
+
+func lpstatOutput(ctx context.Context, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "lpstat", args...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,50 @@
+package core
+
+import "context"
+
+// ---------------------------------------------------------------------------
+// Spooler
+//
+// Everything the bridge asks of the OS print system goes through the
+// Spooler interface: CUPS (lp, lpstat) on macOS and Linux, the print
+// spooler API on Windows. Direct transports (serial:, usb:, socket:, …)
+// bypass it. Tests swap in a fake so the queue, the API handlers and the
+// payload checks can be exercised without a real spooler or printer.
+// ---------------------------------------------------------------------------
+
+// Spooler is the OS print system.
+type Spooler interface {
+	// Printers returns the names of the print queues.
+	Printers(ctx context.Context) []string
+	// Check returns a classified error if printer cannot take a job now
+	// (see Preflight).
+	Check(ctx context.Context, printer string) error
+	// Send hands data to printer's queue as a raw job and, where the
+	// spooler allows, waits until the job has started.
+	Send(ctx context.Context, printer string, data []byte) error
+	// Driver returns the queue's driver name and device URI, or "" for
+	// either if unknown (see airprint.go).
+	Driver(ctx context.Context, printer string) (driver, uri string)
+}
+
+var ActiveSpooler Spooler = OSSpooler{}
+
+// OSSpooler is the real print system, implemented per OS in print_unix.go,
+// lpstat.go and print_windows.go.
+type OSSpooler struct{}
+
+func (OSSpooler) Printers(ctx context.Context) []string {
+	return listPrinters(ctx)
+}
+
+func (OSSpooler) Check(ctx context.Context, printer string) error {
+	return checkPrinter(ctx, printer)
+}
+
+func (OSSpooler) Send(ctx context.Context, printer string, data []byte) error {
+	return sendToPrinter(ctx, printer, data)
+}
+
+func (OSSpooler) Driver(ctx context.Context, printer string) (driver, uri string) {
+	return queueDriver(ctx, printer)
+}
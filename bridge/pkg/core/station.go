@@ -0,0 +1,51 @@
+package core
+
+import (
+	"net/netip"
+)
+
+// ---------------------------------------------------------------------------
+// Station mode
+//
+// By default the bridge listens on 127.0.0.1 only. With "station" enabled
+// it listens on the LAN so one embosser machine can serve a room of
+// Chromebooks: it advertises itself over mDNS as _graham-bridge._tcp,
+// requires API tokens (validate refuses station mode without them), and
+// admits only private-network clients or those in allowed_hosts. Every
+// client is listed on the dashboard, where an admin can block it until the
+// next restart; list it in blocked_hosts to block it permanently.
+//
+// The listen address is read at startup; changing it needs a restart.
+// ---------------------------------------------------------------------------
+
+const (
+	StationServiceType   = "_graham-bridge._tcp"
+	defaultStationListen = ":8080"
+)
+
+// StationEnabled reports whether the bridge serves the LAN.
+func StationEnabled() bool {
+	st := CurrentConfig().Station
+	return st != nil && st.Enabled
+}
+
+func StationListen(st *StationConfig) string {
+	if st.Listen != "" {
+		return st.Listen
+	}
+	return defaultStationListen
+}
+
+// ParseHostRule parses an allowed_hosts/blocked_hosts entry (an IP address
+// or CIDR range) into a prefix, or returns nil.
+func ParseHostRule(s string) *netip.Prefix {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		p = p.Masked()
+		return &p
+	}
+	if a, err := netip.ParseAddr(s); err == nil {
+		p := netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen())
+		return &p
+	}
+	return nil
+}
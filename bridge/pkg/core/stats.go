@@ -0,0 +1,102 @@
+package core
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Usage statistics
+//
+// GET /stats aggregates the page ledger (see reports.go) into the numbers
+// behind decisions such as buying a second embosser:
+//
+//	days      jobs, pages and failures per calendar day
+//	printers  jobs, pages and failure rate per printer
+//	hours     jobs per hour of the day, to find the busiest periods
+//
+// The range defaults to the last 30 days; since=, until= (RFC 3339) and
+// printer= narrow it.
+// ---------------------------------------------------------------------------
+
+const DefaultStatsRange = 30 * 24 * time.Hour
+
+// UsageCount is the tally for one day, printer or hour.
+type UsageCount struct {
+	Key         string  `json:"key"`
+	Jobs        int     `json:"jobs"`
+	Failed      int     `json:"failed"`
+	Pages       int     `json:"pages"` // embossed pages (successful jobs only)
+	FailureRate float64 `json:"failure_rate"`
+}
+
+func (c *UsageCount) add(rec PageRecord) {
+	c.Jobs++
+	if rec.Status == StatusFailed {
+		c.Failed++
+	} else {
+		c.Pages += rec.Pages
+	}
+	c.FailureRate = float64(c.Failed) / float64(c.Jobs)
+}
+
+// UsageStats is the /stats response.
+type UsageStats struct {
+	Since    time.Time    `json:"since"`
+	Until    time.Time    `json:"until"`
+	Total    UsageCount   `json:"total"`
+	Days     []UsageCount `json:"days"`     // oldest first, including empty days
+	Printers []UsageCount `json:"printers"` // busiest first
+	Hours    []UsageCount `json:"hours"`    // 24 entries, "00" to "23"
+}
+
+func StatsFor(f JobFilter) UsageStats {
+	st := UsageStats{Since: f.Since, Until: f.Until, Total: UsageCount{Key: "total"}}
+
+	days := map[string]*UsageCount{}
+	y, m, d0 := f.Since.Local().Date()
+	for d := time.Date(y, m, d0, 0, 0, 0, 0, time.Local); d.Before(f.Until); d = d.AddDate(0, 0, 1) {
+		key := d.Format(time.DateOnly)
+		days[key] = &UsageCount{Key: key}
+	}
+	hours := make([]UsageCount, 24)
+	for h := range hours {
+		hours[h].Key = time.Date(0, 1, 1, h, 0, 0, 0, time.Local).Format("15")
+	}
+	printers := map[string]*UsageCount{}
+
+	EachRecord(f, func(rec PageRecord) {
+		t := rec.Time.Local()
+		st.Total.add(rec)
+		day := t.Format(time.DateOnly)
+		if days[day] == nil {
+			days[day] = &UsageCount{Key: day}
+		}
+		days[day].add(rec)
+		hours[t.Hour()].add(rec)
+		if printers[rec.Printer] == nil {
+			printers[rec.Printer] = &UsageCount{Key: rec.Printer}
+		}
+		printers[rec.Printer].add(rec)
+	})
+
+	for _, c := range days {
+		st.Days = append(st.Days, *c)
+	}
+	slices.SortFunc(st.Days, func(a, b UsageCount) int { return strings.Compare(a.Key, b.Key) })
+	for _, c := range printers {
+		st.Printers = append(st.Printers, *c)
+	}
+	slices.SortFunc(st.Printers, func(a, b UsageCount) int {
+		if a.Jobs != b.Jobs {
+			return b.Jobs - a.Jobs
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	if st.Printers == nil {
+		st.Printers = []UsageCount{}
+	}
+	st.Hours = hours
+	return st
+}
@@ -0,0 +1,141 @@
+package core
+
+// ---------------------------------------------------------------------------
+// Job store
+//
+// All job history goes through the JobStore interface so handlers never
+// touch shared state directly and persistent backends can be swapped in
+// (see storedb.go).
+// ---------------------------------------------------------------------------
+
+// HistorySize is how many jobs the in-memory store keeps.
+const HistorySize = 200
+
+// JobStore records job events and notifies subscribers of changes.
+type JobStore interface {
+	// Append assigns the next ID, stores e (handing e.data to the payload
+	// store) and broadcasts it.
+	Append(e JobEvent) JobEvent
+	// Update applies fn to a stored job and broadcasts the result.
+	Update(id int, fn func(*JobEvent)) (JobEvent, bool)
+	// Get returns the job with the given ID.
+	Get(id int) (JobEvent, bool)
+	// List returns all stored jobs, oldest first.
+	List() []JobEvent
+	// Purge deletes the jobs match selects, with their payloads, and
+	// returns them (see pkg/httpapi/purge.go).
+	Purge(match func(JobEvent) bool) []JobEvent
+
+	// Subscribe starts a change feed, resuming after lastEventID if possible.
+	Subscribe(lastEventID string) *Subscription
+	Unsubscribe(s *Subscription)
+	// Publish sends an auxiliary (non-record) event, such as progress, to
+	// subscribers.
+	Publish(ev StreamEvent)
+}
+
+// Store is the job store the queue and background tasks record to. main
+// replaces it with the backend the config selects (storedb.go).
+var Store JobStore = NewMemoryStore(HistorySize)
+
+// StreamEvent is one message on /log-stream (see pkg/core's Broadcaster).
+// Job records use the default (unnamed) event type; auxiliary updates such
+// as progress are named.
+type StreamEvent = Event
+
+// MemoryStore keeps jobs in a History: HistorySize of them, more only
+// while that many are unfinished, since the queue needs an unfinished
+// job's record and payload to send or release it.
+type MemoryStore struct {
+	jobs   *History[JobEvent]
+	events *Broadcaster
+}
+
+func NewMemoryStore(size int) *MemoryStore {
+	return &MemoryStore{
+		jobs: NewHistory(size,
+			func(e JobEvent) int { return e.ID },
+			func(e JobEvent) bool { return FinishedStatus(e.Status) }),
+		events: NewBroadcaster(),
+	}
+}
+
+func (s *MemoryStore) Append(e JobEvent) JobEvent {
+	e, _ = s.add(e)
+	return e
+}
+
+// add stores e as Append does and returns the ID of the finished job it
+// evicted to make room, or 0.
+func (s *MemoryStore) add(e JobEvent) (JobEvent, int) {
+	e, evicted := s.jobs.Add(func(id int) JobEvent {
+		e.ID = id
+		Payloads.put(e.ID, e.Payload)
+		e.Payload = nil
+		return e
+	})
+	if evicted != 0 {
+		Payloads.remove(evicted)
+		Renders.remove(evicted)
+	}
+	s.events.Publish(StreamEvent{Data: e})
+	return e, evicted
+}
+
+func (s *MemoryStore) Update(id int, fn func(*JobEvent)) (JobEvent, bool) {
+	e, ok := s.jobs.Update(id, fn)
+	if ok {
+		s.events.Publish(StreamEvent{Data: e})
+	}
+	return e, ok
+}
+
+func (s *MemoryStore) Get(id int) (JobEvent, bool) {
+	return s.jobs.Get(id)
+}
+
+func (s *MemoryStore) List() []JobEvent {
+	return s.jobs.List()
+}
+
+func (s *MemoryStore) Purge(match func(JobEvent) bool) []JobEvent {
+	purged := s.jobs.Purge(match)
+	if len(purged) == 0 {
+		return nil
+	}
+	ids := make([]int, len(purged))
+	for i, e := range purged {
+		Payloads.remove(e.ID)
+		Renders.remove(e.ID)
+		ids[i] = e.ID
+	}
+	// Drop the purged records from the replay backlog too; open dashboards
+	// remove them on the "purge" event.
+	s.events.Forget()
+	s.events.Publish(StreamEvent{Name: "purge", Data: map[string][]int{"jobs": ids}})
+	return purged
+}
+
+// load fills an empty store with jobs read back from a database, oldest
+// first, keeping the newest if there are more than fit. Nothing is
+// published.
+func (s *MemoryStore) load(jobs []JobEvent) {
+	s.jobs.Load(jobs)
+}
+
+// oldestID returns the ID of the oldest job held, or the next ID if none.
+func (s *MemoryStore) oldestID() int {
+	return s.jobs.OldestID()
+}
+
+func (s *MemoryStore) Subscribe(lastEventID string) *Subscription {
+	return s.events.Subscribe(lastEventID)
+}
+
+func (s *MemoryStore) Unsubscribe(sub *Subscription) {
+	s.events.Unsubscribe(sub)
+}
+
+func (s *MemoryStore) Publish(ev StreamEvent) {
+	s.events.Publish(ev)
+}
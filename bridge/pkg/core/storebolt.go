@@ -0,0 +1,94 @@
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ---------------------------------------------------------------------------
+// bbolt job store
+//
+// Job records are JSON values in one bucket, keyed by the job ID as a
+// big-endian integer so the keys sort in job order. bbolt locks the file,
+// so a second bridge started against the same file waits a few seconds
+// and then fails with a clear error instead of corrupting it.
+// ---------------------------------------------------------------------------
+
+var boltJobsBucket = []byte("jobs")
+
+type boltDB struct {
+	db *bolt.DB
+}
+
+func openBoltDB(path string) (*boltDB, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("store: open %s: %w (is another bridge using it?)", path, err)
+	}
+	if err := migrateBolt(db, path); err != nil { // storemigrate.go
+		db.Close()
+		return nil, err
+	}
+	return &boltDB{db: db}, nil
+}
+
+func boltKey(id int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(id))
+}
+
+func (b *boltDB) load() ([]JobEvent, error) {
+	var jobs []JobEvent
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltJobsBucket).ForEach(func(k, v []byte) error {
+			e, err := DecodeJob(v)
+			if err != nil {
+				return fmt.Errorf("job %d: %w", binary.BigEndian.Uint64(k), err)
+			}
+			jobs = append(jobs, e)
+			return nil
+		})
+	})
+	return jobs, err
+}
+
+func (b *boltDB) save(e JobEvent) error {
+	data, err := EncodeJob(e)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltJobsBucket).Put(boltKey(e.ID), data)
+	})
+}
+
+func (b *boltDB) remove(ids []int) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltJobsBucket)
+		for _, id := range ids {
+			if err := bucket.Delete(boltKey(id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltDB) pruneBefore(id int) error {
+	end := boltKey(id)
+	return b.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(boltJobsBucket).Cursor()
+		for k, _ := c.First(); k != nil && string(k) < string(end); k, _ = c.First() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltDB) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,238 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Job store backends
+//
+// By default the job history lives in memory and is lost when the bridge
+// restarts. A school that wants it kept picks a backend in the config:
+//
+//	"store": {"backend": "bbolt", "path": "/var/lib/graham/jobs.db", "max_jobs": 2000}
+//
+//	memory  the in-memory ring (default)
+//	bbolt   a single-file key/value database (storebolt.go)
+//	sqlite  an SQLite database, for schools that query it with their own
+//	        tools (storesqlite.go)
+//
+// "path" defaults to jobs.db or jobs.sqlite in the bridge data directory
+// and "max_jobs" to 200. A persistent store keeps the same ring in memory
+// for reads and writes every change through to the file, so the queue
+// and dashboard are no slower than with the memory backend.
+//
+// Payloads are not kept across restarts (payload.go clears them at
+// startup), so jobs from an earlier run have no BRF or preview, and jobs
+// that had not finished when the bridge stopped are marked failed. With
+// "anonymize_students" the file holds only pseudonyms, so jobs read back
+// after a restart carry the pseudonym rather than the real identifier, and
+// names in records from before it was turned on are replaced when the
+// bridge next opens the file. The
+// backend is chosen at startup; changing it needs a restart.
+//
+// Upgrading the bridge upgrades the database file in place, after saving
+// a copy of it (storemigrate.go).
+// ---------------------------------------------------------------------------
+
+// StoreConfig selects the job store backend.
+type StoreConfig struct {
+	Backend string `json:"backend,omitempty"`  // memory (default), bbolt or sqlite
+	Path    string `json:"path,omitempty"`     // database file
+	MaxJobs int    `json:"max_jobs,omitempty"` // jobs kept; default HistorySize
+}
+
+const (
+	BackendMemory = "memory"
+	BackendBolt   = "bbolt"
+	BackendSQLite = "sqlite"
+)
+
+func (c *StoreConfig) validate() error {
+	switch c.Backend {
+	case "", BackendMemory, BackendBolt, BackendSQLite:
+	default:
+		return fmt.Errorf("store: unknown backend %q (memory, bbolt or sqlite)", c.Backend)
+	}
+	if c.MaxJobs < 0 {
+		return fmt.Errorf("store: max_jobs must not be negative")
+	}
+	return nil
+}
+
+// OpenStore returns the job store the config selects.
+func OpenStore(c *StoreConfig) (JobStore, error) {
+	if c == nil {
+		c = &StoreConfig{}
+	}
+	size := c.MaxJobs
+	if size == 0 {
+		size = HistorySize
+	}
+	var db recordDB
+	var err error
+	switch c.Backend {
+	case "", BackendMemory:
+		return NewMemoryStore(size), nil
+	case BackendBolt:
+		db, err = openBoltDB(storePath(c, "jobs.db"))
+	case BackendSQLite:
+		db, err = openSQLiteDB(storePath(c, "jobs.sqlite"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newPersistentStore(db, size)
+}
+
+func storePath(c *StoreConfig, name string) string {
+	if c.Path != "" {
+		return c.Path
+	}
+	return filepath.Join(DataDir(), name)
+}
+
+// recordDB is a file that job records are written through to.
+type recordDB interface {
+	// load returns every record, oldest first.
+	load() ([]JobEvent, error)
+	// save inserts or replaces the record with e's ID.
+	save(e JobEvent) error
+	// remove deletes the records with these IDs.
+	remove(ids []int) error
+	// pruneBefore deletes records with IDs below id.
+	pruneBefore(id int) error
+	Close() error
+}
+
+// PersistentStore is a MemoryStore whose changes are written to a recordDB.
+type PersistentStore struct {
+	*MemoryStore
+	mu sync.Mutex // orders writes to db the same as changes in memory
+	db recordDB
+}
+
+func newPersistentStore(db recordDB, size int) (*PersistentStore, error) {
+	jobs, err := db.load()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &PersistentStore{MemoryStore: NewMemoryStore(size), db: db}
+	for i := range jobs {
+		interrupted := MarkInterrupted(&jobs[i])
+		if pseudonymizeStored(&jobs[i]) || interrupted {
+			s.save(jobs[i])
+		}
+	}
+	s.MemoryStore.load(jobs)
+	if len(jobs) > 0 {
+		s.prune()
+	}
+	return s, nil
+}
+
+// MarkInterrupted fails a job read back from an earlier run that had not
+// finished, and reports whether it did.
+func MarkInterrupted(e *JobEvent) bool {
+	switch e.Status {
+	case StatusQueued, StatusPrinting, StatusHeld, StatusWaiting:
+		e.Status = StatusFailed
+		e.ErrMsg = "the bridge stopped before this job was printed"
+		return true
+	}
+	return false
+}
+
+// pseudonymizeStored replaces a real student identifier read back from a
+// database written before anonymize_students was turned on (or before the
+// bridge wrote pseudonyms to it), and reports whether it did.
+func pseudonymizeStored(e *JobEvent) bool {
+	if !anonymizing() || e.Student == "" || isPseudonym(e.Student) {
+		return false
+	}
+	e.Student = Pseudonym(e.Student)
+	return true
+}
+
+func (s *PersistentStore) Append(e JobEvent) JobEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, evicted := s.MemoryStore.add(e)
+	s.save(e)
+	if evicted != 0 {
+		if err := s.db.remove([]int{evicted}); err != nil {
+			log.Printf("job store: prune: %v", err)
+		}
+	}
+	return e
+}
+
+func (s *PersistentStore) Update(id int, fn func(*JobEvent)) (JobEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.MemoryStore.Update(id, fn)
+	if ok {
+		s.save(e)
+	}
+	return e, ok
+}
+
+func (s *PersistentStore) Purge(match func(JobEvent) bool) []JobEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := s.MemoryStore.Purge(match)
+	if len(purged) > 0 {
+		ids := make([]int, len(purged))
+		for i, e := range purged {
+			ids[i] = e.ID
+		}
+		if err := s.db.remove(ids); err != nil {
+			log.Printf("job store: purge: %v", err)
+		}
+	}
+	return purged
+}
+
+// save writes e to the database. A failed write is logged; the job still
+// runs, and the memory copy stays correct until the bridge restarts.
+// Close closes the store's database.
+func (s *PersistentStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *PersistentStore) save(e JobEvent) {
+	if err := s.db.save(e); err != nil {
+		log.Printf("job store: save job %d: %v", e.ID, err)
+	}
+}
+
+// prune drops records older than any left in the in-memory ring after
+// loading, when the database held more than fit.
+func (s *PersistentStore) prune() {
+	if err := s.db.pruneBefore(s.MemoryStore.oldestID()); err != nil {
+		log.Printf("job store: prune: %v", err)
+	}
+}
+
+// storedJob is a JobEvent as written to a database: every field, without
+// the schema number MarshalJSON adds for clients.
+type storedJob JobEvent
+
+// EncodeJob writes e for a database or backup. The student is written as
+// ShownStudent, a pseudonym when anonymizing, like every other record
+// that leaves the bridge's memory.
+func EncodeJob(e JobEvent) ([]byte, error) {
+	e.Student = ShownStudent(e.Student)
+	return json.Marshal(storedJob(e))
+}
+
+func DecodeJob(data []byte) (JobEvent, error) {
+	var e storedJob
+	err := json.Unmarshal(data, &e)
+	return JobEvent(e), err
+}
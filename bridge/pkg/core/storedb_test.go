@@ -0,0 +1,41 @@
+package core
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPersistentStoresKeepPseudonyms(t *testing.T) {
+	studentKey.Do(func() { studentKey.key = bytes.Repeat([]byte{1}, 32) })
+	old := Cfg.Load()
+	defer Cfg.Store(old)
+	Cfg.Store(&Config{AnonymizeStudents: true})
+	for _, backend := range []string{BackendBolt, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			c := &StoreConfig{Backend: backend, Path: filepath.Join(t.TempDir(), "jobs")}
+			s, err := OpenStore(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Append(JobEvent{Printer: "Index", Status: StatusDone, Student: "Maya Lopez"})
+			if e, _ := s.Get(1); e.Student != "Maya Lopez" {
+				t.Errorf("the running bridge lost the real identifier: %q", e.Student)
+			}
+			s.(*PersistentStore).Close()
+			if raw, _ := os.ReadFile(c.Path); bytes.Contains(raw, []byte("Maya")) {
+				t.Error("the database file holds the student's name")
+			}
+
+			s, err = OpenStore(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.(*PersistentStore).Close()
+			if e, _ := s.Get(1); e.Student != Pseudonym("Maya Lopez") || !SameStudent(e.Student, "maya lopez") {
+				t.Errorf("reloaded student %q", e.Student)
+			}
+		})
+	}
+}
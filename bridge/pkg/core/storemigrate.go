@@ -0,0 +1,181 @@
+package core
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ---------------------------------------------------------------------------
+// Job database migrations
+//
+// Each job database records the version of its layout: bbolt in a "meta"
+// bucket, SQLite in PRAGMA user_version. When a bridge opens a database
+// from an older release it brings it up to dbSchemaVersion, one step at a
+// time, each in its own transaction with the version that step reaches,
+// so a bridge stopped half way resumes from the last finished step.
+//
+// Before the first step the file is copied next to itself, e.g.
+//
+//	jobs.db.v1-20261016-083000.bak
+//
+// so a teacher can go back to the previous bridge with the history intact.
+// The copy is left as it was: if it predates anonymize_students it still
+// has real names in it, so delete it once the new bridge is known good.
+// Databases made before versions were recorded count as version 1. A
+// database written by a newer bridge is refused rather than opened, since
+// this bridge cannot know what that release changed.
+//
+// To change the layout, append a step to dbMigrations; never edit one
+// that has shipped.
+// ---------------------------------------------------------------------------
+
+// dbSchemaVersion is the job database layout this bridge writes.
+const dbSchemaVersion = 2
+
+// dbMigration brings a job database from version-1 to version. A backend
+// with nothing to change for a step leaves its function nil.
+type dbMigration struct {
+	version int
+	what    string
+	bolt    func(tx *bolt.Tx) error
+	sqlite  func(tx *sql.Tx) error
+}
+
+var dbMigrations = []dbMigration{
+	{
+		version: 1,
+		what:    "job records keyed by job ID",
+		bolt: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(boltJobsBucket)
+			return err
+		},
+		sqlite: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`CREATE TABLE IF NOT EXISTS jobs (id INTEGER PRIMARY KEY, record TEXT NOT NULL)`)
+			return err
+		},
+	},
+	{
+		version: 2,
+		what:    "index jobs by student for reports",
+		sqlite: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`CREATE INDEX IF NOT EXISTS jobs_student ON jobs (record->>'student')`)
+			return err
+		},
+	},
+}
+
+var (
+	boltMetaBucket    = []byte("meta")
+	boltSchemaVersion = []byte("schema_version")
+)
+
+// errNewerDB is returned for a database written by a newer bridge.
+var errNewerDB = errors.New("the job database was written by a newer version of the bridge; upgrade the bridge, or restore a .bak file made before that upgrade")
+
+// migrateDB runs the steps after version from. backup copies the database
+// to the file it is given; step runs one migration and records its version.
+func migrateDB(path string, from int, backup func(dst string) error, step func(m dbMigration) error) error {
+	if from > dbSchemaVersion {
+		return fmt.Errorf("store: %s: %w (version %d, this bridge knows %d)", path, errNewerDB, from, dbSchemaVersion)
+	}
+	if from == dbSchemaVersion {
+		return nil
+	}
+	if from > 0 {
+		dst := fmt.Sprintf("%s.v%d-%s.bak", path, from, time.Now().Format("20060102-150405"))
+		if err := backup(dst); err != nil {
+			return fmt.Errorf("store: back up %s before upgrading it: %w", path, err)
+		}
+		log.Printf("job store: upgrading %s from version %d to %d; the old database is saved as %s", path, from, dbSchemaVersion, dst)
+	}
+	for _, m := range dbMigrations {
+		if m.version <= from {
+			continue
+		}
+		if err := step(m); err != nil {
+			return fmt.Errorf("store: %s: upgrade to version %d (%s): %w", path, m.version, m.what, err)
+		}
+	}
+	return nil
+}
+
+// migrateBolt brings a bbolt job database up to dbSchemaVersion.
+func migrateBolt(db *bolt.DB, path string) error {
+	from := 0
+	err := db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(boltMetaBucket); meta != nil {
+			if v := meta.Get(boltSchemaVersion); len(v) == 8 {
+				from = int(binary.BigEndian.Uint64(v))
+			}
+		} else if tx.Bucket(boltJobsBucket) != nil {
+			from = 1
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("store: %s: %w", path, err)
+	}
+	return migrateDB(path, from,
+		func(dst string) error {
+			return db.View(func(tx *bolt.Tx) error { return tx.CopyFile(dst, 0o600) })
+		},
+		func(m dbMigration) error {
+			return db.Update(func(tx *bolt.Tx) error {
+				if m.bolt != nil {
+					if err := m.bolt(tx); err != nil {
+						return err
+					}
+				}
+				meta, err := tx.CreateBucketIfNotExists(boltMetaBucket)
+				if err != nil {
+					return err
+				}
+				return meta.Put(boltSchemaVersion, boltKey(m.version))
+			})
+		})
+}
+
+// migrateSQLite brings an SQLite job database up to dbSchemaVersion.
+func migrateSQLite(db *sql.DB, path string) error {
+	var from, tables int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&from); err != nil {
+		return fmt.Errorf("store: %s: %w", path, err)
+	}
+	if from == 0 {
+		err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'jobs'`).Scan(&tables)
+		if err != nil {
+			return fmt.Errorf("store: %s: %w", path, err)
+		}
+		if tables > 0 {
+			from = 1
+		}
+	}
+	return migrateDB(path, from,
+		func(dst string) error {
+			_, err := db.Exec(`VACUUM INTO ?`, dst)
+			return err
+		},
+		func(m dbMigration) error {
+			tx, err := db.Begin()
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+			if m.sqlite != nil {
+				if err := m.sqlite(tx); err != nil {
+					return err
+				}
+			}
+			// PRAGMA takes no parameters; the version is a constant int.
+			if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, m.version)); err != nil {
+				return err
+			}
+			return tx.Commit()
+		})
+}
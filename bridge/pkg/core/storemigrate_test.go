@@ -0,0 +1,145 @@
+package core
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestStoreMigrations(t *testing.T) {
+	// setVersion rewrites the recorded layout version; 1 makes the file
+	// look like one from before versions were recorded.
+	setVersion := func(db recordDB, v int) {
+		var err error
+		switch d := db.(type) {
+		case *boltDB:
+			err = d.db.Update(func(tx *bolt.Tx) error {
+				if v == 1 {
+					return tx.DeleteBucket(boltMetaBucket)
+				}
+				return tx.Bucket(boltMetaBucket).Put(boltSchemaVersion, boltKey(v))
+			})
+		case *sqliteDB:
+			if v == 1 {
+				_, err = d.db.Exec(`DROP INDEX jobs_student; PRAGMA user_version = 0`)
+			} else {
+				_, err = d.db.Exec(`PRAGMA user_version = 99`)
+			}
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+	}
+	for _, backend := range []string{BackendBolt, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			dir := t.TempDir()
+			c := &StoreConfig{Backend: backend, Path: filepath.Join(dir, "jobs")}
+			s, err := OpenStore(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Append(JobEvent{Printer: "Index", Status: StatusDone, Student: "S1"})
+			setVersion(s.(*PersistentStore).db, 1)
+
+			for range 2 { // the second open finds nothing to do
+				if s, err = OpenStore(c); err != nil {
+					t.Fatal(err)
+				}
+				if e, ok := s.Get(1); !ok || e.Student != "S1" {
+					t.Errorf("job after upgrade: %+v, %v", e, ok)
+				}
+				s.(*PersistentStore).Close()
+			}
+			if v, index := layout(t, backend, c.Path); v != dbSchemaVersion || index != (backend == BackendSQLite) {
+				t.Errorf("upgraded to version %d, student index %v", v, index)
+			}
+			baks, _ := filepath.Glob(filepath.Join(dir, "jobs.v1-*.bak"))
+			if len(baks) != 1 {
+				t.Fatalf("backups %q, want one", baks)
+			}
+			// Going back to the old bridge means restoring the backup,
+			// which must still have the version 1 layout.
+			if v, index := layout(t, backend, baks[0]); v != 1 || index {
+				t.Errorf("backup is version %d, student index %v", v, index)
+			}
+
+			s, _ = OpenStore(c)
+			setVersion(s.(*PersistentStore).db, 99)
+			if _, err := OpenStore(c); !errors.Is(err, errNewerDB) {
+				t.Errorf("opening a newer database: %v", err)
+			}
+		})
+	}
+}
+
+// layout reports the version recorded in the job database at path and
+// whether it has the SQLite student index.
+func layout(t *testing.T, backend, path string) (version int, index bool) {
+	t.Helper()
+	switch backend {
+	case BackendBolt:
+		db, err := bolt.Open(path, 0o600, &bolt.Options{ReadOnly: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		db.View(func(tx *bolt.Tx) error {
+			version = 1
+			if meta := tx.Bucket(boltMetaBucket); meta != nil {
+				version = int(binary.BigEndian.Uint64(meta.Get(boltSchemaVersion)))
+			}
+			return nil
+		})
+	case BackendSQLite:
+		db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		var indexes int
+		if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'index' AND name = 'jobs_student'`).Scan(&indexes)
+		version, index = max(version, 1), indexes > 0
+	}
+	return version, index
+}
+
+func TestStoredNamesPseudonymizedOnOpen(t *testing.T) {
+	studentKey.Do(func() { studentKey.key = bytes.Repeat([]byte{1}, 32) })
+	old := Cfg.Load()
+	defer Cfg.Store(old)
+	for _, backend := range []string{BackendBolt, BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			Cfg.Store(&Config{})
+			c := &StoreConfig{Backend: backend, Path: filepath.Join(t.TempDir(), "jobs")}
+			s, err := OpenStore(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Append(JobEvent{Printer: "Index", Status: StatusDone, Student: "Maya Lopez"})
+			s.(*PersistentStore).Close()
+
+			// The district turns anonymizing on after a term of history.
+			Cfg.Store(&Config{AnonymizeStudents: true})
+			if s, err = OpenStore(c); err != nil {
+				t.Fatal(err)
+			}
+			if e, _ := s.Get(1); e.Student != Pseudonym("Maya Lopez") {
+				t.Errorf("reopened with student %q", e.Student)
+			}
+			s.(*PersistentStore).Close()
+			if raw, _ := os.ReadFile(c.Path); backend == BackendSQLite && bytes.Contains(raw, []byte("Maya")) {
+				t.Error("the name is still in the database file")
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite" // pure Go, so release builds need no C toolchain
+)
+
+// ---------------------------------------------------------------------------
+// SQLite job store
+//
+// One table, jobs(id, record), with each record as JSON. Schools can read
+// it with the sqlite3 shell or a reporting tool while the bridge runs:
+//
+//	SELECT id, record->>'printer', record->>'status' FROM jobs;
+//
+// The database runs in WAL mode so those readers never block the bridge,
+// and has an index on the student for per-student reports (the pseudonym
+// when anonymizing, see storedb.go). Deleted and rewritten records are
+// zeroed on disk (secure_delete), so a purged or pseudonymized student's
+// name does not linger in free pages.
+// ---------------------------------------------------------------------------
+
+type sqliteDB struct {
+	db *sql.DB
+}
+
+func openSQLiteDB(path string) (*sqliteDB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=secure_delete(on)")
+	if err != nil {
+		return nil, fmt.Errorf("store: open %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+	if err := migrateSQLite(db, path); err != nil { // storemigrate.go
+		db.Close()
+		return nil, err
+	}
+	return &sqliteDB{db: db}, nil
+}
+
+func (s *sqliteDB) load() ([]JobEvent, error) {
+	rows, err := s.db.Query(`SELECT id, record FROM jobs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var jobs []JobEvent
+	for rows.Next() {
+		var id int
+		var record []byte
+		if err := rows.Scan(&id, &record); err != nil {
+			return nil, err
+		}
+		e, err := DecodeJob(record)
+		if err != nil {
+			return nil, fmt.Errorf("job %d: %w", id, err)
+		}
+		jobs = append(jobs, e)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *sqliteDB) save(e JobEvent) error {
+	data, err := EncodeJob(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO jobs (id, record) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET record = excluded.record`, e.ID, string(data))
+	return err
+}
+
+func (s *sqliteDB) remove(ids []int) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		if _, err := tx.Exec(`DELETE FROM jobs WHERE id = ?`, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqliteDB) pruneBefore(id int) error {
+	_, err := s.db.Exec(`DELETE FROM jobs WHERE id < ?`, id)
+	return err
+}
+
+func (s *sqliteDB) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ---------------------------------------------------------------------------
+// Shared dashboard state
+//
+// A classroom often has the dashboard open in two places at once, say the
+// teacher station and the smartboard. The selected printer, the job log's
+// student filter and whether the job queue is paused are kept by the
+// bridge rather than in each tab, so both stay consistent: every change is
+// pushed to all open dashboards as a "ui" event on /log-stream, and a tab
+// that connects receives the current state after the job replay.
+//
+//	GET  /ui-state     → {"printer", "student_filter", "paused", "default_printer"}
+//	POST /ui-state     → {"printer": "…"} and/or {"student_filter": "…"}
+//	POST /queue/pause  → stop sending queued jobs
+//	POST /queue/resume → send them again
+//
+// While the queue is paused jobs are still accepted and queued; none is
+// sent until it is resumed. The state lives in ui-state.json in the data
+// directory, so a pause survives a restart. Changing any of it needs
+// print scope; a read-only guest or kiosk follows what others choose.
+// ---------------------------------------------------------------------------
+
+// UIState is the dashboard state shared by every open dashboard.
+type UIState struct {
+	Printer       string `json:"printer"`        // selected printer
+	StudentFilter string `json:"student_filter"` // job log filter
+	Paused        bool   `json:"paused"`         // no queued jobs are sent
+
+	// DefaultPrinter takes requests that name no printer (defaultprinter.go).
+	DefaultPrinter string `json:"default_printer"`
+}
+
+var uiState = struct {
+	sync.Mutex
+	state  UIState
+	loaded bool
+}{}
+
+func UIStatePath() string { return filepath.Join(DataDir(), "ui-state.json") }
+
+// CurrentUIState returns the shared state, reading it from disk the first
+// time.
+func CurrentUIState() UIState {
+	uiState.Lock()
+	defer uiState.Unlock()
+	loadUIStateLocked()
+	return uiState.state
+}
+
+// queuePaused reports whether the dashboard has paused the job queue.
+func queuePaused() bool {
+	return CurrentUIState().Paused
+}
+
+func loadUIStateLocked() {
+	if uiState.loaded {
+		return
+	}
+	uiState.loaded = true
+	data, err := os.ReadFile(UIStatePath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("ui state: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &uiState.state); err != nil {
+		log.Printf("ui state: %s: %v", UIStatePath(), err)
+	}
+}
+
+// ReloadUIState makes the next use reread ui-state.json, after a restore
+// has replaced it.
+func ReloadUIState() {
+	uiState.Lock()
+	uiState.loaded = false
+	uiState.Unlock()
+}
+
+// UpdateUIState applies fn to the shared state, saves it and pushes it to
+// every open dashboard.
+func UpdateUIState(fn func(*UIState)) UIState {
+	uiState.Lock()
+	loadUIStateLocked()
+	fn(&uiState.state)
+	s := uiState.state
+	saveUIStateLocked()
+	uiState.Unlock()
+
+	Store.Publish(StreamEvent{Name: "ui", Data: s})
+	return s
+}
+
+// saveUIStateLocked writes the state via a temporary file, as the paper
+// counts are. uiState must be locked.
+func saveUIStateLocked() {
+	data, _ := json.MarshalIndent(uiState.state, "", "  ")
+	path := UIStatePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		log.Printf("ui state: %v", err)
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		log.Printf("ui state: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		log.Printf("ui state: %v", err)
+	}
+}
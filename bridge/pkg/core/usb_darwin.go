@@ -0,0 +1,137 @@
+//go:build darwin
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// macOS has no device nodes for USB printers. The CUPS usb backend finds
+// printer-class interfaces through IOKit: run with no arguments it lists
+// them with their device URIs, and run with DEVICE_URI set it sends a job
+// to one. The bridge uses it for both, so an embosser can be printed to as
+// usb:usb://ViewPlus/Columbia?serial=… without a CUPS queue. The backend
+// runs as the logged-in user, which IOKit allows for USB printers.
+
+const cupsUSBBackend = "/usr/libexec/cups/backend/usb"
+
+// attachedUSB lists USB printers and the CUPS queues that use them.
+func attachedUSB(ctx context.Context) ([]usbDevice, error) {
+	devices, err := backendUSBDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "lpstat", "-v")
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	out, _ := cmd.Output() // no queues at all is an error for lpstat
+	queues := parseLpstatV(string(out))
+
+	var found []usbDevice
+	for _, d := range devices {
+		found = append(found, usbDevice{
+			MakeModel: d.MakeModel,
+			Dest:      usbPrefix + d.URI,
+			Queue:     queues[d.URI],
+			Setup: fmt.Sprintf("This embosser has no print queue on this Mac. The bridge can print to it directly, "+
+				"or add a queue in Terminal with: lpadmin -p %s -E -v '%s' -m raw", suggestedQueueName(d.MakeModel), d.URI),
+			DeviceID: d.DeviceID,
+		})
+	}
+	return found, nil
+}
+
+// backendUSBDevices runs the usb backend's device listing.
+func backendUSBDevices(ctx context.Context) ([]backendDevice, error) {
+	out, err := exec.CommandContext(ctx, cupsUSBBackend).Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cupsUSBBackend, err)
+	}
+	var devices []backendDevice
+	for _, d := range parseBackendDevices(string(out)) {
+		if strings.HasPrefix(d.URI, "usb://") {
+			devices = append(devices, d)
+		}
+	}
+	return devices, nil
+}
+
+var queueNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// suggestedQueueName turns a make and model into a CUPS queue name.
+func suggestedQueueName(makeModel string) string {
+	if name := strings.Trim(queueNameUnsafe.ReplaceAllString(makeModel, "_"), "_"); name != "" {
+		return name
+	}
+	return "Embosser"
+}
+
+// sendUSB sends a job to a usb:// device URI through the CUPS usb backend,
+// or writes it to a device node for any other path.
+func sendUSB(ctx context.Context, job *PrintJob, path string) error {
+	if !strings.HasPrefix(path, "usb://") {
+		return sendDevice(ctx, job, path)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, cupsUSBBackend,
+		strconv.Itoa(job.ID), os.Getenv("USER"), "Graham Bridge Job", "1", "")
+	cmd.Env = append(os.Environ(), "DEVICE_URI="+path)
+	cmd.Stdin = r
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return fmt.Errorf("start %s: %w", cupsUSBBackend, err)
+	}
+	r.Close()
+	werr := writeChunked(ctx, job, w, ProfileFor(job.Printer), nil)
+	w.Close()
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("usb backend: %w", ctx.Err())
+		}
+		return backendFailure(stderr.String(), err)
+	}
+	return werr
+}
+
+// backendFailure reports the last ERROR: line a backend wrote.
+func backendFailure(stderr string, runErr error) error {
+	msg := ""
+	eachLine(stderr, func(line string) {
+		if m, ok := strings.CutPrefix(line, "ERROR: "); ok {
+			msg = m
+		}
+	})
+	if msg == "" {
+		return fmt.Errorf("usb backend: %w", runErr)
+	}
+	return Classified(ErrCodeDevice, fmt.Errorf("usb backend: %s", msg))
+}
+
+// checkUSB confirms a usb:// device is attached, or a device node exists.
+func checkUSB(ctx context.Context, path string) error {
+	if !strings.HasPrefix(path, "usb://") {
+		return checkDevice(path)
+	}
+	devices, err := backendUSBDevices(ctx)
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		if d.URI == path {
+			return nil
+		}
+	}
+	return Classified(ErrCodeDevice, fmt.Errorf("USB device %s is not attached", path))
+}
@@ -0,0 +1,120 @@
+//go:build linux
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// The kernel's usblp driver gives each USB printer a /dev/usb/lpN node that
+// takes raw bytes, so kiosk images and Raspberry Pis without CUPS can print
+// to usb:/dev/usb/lp0 directly. The node is usually owned by the lp group;
+// permission failures say which group to join. When CUPS is installed its
+// usb backend detaches usblp while it prints, so the node can briefly
+// vanish or be busy.
+
+// usblpIDPath is where usblp publishes a device's IEEE 1284 device ID.
+const usblpIDPath = "/sys/class/usbmisc/%s/device/ieee1284_id"
+
+// attachedUSB lists /dev/usb/lp* nodes and the CUPS queues using them.
+func attachedUSB(ctx context.Context) ([]usbDevice, error) {
+	paths, err := filepath.Glob("/dev/usb/lp*")
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "lpstat", "-v")
+	cmd.Env = append(os.Environ(), "LC_ALL=C", "LANG=C")
+	out, _ := cmd.Output() // no CUPS, or no queues
+	queues := parseLpstatV(string(out))
+
+	var found []usbDevice
+	for _, path := range paths {
+		d := usbDevice{Dest: usbPrefix + path}
+		id, _ := os.ReadFile(fmt.Sprintf(usblpIDPath, filepath.Base(path)))
+		d.DeviceID = strings.TrimSpace(string(id))
+		mfg, mdl := deviceMakeModel(parseDeviceID(d.DeviceID))
+		d.MakeModel = strings.TrimSpace(mfg + " " + mdl)
+		d.Queue = queueForDevice(queues, mfg, mdl, path)
+		d.Setup = "No print queue uses this embosser; the bridge prints to " + path + " directly."
+		if err := deviceWritable(path); err != nil {
+			d.Setup += " " + err.Error()
+		}
+		found = append(found, d)
+	}
+	return found, nil
+}
+
+// queueForDevice finds the CUPS queue for a usblp device, either by the
+// usb://MFG/MDL URI CUPS's own usb backend uses or by its device path.
+func queueForDevice(queues map[string]string, mfg, mdl, path string) string {
+	prefix := "usb://" + url.PathEscape(mfg) + "/" + url.PathEscape(mdl)
+	for uri, q := range queues {
+		if mfg != "" && (uri == prefix || strings.HasPrefix(uri, prefix+"?")) {
+			return q
+		}
+		if strings.HasSuffix(uri, ":"+path) {
+			return q
+		}
+	}
+	return ""
+}
+
+// sendUSB writes a job to a usblp device node.
+func sendUSB(ctx context.Context, job *PrintJob, path string) error {
+	err := sendDevice(ctx, job, path)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, fs.ErrPermission):
+		return Classified(ErrCodePermission, fmt.Errorf("%w: %s", err, permissionHint(path)))
+	case errors.Is(err, syscall.ENODEV), errors.Is(err, syscall.EBUSY):
+		return Classified(ErrCodeDevice, err)
+	}
+	return err
+}
+
+// checkUSB confirms the device node exists and this account may write it.
+func checkUSB(_ context.Context, path string) error {
+	if err := checkDevice(path); err != nil {
+		return err
+	}
+	return deviceWritable(path)
+}
+
+// deviceWritable returns a permission_denied error if path is not writable.
+func deviceWritable(path string) error {
+	if err := unix.Access(path, unix.W_OK); err != nil {
+		return Classified(ErrCodePermission, fmt.Errorf("cannot write to %s: %s", path, permissionHint(path)))
+	}
+	return nil
+}
+
+// permissionHint says how to get write access to a device node.
+func permissionHint(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "check the device's permissions"
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || info.Mode().Perm()&0o020 == 0 {
+		return "ask IT to give this account write access to the device"
+	}
+	group := strconv.Itoa(int(st.Gid))
+	if g, err := user.LookupGroupId(group); err == nil {
+		group = g.Name
+	}
+	return fmt.Sprintf("it belongs to the %q group; add this account with \"sudo usermod -aG %s $USER\", then log out and back in", group, group)
+}
@@ -0,0 +1,20 @@
+//go:build !darwin && !linux
+
+package core
+
+import "context"
+
+// attachedUSB has no scanner on this platform.
+func attachedUSB(context.Context) ([]usbDevice, error) {
+	return nil, nil
+}
+
+// sendUSB writes a job to a USB device node.
+func sendUSB(ctx context.Context, job *PrintJob, path string) error {
+	return sendDevice(ctx, job, path)
+}
+
+// checkUSB is the pre-flight check for usb: destinations.
+func checkUSB(_ context.Context, path string) error {
+	return checkDevice(path)
+}
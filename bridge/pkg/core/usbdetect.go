@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Attached USB embossers
+//
+// An embosser plugged in over USB is no use to the web app until someone
+// adds a print queue for it. The bridge looks for USB printer-class devices
+// that no queue uses and lists them in GET /printers as usb: destinations
+// that print to the device directly. Devices that report a maker other
+// than a known embosser maker are left out. GET /printers?details=1 adds
+// the model and how to set up a queue, which the dashboard shows next to
+// the printer.
+//
+// Finding the devices is platform-specific (usb_darwin.go, usb_linux.go);
+// platforms without a scanner list none.
+// ---------------------------------------------------------------------------
+
+// usbDevice is a USB printer-class device attached to this machine.
+type usbDevice struct {
+	MakeModel string // as the device reports it, e.g. "ViewPlus Columbia"
+	Dest      string // usb: destination that prints to it directly
+	Queue     string // print queue already using it, if any
+	Setup     string // how to add a print queue for it
+	DeviceID  string // IEEE 1284 device ID, if it gave one
+}
+
+// embosserMakers are matched against the start of a device's make and
+// model to pick embossers out of other USB printers.
+var embosserMakers = []string{"viewplus", "index", "enabling", "braillo", "harpo"}
+
+func isEmbosser(makeModel string) bool {
+	lower := strings.ToLower(makeModel)
+	for _, m := range embosserMakers {
+		if strings.HasPrefix(lower, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// usbPrinters lists attached USB embossers that have no print queue and
+// are not already configured as destinations.
+func usbPrinters(ctx context.Context) []PrinterInfo {
+	devices, err := attachedUSB(ctx)
+	if err != nil {
+		log.Printf("USB printer scan: %v", err)
+		return nil
+	}
+	var out []PrinterInfo
+	for _, d := range devices {
+		if d.Queue != "" || (d.MakeModel != "" && !isEmbosser(d.MakeModel)) {
+			continue
+		}
+		if d.MakeModel == "" {
+			d.MakeModel = "USB printer"
+		}
+		if _, ok := CurrentConfig().Printers[d.Dest]; ok {
+			continue
+		}
+		out = append(out, PrinterInfo{Name: d.Dest, Model: d.MakeModel, Setup: d.Setup})
+	}
+	return out
+}
+
+// parseDeviceID splits an IEEE 1284 device ID ("MFG:Index;MDL:Everest-D
+// V5;…") into its fields, keyed by upper-case name.
+func parseDeviceID(id string) map[string]string {
+	fields := make(map[string]string)
+	for _, kv := range strings.Split(id, ";") {
+		k, v, ok := strings.Cut(kv, ":")
+		if ok {
+			fields[strings.ToUpper(strings.TrimSpace(k))] = strings.TrimSpace(v)
+		}
+	}
+	return fields
+}
+
+// deviceMakeModel returns the make and model from a parsed device ID,
+// accepting both the short and long field names.
+func deviceMakeModel(f map[string]string) (mfg, mdl string) {
+	mfg, mdl = f["MFG"], f["MDL"]
+	if mfg == "" {
+		mfg = f["MANUFACTURER"]
+	}
+	if mdl == "" {
+		mdl = f["MODEL"]
+	}
+	return mfg, mdl
+}
@@ -0,0 +1,16 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestParseDeviceID(t *testing.T) {
+	mfg, mdl := deviceMakeModel(parseDeviceID("MFG:Index;MDL:Everest-D V5;CMD:TEXT;"))
+	if mfg != "Index" || mdl != "Everest-D V5" {
+		t.Errorf("got %q %q", mfg, mdl)
+	}
+	mfg, mdl = deviceMakeModel(parseDeviceID("MANUFACTURER:ViewPlus; MODEL:Columbia;"))
+	if mfg != "ViewPlus" || mdl != "Columbia" {
+		t.Errorf("long field names: got %q %q", mfg, mdl)
+	}
+}
@@ -0,0 +1,32 @@
+package core
+
+import (
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Physical verification
+//
+// In a production room every embossed document is checked by hand (dots
+// crisp, pages complete and in order) before it goes out to a student. A
+// finished job is marked as checked with
+//
+//	POST /jobs/{id}/verify    → the job, with "verified": {"time", "by"}
+//	POST /jobs/{id}/unverify  → clears it, e.g. after a mistaken click
+//
+// and GET /jobs?verified=no lists the embossed jobs still waiting for a
+// check (?verified=yes those already done). Only jobs that embossed
+// successfully can be verified; a failed one is resent, and its resend is
+// what gets checked. The mark is kept with the job history.
+// ---------------------------------------------------------------------------
+
+// Verification records who checked a job's output, and when.
+type Verification struct {
+	Time time.Time `json:"time"`
+	By   string    `json:"by"` // caller, as named in the audit log
+}
+
+// needsVerification reports whether e came out and has not been checked.
+func needsVerification(e JobEvent) bool {
+	return e.Status == StatusDone && e.Verified == nil
+}
@@ -0,0 +1,154 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Chat webhooks
+//
+// Many AT teams coordinate in Slack or Microsoft Teams rather than email.
+// Each entry in "webhooks" posts notifications to an incoming webhook URL,
+// formatted for the chat service:
+//
+//	"webhooks": [
+//	  {"url": "https://hooks.slack.com/services/…", "format": "slack"},
+//	  {"url": "https://….logic.azure.com/workflows/…", "format": "teams",
+//	   "events": ["printer_offline"]}
+//	]
+//
+// The events (notify.go) are
+//
+//	job_failed         failed jobs, batched like the failure email
+//	printer_offline    the health watchdog saw a printer go offline or come back
+//	printer_attention  the watchdog saw a paper jam, paper out, open cover or error
+//	batch_done         a printer's queue emptied after a long run of jobs
+//
+// and a webhook without "events" gets all of them. Slack gets a mrkdwn
+// "text" message; Teams gets an Adaptive Card, which Teams Workflows
+// webhooks (the replacement for Office 365 connectors) accept. A webhook
+// that fails is logged and not retried.
+// ---------------------------------------------------------------------------
+
+const webhookTimeout = 10 * time.Second
+
+// WebhookConfig is one chat webhook.
+type WebhookConfig struct {
+	URL    string   `json:"url"`
+	Format string   `json:"format"`           // "slack" or "teams"
+	Events []string `json:"events,omitempty"` // default all
+}
+
+func (h WebhookConfig) validate() error {
+	u, err := url.Parse(h.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("webhooks: url must be an https:// address")
+	}
+	if h.Format != "slack" && h.Format != "teams" {
+		return fmt.Errorf("webhooks: format must be slack or teams, not %q", h.Format)
+	}
+	for _, ev := range h.Events {
+		if !slices.Contains(notifyEvents, ev) {
+			return fmt.Errorf("webhooks: unknown event %q", ev)
+		}
+	}
+	return nil
+}
+
+// wants reports whether the webhook takes event.
+func (h WebhookConfig) wants(event string) bool {
+	return len(h.Events) == 0 || slices.Contains(h.Events, event)
+}
+
+// postWebhooks sends a notification to every webhook that takes event.
+func postWebhooks(event, title, body string) {
+	for _, h := range CurrentConfig().Webhooks {
+		if !h.wants(event) {
+			continue
+		}
+		go func() {
+			if err := sendWebhook(h, title, body); err != nil {
+				log.Printf("webhook %s: %v", redactURL(h.URL), err)
+			}
+		}()
+	}
+}
+
+// webhookPayload formats a notification for a chat service.
+func webhookPayload(format, title, body string) any {
+	host := HostnameOr("this computer")
+	if format == "teams" {
+		text := func(s string, bold bool) map[string]any {
+			b := map[string]any{"type": "TextBlock", "text": s, "wrap": true}
+			if bold {
+				b["weight"] = "Bolder"
+			}
+			return b
+		}
+		return map[string]any{
+			"type": "message",
+			"attachments": []any{map[string]any{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"type":    "AdaptiveCard",
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"version": "1.4",
+					"body": []any{
+						text(title, true),
+						text(strings.TrimSpace(body), false),
+						map[string]any{"type": "TextBlock", "text": "Graham Bridge on " + host, "isSubtle": true, "size": "Small"},
+					},
+				},
+			}},
+		}
+	}
+	return map[string]string{
+		"text": "*" + title + "*\n" + strings.TrimSpace(body) + "\n_Graham Bridge on " + host + "_",
+	}
+}
+
+// sendWebhook posts one notification.
+func sendWebhook(h WebhookConfig, title, body string) error {
+	data, err := json.Marshal(webhookPayload(h.Format, title, body))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "graham-bridge/"+BridgeVersion)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("answered %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// redactURL drops the path of a webhook URL for logs; it holds the secret.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	return u.Scheme + "://" + u.Host + "/…"
+}
@@ -0,0 +1,25 @@
+package core
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWebhookPayload(t *testing.T) {
+	slack, _ := json.Marshal(webhookPayload("slack", "Print job #4 failed on Index", "Out of paper.\n"))
+	if !strings.Contains(string(slack), `"text":"*Print job #4 failed on Index*\nOut of paper.`) {
+		t.Errorf("slack payload %s", slack)
+	}
+	teams, _ := json.Marshal(webhookPayload("teams", "Printer Index is offline", "Check the cable."))
+	if !strings.Contains(string(teams), "application/vnd.microsoft.card.adaptive") || !strings.Contains(string(teams), "Check the cable.") {
+		t.Errorf("teams payload %s", teams)
+	}
+	if (WebhookConfig{URL: "http://hooks.slack.com/x", Format: "slack"}).validate() == nil {
+		t.Error("plain http webhook accepted")
+	}
+	h := WebhookConfig{URL: "https://hooks.slack.com/x", Format: "teams", Events: []string{eventPrinterOffline}}
+	if h.validate() != nil || h.wants(eventJobFailed) || !h.wants(eventPrinterOffline) {
+		t.Error("events filter wrong")
+	}
+}
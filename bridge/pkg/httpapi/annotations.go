@@ -0,0 +1,63 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Job annotations
+//
+// POST /jobs/{id}/annotations. The notes themselves live on the job
+// record; see pkg/core/annotations.go.
+// ---------------------------------------------------------------------------
+
+// handleJobAnnotate serves POST /jobs/{id}/annotations.
+func (h jobsAPI) handleJobAnnotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	src, ok := h.jobFromPath(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8192)).Decode(&req); err != nil {
+		http.Error(w, `body must be {"text": "…"}`, http.StatusBadRequest)
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" || len([]rune(text)) > core.MaxAnnotationLen {
+		http.Error(w, fmt.Sprintf("an annotation needs between 1 and %d characters", core.MaxAnnotationLen), http.StatusBadRequest)
+		return
+	}
+	a := core.Annotation{Time: time.Now().UTC(), By: identify(r), Text: text}
+	var refused string
+	e, _ := h.store.Update(src.ID, func(e *core.JobEvent) {
+		switch {
+		case !core.FinishedStatus(e.Status):
+			refused = fmt.Sprintf("job %d is %s; annotate it once it has finished", e.ID, e.Status)
+		case len(e.Annotations) >= core.MaxAnnotations:
+			refused = fmt.Sprintf("job %d already has %d annotations", e.ID, core.MaxAnnotations)
+		default:
+			e.Annotations = append(e.Annotations, a)
+		}
+	})
+	if refused != "" {
+		http.Error(w, refused, http.StatusConflict)
+		return
+	}
+	log.Printf("job %d annotated by %s", e.ID, a.By)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestJobAnnotations(t *testing.T) {
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	core.Cfg.Store(&core.Config{})
+	s := core.NewMemoryStore(10)
+	done := s.Append(core.JobEvent{Printer: "Tiger", Status: core.StatusDone, Time: time.Now()})
+	queued := s.Append(core.JobEvent{Printer: "Tiger", Status: core.StatusQueued, Time: time.Now()})
+
+	annotate := func(id int, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/jobs/x/annotations", strings.NewReader(body))
+		r.SetPathValue("id", strconv.Itoa(id))
+		jobsAPI{store: s}.handleJobAnnotate(rec, r)
+		return rec
+	}
+	if rec := annotate(done.ID, `{"text": " page 3 had weak dots, re-ran "}`); rec.Code != http.StatusCreated {
+		t.Fatalf("annotate: %d %s", rec.Code, rec.Body)
+	}
+	if rec := annotate(queued.ID, `{"text": "too early"}`); rec.Code != http.StatusConflict {
+		t.Errorf("annotating a queued job: %d", rec.Code)
+	}
+	if rec := annotate(done.ID, `{"text": ""}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty annotation: %d", rec.Code)
+	}
+	e, _ := s.Get(done.ID)
+	if len(e.Annotations) != 1 || e.Annotations[0].Text != "page 3 had weak dots, re-ran" || e.Annotations[0].By != "anonymous" {
+		t.Errorf("annotations = %+v", e.Annotations)
+	}
+}
@@ -0,0 +1,75 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Client applications
+//
+// Which app a token belongs to, and GET /apps. Quotas and usage counts
+// are in pkg/core/apps.go.
+// ---------------------------------------------------------------------------
+
+// appPrincipal looks tok up among the applications' tokens.
+func appPrincipal(c *core.Config, tok string) (principal, bool) {
+	for name, a := range c.Apps {
+		for _, t := range a.Tokens {
+			if !secretEqual(tok, t.Token) {
+				continue
+			}
+			p := principal{Name: "token:" + name + "/" + t.Name, Scope: t.Scope, Hold: t.Hold, App: name}
+			if a.Revoked {
+				// Named for the audit log, but without a scope it is refused.
+				p.Name += " (revoked)"
+				p.Scope = ""
+			}
+			return p, true
+		}
+	}
+	return principal{}, false
+}
+
+// callerApp returns the application the request's token belongs to, or
+// "" for callers that see every application's jobs.
+func callerApp(r *http.Request) string {
+	p, _ := authenticate(r)
+	return p.App
+}
+
+// appInfo is one application in GET /apps. Token secrets are not shown.
+type appInfo struct {
+	Name       string        `json:"name"`
+	Tokens     []string      `json:"tokens"`
+	Revoked    bool          `json:"revoked"`
+	DailyJobs  int           `json:"daily_jobs,omitempty"`
+	DailyPages int           `json:"daily_pages,omitempty"`
+	Today      core.AppUsage `json:"today"`
+}
+
+// handleApps serves GET /apps.
+func (h jobsAPI) handleApps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	c := core.CurrentConfig()
+	list := make([]appInfo, 0, len(c.Apps))
+	for name, a := range c.Apps {
+		info := appInfo{Name: name, Tokens: []string{}, Revoked: a.Revoked, DailyJobs: a.DailyJobs, DailyPages: a.DailyPages,
+			Today: core.AppUsageToday(h.store, name, time.Now())}
+		for _, t := range a.Tokens {
+			info.Tokens = append(info.Tokens, t.Name)
+		}
+		list = append(list, info)
+	}
+	slices.SortFunc(list, func(a, b appInfo) int { return strings.Compare(a.Name, b.Name) })
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
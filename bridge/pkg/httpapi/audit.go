@@ -0,0 +1,181 @@
+package httpapi
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Audit log
+//
+// Every API call is appended as one JSON line to audit.log in the bridge
+// data directory (override with "audit_log" in the config), recording who
+// called what and with what outcome. Districts use it to answer "who printed
+// what" on shared equipment. The file is only ever appended to, except by
+// DELETE /jobs (purge.go); GET /audit exports it.
+// ---------------------------------------------------------------------------
+
+// AuditEntry is one line of the audit log.
+type AuditEntry struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	Identity   string    `json:"identity"` // who made the call (see identify)
+	RemoteIP   string    `json:"remote_ip"`
+	Origin     string    `json:"origin,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+
+	// RequestID matches the bridge log and any jobs made (requestid.go).
+	RequestID string `json:"request_id,omitempty"`
+}
+
+var audit = core.AppendLog{Name: "audit", Path: auditPath}
+
+// auditPath returns the configured audit log location.
+func auditPath() string {
+	if p := core.CurrentConfig().AuditLog; p != "" {
+		return p
+	}
+	return filepath.Join(core.DataDir(), "audit.log")
+}
+
+// statusRecorder captures the response status for the audit log while
+// still letting SSE handlers flush.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if s.status == 0 {
+		s.status = code
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// WithAudit records every request except the web app's frequent /status
+// health-check polls.
+func WithAudit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/status" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		audit.Record(AuditEntry{
+			Time:       start,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     rec.status,
+			Identity:   identify(r),
+			RemoteIP:   ip,
+			Origin:     r.Header.Get("Origin"),
+			DurationMS: time.Since(start).Milliseconds(),
+			RequestID:  requestID(r.Context()),
+		})
+	})
+}
+
+// identify names the caller for the audit log.
+func identify(r *http.Request) string {
+	p, _ := authenticate(r)
+	return p.Name
+}
+
+// handleAuditExport streams the audit log. Query parameters:
+//
+//	since=<RFC 3339 time>  only entries at or after this time
+//	format=csv             CSV instead of JSON Lines
+func handleAuditExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var since time.Time
+	if s := r.URL.Query().Get("since"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, "since must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		since = t
+	}
+
+	f, err := os.Open(auditPath())
+	if os.IsNotExist(err) {
+		f = nil
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("open audit log: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	asCSV := r.URL.Query().Get("format") == "csv"
+	var cw *csv.Writer
+	if asCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="graham-bridge-audit.csv"`)
+		cw = csv.NewWriter(w)
+		cw.Write([]string{"time", "method", "path", "status", "identity", "remote_ip", "origin", "duration_ms", "request_id"})
+		defer cw.Flush()
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	if f == nil {
+		return
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil || e.Time.Before(since) {
+			continue
+		}
+		if asCSV {
+			cw.Write([]string{
+				e.Time.Format(time.RFC3339), e.Method, e.Path, strconv.Itoa(e.Status),
+				e.Identity, e.RemoteIP, e.Origin, strconv.FormatInt(e.DurationMS, 10), e.RequestID,
+			})
+		} else {
+			w.Write(sc.Bytes())
+			w.Write([]byte{'\n'})
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestAuditExportCSV(t *testing.T) {
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	path := filepath.Join(t.TempDir(), "audit.log")
+	core.Cfg.Store(&core.Config{AuditLog: path})
+	line := `{"time":"2026-03-02T09:00:00Z","method":"POST","path":"/print","status":200,"identity":"token:lab","remote_ip":"10.0.0.7","duration_ms":40,"request_id":"r-17"}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	handleAuditExport(rec, httptest.NewRequest("GET", "/audit?format=csv", nil))
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil || len(rows) != 2 {
+		t.Fatalf("rows = %v, %v", rows, err)
+	}
+	col := slices.Index(rows[0], "request_id")
+	if col < 0 || rows[1][col] != "r-17" {
+		t.Errorf("request_id column: header %v, row %v", rows[0], rows[1])
+	}
+}
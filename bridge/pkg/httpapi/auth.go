@@ -0,0 +1,222 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Authentication and token scopes
+//
+// Callers authenticate with one of:
+//
+//	Authorization: Bearer <token>  (or X-Bridge-Token: <token>)
+//	Authorization: Basic <any user>:<dashboard_password>
+//	a session cookie issued after a successful Basic login, or a read-only
+//	one for a kiosk display opened with a read token (kiosk.go) or a
+//	guest's link (guests.go)
+//
+// Tokens come from the config's "tokens" list or an application's tokens
+// (apps.go), each with a scope, or are read-only guest tokens; the
+// legacy "admin_token" and dashboard logins have admin scope. Scopes are
+// ordered read < print < admin: a print token can also read, and only an
+// admin can export the audit log or reload the config.
+//
+// Dashboard endpoints are enforced whenever any credential is configured.
+// The web app's endpoints (/print, /printers) are enforced only once API
+// tokens are configured, so existing installs keep working unchanged.
+// ---------------------------------------------------------------------------
+
+const (
+	sessionCookie = "graham_bridge_session"
+	sessionTTL    = 12 * time.Hour
+)
+
+// principal is an authenticated caller.
+type principal struct {
+	Name  string // shown in the audit log
+	Scope string
+	Hold  bool   // jobs wait for release (see APIToken.Hold)
+	App   string // application whose token this is (apps.go)
+}
+
+var anonymous = principal{Name: "anonymous"}
+
+var sessions = struct {
+	sync.Mutex
+	m map[string]session // by session ID
+}{m: make(map[string]session)}
+
+// session is a logged-in browser: the dashboard after a Basic login, or a
+// kiosk display (kiosk.go).
+type session struct {
+	p       principal
+	expires time.Time
+	// valid reports whether the password or token the session was opened
+	// with is still accepted. It is checked on every request, so changing
+	// the password or revoking a token or application in the config ends
+	// the sessions it opened rather than leaving a kiosk logged in for
+	// weeks.
+	valid func(c *core.Config) bool
+}
+
+// authRequired reports whether any credentials are configured.
+func authRequired() bool {
+	c := core.CurrentConfig()
+	return c.DashboardPassword != "" || c.AdminToken != "" || core.APITokensConfigured(c)
+}
+
+// authenticate identifies the caller from its token, Basic credentials or
+// session cookie. loggedIn is true for a fresh Basic login.
+func authenticate(r *http.Request) (p principal, loggedIn bool) {
+	c := core.CurrentConfig()
+	if tok := bearerToken(r); tok != "" {
+		return tokenPrincipal(c, tok), false
+	}
+	if p, ok := sessionPrincipal(r); ok {
+		return p, false
+	}
+	if _, pass, ok := r.BasicAuth(); ok && c.DashboardPassword != "" && secretEqual(pass, c.DashboardPassword) {
+		return principal{Name: "dashboard", Scope: core.ScopeAdmin}, true
+	}
+	return anonymous, false
+}
+
+// tokenPrincipal identifies the caller presenting API token tok.
+func tokenPrincipal(c *core.Config, tok string) principal {
+	if c.AdminToken != "" && secretEqual(tok, c.AdminToken) {
+		return principal{Name: "admin-token", Scope: core.ScopeAdmin}
+	}
+	for _, t := range c.Tokens {
+		if secretEqual(tok, t.Token) {
+			return principal{Name: "token:" + t.Name, Scope: t.Scope, Hold: t.Hold}
+		}
+	}
+	if p, ok := appPrincipal(c, tok); ok {
+		return p
+	}
+	if p, ok := guestPrincipal(tok); ok {
+		return p
+	}
+	return anonymous
+}
+
+// requireScope guards a dashboard or admin endpoint.
+func requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return guard(scope, authRequired, next)
+}
+
+// requireAPIScope guards a web-app endpoint; it is only enforced once API
+// tokens are configured.
+func requireAPIScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return guard(scope, func() bool { return core.APITokensConfigured(core.CurrentConfig()) }, next)
+}
+
+func guard(scope string, enforced func() bool, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !enforced() {
+			next(w, r)
+			return
+		}
+		p, loggedIn := authenticate(r)
+		if p.Scope == "" {
+			if core.CurrentConfig().DashboardPassword != "" && bearerToken(r) == "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="Graham Bridge", charset="UTF-8"`)
+			}
+			http.Error(w, "Unauthorized: invalid or missing bridge token", http.StatusUnauthorized)
+			return
+		}
+		if core.ScopeRank[p.Scope] < core.ScopeRank[scope] {
+			http.Error(w, "Forbidden: this token lacks the "+scope+" scope", http.StatusForbidden)
+			return
+		}
+		if loggedIn {
+			_, pass, _ := r.BasicAuth()
+			startSession(w, p, sessionTTL, func(c *core.Config) bool {
+				return c.DashboardPassword != "" && secretEqual(pass, c.DashboardPassword)
+			})
+		}
+		next(w, r)
+	}
+}
+
+// bearerToken extracts an API token from Authorization or X-Bridge-Token.
+func bearerToken(r *http.Request) string {
+	if tok := r.Header.Get("X-Bridge-Token"); tok != "" {
+		return tok
+	}
+	if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "bearer ") {
+		return strings.TrimSpace(h[7:])
+	}
+	return ""
+}
+
+func secretEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// startSession logs the browser in as p for ttl, for as long as valid
+// accepts the current config.
+func startSession(w http.ResponseWriter, p principal, ttl time.Duration, valid func(c *core.Config) bool) {
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+
+	sessions.Lock()
+	now := time.Now()
+	for k, s := range sessions.m {
+		if now.After(s.expires) {
+			delete(sessions.m, k)
+		}
+	}
+	sessions.m[id] = session{p: p, expires: now.Add(ttl), valid: valid}
+	sessions.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// sessionPrincipal returns who the request's session cookie belongs to.
+func sessionPrincipal(r *http.Request) (principal, bool) {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return principal{}, false
+	}
+	sessions.Lock()
+	s, ok := sessions.m[c.Value]
+	sessions.Unlock()
+	if !ok || !time.Now().Before(s.expires) {
+		return principal{}, false
+	}
+	if !s.valid(core.CurrentConfig()) {
+		sessions.Lock()
+		delete(sessions.m, c.Value)
+		sessions.Unlock()
+		return principal{}, false
+	}
+	return s.p, true
+}
+
+// endSessions logs out every session whose principal matches.
+func endSessions(match func(principal) bool) {
+	sessions.Lock()
+	defer sessions.Unlock()
+	for k, s := range sessions.m {
+		if match(s.p) {
+			delete(sessions.m, k)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package httpapi
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Backup and restore
+//
+// Moving a station to a new laptop over the summer means carrying over its
+// config (with the API tokens and printer profiles in it), the job history
+// and the bridge's own files. A backup is one gzipped tar bundle:
+//
+//	graham-bridge backup > bundle.tar.gz
+//	graham-bridge restore < bundle.tar.gz
+//
+//	manifest.json   bridge version, time and job count
+//	config.json     the config file
+//	jobs.json       the job history, whatever backend kept it, with
+//	                students as pseudonyms when anonymize_students is on
+//	data/…          paper counts, dashboard state, guest tokens, page
+//	                ledger, audit log, bridge ID and the student pseudonym key
+//
+// The dashboard does the same with GET /backup (a download) and
+// POST /restore (the bundle as the body), both admin only. A restore reads
+// and checks the whole bundle before changing anything, keeps the old
+// config as <config>.bak, replaces the job history and reloads the config.
+// The bridge ID, the pseudonym key and a change of store backend take
+// effect at the next restart; the response says when one is needed.
+//
+// The bundle holds every API token in the clear, so keep it as carefully
+// as the config file. It also holds the pseudonym key, with which anyone
+// who has the bundle could test a guessed student number against the
+// pseudonyms in it. Documents are not included (pkg/core/payload.go never
+// keeps them across restarts), and jobs that were still waiting in the
+// bundle come back failed, as after any restart.
+// ---------------------------------------------------------------------------
+
+const (
+	backupFormat = 1
+	// maxBackupBytes bounds a bundle uploaded to /restore and each file
+	// unpacked from one.
+	maxBackupBytes = 256 << 20
+)
+
+// backupManifest describes a bundle.
+type backupManifest struct {
+	App     string    `json:"app"`
+	Format  int       `json:"format"`
+	Version string    `json:"version"` // bridge that made it
+	Created time.Time `json:"created"`
+	Jobs    int       `json:"jobs"`
+}
+
+// backupFile is one of the bridge's files carried in a bundle.
+type backupFile struct {
+	name string        // path in the bundle
+	path func() string // where it lives on this machine
+	// apply installs the restored contents; nil writes them to path.
+	apply func(data []byte) error
+	// restart reports that the running bridge keeps using the old
+	// contents until it restarts.
+	restart bool
+}
+
+// backupFiles lists what a bundle carries besides the manifest and the
+// job history. The config comes first: the audit log path depends on it.
+func backupFiles() []backupFile {
+	return []backupFile{
+		{name: "config.json", path: func() string { return core.CfgPath }, apply: restoreConfig},
+		{name: "data/paper.json", path: core.PaperPath, apply: func(data []byte) error {
+			if err := core.WriteFileAtomic(core.PaperPath(), data); err != nil {
+				return err
+			}
+			core.ReloadPaper()
+			return nil
+		}},
+		{name: "data/ui-state.json", path: core.UIStatePath, apply: func(data []byte) error {
+			if err := core.WriteFileAtomic(core.UIStatePath(), data); err != nil {
+				return err
+			}
+			core.ReloadUIState()
+			return nil
+		}},
+		{name: "data/guests.json", path: guestsPath, apply: func(data []byte) error {
+			if err := core.WriteFileAtomic(guestsPath(), data); err != nil {
+				return err
+			}
+			guests.Lock()
+			guests.loaded = false
+			guests.Unlock()
+			return nil
+		}},
+		{name: "data/pages.log", path: core.PageLedger.Path, apply: core.PageLedger.Replace},
+		{name: "data/audit.log", path: auditPath, apply: audit.Replace},
+		{name: "data/bridge-id", path: func() string { return filepath.Join(core.DataDir(), "bridge-id") }, restart: true},
+		{name: "data/student.key", path: func() string { return filepath.Join(core.DataDir(), "student.key") }, restart: true},
+	}
+}
+
+// writeBackup writes a bundle of the current config, files and the job
+// history in s.
+func writeBackup(w io.Writer, s core.JobStore) error {
+	jobs := s.List()
+	records := make([]json.RawMessage, len(jobs))
+	for i, e := range jobs {
+		data, err := core.EncodeJob(e) // pseudonymized, as in a history database
+		if err != nil {
+			return fmt.Errorf("backup: job %d: %w", e.ID, err)
+		}
+		records[i] = data
+	}
+	jobsJSON, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+	manifest, _ := json.MarshalIndent(backupManifest{
+		App: "graham-bridge", Format: backupFormat, Version: core.BridgeVersion,
+		Created: time.Now().UTC(), Jobs: len(jobs),
+	}, "", "  ")
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: time.Now()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := add("manifest.json", manifest); err != nil {
+		return err
+	}
+	for _, f := range backupFiles() {
+		data, err := os.ReadFile(f.path())
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("backup: %w", err)
+		}
+		if err := add(f.name, data); err != nil {
+			return err
+		}
+	}
+	if err := add("jobs.json", jobsJSON); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// backupBundle is a bundle read into memory and checked.
+type backupBundle struct {
+	manifest backupManifest
+	files    map[string][]byte // by bundle path
+	jobs     []core.JobEvent   // nil if the bundle has no history
+}
+
+// readBackup reads and checks a bundle without applying any of it.
+func readBackup(r io.Reader) (*backupBundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a bridge backup (expected a .tar.gz): %w", err)
+	}
+	b := &backupBundle{files: map[string][]byte{}}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read backup: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxBackupBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("read backup: %s: %w", hdr.Name, err)
+		}
+		if len(data) > maxBackupBytes {
+			return nil, fmt.Errorf("read backup: %s is too large", hdr.Name)
+		}
+		b.files[hdr.Name] = data
+	}
+
+	manifest, ok := b.files["manifest.json"]
+	if !ok {
+		return nil, errors.New("not a bridge backup: no manifest.json")
+	}
+	if err := json.Unmarshal(manifest, &b.manifest); err != nil || b.manifest.App != "graham-bridge" {
+		return nil, errors.New("not a bridge backup: bad manifest.json")
+	}
+	if b.manifest.Format > backupFormat {
+		return nil, fmt.Errorf("this backup was made by bridge %s, which is newer than this one (%s); upgrade the bridge first",
+			b.manifest.Version, core.BridgeVersion)
+	}
+	if data, ok := b.files["config.json"]; ok {
+		var c core.Config
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("backup config.json: %w", err)
+		}
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("backup config.json: %w", err)
+		}
+	}
+	if data, ok := b.files["jobs.json"]; ok {
+		var records []json.RawMessage
+		if err := json.Unmarshal(data, &records); err != nil {
+			return nil, fmt.Errorf("backup jobs.json: %w", err)
+		}
+		b.jobs = make([]core.JobEvent, 0, len(records))
+		for i, rec := range records {
+			e, err := core.DecodeJob(rec)
+			if err != nil {
+				return nil, fmt.Errorf("backup jobs.json: record %d: %w", i, err)
+			}
+			core.MarkInterrupted(&e)
+			b.jobs = append(b.jobs, e)
+		}
+	}
+	return b, nil
+}
+
+// restoreResult is the /restore response.
+type restoreResult struct {
+	Status        string   `json:"status"` // "restored"
+	From          string   `json:"from"`   // version of the bridge that made the bundle
+	Files         []string `json:"files"`
+	Jobs          int      `json:"jobs"`
+	RestartNeeded bool     `json:"restart_needed"`
+}
+
+// restoreBackup applies a checked bundle. history returns the store the
+// jobs go into, called once the config is restored; it returns nil to skip
+// them.
+func restoreBackup(b *backupBundle, history func() (core.JobStore, error)) (restoreResult, error) {
+	res := restoreResult{Status: "restored", From: b.manifest.Version, Files: []string{}}
+	oldStore := core.CurrentConfig().Store
+	for _, f := range backupFiles() {
+		data, ok := b.files[f.name]
+		if !ok {
+			continue
+		}
+		if f.restart {
+			if cur, err := os.ReadFile(f.path()); err != nil || !bytes.Equal(cur, data) {
+				res.RestartNeeded = true
+			}
+		}
+		var err error
+		if f.apply != nil {
+			err = f.apply(data)
+		} else {
+			err = core.WriteFileAtomic(f.path(), data)
+		}
+		if err != nil {
+			return res, fmt.Errorf("restore %s: %w", f.name, err)
+		}
+		res.Files = append(res.Files, f.name)
+	}
+	if !sameStoreConfig(oldStore, core.CurrentConfig().Store) {
+		res.RestartNeeded = true
+	}
+	if b.jobs != nil {
+		s, err := history()
+		if err != nil {
+			return res, fmt.Errorf("restore job history: %w", err)
+		}
+		if s != nil {
+			if err := core.ReplaceHistory(s, b.jobs); err != nil {
+				return res, fmt.Errorf("restore job history: %w", err)
+			}
+			res.Jobs = len(b.jobs)
+		}
+	}
+	log.Printf("restored a backup made by bridge %s: %d file(s), %d job(s)", res.From, len(res.Files), res.Jobs)
+	return res, nil
+}
+
+func sameStoreConfig(a, b *core.StoreConfig) bool {
+	if a == nil {
+		a = &core.StoreConfig{}
+	}
+	if b == nil {
+		b = &core.StoreConfig{}
+	}
+	return *a == *b
+}
+
+// restoreConfig installs a restored config file, keeping the old one as
+// .bak, and makes it active.
+func restoreConfig(data []byte) error {
+	if cur, err := os.ReadFile(core.CfgPath); err == nil {
+		if err := core.WriteFileAtomic(core.CfgPath+".bak", cur); err != nil {
+			return err
+		}
+	}
+	if err := core.WriteFileAtomic(core.CfgPath, data); err != nil {
+		return err
+	}
+	return core.LoadConfig(core.CfgPath)
+}
+
+// handleBackup serves GET /backup: the bundle as a download.
+func (h jobsAPI) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var buf bytes.Buffer
+	if err := writeBackup(&buf, h.store); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	name := "graham-bridge-backup-" + time.Now().Format("2006-01-02") + ".tar.gz"
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Write(buf.Bytes())
+}
+
+// handleRestore serves POST /restore with a bundle as the body.
+func (h jobsAPI) handleRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	b, err := readBackup(http.MaxBytesReader(w, r.Body, maxBackupBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Checked before anything is restored, so a refusal changes nothing.
+	if err := core.CheckHistoryIdle(h.store); b.jobs != nil && err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	res, err := restoreBackup(b, func() (core.JobStore, error) { return h.store, nil })
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// RunBackupCommand handles "graham-bridge backup" and "graham-bridge
+// restore", which read the store directly and so are for a bridge that is
+// not running. It reports whether args named one of them.
+func RunBackupCommand(args []string) (bool, error) {
+	if len(args) == 0 || (args[0] != "backup" && args[0] != "restore") {
+		return false, nil
+	}
+	openHistory := func() (core.JobStore, error) {
+		s, err := core.OpenStore(core.CurrentConfig().Store)
+		if err != nil {
+			return nil, fmt.Errorf("%w (stop the bridge first, or use Backup on its dashboard)", err)
+		}
+		return s, nil
+	}
+	closeHistory := func(s core.JobStore) {
+		if p, ok := s.(*core.PersistentStore); ok {
+			p.Close()
+		}
+	}
+	if args[0] == "backup" {
+		s, err := openHistory()
+		if err != nil {
+			return true, err
+		}
+		defer closeHistory(s)
+		return true, writeBackup(os.Stdout, s)
+	}
+
+	b, err := readBackup(os.Stdin)
+	if err != nil {
+		return true, err
+	}
+	var opened core.JobStore
+	res, err := restoreBackup(b, func() (core.JobStore, error) {
+		if c := core.CurrentConfig().Store; c == nil || c.Backend == "" || c.Backend == core.BackendMemory {
+			log.Printf("restore: job history not restored: the config keeps it in memory only")
+			return nil, nil
+		}
+		s, err := openHistory()
+		opened = s
+		return s, err
+	})
+	if opened != nil {
+		closeHistory(opened)
+	}
+	if err != nil {
+		return true, err
+	}
+	fmt.Fprintf(os.Stderr, "restored %d file(s) and %d job(s) from a backup made by bridge %s\n", len(res.Files), res.Jobs, res.From)
+	return true, nil
+}
@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestBackupRestore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+	oldCfg, oldPath := core.Cfg.Load(), core.CfgPath
+	defer func() { core.Cfg.Store(oldCfg); core.CfgPath = oldPath }()
+
+	path := filepath.Join(core.DataDir(), "config.json")
+	os.MkdirAll(core.DataDir(), 0o700)
+	os.WriteFile(path, []byte(`{"printers": {"Tiger": {"cells_per_line": 34}}}`), 0o600)
+	os.WriteFile(filepath.Join(core.DataDir(), "bridge-id"), []byte("abc\n"), 0o600)
+	if err := core.LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	s := core.NewMemoryStore(10)
+	s.Append(core.JobEvent{Printer: "Tiger", Status: core.StatusDone, Student: "S1"})
+	s.Append(core.JobEvent{Printer: "Tiger", Status: core.StatusHeld, Student: "S2"})
+	var bundle bytes.Buffer
+	if err := writeBackup(&bundle, s); err != nil {
+		t.Fatal(err)
+	}
+
+	// The new laptop: another config and a job of its own.
+	os.WriteFile(path, []byte(`{}`), 0o600)
+	core.LoadConfig(path)
+	s = core.NewMemoryStore(10)
+	s.Append(core.JobEvent{Printer: "Index", Status: core.StatusDone})
+
+	b, err := readBackup(bytes.NewReader(bundle.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := restoreBackup(b, func() (core.JobStore, error) { return s, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Jobs != 2 || res.RestartNeeded || !slices.Contains(res.Files, "config.json") {
+		t.Errorf("restore = %+v", res)
+	}
+	if core.ProfileFor("Tiger").CellsPerLine != 34 {
+		t.Error("restored config not loaded")
+	}
+	if bak, _ := os.ReadFile(path + ".bak"); string(bak) != "{}" {
+		t.Errorf("old config kept as %q", bak)
+	}
+	jobs := s.List()
+	if len(jobs) != 2 || jobs[0].Student != "S1" || jobs[1].Status != core.StatusFailed {
+		t.Errorf("restored history %+v", jobs)
+	}
+
+	s.Update(2, func(e *core.JobEvent) { e.Status = core.StatusPrinting })
+	rec := httptest.NewRecorder()
+	jobsAPI{store: s}.handleRestore(rec, httptest.NewRequest(http.MethodPost, "/restore", bytes.NewReader(bundle.Bytes())))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("restore while printing: %d %s", rec.Code, rec.Body)
+	}
+	if _, err := readBackup(strings.NewReader("not a bundle")); err == nil {
+		t.Error("readBackup accepted junk")
+	}
+}
+
+func TestBackupPseudonymizesStudents(t *testing.T) {
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	core.Cfg.Store(&core.Config{AnonymizeStudents: true})
+	s := core.NewMemoryStore(10)
+	s.Append(core.JobEvent{Printer: "Tiger", Status: core.StatusDone, Student: "Maya Lopez"})
+
+	var bundle bytes.Buffer
+	if err := writeBackup(&bundle, s); err != nil {
+		t.Fatal(err)
+	}
+	b, err := readBackup(&bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.jobs) != 1 || b.jobs[0].Student != core.Pseudonym("Maya Lopez") {
+		t.Errorf("backed-up jobs %+v", b.jobs)
+	}
+}
@@ -0,0 +1,205 @@
+package httpapi
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Compression
+//
+// A term of job history or the preview of a textbook volume runs to
+// megabytes of JSON, which a station serving a classroom over Wi-Fi sends
+// slowly. Responses are gzip-compressed when the client sends
+// "Accept-Encoding: gzip" and the body is text (JSON, CSV, HTML, …) of at
+// least gzipMinSize bytes; smaller bodies, binary payloads (PDFs, images)
+// and event streams (/log-stream) go out as they are.
+//
+// The other way, POST bodies sent with "Content-Encoding: gzip" are
+// decompressed before the handler reads them, so a web app can upload a
+// large BRF compressed. Upload limits (5 MB for /print) apply to the
+// decompressed body, and a body over the limit is refused with 413; GET
+// /status reports the limit as "max_upload_bytes".
+// ---------------------------------------------------------------------------
+
+const (
+	gzipMinSize    = 1024
+	maxUploadBytes = 5 * 1024 * 1024
+)
+
+// WithGzip compresses responses and decompresses request bodies.
+func WithGzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch enc := strings.ToLower(r.Header.Get("Content-Encoding")); enc {
+		case "", "identity":
+		case "gzip":
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, "body is not valid gzip", http.StatusBadRequest)
+				return
+			}
+			defer zr.Close()
+			r.Body = zr
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+		default:
+			http.Error(w, "unsupported Content-Encoding "+enc+" (use gzip)", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		gw := &gzipWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether r's Accept-Encoding allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// compressible reports whether a body of this Content-Type is worth
+// compressing.
+func compressible(contentType string) bool {
+	ct, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch ct = strings.TrimSpace(ct); {
+	case ct == "text/event-stream":
+		return false
+	case strings.HasPrefix(ct, "text/"):
+		return true
+	}
+	switch ct {
+	case "application/json", "application/x-ndjson", "application/javascript", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// gzipWriter holds back the first gzipMinSize bytes of a response to
+// decide whether to compress it.
+type gzipWriter struct {
+	http.ResponseWriter
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (g *gzipWriter) WriteHeader(code int) {
+	if g.decided || g.status != 0 {
+		return
+	}
+	g.status = code
+	if code < 200 || code == http.StatusNoContent || code == http.StatusNotModified {
+		g.decide(false)
+	}
+}
+
+func (g *gzipWriter) Write(p []byte) (int, error) {
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	if g.decided {
+		if g.gz != nil {
+			return g.gz.Write(p)
+		}
+		return g.ResponseWriter.Write(p)
+	}
+	g.buf = append(g.buf, p...)
+	if len(g.buf) >= gzipMinSize {
+		if err := g.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide sends the header and anything held back, compressed if try is
+// set and the response allows it.
+func (g *gzipWriter) decide(try bool) error {
+	g.decided = true
+	h := g.Header()
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	if h.Get("Content-Type") == "" && len(g.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(g.buf))
+	}
+	if try && h.Get("Content-Encoding") == "" && h.Get("Content-Range") == "" && compressible(h.Get("Content-Type")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag) // the compressed bytes differ
+		}
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+	buf := g.buf
+	g.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if g.gz != nil {
+		_, err := g.gz.Write(buf)
+		return err
+	}
+	_, err := g.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush sends what has been written so far. A response flushed before
+// gzipMinSize bytes (an event stream) is not compressed.
+func (g *gzipWriter) Flush() {
+	if !g.decided {
+		g.decide(false)
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (g *gzipWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// close finishes the response.
+func (g *gzipWriter) close() {
+	if !g.decided {
+		if g.status == 0 && len(g.buf) == 0 {
+			return // the handler wrote nothing; net/http sends 200
+		}
+		g.decide(false)
+	}
+	if g.gz != nil {
+		g.gz.Close()
+	}
+}
+
+// badUploadBody answers a request whose JSON body could not be decoded:
+// 413 if it went over its size limit, 400 otherwise.
+func badUploadBody(w http.ResponseWriter, err error) {
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		http.Error(w, fmt.Sprintf("body is over the %d byte limit (after decompression)", tooBig.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+}
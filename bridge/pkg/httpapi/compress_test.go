@@ -0,0 +1,63 @@
+package httpapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGzip(t *testing.T) {
+	big := strings.Repeat(`{"printer":"Index"},`, 200)
+	h := WithGzip(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			r.Body = http.MaxBytesReader(w, r.Body, 100)
+			var req printRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				badUploadBody(w, err)
+				return
+			}
+			io.WriteString(w, req.Printer)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, big)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+	r.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("not compressed: %v", rec.Header())
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != big {
+		t.Errorf("decompressed %d bytes, want %d", len(body), len(big))
+	}
+
+	upload := func(body string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		io.WriteString(zw, body)
+		zw.Close()
+		r := httptest.NewRequest(http.MethodPost, "/print", &buf)
+		r.Header.Set("Content-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec
+	}
+	if rec := upload(`{"printer":"Index"}`); rec.Body.String() != "Index" {
+		t.Errorf("gzip upload: %d %q", rec.Code, rec.Body)
+	}
+	if rec := upload(`{"data":"` + strings.Repeat("A", 200) + `"}`); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized upload: %d", rec.Code)
+	}
+}
@@ -0,0 +1,27 @@
+package httpapi
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Config reload
+// ---------------------------------------------------------------------------
+
+// handleConfigReload re-reads the config file, e.g. after adding a token.
+func handleConfigReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := core.LoadConfig(core.CfgPath); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	log.Printf("config reloaded from %s", core.CfgPath)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"reloaded"}`))
+}
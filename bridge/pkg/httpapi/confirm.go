@@ -0,0 +1,141 @@
+package httpapi
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Confirming large jobs
+//
+// A stray click on a 200-page textbook ties up the embosser for an hour
+// and empties a box of paper. With
+//
+//	"confirm_pages": 50
+//
+// a /print or /print-url request for more pages than that is not queued.
+// It is answered 409 with an estimate instead:
+//
+//	{"status": "estimate", "error_code": "confirmation_required",
+//	 "pages": 212, "sheets": 106, "interpoint": true,
+//	 "estimated_seconds": 2100, "confirm": "tgq1a8.3f9a…"}
+//
+// and prints when the same request is sent again with "confirm" set to
+// that value. The value stands for that document on that printer, so it
+// cannot confirm a different one. It is signed with a key kept in the data
+// directory (confirm.key), so nobody can work one out from the document,
+// and it lasts confirmTTL: long enough for a teacher to decide, not a
+// standing pass for the document. Pages are counted as the request sent
+// them, before pre hooks (pkg/core/hooks.go) run, so an unconfirmed
+// request never reaches a hook. Clients that do not know about
+// confirmations show the error's message, which says what to do. Sheets
+// are pages divided by the printer's pages_per_sheet; the time is the
+// printer's chars_per_second estimate, left out when it has none.
+//
+// Dashboard resends, page-range reprints and resumes (pagerange.go) are
+// confirmed the same way; the page routes take the value as ?confirm=.
+// ---------------------------------------------------------------------------
+
+// confirmTTL is how long a "confirm" value is accepted.
+const confirmTTL = 15 * time.Minute
+
+var confirmKey = struct {
+	sync.Once
+	key []byte
+}{}
+
+// confirmMAC signs data on printer until expires (Unix seconds).
+func confirmMAC(printer string, data []byte, expires int64) []byte {
+	confirmKey.Do(func() {
+		key, err := core.SecretFile("confirm.key", func() ([]byte, error) {
+			key := make([]byte, 32)
+			_, err := rand.Read(key)
+			return key, err
+		})
+		if err != nil {
+			log.Printf("confirm: %v; confirmations will not survive a restart", err)
+			key = make([]byte, 32)
+			rand.Read(key)
+		}
+		confirmKey.key = key
+	})
+	mac := hmac.New(sha256.New, confirmKey.key)
+	fmt.Fprintf(mac, "%s\x00%d\x00", printer, expires)
+	mac.Write(data)
+	return mac.Sum(nil)[:16]
+}
+
+// confirmToken is the "confirm" value that lets data print on printer, as
+// named in the request (a pool sends its jobs to any member), until
+// confirmTTL after now.
+func confirmToken(printer string, data []byte, now time.Time) string {
+	expires := now.Add(confirmTTL).Unix()
+	return strconv.FormatInt(expires, 36) + "." + hex.EncodeToString(confirmMAC(printer, data, expires))
+}
+
+// confirmed reports whether got is a current confirm value for data on
+// printer.
+func confirmed(printer string, data []byte, got string, now time.Time) bool {
+	exp, sig, ok := strings.Cut(got, ".")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(exp, 36, 64)
+	if err != nil || now.Unix() > expires {
+		return false
+	}
+	want := hex.EncodeToString(confirmMAC(printer, data, expires))
+	return secretEqual(sig, want)
+}
+
+// confirmLarge checks a job of pages pages against confirm_pages before
+// it is queued: data on printer is the request as sent and got the
+// "confirm" value it carried. Unless the job is small enough or got
+// confirms it, it answers with an estimate for est and reports false.
+func confirmLarge(w http.ResponseWriter, est core.JobEvent, printer string, data []byte, got string) bool {
+	limit := core.CurrentConfig().ConfirmPages
+	now := time.Now()
+	if limit <= 0 || est.Pages <= limit || confirmed(printer, data, got, now) {
+		return true
+	}
+	log.Printf("print request for %d pages on %q awaits confirmation", est.Pages, est.Printer)
+	writeEstimate(w, est, confirmToken(printer, data, now))
+	return false
+}
+
+// writeEstimate answers a large job with what it would take to print.
+func writeEstimate(w http.ResponseWriter, job core.JobEvent, confirm string) {
+	p := core.ProfileFor(job.Printer)
+	sheets := (job.Pages + p.PagesPerSheet - 1) / p.PagesPerSheet
+	msg := fmt.Sprintf("this job is %d pages (%d sheets) on %s; send it again with \"confirm\" to print it",
+		job.Pages, sheets, job.Printer)
+	body := map[string]any{
+		"status":     "estimate",
+		"error":      msg,
+		"error_code": core.ErrCodeConfirm,
+		"guidance":   core.ErrorGuidance[core.ErrCodeConfirm],
+		"printer":    job.Printer,
+		"pages":      job.Pages,
+		"sheets":     sheets,
+		"interpoint": p.PagesPerSheet == 2,
+		"confirm":    confirm,
+	}
+	if job.Estimate > 0 {
+		body["estimated_seconds"] = job.Estimate
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,131 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestConfirmLargeJobs(t *testing.T) {
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	core.Cfg.Store(&core.Config{ConfirmPages: 2, Printers: map[string]core.PrinterProfile{"Mock Everest": {PagesPerSheet: 2}}})
+	m := &mockSpooler{printers: []string{"Mock Everest"}}
+	withSpooler(t, m)
+	post := func(data, confirm string) *httptest.ResponseRecorder {
+		body := `{"printer":"Mock Everest","data":"` + base64.StdEncoding.EncodeToString([]byte(data)) + `","confirm":"` + confirm + `"}`
+		rec := httptest.NewRecorder()
+		printHandler(rec, httptest.NewRequest(http.MethodPost, "/print", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := post("A\n\fB\n\f", ""); rec.Code != http.StatusOK {
+		t.Fatalf("two pages: %d %s", rec.Code, rec.Body)
+	}
+	rec := post("A\n\fB\n\fC\n\f", "")
+	var est struct {
+		Status, Confirm string
+		Pages, Sheets   int
+		Interpoint      bool
+	}
+	json.Unmarshal(rec.Body.Bytes(), &est)
+	if rec.Code != http.StatusConflict || est.Status != "estimate" || est.Pages != 3 || est.Sheets != 2 || !est.Interpoint || est.Confirm == "" {
+		t.Fatalf("three pages: %d %s", rec.Code, rec.Body)
+	}
+	if rec := post("A\n\fB\n\fD\n\f", est.Confirm); rec.Code != http.StatusConflict {
+		t.Errorf("another document with the first one's confirmation: %d", rec.Code)
+	}
+	if rec := post("A\n\fB\n\fC\n\f", est.Confirm); rec.Code != http.StatusOK {
+		t.Errorf("confirmed: %d %s", rec.Code, rec.Body)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n := len(m.sent["Mock Everest"]); n != 2 {
+		t.Errorf("%d jobs sent, want 2", n)
+	}
+}
+
+func TestConfirmTokens(t *testing.T) {
+	now := time.Now()
+	data := []byte("A\n\fB\n\f")
+	tok := confirmToken("Index", data, now)
+	if !confirmed("Index", data, tok, now.Add(confirmTTL-time.Minute)) {
+		t.Error("a fresh confirmation was refused")
+	}
+	if confirmed("Index", data, tok, now.Add(confirmTTL+time.Minute)) {
+		t.Error("an expired confirmation was accepted")
+	}
+	if confirmed("Tiger", data, tok, now) || confirmed("Index", []byte("A\n\f"), tok, now) {
+		t.Error("a confirmation was accepted for another printer or document")
+	}
+	// Moving the expiry breaks the signature.
+	_, sig, _ := strings.Cut(tok, ".")
+	later := confirmToken("Index", data, now.Add(time.Hour))
+	exp, _, _ := strings.Cut(later, ".")
+	if confirmed("Index", data, exp+"."+sig, now) {
+		t.Error("a confirmation with a forged expiry was accepted")
+	}
+}
+
+func TestConfirmBeforeHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell command")
+	}
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	marker := filepath.Join(t.TempDir(), "ran")
+	core.Cfg.Store(&core.Config{ConfirmPages: 1, Hooks: []core.HookConfig{
+		{Name: "mark", Stage: core.HookPre, Command: "/bin/sh", Args: []string{"-c", "echo >> " + marker}, OnError: core.HookContinue},
+	}})
+	withSpooler(t, &mockSpooler{printers: []string{"Mock Everest"}})
+	body := `{"printer":"Mock Everest","data":"` + base64.StdEncoding.EncodeToString([]byte("A\n\fB\n\f")) + `"}`
+	rec := httptest.NewRecorder()
+	printHandler(rec, httptest.NewRequest(http.MethodPost, "/print", strings.NewReader(body)))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("two pages over a limit of one: %d %s", rec.Code, rec.Body)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("a pre hook ran before the job was confirmed")
+	}
+}
+
+func TestConfirmPageReprints(t *testing.T) {
+	old, oldStore := core.Cfg.Load(), core.Store
+	defer func() { core.Cfg.Store(old); core.Store = oldStore }()
+	core.Cfg.Store(&core.Config{ConfirmPages: 1})
+	withSpooler(t, &mockSpooler{printers: []string{"Mock Everest"}})
+	core.Store = core.NewMemoryStore(10)
+	core.Store.Append(core.JobEvent{Printer: "Mock Everest", Status: core.StatusDone, Pages: 3, Payload: []byte("A\n\fB\n\fC\n\f")})
+
+	reprint := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/jobs/1/print?"+query, nil)
+		r.SetPathValue("id", "1")
+		jobsAPI{store: core.Store}.handleJobPrintPages(rec, r)
+		return rec
+	}
+	if rec := reprint("pages=2"); rec.Code != http.StatusOK {
+		t.Errorf("one page: %d %s", rec.Code, rec.Body)
+	}
+	rec := reprint("pages=2-3")
+	var est struct{ Confirm string }
+	json.Unmarshal(rec.Body.Bytes(), &est)
+	if rec.Code != http.StatusConflict || est.Confirm == "" {
+		t.Fatalf("two pages: %d %s", rec.Code, rec.Body)
+	}
+	if rec := reprint("pages=1-2&confirm=" + est.Confirm); rec.Code != http.StatusConflict {
+		t.Errorf("other pages with the first pages' confirmation: %d", rec.Code)
+	}
+	if rec := reprint("pages=2-3&confirm=" + est.Confirm); rec.Code != http.StatusOK {
+		t.Errorf("confirmed: %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Paper endpoints
+//
+// GET /paper and the Refilled button. The estimate is kept by
+// pkg/core/consumables.go.
+// ---------------------------------------------------------------------------
+
+// handlePaper serves GET /paper.
+func handlePaper(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	levels := core.PaperLevels()
+	if levels == nil {
+		levels = []core.PaperLevel{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(levels)
+}
+
+// handlePaperReset serves POST /paper/{printer}/reset with an optional
+// {"loaded": <sheets>} body; without it the profile's paper_sheets is used.
+func handlePaperReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	printer := r.PathValue("printer")
+	var req struct {
+		Loaded int `json:"loaded"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&req); err != nil || req.Loaded < 0 {
+			http.Error(w, "body must be {\"loaded\": <sheets>}", http.StatusBadRequest)
+			return
+		}
+	}
+
+	snapshot, ok := core.RefillPaper(printer, req.Loaded)
+	if !ok {
+		http.Error(w, "paper tracking is not configured for this printer (set paper_sheets in its profile)", http.StatusNotFound)
+		return
+	}
+
+	log.Printf("paper refilled on %q: %d sheets", printer, snapshot.Loaded)
+	core.Store.Publish(core.StreamEvent{Name: "paper", Data: snapshot})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
@@ -0,0 +1,337 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// HTTP handlers
+// ---------------------------------------------------------------------------
+
+const (
+	sseHeartbeat = 15 * time.Second
+	sseRetry     = 3 * time.Second
+)
+
+// handleLogStream streams job events as Server-Sent Events.
+func (h jobsAPI) handleLogStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	schema, err := requestedSchema(r) // schema.go
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	app := callerApp(r)
+	send := func(ev core.StreamEvent) {
+		if e, ok := ev.Data.(core.JobEvent); ok && !core.VisibleTo(e, app) {
+			return // another application's job (apps.go)
+		}
+		if data, ok := versionedData(ev, schema); ok {
+			ev.Data = data
+			writeSSE(w, flusher, ev)
+		}
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	// Open the stream immediately so clients receive headers and EventSource
+	// fires onopen even when there are no jobs yet (net/http delays headers
+	// until the first Write).
+	// The retry directive sets the EventSource reconnect delay.
+	fmt.Fprintf(w, ": stream open\nretry: %d\n\n", sseRetry.Milliseconds())
+	flusher.Flush()
+
+	// Resume from Last-Event-ID when the missed events are still in the
+	// backlog; otherwise tell the client to reset and replay all jobs.
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventId")
+	}
+	sub := h.store.Subscribe(lastID)
+	defer h.store.Unsubscribe(sub)
+	if sub.Resumed {
+		for _, ev := range sub.Replay {
+			send(ev)
+		}
+	} else {
+		send(core.StreamEvent{Name: "reset", Data: struct{}{}, Seq: sub.Seq})
+		for _, e := range h.store.List() {
+			send(core.StreamEvent{Data: e, Seq: sub.Seq})
+		}
+		send(core.StreamEvent{Name: "ui", Data: core.CurrentUIState(), Seq: sub.Seq})
+	}
+
+	// Heartbeats keep proxies from closing an idle stream overnight and let
+	// the dashboard tell "no jobs" apart from a dead connection. The comment
+	// line satisfies intermediaries; the named event is visible to scripts.
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case t := <-heartbeat.C:
+			fmt.Fprintf(w, ": ping\nevent: heartbeat\ndata: {\"time\":%q}\n\n", t.Format(time.RFC3339))
+			flusher.Flush()
+		case <-sub.Gone:
+			// Too far behind; closing lets EventSource reconnect and resume.
+			return
+		case ev := <-sub.C:
+			send(ev)
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, f http.Flusher, ev core.StreamEvent) {
+	data, _ := json.Marshal(ev.Data)
+	if ev.Seq != 0 {
+		fmt.Fprintf(w, "id: %s\n", core.EventID(ev.Seq))
+	}
+	if ev.Name != "" {
+		fmt.Fprintf(w, "event: %s\n", ev.Name)
+	}
+	fmt.Fprintf(w, "data: %s\n\n", data)
+	f.Flush()
+}
+
+// handlePrinters returns a JSON array of destination names (see
+// core.AllDestinations). With ?details=1 each entry is a PrinterInfo object
+// carrying the printer's media settings, a driver's declared capabilities,
+// USB setup guidance or a warning about a driver that re-renders jobs.
+func handlePrinters(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), core.ListTimeout())
+	defer cancel()
+	printers := core.AllDestinations(ctx)
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("details") == "1" {
+		for i := range printers {
+			printers[i].Media = core.MediaNames(printers[i].Name)
+			printers[i].Warning = core.QueueWarning(ctx, printers[i].Name)
+		}
+		json.NewEncoder(w).Encode(append(printers, core.PoolInfos()...))
+		return
+	}
+	names := make([]string, len(printers))
+	for i, p := range printers {
+		names[i] = p.Name
+	}
+	for _, p := range core.PoolInfos() {
+		names = append(names, p.Name)
+	}
+	json.NewEncoder(w).Encode(names)
+}
+
+// handleTestPrint sends a known-good BRF test page to a named printer.
+func handleTestPrint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Printer string `json:"printer"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Printer == "" {
+		http.Error(w, "printer name required", http.StatusBadRequest)
+		return
+	}
+
+	// A simple BRF test page:
+	//   Line 1: heading (in Grade 1 braille the caps indicator is ,)
+	//   Lines 2-4: alphabet rows a-j, k-t, u-z
+	//   Line 5: numbers #a-#e  (1–5 with number indicator)
+	//   Line 6: "hello world" in Grade 2
+	const testBRF = ",GRAHAM BRIDGE TE/ PAGE\r\n\r\n" +
+		"abcdefghij\r\n" +
+		"klmnopqrst\r\n" +
+		"uvwxyz\r\n\r\n" +
+		"#a #b #c #d #e\r\n\r\n" +
+		"hello _w.\r\n"
+
+	printGenerated(w, r, req.Printer, []byte(testBRF))
+}
+
+// printGenerated queues a page the bridge built itself (test page, ruler)
+// and reports the outcome like /print. The page is the caller's job like
+// any other: it is held for a token marked "hold" or for the printer's
+// quiet hours, and counts against an application's quota.
+func printGenerated(w http.ResponseWriter, r *http.Request, printer string, data []byte) {
+	printer, pool := core.ResolvePool(printer)
+	job, perr := core.PrepareJob(printer, data)
+	if perr != nil {
+		http.Error(w, perr.Message, perr.Status)
+		return
+	}
+	caller, _ := authenticate(r)
+	job.App = caller.App
+	job.Pool = pool
+	job.RequestID = requestID(r.Context())
+	e, sent := dispatchJob(w, r, caller, job, data, core.JobOptions{})
+	if !sent {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"status": "queued", "id": e.ID})
+}
+
+// handleJobPDF returns the PDF a virtual printer rendered for a job.
+func (h jobsAPI) handleJobPDF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	e, ok := h.jobFromPath(w, r)
+	if !ok {
+		return
+	}
+	if !e.Rendered {
+		http.Error(w, "job was not sent to a virtual printer", http.StatusNotFound)
+		return
+	}
+	pdf, err := core.Renders.Get(e.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="job-%d.pdf"`, e.ID))
+	w.Write(pdf)
+}
+
+// handleJobBRF returns the full BRF payload of a recorded job as plain text,
+// for loading into the dashboard editor.
+func (h jobsAPI) handleJobBRF(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	e, ok := h.jobFromPath(w, r)
+	if !ok {
+		return
+	}
+	data, err := core.Payloads.Get(e.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(data)
+}
+
+// handleJobResend sends an (optionally edited) copy of a recorded job as a
+// new job. Body: {"printer":"Name","data":"<base64 BRF>"}; both fields are
+// optional and default to the original job's printer and payload. "urgent"
+// and "confirm" work as for /print.
+func (h jobsAPI) handleJobResend(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	src, ok := h.jobFromPath(w, r)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+	var req printRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		badUploadBody(w, err)
+		return
+	}
+	if req.Printer == "" {
+		req.Printer = src.Printer
+	}
+	if req.Data == "" {
+		resendStored(w, r, src, req)
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(req.Data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid base64 data: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(data) == 0 {
+		http.Error(w, "data is required", http.StatusBadRequest)
+		return
+	}
+
+	// Edited data is new input: it goes through /print's hooks and
+	// fix-ups, and its checks as a job of whoever resent it: their hold,
+	// quota and application, and the printer's quiet hours.
+	log.Printf("resend request: job=%d printer=%q bytes=%d", src.ID, req.Printer, len(data))
+	submitPrint(w, r, req.Printer, data, core.JobOptions{
+		Student:    src.Student,
+		Urgent:     req.Urgent,
+		Confirm:    req.Confirm,
+		ResentFrom: src.ID,
+	})
+}
+
+// resendStored sends a recorded job's payload again as it was sent. The
+// payload has already been through the pre hooks and the profile's
+// fix-ups, so it skips them, as reprintPages does, and only gets the
+// checks every submitted job gets (dispatchJob).
+func resendStored(w http.ResponseWriter, r *http.Request, src core.JobEvent, req printRequest) {
+	data, err := core.Payloads.Get(src.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	if len(data) == 0 {
+		http.Error(w, "data is required", http.StatusBadRequest)
+		return
+	}
+	printer, pool := core.ResolvePool(req.Printer) // pkg/core/pool.go
+
+	log.Printf("resend request: job=%d printer=%q bytes=%d", src.ID, printer, len(data))
+	e := core.NewJobEvent(printer, data)
+	e.Pages = src.Pages
+	e.ResentFrom = src.ID
+	e.Student = src.Student
+	e.Pool = pool
+	e.Urgent = req.Urgent
+	e.Media, e.Dots, e.LineSpacing, e.Interlined, e.Reversed = src.Media, src.Dots, src.LineSpacing, src.Interlined, src.Reversed
+	if !confirmLarge(w, e, req.Printer, data, req.Confirm) { // confirm.go
+		return
+	}
+	caller, _ := authenticate(r)
+	e.App = caller.App
+	e.RequestID = requestID(r.Context())
+	e, sent := dispatchJob(w, r, caller, e, data, core.JobOptions{})
+	if !sent {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"status": "queued", "id": e.ID})
+}
+
+// jobFromPath resolves the {id} path value to a recorded job, writing an
+// error response when it is missing or unknown.
+func (h jobsAPI) jobFromPath(w http.ResponseWriter, r *http.Request) (core.JobEvent, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return core.JobEvent{}, false
+	}
+	e, ok := h.store.Get(id)
+	if !ok || !core.VisibleTo(e, callerApp(r)) {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return core.JobEvent{}, false
+	}
+	return e, true
+}
@@ -0,0 +1,131 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestResendChecksLikePrint(t *testing.T) {
+	old, oldStore := core.Cfg.Load(), core.Store
+	defer func() { core.Cfg.Store(old); core.Store = oldStore }()
+	c := &core.Config{
+		Tokens: []core.APIToken{{Name: "para", Token: "para-token-0123456789", Scope: core.ScopePrint, Hold: true}},
+		Apps: map[string]core.AppConfig{
+			"web": {Tokens: []core.APIToken{{Name: "lab", Token: "web-token-0123456789", Scope: core.ScopePrint}}},
+			"dux": {Tokens: []core.APIToken{{Name: "lab", Token: "dux-token-0123456789", Scope: core.ScopePrint}}, DailyJobs: 1},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	core.Cfg.Store(c)
+	withSpooler(t, &mockSpooler{printers: []string{"Mock Everest"}})
+	core.Store = core.NewMemoryStore(10)
+	src := core.Store.Append(core.JobEvent{Printer: "Mock Everest", Status: core.StatusDone, App: "dux", Time: time.Now(), Payload: []byte("ABC\n\f")})
+
+	resend := func(tok string) (*httptest.ResponseRecorder, core.JobEvent) {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/jobs/1/resend", strings.NewReader(`{}`))
+		r.Header.Set("Authorization", "Bearer "+tok)
+		r.SetPathValue("id", "1")
+		jobsAPI{store: core.Store}.handleJobResend(rec, r)
+		var resp struct{ ID int }
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		e, _ := core.Store.Get(resp.ID)
+		return rec, e
+	}
+
+	rec, e := resend("para-token-0123456789")
+	if rec.Code != http.StatusAccepted || e.Status != core.StatusHeld || e.ResentFrom != src.ID || e.App != "" {
+		t.Errorf("resend by a held token: %d %s, job %+v", rec.Code, rec.Body, e)
+	}
+	// dux has printed its one job today, so its resend is over quota.
+	if rec, _ := resend("dux-token-0123456789"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("resend over the app's quota: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestResendSendsStoredPayloadAsIs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell command")
+	}
+	old, oldStore := core.Cfg.Load(), core.Store
+	defer func() { core.Cfg.Store(old); core.Store = oldStore }()
+	core.Cfg.Store(&core.Config{
+		Printers: map[string]core.PrinterProfile{"Mock Everest": {ReversePages: true}},
+		Hooks: []core.HookConfig{
+			{Name: "header", Stage: core.HookPre, Command: "/bin/sh", Args: []string{"-c", `printf 'HEADER\n'; sed 1d`}},
+		},
+	})
+	m := &mockSpooler{printers: []string{"Mock Everest"}}
+	withSpooler(t, m)
+	core.Store = core.NewMemoryStore(10)
+
+	body := `{"printer":"Mock Everest","data":"` + base64.StdEncoding.EncodeToString([]byte("A\n\fB\n\f")) + `"}`
+	rec := httptest.NewRecorder()
+	printHandler(rec, httptest.NewRequest(http.MethodPost, "/print", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("print: %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/jobs/1/resend", strings.NewReader(`{}`))
+	r.SetPathValue("id", "1")
+	jobsAPI{store: core.Store}.handleJobResend(rec, r)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("resend: %d %s", rec.Code, rec.Body)
+	}
+
+	m.mu.Lock()
+	sent := m.sent["Mock Everest"]
+	m.mu.Unlock()
+	if len(sent) != 2 || strings.Count(string(sent[0]), "HEADER") != 1 || string(sent[1]) != string(sent[0]) {
+		t.Fatalf("spooler got %q", sent)
+	}
+	if e, _ := core.Store.Get(2); e.ResentFrom != 1 || !e.Reversed || len(e.Hooks) != 0 {
+		t.Errorf("resent job: %+v", e)
+	}
+}
+
+func TestGeneratedPagesFollowHolds(t *testing.T) {
+	old, oldStore := core.Cfg.Load(), core.Store
+	defer func() { core.Cfg.Store(old); core.Store = oldStore }()
+	now := time.Now()
+	core.Cfg.Store(&core.Config{
+		Tokens: []core.APIToken{{Name: "para", Token: "para-token-0123456789", Scope: core.ScopePrint, Hold: true}},
+		Printers: map[string]core.PrinterProfile{"Mock Quiet": {QuietHours: []core.QuietWindow{
+			{Start: now.Add(-time.Hour).Format("15:04"), End: now.Add(time.Hour).Format("15:04")},
+		}}},
+	})
+	withSpooler(t, &mockSpooler{printers: []string{"Mock Everest", "Mock Quiet"}})
+	core.Store = core.NewMemoryStore(10)
+
+	testPage := func(printer, tok string) core.JobEvent {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/testprint", strings.NewReader(`{"printer":"`+printer+`"}`))
+		if tok != "" {
+			r.Header.Set("Authorization", "Bearer "+tok)
+		}
+		handleTestPrint(rec, r)
+		var resp struct{ ID int }
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		e, _ := core.Store.Get(resp.ID)
+		if rec.Code != http.StatusAccepted {
+			t.Errorf("test page to %s: %d %s", printer, rec.Code, rec.Body)
+		}
+		return e
+	}
+	if e := testPage("Mock Quiet", ""); e.Status != core.StatusHeld || e.HoldReason != core.HoldQuietHours {
+		t.Errorf("test page in quiet hours: %+v", e)
+	}
+	if e := testPage("Mock Everest", "para-token-0123456789"); e.Status != core.StatusHeld || e.HoldReason != core.HoldApproval {
+		t.Errorf("test page from a held token: %+v", e)
+	}
+}
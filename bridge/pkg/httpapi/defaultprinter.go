@@ -0,0 +1,68 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Default printer
+//
+// Filling in the printer a request leaves out, and GET/PUT
+// /printers/default.
+// ---------------------------------------------------------------------------
+
+// resolvePrinter fills in the default for a request with no printer. It
+// reports false, having answered 400, when there is none.
+func resolvePrinter(w http.ResponseWriter, printer *string) bool {
+	if *printer == "" {
+		*printer = core.DefaultPrinter()
+	}
+	if *printer == "" {
+		http.Error(w, "printer name is required (or set a default with PUT /printers/default)", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// handleDefaultPrinter serves GET /printers/default.
+func handleDefaultPrinter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeDefaultPrinter(w)
+}
+
+// handleSetDefaultPrinter serves PUT /printers/default.
+func handleSetDefaultPrinter(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Printer *string `json:"printer"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil || req.Printer == nil {
+		http.Error(w, "body must be {\"printer\": \"Name\"}", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(*req.Printer)
+	if name != "" && !core.KnownDestination(r.Context(), name) {
+		http.Error(w, fmt.Sprintf("no printer named %q", name), http.StatusNotFound)
+		return
+	}
+	core.UpdateUIState(func(s *core.UIState) { s.DefaultPrinter = name })
+	if name == "" {
+		log.Printf("default printer cleared")
+	} else {
+		log.Printf("default printer set to %q", name)
+	}
+	writeDefaultPrinter(w)
+}
+
+func writeDefaultPrinter(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"printer": core.DefaultPrinter()})
+}
@@ -0,0 +1,29 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestResolvePrinter(t *testing.T) {
+	saved := core.CurrentUIState().DefaultPrinter
+	core.UpdateUIState(func(s *core.UIState) { s.DefaultPrinter = "" })
+	t.Cleanup(func() { core.UpdateUIState(func(s *core.UIState) { s.DefaultPrinter = saved }) })
+
+	printer := ""
+	rec := httptest.NewRecorder()
+	if resolvePrinter(rec, &printer) || rec.Code != http.StatusBadRequest {
+		t.Errorf("no default: resolved %q, status %d", printer, rec.Code)
+	}
+	core.UpdateUIState(func(s *core.UIState) { s.DefaultPrinter = "Index Everest" })
+	if !resolvePrinter(httptest.NewRecorder(), &printer) || printer != "Index Everest" {
+		t.Errorf("default not used: %q", printer)
+	}
+	printer = "Juliet"
+	if !resolvePrinter(httptest.NewRecorder(), &printer) || printer != "Juliet" {
+		t.Errorf("named printer replaced: %q", printer)
+	}
+}
@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Failure responses
+//
+// How a classified send failure (pkg/core/failures.go) is written back
+// to the caller.
+// ---------------------------------------------------------------------------
+
+// writeJobFailure answers a print request whose job failed.
+func writeJobFailure(w http.ResponseWriter, e core.JobEvent, err error) {
+	if core.ErrorCode(err) == core.ErrCodeStuck {
+		// Not a failure yet: the job is still in the spooler's queue.
+		writeFailure(w, http.StatusAccepted, core.StatusStuck, e.ID, err)
+		return
+	}
+	writeFailure(w, http.StatusInternalServerError, core.StatusFailed, e.ID, err)
+}
+
+// writeFailure writes a classified error response. id is omitted when 0.
+func writeFailure(w http.ResponseWriter, httpStatus int, status string, id int, err error) {
+	code := core.ErrorCode(err)
+	body := map[string]any{
+		"status":     status,
+		"error":      err.Error(),
+		"error_code": code,
+		"guidance":   core.ErrorGuidance[code],
+	}
+	if id != 0 {
+		body["id"] = id
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(body)
+}
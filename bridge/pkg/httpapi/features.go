@@ -0,0 +1,118 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Feature negotiation
+//
+// Bridges in schools run different versions and configs, so the web app
+// asks which optional parts this one has before offering them:
+//
+//	GET /features → {"version": "3.3.0",
+//	                 "features": {"pef": true, "auth": false, …},
+//	                 "printers": {"Tiger": ["eight_dot", "graphics"]}}
+//
+// "features" says what the bridge as a whole does:
+//
+//	translation  translates print to braille (never: the web app does)
+//	pef          converts PEF files fetched with /print-url
+//	graphics     some printer has a graphics mode (graphics_dpi)
+//	auth         credentials are configured (see auth.go)
+//	duplex       some printer embosses both sides (pages_per_sheet 2)
+//	eight_dot    some printer takes eight-dot jobs
+//	interline    some printer merges ink print between braille lines
+//	history      the job history survives restarts (see pkg/core/storedb.go)
+//	station      other machines on the LAN may print here
+//	confirm      long jobs must be confirmed (see confirm.go)
+//
+// "printers" lists the features of each configured printer that has any
+// (graphics, duplex, eight_dot, interline, and media when it has media
+// settings), so the app can offer them only where they work. A key the
+// app does not know is a feature newer than it; one it expects but does
+// not find is one this bridge lacks.
+// ---------------------------------------------------------------------------
+
+// Per-printer features listed in /features.
+const (
+	featureGraphics  = "graphics"
+	featureDuplex    = "duplex"
+	featureEightDot  = "eight_dot"
+	featureInterline = "interline"
+	featureMedia     = "media"
+)
+
+// FeatureSet is the /features response.
+type FeatureSet struct {
+	Version  string              `json:"version"`
+	Features map[string]bool     `json:"features"`
+	Printers map[string][]string `json:"printers"`
+}
+
+// printerFeatures lists the optional features a printer's profile enables.
+func printerFeatures(p core.PrinterProfile) []string {
+	var f []string
+	if p.GraphicsDPI > 0 {
+		f = append(f, featureGraphics)
+	}
+	if p.PagesPerSheet == 2 {
+		f = append(f, featureDuplex)
+	}
+	if p.EightDot != nil {
+		f = append(f, featureEightDot)
+	}
+	if p.Interline != nil {
+		f = append(f, featureInterline)
+	}
+	if len(p.Media) > 0 {
+		f = append(f, featureMedia)
+	}
+	return f
+}
+
+// currentFeatures describes this bridge under the current config.
+func currentFeatures() FeatureSet {
+	c := core.CurrentConfig()
+	fs := FeatureSet{
+		Version: core.BridgeVersion,
+		Features: map[string]bool{
+			"translation": false,
+			"pef":         true,
+			"auth":        authRequired(),
+			"history":     c.Store != nil && c.Store.Backend != "" && c.Store.Backend != core.BackendMemory,
+			"station":     core.StationEnabled(),
+			"confirm":     c.ConfirmPages > 0,
+		},
+		Printers: map[string][]string{},
+	}
+	for _, k := range []string{featureGraphics, featureDuplex, featureEightDot, featureInterline} {
+		fs.Features[k] = false
+	}
+	for name, p := range c.Printers {
+		f := printerFeatures(p)
+		if len(f) == 0 {
+			continue
+		}
+		fs.Printers[name] = f
+		for _, k := range f {
+			if k != featureMedia {
+				fs.Features[k] = true
+			}
+		}
+	}
+	return fs
+}
+
+// handleFeatures serves GET /features.
+func handleFeatures(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(currentFeatures())
+}
@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestFeatures(t *testing.T) {
+	old := core.Cfg.Load()
+	core.Cfg.Store(&core.Config{Printers: map[string]core.PrinterProfile{
+		"Tiger":  {GraphicsDPI: 20, EightDot: &core.MediaCodes{}},
+		"Basic":  {CellsPerLine: 32},
+		"Duplex": {PagesPerSheet: 2, Media: map[string]core.MediaCodes{"labels": {}}},
+	}})
+	defer core.Cfg.Store(old)
+	rec := httptest.NewRecorder()
+	handleFeatures(rec, httptest.NewRequest(http.MethodGet, "/features", nil))
+	var fs FeatureSet
+	if err := json.Unmarshal(rec.Body.Bytes(), &fs); err != nil {
+		t.Fatal(err)
+	}
+	f := fs.Features
+	if !f["pef"] || f["translation"] || f["auth"] || !f["graphics"] || !f["duplex"] || !f["eight_dot"] || f["interline"] {
+		t.Errorf("features = %v", f)
+	}
+	if len(fs.Printers) != 2 || !slices.Equal(fs.Printers["Tiger"], []string{"graphics", "eight_dot"}) ||
+		!slices.Equal(fs.Printers["Duplex"], []string{"duplex", "media"}) {
+		t.Errorf("printers = %v", fs.Printers)
+	}
+}
@@ -0,0 +1,32 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Geometry and ruler endpoints
+// ---------------------------------------------------------------------------
+
+// handleGeometry serves GET /printers/{name}/geometry.
+func handleGeometry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.GeometryFor(r.PathValue("name")))
+}
+
+// handleRuler serves POST /printers/{name}/ruler.
+func handleRuler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	printer := r.PathValue("name")
+	printGenerated(w, r, printer, core.RulerPage(core.GeometryFor(printer)))
+}
@@ -0,0 +1,274 @@
+package httpapi
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Guest access
+//
+// A paraprofessional helping at the embosser needs to see the queue and
+// the printers, but should not print, cancel jobs or change settings.
+// Rather than hand out the dashboard password, an admin issues a guest
+// token from the dashboard:
+//
+//	GET    /guests          → [{"name", "created", "expires"}]
+//	POST   /guests          ← {"name": "Ms Ortiz", "days": 30}
+//	                        → {"name", "token", "link", "expires"}
+//	DELETE /guests/{name}   → revoke it
+//
+// The token has read scope only: it opens the dashboard (via the link,
+// /guest?token=…, which swaps it for a session cookie) and the read API,
+// and every button that would change something is hidden and refused. It
+// expires after "days" (30 by default, at most 365) and can be revoked at
+// any time, which also ends the sessions it opened.
+//
+// Guests are kept in guests.json in the data directory, with only a hash
+// of each token, so the token is shown once, when it is issued. They are
+// issued only once the bridge has a dashboard password or admin token:
+// without one the dashboard is open to everyone anyway.
+// ---------------------------------------------------------------------------
+
+const (
+	guestDefaultDays = 30
+	guestMaxDays     = 365
+	guestTokenPrefix = "guest-"
+)
+
+// guestToken is an issued guest token.
+type guestToken struct {
+	Name    string    `json:"name"`
+	Hash    string    `json:"hash,omitempty"` // SHA-256 of the token, hex
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"`
+}
+
+var guests = struct {
+	sync.Mutex
+	list   []guestToken
+	loaded bool
+}{}
+
+func guestsPath() string { return filepath.Join(core.DataDir(), "guests.json") }
+
+func hashGuestToken(tok string) string {
+	sum := sha256.Sum256([]byte(tok))
+	return hex.EncodeToString(sum[:])
+}
+
+func loadGuestsLocked() {
+	if guests.loaded {
+		return
+	}
+	guests.loaded = true
+	guests.list = nil
+	data, err := os.ReadFile(guestsPath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("guests: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &guests.list); err != nil {
+		log.Printf("guests: %s: %v", guestsPath(), err)
+	}
+}
+
+// saveGuestsLocked writes the guest list, dropping expired tokens.
+func saveGuestsLocked() error {
+	now := time.Now()
+	guests.list = slices.DeleteFunc(guests.list, func(g guestToken) bool { return now.After(g.Expires) })
+	data, _ := json.MarshalIndent(guests.list, "", "  ")
+	return core.WriteFileAtomic(guestsPath(), data)
+}
+
+// guestPrincipal looks tok up among the unexpired guest tokens.
+func guestPrincipal(tok string) (principal, bool) {
+	if !strings.HasPrefix(tok, guestTokenPrefix) {
+		return principal{}, false
+	}
+	h := hashGuestToken(tok)
+	guests.Lock()
+	defer guests.Unlock()
+	loadGuestsLocked()
+	for _, g := range guests.list {
+		if secretEqual(h, g.Hash) && time.Now().Before(g.Expires) {
+			return principal{Name: "guest:" + g.Name, Scope: core.ScopeRead}, true
+		}
+	}
+	return principal{}, false
+}
+
+// guestExpiry returns when the guest p belongs to expires, or the zero
+// time if p is not a guest.
+func guestExpiry(p principal) time.Time {
+	name, ok := strings.CutPrefix(p.Name, "guest:")
+	if !ok {
+		return time.Time{}
+	}
+	guests.Lock()
+	defer guests.Unlock()
+	loadGuestsLocked()
+	for _, g := range guests.list {
+		if g.Name == name {
+			return g.Expires
+		}
+	}
+	return time.Time{}
+}
+
+// issueGuest creates a guest token valid for days and returns it.
+func issueGuest(name string, days int) (string, guestToken, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "" || len(name) > 64 || strings.ContainsAny(name, "/\n"):
+		return "", guestToken{}, errors.New("a guest needs a name of up to 64 characters, without slashes")
+	case days == 0:
+		days = guestDefaultDays
+	case days < 0 || days > guestMaxDays:
+		return "", guestToken{}, fmt.Errorf("days must be between 1 and %d", guestMaxDays)
+	}
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	tok := guestTokenPrefix + hex.EncodeToString(buf)
+	now := time.Now().UTC()
+	g := guestToken{Name: name, Hash: hashGuestToken(tok), Created: now, Expires: now.AddDate(0, 0, days)}
+
+	guests.Lock()
+	defer guests.Unlock()
+	loadGuestsLocked()
+	if slices.ContainsFunc(guests.list, func(o guestToken) bool { return o.Name == name && now.Before(o.Expires) }) {
+		return "", guestToken{}, fmt.Errorf("there is already a guest named %q; revoke it first", name)
+	}
+	guests.list = append(guests.list, g)
+	if err := saveGuestsLocked(); err != nil {
+		guests.list = guests.list[:len(guests.list)-1]
+		return "", guestToken{}, err
+	}
+	return tok, g, nil
+}
+
+// revokeGuest removes the guest named name and ends its sessions.
+func revokeGuest(name string) (bool, error) {
+	guests.Lock()
+	loadGuestsLocked()
+	n := len(guests.list)
+	guests.list = slices.DeleteFunc(guests.list, func(g guestToken) bool { return g.Name == name })
+	found := len(guests.list) < n
+	var err error
+	if found {
+		err = saveGuestsLocked()
+	}
+	guests.Unlock()
+	if found {
+		endSessions(func(p principal) bool { return strings.TrimSuffix(p.Name, " (kiosk)") == "guest:"+name })
+	}
+	return found, err
+}
+
+// handleGuests serves GET and POST /guests.
+func handleGuests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		guests.Lock()
+		loadGuestsLocked()
+		list := make([]guestToken, 0, len(guests.list))
+		for _, g := range guests.list {
+			if time.Now().Before(g.Expires) {
+				g.Hash = ""
+				list = append(list, g)
+			}
+		}
+		guests.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	case http.MethodPost:
+		if !authRequired() {
+			http.Error(w, "the dashboard is open to everyone: set a dashboard_password before issuing guest tokens", http.StatusConflict)
+			return
+		}
+		var req struct {
+			Name string `json:"name"`
+			Days int    `json:"days"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			http.Error(w, `body must be {"name": "…", "days": 30}`, http.StatusBadRequest)
+			return
+		}
+		tok, g, err := issueGuest(req.Name, req.Days)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("guests: issued a read-only token for %q until %s", g.Name, g.Expires.Format(time.DateOnly))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]any{
+			"name": g.Name, "token": tok, "expires": g.Expires,
+			"link": "/guest?token=" + tok,
+		})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleGuestRevoke serves DELETE /guests/{name}.
+func handleGuestRevoke(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	found, err := revokeGuest(name)
+	switch {
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case !found:
+		http.Error(w, "no guest named "+name, http.StatusNotFound)
+	default:
+		log.Printf("guests: revoked %q", name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleGuestLink serves GET /guest?token=…: it swaps a guest token for a
+// read-only session and opens the dashboard.
+func handleGuestLink(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	tok := r.URL.Query().Get("token")
+	p, ok := guestPrincipal(tok)
+	if !ok {
+		http.Error(w, "Unauthorized: this guest link has expired or been revoked", http.StatusUnauthorized)
+		return
+	}
+	startSession(w, p, min(sessionTTL, time.Until(guestExpiry(p))), func(*core.Config) bool {
+		_, ok := guestPrincipal(tok)
+		return ok
+	})
+	http.Redirect(w, r, "/debug", http.StatusSeeOther)
+}
+
+// handleSession serves GET /session: who the dashboard is logged in as
+// and with which scope, so it can hide what the caller may not do.
+func handleSession(w http.ResponseWriter, r *http.Request) {
+	p := principal{Name: "anonymous", Scope: core.ScopeAdmin}
+	if authRequired() {
+		p, _ = authenticate(r)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"name": p.Name, "scope": p.Scope})
+}
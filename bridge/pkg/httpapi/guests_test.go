@@ -0,0 +1,68 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestGuests(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+	guests.Lock()
+	guests.loaded = false
+	guests.Unlock()
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	core.Cfg.Store(&core.Config{DashboardPassword: "hunter2"})
+
+	rec := httptest.NewRecorder()
+	handleGuests(rec, httptest.NewRequest(http.MethodPost, "/guests", strings.NewReader(`{"name": "Ms Ortiz", "days": 7}`)))
+	var issued struct{ Token, Link string }
+	if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &issued) != nil || issued.Token == "" {
+		t.Fatalf("issue: %d %s", rec.Code, rec.Body)
+	}
+
+	// The link opens a read-only dashboard session.
+	rec = httptest.NewRecorder()
+	handleGuestLink(rec, httptest.NewRequest(http.MethodGet, issued.Link, nil))
+	cookies := rec.Result().Cookies()
+	if rec.Code != http.StatusSeeOther || len(cookies) != 1 {
+		t.Fatalf("guest link: %d, cookies %v", rec.Code, cookies)
+	}
+	call := func(scope string, auth func(*http.Request)) int {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+		auth(r)
+		requireScope(scope, func(http.ResponseWriter, *http.Request) {})(rec, r)
+		return rec.Code
+	}
+	withCookie := func(r *http.Request) { r.AddCookie(cookies[0]) }
+	withToken := func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+issued.Token) }
+	if code := call(core.ScopeRead, withCookie); code != http.StatusOK {
+		t.Errorf("guest session reading: %d", code)
+	}
+	if code := call(core.ScopePrint, withToken); code != http.StatusForbidden {
+		t.Errorf("guest token printing: %d", code)
+	}
+
+	rec = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/guests/Ms%20Ortiz", nil)
+	r.SetPathValue("name", "Ms Ortiz")
+	handleGuestRevoke(rec, r)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke: %d", rec.Code)
+	}
+	if code := call(core.ScopeRead, withCookie); code != http.StatusUnauthorized {
+		t.Errorf("revoked guest's session: %d", code)
+	}
+	if code := call(core.ScopeRead, withToken); code != http.StatusUnauthorized {
+		t.Errorf("revoked guest's token: %d", code)
+	}
+}
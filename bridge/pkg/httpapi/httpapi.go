@@ -0,0 +1,73 @@
+// Package httpapi is the Graham Bridge's HTTP server: the endpoints web
+// apps and the dashboard call, over the printing core in pkg/core. It
+// serves raw print access to Braille embossers (especially ViewPlus devices)
+// on the user's machine.
+//
+// Endpoints:
+//
+//	GET  /status  → 200 {"status":"ok","job_schemas":[1,2],"max_upload_bytes":5242880}
+//	GET  /debug, /ui/{file} → the dashboard (see ui.go)
+//	GET  /kiosk             → read-only wall display of printers and the queue (?token=<read token>; see kiosk.go)
+//	GET  /guest?token=…     → open the dashboard read-only with a guest token (see guests.go)
+//	GET  /session           → {"name","scope"} of the dashboard's caller
+//	GET, POST /guests       → list or issue read-only guest tokens (admin scope)
+//	DELETE /guests/{name}   → revoke a guest token (admin scope)
+//	GET  /client.js → JavaScript module for web apps: discovery, pairing, printing, events
+//	GET  /features  → optional subsystems this bridge has, overall and per printer
+//	POST /print   → {"printer":"Name","data":"<base64 BRF>","student":"ID","urgent":false,"media":"labels","dots":8,"line_spacing":"double","print_text":"…"}
+//	                "also":[{"printer":"virtual:Proofs"}] adds linked companion jobs
+//	                "confirm":"…" prints a job over confirm_pages (see confirm.go)
+//	                (only data is required; printer defaults to GET /printers/default)
+//	POST /print-url         → {"printer":"Name","url":"https://….brf|.pef","student":"ID"}
+//	GET  /printers          → printer and pool names (?details=1 adds media settings, driver capabilities, USB setup help, pool members)
+//	GET, PUT /printers/default → {"printer":"Name"} for requests that name none (PUT: admin scope)
+//	GET  /printers/{name}/geometry → dot spacing and page size for tactile graphics
+//	GET  /printers/{name}/status   → ready/paused/offline/paper_out/…, queued job count
+//	GET  /printers/{name}/identity → model, firmware and serial number reported by the embosser
+//	GET  /printers/{name}/stats    → success rate, mean send time and common errors of recent jobs (?last=50)
+//	POST /printers/{name}/ruler    → emboss a calibration ruler page
+//	POST /lint              → {"printer":"Name","data":"<base64 BRF>"} → BANA format findings
+//	GET  /jobs              → job history with page totals (?student=&printer=&status=&verified=no&since=&until=)
+//	DELETE /jobs            → purge a student's records (?student=&before=, admin scope)
+//	GET  /reports/pages     → page totals (?by=student|printer|month&since=&until=&format=csv)
+//	GET  /paper             → estimated paper left per printer
+//	POST /paper/{printer}/reset → {"loaded":N} (optional) after refilling paper
+//	GET  /stats             → jobs/pages per day, failure rate per printer, busiest hours
+//	GET  /jobs/{id}/brf     → full BRF payload of a recorded job
+//	GET  /jobs/{id}/preview → the payload as sent, in pages of Unicode braille rows
+//	GET  /jobs/{id}/pdf     → PDF rendered by a virtual: printer
+//	POST /jobs/{id}/resend  → {"printer":"Name","data":"<base64 BRF>"} (both optional; checked like /print, hooks only for edited data)
+//	POST /jobs/{id}/print?pages=5-10 → reprint only those pages (or a list: pages=3,7,9-12; &confirm= for a long one)
+//	POST /jobs/{id}/annotations → {"text":"page 3 had weak dots, re-ran"} on a finished job
+//	POST /jobs/{id}/verify, /jobs/{id}/unverify → mark an embossed job's output as checked, or not
+//	POST /jobs/{id}/resume  → reprint a failed job from the page after "pages_sent"
+//	POST /jobs/{id}/release → print a held or waiting job (admin scope)
+//	POST /jobs/{id}/discard → cancel a held or waiting job (admin scope)
+//	POST /jobs/{id}/next    → emboss a queued job after the current one (admin scope)
+//	GET  /audit             → audit log export (?since=<RFC 3339>&format=csv)
+//	POST /config/reload     → re-read the config file (admin scope)
+//	GET  /backup            → config, files and job history as a .tar.gz (admin scope)
+//	POST /restore           → restore such a bundle, sent as the body (admin scope)
+//	GET, POST /log-level    → {"level":"trace","minutes":15} raises logging for a while (admin scope)
+//	GET  /clients           → machines that have called the bridge
+//	GET  /apps              → client applications, their tokens and today's usage (admin scope)
+//	GET, POST /ui-state     → dashboard state shared between tabs (see uistate.go; POST needs print scope)
+//	POST /queue/pause, /queue/resume → hold or send queued jobs
+//	POST /clients/{ip}/block, /clients/{ip}/unblock → station host control (admin scope)
+//	GET, POST, DELETE /loopback/{name} → bytes sent to loopback:{name}, injected failures (admin scope)
+//	GET  /debug/pprof/, /debug/runtime → profiler and runtime snapshot, with --debug-profiling only (admin scope)
+//
+// CORS allows only trusted Graham Braille Editor web origins (plus local dev URLs
+// and any "allowed_origins" from the config); requests from other web pages
+// are rejected even when they try to avoid CORS by omitting Origin.
+// Text responses are gzip-compressed for clients that accept it, and
+// request bodies may be sent gzip-compressed (see compress.go). Every
+// request is logged under an ID returned in X-Request-ID (see requestid.go)
+// and has deadlines set by its kind of endpoint (see timeouts.go).
+// The server binds to 127.0.0.1 only (not 0.0.0.0) unless station mode is
+// enabled in the config (see station.go).
+//
+// Started by Chrome or Edge with an extension origin as its argument, the
+// bridge serves the web-app endpoints over native messaging instead (see
+// nativehost.go).
+package httpapi
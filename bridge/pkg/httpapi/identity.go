@@ -0,0 +1,34 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Printer identity endpoint
+// ---------------------------------------------------------------------------
+
+// handlePrinterIdentity serves GET /printers/{name}/identity.
+func handlePrinterIdentity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), core.IdentityTimeout)
+	defer cancel()
+	id, err := core.QueryIdentity(ctx, r.PathValue("name"))
+	if errors.Is(err, core.ErrPrinterBusy) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		id.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(id)
+}
@@ -0,0 +1,153 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Job history API
+//
+// GET /jobs lists recorded jobs, optionally filtered, with page totals per
+// student so TVIs can document braille access for IEPs:
+//
+//	student=<id>            exact student identifier (case-insensitive)
+//	printer=<name>          exact destination
+//	status=<status>         e.g. held, queued, done or failed
+//	app=<name>              jobs sent with an application's tokens (apps.go)
+//	verified=yes|no         embossed jobs whose output was or was not checked (verify.go)
+//	since=, until=<RFC 3339>
+// ---------------------------------------------------------------------------
+
+// maxStudentLen bounds the student identifier stored on a job.
+const maxStudentLen = 64
+
+// JobTotals sums jobs and embossed pages. Pages count successful jobs only.
+type JobTotals struct {
+	Jobs  int `json:"jobs"`
+	Pages int `json:"pages"`
+}
+
+type jobsResponse struct {
+	Jobs      []core.JobEvent      `json:"jobs"`
+	Totals    JobTotals            `json:"totals"`
+	ByStudent map[string]JobTotals `json:"by_student"`
+}
+
+// parseJobFilter reads the /jobs query parameters.
+func parseJobFilter(r *http.Request) (core.JobFilter, error) {
+	q := r.URL.Query()
+	f := core.JobFilter{
+		Student:  strings.TrimSpace(q.Get("student")),
+		Printer:  q.Get("printer"),
+		Status:   q.Get("status"),
+		App:      q.Get("app"),
+		Verified: q.Get("verified"),
+	}
+	if f.Verified != "" && f.Verified != "yes" && f.Verified != "no" {
+		return f, errors.New("verified must be yes or no")
+	}
+	if app := callerApp(r); app != "" {
+		f.App = app // an application sees only its own jobs
+	}
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		if s := q.Get(p.name); s != "" {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return f, errors.New("since and until must be RFC 3339 times")
+			}
+			*p.dst = t
+		}
+	}
+	return f, nil
+}
+
+// handleJobs serves the filtered job history.
+func (h jobsAPI) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	f, err := parseJobFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := jobsResponse{Jobs: []core.JobEvent{}, ByStudent: map[string]JobTotals{}}
+	for _, e := range h.store.List() {
+		if !f.Match(e) {
+			continue
+		}
+		resp.Jobs = append(resp.Jobs, e)
+		addTotals(&resp.Totals, e)
+		if e.Student != "" {
+			key := core.ShownStudent(e.Student)
+			t := resp.ByStudent[key]
+			addTotals(&t, e)
+			resp.ByStudent[key] = t
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func addTotals(t *JobTotals, e core.JobEvent) {
+	t.Jobs++
+	if e.Status == core.StatusDone {
+		t.Pages += e.Pages
+	}
+}
+
+// normalizeStudent trims a student identifier to a bounded single line.
+func normalizeStudent(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > maxStudentLen {
+		s = strings.ToValidUTF8(s[:maxStudentLen], "")
+	}
+	return s
+}
+
+// handleJobRelease sends a held or waiting job to its printer.
+func (h jobsAPI) handleJobRelease(w http.ResponseWriter, r *http.Request) {
+	h.heldJobAction(w, r, "released", "held or waiting", core.ReleaseJob)
+}
+
+// handleJobDiscard cancels a held or waiting job.
+func (h jobsAPI) handleJobDiscard(w http.ResponseWriter, r *http.Request) {
+	h.heldJobAction(w, r, "discarded", "held or waiting", core.DiscardJob)
+}
+
+// handleJobNext moves a job to the front of its printer's queue.
+func (h jobsAPI) handleJobNext(w http.ResponseWriter, r *http.Request) {
+	h.heldJobAction(w, r, "moved to the front of the queue", "queued, held or waiting", core.EmbossNext)
+}
+
+func (h jobsAPI) heldJobAction(w http.ResponseWriter, r *http.Request, verb, from string, act func(int) (core.JobEvent, bool)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	src, ok := h.jobFromPath(w, r)
+	if !ok {
+		return
+	}
+	e, ok := act(src.ID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("job %d is %s, not %s", src.ID, e.Status, from), http.StatusConflict)
+		return
+	}
+	log.Printf("job %d %s by %s", e.ID, verb, identify(r))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"status": e.Status, "id": e.ID})
+}
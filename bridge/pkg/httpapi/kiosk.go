@@ -0,0 +1,66 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Kiosk display
+//
+// GET /kiosk is a full-screen, read-only view for a wall monitor beside
+// the embossers: each printer's state and the live queue in large type,
+// with no buttons. It updates from /log-stream like the dashboard.
+//
+// A wall monitor cannot type a password, so when credentials are
+// configured the kiosk is opened once with a read token in the link:
+//
+//	http://bridge:8080/kiosk?token=<a token with scope "read">
+//
+// The token is exchanged for a read-only session cookie that lasts
+// kioskSessionTTL, and the page reloads that link if the bridge restarts
+// and forgets the session. Only read tokens are taken, since the link is
+// on show; the session is read-only in any case, so a kiosk can never
+// print, cancel or change settings. An application's token (apps.go)
+// shows only that application's jobs. The session ends as soon as its
+// token is removed from the config or its application is revoked.
+//
+// ?theme=light or ?theme=high-contrast picks the dashboard theme.
+// ---------------------------------------------------------------------------
+
+// kioskSessionTTL is how long a kiosk stays logged in without reloading.
+const kioskSessionTTL = 30 * 24 * time.Hour
+
+// handleKiosk serves GET /kiosk.
+func handleKiosk(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if tok := r.URL.Query().Get("token"); tok != "" {
+		p := tokenPrincipal(core.CurrentConfig(), tok)
+		switch {
+		case p.Scope == "":
+			http.Error(w, "Unauthorized: this kiosk link's token is not valid", http.StatusUnauthorized)
+			return
+		case p.Scope != core.ScopeRead:
+			http.Error(w, "Forbidden: a kiosk link shows its token, so it must be a token with the read scope only", http.StatusForbidden)
+			return
+		}
+		ttl := kioskSessionTTL
+		if exp := guestExpiry(p); !exp.IsZero() {
+			ttl = min(ttl, time.Until(exp))
+		}
+		startSession(w, principal{Name: p.Name + " (kiosk)", Scope: core.ScopeRead, App: p.App}, ttl, func(c *core.Config) bool {
+			return tokenPrincipal(c, tok).Scope != ""
+		})
+	} else if authRequired() {
+		if p, _ := authenticate(r); p.Scope == "" {
+			http.Error(w, "Unauthorized: open the kiosk with /kiosk?token=<read token>", http.StatusUnauthorized)
+			return
+		}
+	}
+	serveUIFile(w, r, "kiosk.html")
+}
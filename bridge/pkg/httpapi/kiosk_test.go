@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestKiosk(t *testing.T) {
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	core.Cfg.Store(&core.Config{Tokens: []core.APIToken{
+		{Name: "wall", Token: "wall-token-0123456789", Scope: core.ScopeRead},
+		{Name: "web", Token: "web-token-0123456789", Scope: core.ScopePrint},
+	}})
+	kiosk := func(query string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/kiosk"+query, nil)
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		handleKiosk(rec, r)
+		return rec
+	}
+	if rec := kiosk(""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("kiosk without a token: %d", rec.Code)
+	}
+	if rec := kiosk("?token=web-token-0123456789"); rec.Code != http.StatusForbidden {
+		t.Errorf("kiosk with a print token: %d", rec.Code)
+	}
+	rec := kiosk("?token=wall-token-0123456789")
+	cookies := rec.Result().Cookies()
+	if rec.Code != http.StatusOK || len(cookies) != 1 || !strings.Contains(rec.Body.String(), "kiosk.js") {
+		t.Fatalf("kiosk with a read token: %d, cookies %v", rec.Code, cookies)
+	}
+	if rec := kiosk("", cookies...); rec.Code != http.StatusOK {
+		t.Errorf("kiosk reloaded with its session: %d", rec.Code)
+	}
+
+	// The session reads but cannot act.
+	ran := false
+	pause := requireScope(core.ScopePrint, func(http.ResponseWriter, *http.Request) { ran = true })
+	r := httptest.NewRequest(http.MethodPost, "/queue/pause", nil)
+	r.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	pause(rec, r)
+	if ran || rec.Code != http.StatusForbidden {
+		t.Errorf("kiosk session paused the queue: %d", rec.Code)
+	}
+}
+
+func TestKioskSessionEndsWhenAppRevoked(t *testing.T) {
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	app := func(revoked bool) *core.Config {
+		return &core.Config{Apps: map[string]core.AppConfig{
+			"web": {Tokens: []core.APIToken{{Name: "wall", Token: "wall-token-0123456789", Scope: core.ScopeRead}}, Revoked: revoked},
+		}}
+	}
+	core.Cfg.Store(app(false))
+	rec := httptest.NewRecorder()
+	handleKiosk(rec, httptest.NewRequest(http.MethodGet, "/kiosk?token=wall-token-0123456789", nil))
+	cookies := rec.Result().Cookies()
+	if rec.Code != http.StatusOK || len(cookies) != 1 {
+		t.Fatalf("kiosk with an app's read token: %d, cookies %v", rec.Code, cookies)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/kiosk", nil)
+	r.AddCookie(cookies[0])
+	if p, ok := sessionPrincipal(r); !ok || p.App != "web" {
+		t.Fatalf("kiosk session = %+v, %v", p, ok)
+	}
+
+	core.Cfg.Store(app(true))
+	if p, ok := sessionPrincipal(r); ok {
+		t.Errorf("kiosk session outlived its app's revocation: %+v", p)
+	}
+	core.Cfg.Store(app(false))
+	if _, ok := sessionPrincipal(r); ok {
+		t.Error("a revoked kiosk session came back")
+	}
+}
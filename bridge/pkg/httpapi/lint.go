@@ -0,0 +1,54 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// BRF lint endpoint
+//
+// POST /lint runs the checks in pkg/core/lint.go on a payload before it
+// is printed.
+// ---------------------------------------------------------------------------
+
+// lintRequest is the JSON body for POST /lint.
+type lintRequest struct {
+	Printer string `json:"printer,omitempty"` // selects the page layout
+	Data    string `json:"data"`              // Base64-encoded BRF content
+}
+
+// handleLint serves POST /lint.
+func handleLint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+	var req lintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		badUploadBody(w, err)
+		return
+	}
+	data, err := base64.StdEncoding.DecodeString(req.Data)
+	if err != nil || len(data) == 0 {
+		http.Error(w, "data must be non-empty base64", http.StatusBadRequest)
+		return
+	}
+	if err := brf.Check(data); err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+	data, _ = brf.Normalize(data)
+
+	g := core.GeometryFor(req.Printer)
+	rep := core.LintBRF(data, g.CellsPerLine, g.LinesPerPage)
+	rep.Printer = req.Printer
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rep)
+}
@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Log level endpoint
+// ---------------------------------------------------------------------------
+
+// handleLogLevel serves GET and POST /log-level.
+func handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	var s core.LogLevelStatus
+	switch r.Method {
+	case http.MethodGet:
+		core.LogLevelState.Lock()
+		s = core.LogLevelStatusLocked()
+		core.LogLevelState.Unlock()
+	case http.MethodPost:
+		var req struct {
+			Level   string `json:"level"`
+			Minutes int    `json:"minutes"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			http.Error(w, "body must be {\"level\": \"info|debug|trace\", \"minutes\": N}", http.StatusBadRequest)
+			return
+		}
+		level := slices.Index(core.LogLevelNames, strings.ToLower(req.Level))
+		if level < 0 {
+			http.Error(w, "level must be info, debug or trace", http.StatusBadRequest)
+			return
+		}
+		d := time.Duration(req.Minutes) * time.Minute
+		if req.Minutes == 0 {
+			d = core.DefaultLogLevelDuration
+		}
+		if d <= 0 || d > core.MaxLogLevelDuration {
+			http.Error(w, "minutes must be from 1 to 480", http.StatusBadRequest)
+			return
+		}
+		s = core.SetLogLevel(level, d)
+		if level == core.LevelInfo {
+			log.Printf("log level set to info")
+		} else {
+			log.Printf("log level set to %s until %s", s.Level, s.Until.Format(time.Kitchen))
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
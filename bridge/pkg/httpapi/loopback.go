@@ -0,0 +1,50 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Loopback printer endpoint
+//
+// GET shows what loopback:{name} was sent, POST injects failures and
+// latency, DELETE starts it afresh.
+// ---------------------------------------------------------------------------
+
+// handleLoopback serves GET, POST and DELETE /loopback/{name}.
+func handleLoopback(w http.ResponseWriter, r *http.Request) {
+	printer := core.LoopbackPrefix + r.PathValue("name")
+	core.Loopbacks.Lock()
+	defer core.Loopbacks.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var set core.LoopbackSettings
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&set); err != nil {
+			http.Error(w, "body must be {\"fail\": N, \"fail_code\": \"…\", \"fail_after_bytes\": N, \"latency_ms\": N}", http.StatusBadRequest)
+			return
+		}
+		if set.Fail < 0 || set.FailAfter < 0 || set.LatencyMS < 0 {
+			http.Error(w, "fail, fail_after_bytes and latency_ms must not be negative", http.StatusBadRequest)
+			return
+		}
+		if set.FailCode != "" && core.ErrorGuidance[set.FailCode] == "" {
+			http.Error(w, fmt.Sprintf("unknown fail_code %q", set.FailCode), http.StatusBadRequest)
+			return
+		}
+		core.Loopback(printer).Settings = set
+		log.Printf("%s: fail %d, latency %d ms", printer, set.Fail, set.LatencyMS)
+	case http.MethodDelete:
+		core.ResetLoopback(printer)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.Loopback(printer))
+}
@@ -0,0 +1,47 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestLoopbackPrinter(t *testing.T) {
+	set := func(body string) {
+		r := httptest.NewRequest(http.MethodPost, "/loopback/T", strings.NewReader(body))
+		r.SetPathValue("name", "T")
+		rec := httptest.NewRecorder()
+		handleLoopback(rec, r)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("settings %s: %d %s", body, rec.Code, rec.Body)
+		}
+	}
+	emboss := func() int {
+		rec := httptest.NewRecorder()
+		body := `{"printer":"loopback:T","data":"QUJDCgw="}` // "ABC\n\f"
+		printHandler(rec, httptest.NewRequest(http.MethodPost, "/print", strings.NewReader(body)))
+		return rec.Code
+	}
+	set(`{"fail": 1, "fail_after_bytes": 2}`)
+	if code := emboss(); code == http.StatusOK {
+		t.Error("injected failure printed")
+	}
+	if code := emboss(); code != http.StatusOK {
+		t.Errorf("second job: %d", code)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/loopback/T", nil)
+	r.SetPathValue("name", "T")
+	rec := httptest.NewRecorder()
+	handleLoopback(rec, r)
+	var st core.LoopbackState
+	json.Unmarshal(rec.Body.Bytes(), &st)
+	if len(st.Jobs) != 2 || string(st.Jobs[0].Data) != "AB" || st.Jobs[0].ErrMsg == "" ||
+		string(st.Jobs[1].Data) != "ABC\n\f" || st.Settings.Fail != 0 {
+		t.Errorf("loopback state %+v", st)
+	}
+}
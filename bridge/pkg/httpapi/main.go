@@ -0,0 +1,441 @@
+package httpapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// CORS and origin verification
+// ---------------------------------------------------------------------------
+
+// defaultOrigins are the trusted Graham Braille Editor web origins (plus
+// local dev URLs). Config "allowed_origins" extends this list.
+var defaultOrigins = []string{
+	"https://grahamthetvi.github.io",
+	"https://grahambrailleeditor.com",
+	"https://www.grahambrailleeditor.com",
+	"http://localhost:5173",
+	"http://127.0.0.1:5173",
+	"http://localhost:8080",
+	"http://127.0.0.1:8080",
+}
+
+// originAllowed reports whether a browser origin may call the bridge.
+func originAllowed(origin string) bool {
+	return slices.Contains(defaultOrigins, origin) ||
+		slices.Contains(core.CurrentConfig().AllowedOrigins, origin)
+}
+
+// verifyOrigin rejects requests initiated by web pages other than the
+// trusted web apps, so a malicious page open on the same machine cannot
+// emboss content or enumerate printers.
+//
+// Browsers label requests with Origin and/or Sec-Fetch-Site; a request
+// with neither comes from a local tool such as curl and is allowed. A
+// state-changing request without Origin must at least carry a trusted
+// Referer if it has one.
+func verifyOrigin(r *http.Request) error {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if !originAllowed(origin) {
+			return fmt.Errorf("origin %q not allowed", origin)
+		}
+		return nil
+	}
+	switch site := r.Header.Get("Sec-Fetch-Site"); site {
+	case "", "none", "same-origin":
+	default:
+		return fmt.Errorf("%s request without an Origin header", site)
+	}
+	if isStateChanging(r.Method) {
+		if ref := r.Header.Get("Referer"); ref != "" {
+			u, err := url.Parse(ref)
+			if err != nil || !originAllowed(u.Scheme+"://"+u.Host) {
+				return fmt.Errorf("referer %q not allowed", ref)
+			}
+		}
+	}
+	return nil
+}
+
+func isStateChanging(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+func withCORS(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+
+		// Only allow specific trusted origins to prevent Cross-Site Request Forgery (CSRF).
+		// An empty string origin ("") is often sent for same-origin requests or curl commands.
+		if origin == "" {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else if originAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
+		if origin == "" || originAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Bridge-Token, X-Request-ID")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+			// Needed for Chrome Private Network Access (PNA)
+			w.Header().Set("Access-Control-Allow-Private-Network", "true")
+		}
+
+		// Handle pre-flight
+		if r.Method == http.MethodOptions {
+			if origin != "" && !originAllowed(origin) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// Actively refuse unauthorized requests at the server level
+		if err := verifyOrigin(r); err != nil {
+			log.Printf("rejected %s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, "Forbidden: "+err.Error(), http.StatusForbidden)
+			return
+		}
+
+		// API responses change with every job; dashboard assets set
+		// their own caching (ui.go).
+		w.Header().Set("Cache-Control", "no-store")
+		next(w, r)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Handlers
+// ---------------------------------------------------------------------------
+
+// statusHandler returns a simple health-check response.
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": "ok", "app": "graham-bridge", "version": core.BridgeVersion,
+		"job_schema": core.JobSchema, "job_schemas": jobSchemas, // schema.go
+		"max_upload_bytes": maxUploadBytes, // compress.go
+	})
+}
+
+// printRequest is the JSON body for the /print endpoint.
+type printRequest struct {
+	Printer string `json:"printer"`           // OS printer name
+	Data    string `json:"data"`              // Base64-encoded BRF content
+	Student string `json:"student,omitempty"` // optional student identifier for reports
+	Urgent  bool   `json:"urgent,omitempty"`  // print even during quiet hours
+	Media   string `json:"media,omitempty"`   // media setting from the printer profile (pkg/core/media.go)
+	Dots    int    `json:"dots,omitempty"`    // 6 (default) or 8 for eight-dot cells (pkg/core/dots.go)
+
+	// PrintText is the ink-print version, line for line (see pkg/core/interline.go).
+	PrintText string `json:"print_text,omitempty"`
+
+	// LineSpacing is "single" (default), "double" or "interline" (see pkg/core/spacing.go).
+	LineSpacing string `json:"line_spacing,omitempty"`
+
+	// Also lists companion outputs sent as linked jobs (see pkg/core/chain.go).
+	Also []core.Companion `json:"also,omitempty"`
+
+	// Confirm is the value from an "estimate" answer, to print a job over
+	// the config's confirm_pages (see confirm.go).
+	Confirm string `json:"confirm,omitempty"`
+}
+
+// printHandler decodes the request and sends raw bytes to the printer.
+func printHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Enforce a 5 MB limit on the request body to prevent memory exhaustion DOS attacks
+	// (a gzip-compressed body is limited after decompression)
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBytes)
+
+	var req printRequest
+	dec := json.NewDecoder(r.Body)
+	if err := dec.Decode(&req); err != nil {
+		badUploadBody(w, err)
+		return
+	}
+
+	if !resolvePrinter(w, &req.Printer) {
+		return
+	}
+	if req.Data == "" {
+		http.Error(w, "data is required", http.StatusBadRequest)
+		return
+	}
+
+	if !core.ValidDots(req.Dots) {
+		http.Error(w, "dots must be 6 or 8", http.StatusBadRequest)
+		return
+	}
+	if !core.ValidSpacing(req.LineSpacing) {
+		http.Error(w, "line_spacing must be single, double or interline", http.StatusBadRequest)
+		return
+	}
+
+	if err := core.ValidCompanions(req.Printer, req.Also, req.PrintText); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rawBytes, err := base64.StdEncoding.DecodeString(req.Data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid base64 data: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	submitPrint(w, r, req.Printer, rawBytes, core.JobOptions{
+		Student:   req.Student,
+		Urgent:    req.Urgent,
+		Media:     req.Media,
+		Dots:      req.Dots,
+		Spacing:   req.LineSpacing,
+		PrintText: req.PrintText,
+		Also:      req.Also,
+		Confirm:   req.Confirm,
+	})
+}
+
+// submitPrint checks, queues and (unless held) waits for a print job on
+// behalf of /print, /print-url and edited resends.
+func submitPrint(w http.ResponseWriter, r *http.Request, printer string, data []byte, opts core.JobOptions) {
+	requested := printer
+	printer, pool := core.ResolvePool(printer) // pkg/core/pool.go
+	// A large job waits for confirmation of the request as sent
+	// (confirm.go), before any hook sees it. Double spacing doubles its
+	// pages; a payload too broken to count is refused below.
+	if core.CurrentConfig().ConfirmPages > 0 {
+		if est, perr := core.PrepareJob(printer, data); perr == nil {
+			core.ApplyLineSpacing(&est, opts.Spacing)
+			if !confirmLarge(w, est, requested, data, opts.Confirm) {
+				return
+			}
+		}
+	}
+	student := normalizeStudent(opts.Student)
+	data, hooks, hookErr := core.RunPreHooks(printer, student, data)
+	job, perr := core.PrepareJob(printer, data)
+	if perr != nil {
+		log.Printf("print request refused: printer=%q: %v", printer, perr)
+		http.Error(w, perr.Message, perr.Status)
+		return
+	}
+	caller, _ := authenticate(r)
+	job.Student = student
+	job.App = caller.App
+	job.Hooks = hooks
+	job.Pool = pool
+	job.RequestID = requestID(r.Context())
+	job.Urgent = opts.Urgent
+	job.ResentFrom = opts.ResentFrom
+	if hookErr != nil {
+		e := core.RejectJob(job, hookErr)
+		log.Printf("job %d refused: %v", e.ID, hookErr)
+		writeFailure(w, http.StatusUnprocessableEntity, core.StatusRejected, e.ID, hookErr)
+		return
+	}
+	if opts.PrintText != "" {
+		if perr := core.AttachPrintText(&job, opts.PrintText); perr != nil {
+			http.Error(w, perr.Message, perr.Status)
+			return
+		}
+	}
+	if perr := core.ApplyLineSpacing(&job, opts.Spacing); perr != nil {
+		http.Error(w, perr.Message, perr.Status)
+		return
+	}
+	core.ApplyReverse(&job)
+	if perr := core.ApplyDots(&job, opts.Dots); perr != nil {
+		http.Error(w, perr.Message, perr.Status)
+		return
+	}
+	if perr := core.ApplyMedia(&job, opts.Media); perr != nil {
+		http.Error(w, perr.Message, perr.Status)
+		return
+	}
+
+	log.Printf("print request: printer=%q bytes=%d", printer, len(data))
+	e, sent := dispatchJob(w, r, caller, job, data, opts)
+	if !sent {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"status": "queued", "id": e.ID})
+}
+
+// dispatchJob takes a prepared job through the checks every job a caller
+// submits gets once its payload is final: the application's quota, the
+// printer's readiness, hold for release and quiet hours. It then queues
+// the job and waits for it to be sent. It answers the request itself
+// unless the job was sent, when it returns the job and true for the
+// caller to report. data and opts are the payload and options companion
+// jobs (pkg/core/chain.go) are built from.
+func dispatchJob(w http.ResponseWriter, r *http.Request, caller principal, job core.JobEvent, data []byte, opts core.JobOptions) (core.JobEvent, bool) {
+	printer := job.Printer
+	// The job counts against its application's quota from here until it is
+	// recorded, so concurrent requests cannot all fit under the same cap.
+	release, err := core.ReserveAppQuota(caller.App, job.Pages) // pkg/core/apps.go
+	if err != nil {
+		log.Printf("print request refused: %v", err)
+		writeFailure(w, http.StatusTooManyRequests, core.StatusRejected, 0, err)
+		return job, false
+	}
+	defer release()
+	if err := core.Preflight(r.Context(), printer); err != nil && core.WaitsForPrinter(err) {
+		e := core.WaitJob(job, err)
+		release()
+		log.Printf("job %d waiting for %q: %v", e.ID, printer, err)
+		core.SendCompanions(r.Context(), e, data, opts, false)
+		writeWaiting(w, e)
+		return e, false
+	} else if err != nil {
+		writePreflightFailure(w, printer, err)
+		return job, false
+	}
+
+	// Jobs from tokens marked "hold" wait for a teacher to release them;
+	// others wait out the printer's quiet hours unless marked urgent.
+	if caller.Hold {
+		job.HoldReason = core.HoldApproval
+		e := core.HoldJob(job)
+		release()
+		log.Printf("job %d held for release (%s)", e.ID, caller.Name)
+		core.SendCompanions(r.Context(), e, data, opts, true)
+		writeHeld(w, e)
+		return e, false
+	}
+	if until, quiet := core.QuietUntil(printer, time.Now()); quiet && !job.Urgent {
+		job.HoldReason, job.HeldUntil = core.HoldQuietHours, until
+		e := core.HoldJob(job)
+		release()
+		log.Printf("job %d held for quiet hours on %q until %s", e.ID, printer, until.Format(time.Kitchen))
+		core.SendCompanions(r.Context(), e, data, opts, false)
+		writeHeld(w, e)
+		return e, false
+	}
+
+	// Queue the job behind any others for the same printer and wait for it
+	// to be sent; the job event is recorded for the debug UI as it runs.
+	e, done := core.EnqueueJob(job)
+	release()
+	core.SendCompanions(r.Context(), e, data, opts, false)
+	finished, printErr := core.AwaitJob(r.Context(), done)
+	if printErr != nil && finished {
+		writeJobFailure(w, e, printErr)
+		return e, false
+	}
+	if !finished {
+		writeAccepted(w, e)
+		return e, false
+	}
+	return e, true
+}
+
+// writeHeld answers 202 for a job placed on hold.
+func writeHeld(w http.ResponseWriter, e core.JobEvent) {
+	resp := map[string]any{"status": core.StatusHeld, "id": e.ID, "reason": e.HoldReason}
+	if !e.HeldUntil.IsZero() {
+		resp["until"] = e.HeldUntil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeAccepted answers 202 for a job that is still queued or printing when
+// the handler stops waiting; its outcome appears in the job log.
+func writeAccepted(w http.ResponseWriter, e core.JobEvent) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{"status": "accepted", "id": e.ID})
+}
+
+// APIRoutes registers the web app's endpoints, reading job history from s.
+// The native-messaging host serves these too (see nativehost.go).
+func APIRoutes(mux *http.ServeMux, s core.JobStore) {
+	jobs := jobsAPI{store: s}
+	mux.HandleFunc("/status", withCORS(statusHandler))
+	mux.HandleFunc("/client.js", withCORS(handleClientJS))
+	mux.HandleFunc("/features", withCORS(requireAPIScope(core.ScopeRead, handleFeatures)))
+	mux.HandleFunc("/print", withCORS(requireAPIScope(core.ScopePrint, printHandler)))
+	mux.HandleFunc("/printers", withCORS(requireAPIScope(core.ScopeRead, handlePrinters)))
+	mux.HandleFunc("/print-url", withCORS(requireAPIScope(core.ScopePrint, handlePrintURL)))
+	mux.HandleFunc("/printers/default", withCORS(requireAPIScope(core.ScopeRead, handleDefaultPrinter)))
+	mux.HandleFunc("/printers/{name}/geometry", withCORS(requireAPIScope(core.ScopeRead, handleGeometry)))
+	mux.HandleFunc("/printers/{name}/status", withCORS(requireAPIScope(core.ScopeRead, handlePrinterStatus)))
+	mux.HandleFunc("/printers/{name}/identity", withCORS(requireAPIScope(core.ScopeRead, handlePrinterIdentity)))
+	mux.HandleFunc("/printers/{name}/stats", withCORS(requireAPIScope(core.ScopeRead, handlePrinterReliability)))
+	mux.HandleFunc("/lint", withCORS(requireAPIScope(core.ScopeRead, handleLint)))
+	mux.HandleFunc("/jobs/{id}/preview", withCORS(requireAPIScope(core.ScopeRead, jobs.handleJobPreview)))
+}
+
+// DashboardRoutes registers the dashboard and admin endpoints (password or
+// token protected if configured), reading job history from s.
+func DashboardRoutes(mux *http.ServeMux, s core.JobStore) {
+	jobs := jobsAPI{store: s}
+	mux.HandleFunc("/debug", withCORS(requireScope(core.ScopeRead, handleDebugPage)))
+	mux.HandleFunc("/kiosk", withCORS(handleKiosk))
+	mux.HandleFunc("/guest", withCORS(handleGuestLink))
+	mux.HandleFunc("/session", withCORS(requireScope(core.ScopeRead, handleSession)))
+	mux.HandleFunc("/guests", withCORS(requireScope(core.ScopeAdmin, handleGuests)))
+	mux.HandleFunc("DELETE /guests/{name}", withCORS(requireScope(core.ScopeAdmin, handleGuestRevoke)))
+	mux.HandleFunc("/ui/{file...}", withCORS(requireScope(core.ScopeRead, handleUIAsset)))
+	mux.HandleFunc("/log-stream", withCORS(requireScope(core.ScopeRead, jobs.handleLogStream)))
+	mux.HandleFunc("/testprint", withCORS(requireScope(core.ScopePrint, handleTestPrint)))
+	mux.HandleFunc("PUT /printers/default", withCORS(requireScope(core.ScopeAdmin, handleSetDefaultPrinter)))
+	mux.HandleFunc("/printers/{name}/ruler", withCORS(requireScope(core.ScopePrint, handleRuler)))
+	mux.HandleFunc("/jobs", withCORS(requireScope(core.ScopeRead, jobs.handleJobs)))
+	mux.HandleFunc("DELETE /jobs", withCORS(requireScope(core.ScopeAdmin, jobs.handlePurge)))
+	mux.HandleFunc("/reports/pages", withCORS(requireScope(core.ScopeRead, handlePageReport)))
+	mux.HandleFunc("/stats", withCORS(requireScope(core.ScopeRead, handleUsageStats)))
+	mux.HandleFunc("/paper", withCORS(requireScope(core.ScopeRead, handlePaper)))
+	mux.HandleFunc("/paper/{printer}/reset", withCORS(requireScope(core.ScopePrint, handlePaperReset)))
+	mux.HandleFunc("/jobs/{id}/brf", withCORS(requireScope(core.ScopeRead, jobs.handleJobBRF)))
+	mux.HandleFunc("/jobs/{id}/pdf", withCORS(requireScope(core.ScopeRead, jobs.handleJobPDF)))
+	mux.HandleFunc("/jobs/{id}/resend", withCORS(requireScope(core.ScopePrint, jobs.handleJobResend)))
+	mux.HandleFunc("/jobs/{id}/print", withCORS(requireScope(core.ScopePrint, jobs.handleJobPrintPages)))
+	mux.HandleFunc("/jobs/{id}/annotations", withCORS(requireScope(core.ScopePrint, jobs.handleJobAnnotate)))
+	mux.HandleFunc("/jobs/{id}/verify", withCORS(requireScope(core.ScopePrint, jobs.handleJobVerify)))
+	mux.HandleFunc("/jobs/{id}/unverify", withCORS(requireScope(core.ScopePrint, jobs.handleJobUnverify)))
+	mux.HandleFunc("/jobs/{id}/resume", withCORS(requireScope(core.ScopePrint, jobs.handleJobResume)))
+	mux.HandleFunc("/jobs/{id}/release", withCORS(requireScope(core.ScopeAdmin, jobs.handleJobRelease)))
+	mux.HandleFunc("/jobs/{id}/discard", withCORS(requireScope(core.ScopeAdmin, jobs.handleJobDiscard)))
+	mux.HandleFunc("/jobs/{id}/next", withCORS(requireScope(core.ScopeAdmin, jobs.handleJobNext)))
+	mux.HandleFunc("/audit", withCORS(requireScope(core.ScopeAdmin, handleAuditExport)))
+	mux.HandleFunc("/config/reload", withCORS(requireScope(core.ScopeAdmin, handleConfigReload)))
+	mux.HandleFunc("/backup", withCORS(requireScope(core.ScopeAdmin, jobs.handleBackup)))
+	mux.HandleFunc("/restore", withCORS(requireScope(core.ScopeAdmin, jobs.handleRestore)))
+	mux.HandleFunc("/log-level", withCORS(requireScope(core.ScopeAdmin, handleLogLevel)))
+	mux.HandleFunc("/clients", withCORS(requireScope(core.ScopeRead, handleClients)))
+	mux.HandleFunc("/apps", withCORS(requireScope(core.ScopeAdmin, jobs.handleApps)))
+	mux.HandleFunc("/ui-state", withCORS(requireScope(core.ScopeRead, handleUIState)))
+	mux.HandleFunc("POST /ui-state", withCORS(requireScope(core.ScopePrint, handleUIState)))
+	mux.HandleFunc("/queue/pause", withCORS(requireScope(core.ScopePrint, handleQueuePause)))
+	mux.HandleFunc("/queue/resume", withCORS(requireScope(core.ScopePrint, handleQueueResume)))
+	mux.HandleFunc("/clients/{ip}/block", withCORS(requireScope(core.ScopeAdmin, handleClientBlock)))
+	mux.HandleFunc("/clients/{ip}/unblock", withCORS(requireScope(core.ScopeAdmin, handleClientUnblock)))
+	mux.HandleFunc("/loopback/{name}", withCORS(requireScope(core.ScopeAdmin, handleLoopback)))
+}
@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// TestMain points the user config directory, and so the data directory
+// (audit log, page ledger, student key), at a scratch directory, so a test
+// run never writes to the developer's own bridge files.
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "graham-bridge-test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, v := range []string{"HOME", "XDG_CONFIG_HOME", "AppData"} {
+		os.Setenv(v, home)
+	}
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
+
+func TestDataStaysInTestHome(t *testing.T) {
+	old := core.CfgPath
+	defer func() { core.CfgPath = old }()
+	core.CfgPath = ""
+	home := os.Getenv("HOME")
+	for _, p := range []string{core.PageLedger.Path(), auditPath(), core.DataDir()} {
+		if rel, err := filepath.Rel(home, p); err != nil || strings.HasPrefix(rel, "..") {
+			t.Errorf("%s is outside the test home %s", p, home)
+		}
+	}
+}
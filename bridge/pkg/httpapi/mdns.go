@@ -0,0 +1,188 @@
+package httpapi
+
+import (
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// mDNS / DNS-SD advertisement
+//
+// A minimal multicast DNS responder (RFC 6762/6763) so clients on the LAN
+// can discover the bridge without typing an address. It announces its
+// services at startup and answers PTR, SRV, TXT and A queries for them.
+// ---------------------------------------------------------------------------
+
+const (
+	mdnsTTL      = 120 // seconds
+	mdnsCacheBit = 0x8000
+)
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// mdnsService is one DNS-SD service instance.
+type mdnsService struct {
+	Instance string // human-readable, e.g. "Graham Bridge (Room 12)"
+	Type     string // e.g. "_graham-bridge._tcp"
+	Port     int
+	TXT      []string // key=value pairs
+}
+
+func (s mdnsService) typeName() string { return s.Type + ".local." }
+
+func (s mdnsService) instanceName() string {
+	// Dots would split the instance label.
+	return strings.ReplaceAll(s.Instance, ".", "-") + "." + s.typeName()
+}
+
+// advertise runs the responder until the socket fails. It is meant to run
+// in its own goroutine.
+func advertise(services []mdnsService) {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		log.Printf("mDNS: %v", err)
+		return
+	}
+	defer conn.Close()
+	host := mdnsHostName()
+
+	// Announce twice, a second apart, as RFC 6762 §8.3 recommends.
+	for i := 0; i < 2; i++ {
+		if msg := mdnsAnswer(services, host, nil); msg != nil {
+			conn.WriteToUDP(msg, mdnsGroup)
+		}
+		time.Sleep(time.Second)
+	}
+
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			log.Printf("mDNS: %v", err)
+			return
+		}
+		var p dnsmessage.Parser
+		h, err := p.Start(buf[:n])
+		if err != nil || h.Response {
+			continue
+		}
+		qs, err := p.AllQuestions()
+		if err != nil {
+			continue
+		}
+		msg := mdnsAnswer(services, host, qs)
+		if msg == nil {
+			continue
+		}
+		// Legacy (non-5353) queriers get a unicast reply.
+		dst := mdnsGroup
+		if src.Port != mdnsGroup.Port {
+			dst = src
+		}
+		conn.WriteToUDP(msg, dst)
+	}
+}
+
+// mdnsAnswer builds a response to qs, or a full announcement when qs is
+// nil. It returns nil when nothing matches.
+func mdnsAnswer(services []mdnsService, host string, qs []dnsmessage.Question) []byte {
+	wants := func(name string, types ...dnsmessage.Type) bool {
+		if qs == nil {
+			return true
+		}
+		for _, q := range qs {
+			if !strings.EqualFold(q.Name.String(), name) {
+				continue
+			}
+			if q.Type == dnsmessage.TypeALL {
+				return true
+			}
+			for _, t := range types {
+				if q.Type == t {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, Authoritative: true})
+	b.EnableCompression()
+	b.StartAnswers()
+	hdr := func(name string, unique bool) dnsmessage.ResourceHeader {
+		class := dnsmessage.ClassINET
+		if unique {
+			class |= mdnsCacheBit
+		}
+		return dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Class: class, TTL: mdnsTTL}
+	}
+
+	answered, withHost := false, false
+	for _, s := range services {
+		if wants("_services._dns-sd._udp.local.", dnsmessage.TypePTR) {
+			b.PTRResource(hdr("_services._dns-sd._udp.local.", false),
+				dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(s.typeName())})
+			answered = true
+		}
+		inst := s.instanceName()
+		ptr := wants(s.typeName(), dnsmessage.TypePTR)
+		if ptr {
+			b.PTRResource(hdr(s.typeName(), false), dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(inst)})
+		}
+		if ptr || wants(inst, dnsmessage.TypeSRV) {
+			b.SRVResource(hdr(inst, true), dnsmessage.SRVResource{
+				Port: uint16(s.Port), Target: dnsmessage.MustNewName(host)})
+			withHost = true
+		}
+		if ptr || wants(inst, dnsmessage.TypeTXT) {
+			txt := s.TXT
+			if len(txt) == 0 {
+				txt = []string{""}
+			}
+			b.TXTResource(hdr(inst, true), dnsmessage.TXTResource{TXT: txt})
+		}
+		answered = answered || ptr || withHost || wants(inst, dnsmessage.TypeTXT)
+	}
+	if withHost || wants(host, dnsmessage.TypeA) {
+		for _, ip := range lanIPv4s() {
+			b.AResource(hdr(host, true), dnsmessage.AResource{A: [4]byte(ip.To4())})
+		}
+		answered = true
+	}
+	if !answered {
+		return nil
+	}
+	msg, err := b.Finish()
+	if err != nil {
+		return nil
+	}
+	return msg
+}
+
+// mdnsHostName is this machine's .local name.
+func mdnsHostName() string {
+	h := core.HostnameOr("graham-bridge")
+	h, _, _ = strings.Cut(h, ".")
+	return h + ".local."
+}
+
+// lanIPv4s lists this machine's non-loopback IPv4 addresses.
+func lanIPv4s() []net.IP {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok && n.IP.To4() != nil && !n.IP.IsLoopback() && !n.IP.IsLinkLocalUnicast() {
+			ips = append(ips, n.IP)
+		}
+	}
+	return ips
+}
@@ -0,0 +1,223 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Native messaging host
+//
+// Managed Chromebooks and locked-down Windows machines often block web pages
+// from calling localhost. A companion browser extension can reach the bridge
+// through Chrome/Edge native messaging instead: the browser starts the
+// bridge with the extension's origin as its only argument and exchanges
+// length-prefixed JSON messages over stdin and stdout, with no network
+// involved.
+//
+// Each message names a web-app endpoint and carries its JSON body:
+//
+//	{"id": 1, "method": "POST", "path": "/print", "token": "…", "body": {…}}
+//
+// and is answered with the endpoint's status and response:
+//
+//	{"id": 1, "status": 200, "body": {"status": "queued"}}
+//
+// Only the endpoints in APIRoutes are served, with the same checks, tokens
+// and audit logging as over HTTP. Extensions must be listed in the config's
+// "native_host" block; --install-native-host writes the browser manifests
+// (and, on Windows, registry entries) that point the browser at this binary.
+// ---------------------------------------------------------------------------
+
+const nativeHostName = "com.grahambrailleeditor.bridge"
+
+// The browser sends messages of up to 64 MiB and refuses replies over 1 MiB.
+const (
+	maxNativeRequest  = 64 << 20
+	maxNativeResponse = 1 << 20
+)
+
+// NativeHostOrigin finds the chrome-extension:// origin the browser passes
+// when it starts a native-messaging host. Windows builds also receive a
+// --parent-window argument, which is ignored.
+func NativeHostOrigin(args []string) (string, bool) {
+	for _, a := range args {
+		if strings.HasPrefix(a, "chrome-extension://") {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+// extensionAllowed reports whether origin is a configured extension.
+func extensionAllowed(origin string) bool {
+	h := core.CurrentConfig().NativeHost
+	id := strings.TrimSuffix(strings.TrimPrefix(origin, "chrome-extension://"), "/")
+	return h != nil && slices.Contains(h.Extensions, id)
+}
+
+// nativeRequest is one message from the extension.
+type nativeRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"` // echoed in the reply
+	Method string          `json:"method,omitempty"`
+	Path   string          `json:"path"`
+	Token  string          `json:"token,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// nativeResponse is the reply to one nativeRequest. Body holds the
+// endpoint's JSON response, or its error text.
+type nativeResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Status int             `json:"status"`
+	Body   any             `json:"body,omitempty"`
+}
+
+// RunNativeHost serves messages from in until the browser closes it,
+// handling each concurrently so a long print does not hold up status
+// checks. It returns once every request has been answered.
+func RunNativeHost(origin string, in io.Reader, out io.Writer) error {
+	if !extensionAllowed(origin) {
+		return fmt.Errorf("%s is not listed in native_host.extensions", origin)
+	}
+	log.Printf("native host: serving %s", origin)
+	mux := http.NewServeMux()
+	APIRoutes(mux, core.Store)
+	h := withTimeouts(WithRequestLog(WithAudit(mux)))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		msg, err := readNativeMessage(in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		wg.Go(func() {
+			resp := serveNative(h, msg)
+			mu.Lock()
+			defer mu.Unlock()
+			if err := writeNativeMessage(out, resp); err != nil {
+				log.Printf("native host: %v", err)
+			}
+		})
+	}
+}
+
+// serveNative runs one message through the HTTP handlers.
+func serveNative(h http.Handler, msg []byte) nativeResponse {
+	var req nativeRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return nativeResponse{Status: http.StatusBadRequest, Body: fmt.Sprintf("invalid message: %v", err)}
+	}
+	resp := nativeResponse{ID: req.ID}
+	if !strings.HasPrefix(req.Path, "/") {
+		resp.Status, resp.Body = http.StatusBadRequest, "path must start with /"
+		return resp
+	}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+		if len(req.Body) > 0 {
+			req.Method = http.MethodPost
+		}
+	}
+	r, err := http.NewRequest(req.Method, "http://native-host"+req.Path, bytes.NewReader(req.Body))
+	if err != nil {
+		resp.Status, resp.Body = http.StatusBadRequest, err.Error()
+		return resp
+	}
+	r.RemoteAddr = "native-messaging"
+	if req.Token != "" {
+		r.Header.Set("X-Bridge-Token", req.Token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+
+	resp.Status = rec.Code
+	if body := bytes.TrimSpace(rec.Body.Bytes()); json.Valid(body) {
+		resp.Body = json.RawMessage(body)
+	} else {
+		resp.Body = string(body)
+	}
+	return resp
+}
+
+// readNativeMessage reads one message: a 32-bit length in native byte order
+// (little-endian on every platform the bridge ships for) and that many
+// bytes of JSON.
+func readNativeMessage(r io.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	if n > maxNativeRequest {
+		return nil, fmt.Errorf("message of %d bytes exceeds the %d byte limit", n, maxNativeRequest)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, fmt.Errorf("read message: %w", err)
+	}
+	return msg, nil
+}
+
+// writeNativeMessage writes one reply, replacing any too large for the
+// browser with an error.
+func writeNativeMessage(w io.Writer, resp nativeResponse) error {
+	msg, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	if len(msg) > maxNativeResponse {
+		msg, _ = json.Marshal(nativeResponse{ID: resp.ID, Status: http.StatusInternalServerError,
+			Body: "response too large for native messaging"})
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(len(msg))); err != nil {
+		return err
+	}
+	_, err = w.Write(msg)
+	return err
+}
+
+// nativeManifest builds the host manifest the browser reads to find and
+// authorize the bridge.
+func nativeManifest() ([]byte, error) {
+	h := core.CurrentConfig().NativeHost
+	if h == nil || len(h.Extensions) == 0 {
+		return nil, errors.New("list the companion extension IDs under native_host.extensions in the config first")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	if p, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = p
+	}
+	origins := make([]string, len(h.Extensions))
+	for i, id := range h.Extensions {
+		origins[i] = "chrome-extension://" + id + "/"
+	}
+	return json.MarshalIndent(map[string]any{
+		"name":            nativeHostName,
+		"description":     "Graham Bridge – braille embosser printing",
+		"path":            exe,
+		"type":            "stdio",
+		"allowed_origins": origins,
+	}, "", "  ")
+}
@@ -0,0 +1,66 @@
+//go:build !windows
+
+package httpapi
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// nativeHostBrowsers are the per-user profile directories of Chromium-based
+// browsers, relative to the user config directory. Each has a
+// NativeMessagingHosts directory for host manifests.
+func nativeHostBrowsers() []string {
+	if runtime.GOOS == "darwin" {
+		return []string{"Google/Chrome", "Chromium", "Microsoft Edge"}
+	}
+	return []string{"google-chrome", "chromium", "microsoft-edge"}
+}
+
+// RegisterNativeHost installs (or removes) the host manifest for every
+// browser with a profile on this account.
+func RegisterNativeHost(install bool) error {
+	var manifest []byte
+	if install {
+		m, err := nativeManifest()
+		if err != nil {
+			return err
+		}
+		manifest = m
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, browser := range nativeHostBrowsers() {
+		profile := filepath.Join(base, browser)
+		if _, err := os.Stat(profile); err != nil {
+			continue
+		}
+		found = true
+		path := filepath.Join(profile, "NativeMessagingHosts", nativeHostName+".json")
+		if !install {
+			if err := os.Remove(path); err == nil {
+				log.Printf("removed %s", path)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, manifest, 0o644); err != nil {
+			return err
+		}
+		log.Printf("installed %s", path)
+	}
+	if install && !found {
+		return errors.New("no Chrome, Chromium or Edge profile found for this user")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+//go:build windows
+
+package httpapi
+
+import (
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/registry"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// nativeHostKeys are where Chromium-based browsers look up native-messaging
+// hosts. The per-user keys are written here; managed devices can push the
+// same keys under HKEY_LOCAL_MACHINE by policy.
+var nativeHostKeys = []string{
+	`Software\Google\Chrome\NativeMessagingHosts\`,
+	`Software\Chromium\NativeMessagingHosts\`,
+	`Software\Microsoft\Edge\NativeMessagingHosts\`,
+}
+
+// RegisterNativeHost writes (or removes) the host manifest in the bridge
+// data directory and points each browser's registry key at it.
+func RegisterNativeHost(install bool) error {
+	path := filepath.Join(core.DataDir(), nativeHostName+".json")
+	if !install {
+		for _, k := range nativeHostKeys {
+			if err := registry.DeleteKey(registry.CURRENT_USER, k+nativeHostName); err != nil && !errors.Is(err, registry.ErrNotExist) {
+				return err
+			}
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		log.Printf("removed native host registration")
+		return nil
+	}
+
+	manifest, err := nativeManifest()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(core.DataDir(), 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, manifest, 0o644); err != nil {
+		return err
+	}
+	for _, k := range nativeHostKeys {
+		key, _, err := registry.CreateKey(registry.CURRENT_USER, k+nativeHostName, registry.SET_VALUE)
+		if err != nil {
+			return err
+		}
+		err = key.SetStringValue("", path)
+		key.Close()
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("installed %s", path)
+	return nil
+}
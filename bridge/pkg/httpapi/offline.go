@@ -0,0 +1,21 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Waiting for the printer
+// ---------------------------------------------------------------------------
+
+// writeWaiting answers 202 for a job waiting for its printer.
+func writeWaiting(w http.ResponseWriter, e core.JobEvent) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": core.StatusWaiting, "id": e.ID, "reason": e.ErrMsg, "guidance": e.Guidance,
+	})
+}
@@ -0,0 +1,104 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Page-range printing
+//
+// Reprinting some pages of a recorded job, and resuming a failed one
+// after the pages it got through.
+// ---------------------------------------------------------------------------
+
+// handleJobPrintPages serves POST /jobs/{id}/print?pages=….
+func (h jobsAPI) handleJobPrintPages(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	src, ok := h.jobFromPath(w, r)
+	if !ok {
+		return
+	}
+	pages := r.URL.Query().Get("pages")
+	list, err := brf.ParsePages(pages)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reprintPages(w, r, src, list, pages)
+}
+
+// handleJobResume serves POST /jobs/{id}/resume: reprint a failed job from
+// the page after the last one written.
+func (h jobsAPI) handleJobResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	src, ok := h.jobFromPath(w, r)
+	if !ok {
+		return
+	}
+	if src.Status != core.StatusFailed {
+		http.Error(w, fmt.Sprintf("job %d is %s, not failed", src.ID, src.Status), http.StatusConflict)
+		return
+	}
+	first, last := src.PagesSent+1, 0
+	pages := strconv.Itoa(first) + "-"
+	if src.Reversed {
+		// The pages not yet sent are the first ones of the document.
+		first, last = 1, src.Pages-src.PagesSent
+		if last < 1 {
+			http.Error(w, fmt.Sprintf("all pages of job %d were sent", src.ID), http.StatusUnprocessableEntity)
+			return
+		}
+		pages = "1-" + strconv.Itoa(last)
+	}
+	reprintPages(w, r, src, []brf.PageRange{{First: first, Last: last}}, pages)
+}
+
+// reprintPages sends the listed pages of a recorded job as a new job of
+// the caller's, checked like /print; pages is the list as the client gave
+// it.
+func reprintPages(w http.ResponseWriter, r *http.Request, src core.JobEvent, list []brf.PageRange, pages string) {
+	data, err := core.Payloads.Get(src.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	part, count, err := core.PickPages(src, data, list)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	log.Printf("page range request: job=%d pages=%s printer=%q bytes=%d", src.ID, pages, src.Printer, len(part))
+	e := core.NewJobEvent(src.Printer, part)
+	e.Pages = count
+	e.ResentFrom = src.ID
+	e.PageRange = pages
+	e.Student = src.Student
+	e.Media, e.Dots, e.LineSpacing, e.Interlined = src.Media, src.Dots, src.LineSpacing, src.Interlined
+	if !confirmLarge(w, e, src.Printer, part, r.URL.Query().Get("confirm")) { // confirm.go
+		return
+	}
+	caller, _ := authenticate(r)
+	e.App = caller.App
+	e.RequestID = requestID(r.Context())
+	e, sent := dispatchJob(w, r, caller, e, part, core.JobOptions{})
+	if !sent {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"status": "queued", "id": e.ID, "pages": count})
+}
@@ -0,0 +1,27 @@
+package httpapi
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Pre-flight failures
+// ---------------------------------------------------------------------------
+
+// writePreflightFailure answers a request refused by preflight.
+func writePreflightFailure(w http.ResponseWriter, printer string, err error) {
+	log.Printf("print request refused: printer=%q: %v", printer, err)
+	status := http.StatusConflict
+	switch core.ErrorCode(err) {
+	case core.ErrCodeNotFound, core.ErrCodeDevice:
+		status = http.StatusNotFound
+	case core.ErrCodeSpooler:
+		status = http.StatusServiceUnavailable
+	case core.ErrCodePermission:
+		status = http.StatusForbidden
+	}
+	writeFailure(w, status, core.StatusRejected, 0, err)
+}
@@ -0,0 +1,119 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Page-grid preview
+//
+// GET /jobs/{id}/preview returns a job's payload exactly as it will be (or
+// was) sent, after line reflow, eject fixes and interline merging, laid out
+// in pages of rows in Unicode braille (U+2800–U+283F). The web app can draw
+// its own preview from it without re-implementing the bridge's rewriting.
+//
+// Rows hold only braille cells. Interline print lines are returned in
+// "print", parallel to "pages", and other control bytes (escape sequences)
+// are left out and counted in "control_bytes".
+// ---------------------------------------------------------------------------
+
+// JobPreview is the response from GET /jobs/{id}/preview.
+type JobPreview struct {
+	JobID        int        `json:"job_id"`
+	Printer      string     `json:"printer"`
+	CellsPerLine int        `json:"cells_per_line"`
+	LinesPerPage int        `json:"lines_per_page"`
+	PageCount    int        `json:"page_count"`
+	Pages        [][]string `json:"pages"`
+	Print        [][]string `json:"print,omitempty"`         // interline print text per row, "" where none
+	ControlBytes int        `json:"control_bytes,omitempty"` // escape and control bytes sent but not shown
+}
+
+// handleJobPreview serves GET /jobs/{id}/preview.
+func (h jobsAPI) handleJobPreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	e, ok := h.jobFromPath(w, r)
+	if !ok {
+		return
+	}
+	data, err := core.Payloads.Get(e.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+	p := core.ProfileFor(e.Printer)
+	var mode *core.InterlineMode
+	if e.Interlined {
+		mode = p.Interline
+	}
+	pv := previewPages(data, p.LinesPerPage, mode)
+	pv.JobID, pv.Printer, pv.CellsPerLine = e.ID, e.Printer, p.CellsPerLine
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pv)
+}
+
+// previewPages lays data out in pages of at most linesPerPage braille rows,
+// splitting at form feeds as brf.Pages does. With mode set, lines starting
+// with its ink_start are interline print for the row above.
+func previewPages(data []byte, linesPerPage int, mode *core.InterlineMode) JobPreview {
+	pv := JobPreview{LinesPerPage: linesPerPage, Pages: [][]string{}}
+	var printRows [][]string
+	hasPrint := false
+	for _, ff := range strings.Split(strings.ReplaceAll(string(data), "\r", ""), "\f") {
+		lines := strings.Split(ff, "\n")
+		if lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		// Text after the last line break is only a row if it has cells,
+		// not just an escape sequence ahead of the form feed.
+		open := !strings.HasSuffix(ff, "\n")
+		var rows, prints []string
+		for i, line := range lines {
+			if mode != nil && mode.InkStart != "" && len(rows) > 0 {
+				if ink, ok := strings.CutPrefix(line, mode.InkStart); ok {
+					prints[len(prints)-1] = strings.TrimSuffix(ink, mode.InkEnd)
+					pv.ControlBytes += len(mode.InkStart) + len(mode.InkEnd)
+					hasPrint = true
+					continue
+				}
+			}
+			row, skipped := brf.ToUnicode(line)
+			pv.ControlBytes += skipped
+			if open && i == len(lines)-1 && row == "" {
+				continue
+			}
+			rows = append(rows, row)
+			prints = append(prints, "")
+		}
+		for len(rows) > linesPerPage {
+			pv.Pages = append(pv.Pages, rows[:linesPerPage])
+			printRows = append(printRows, prints[:linesPerPage])
+			rows, prints = rows[linesPerPage:], prints[linesPerPage:]
+		}
+		pv.Pages = append(pv.Pages, rows)
+		printRows = append(printRows, prints)
+	}
+	// Drop the empty page after a final form feed.
+	if n := len(pv.Pages); n > 1 && len(pv.Pages[n-1]) == 0 {
+		pv.Pages, printRows = pv.Pages[:n-1], printRows[:n-1]
+	}
+	for i, rows := range pv.Pages {
+		if rows == nil {
+			pv.Pages[i] = []string{}
+		}
+	}
+	if hasPrint {
+		pv.Print = printRows
+	}
+	pv.PageCount = len(pv.Pages)
+	return pv
+}
@@ -0,0 +1,27 @@
+package httpapi
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestPreviewPages(t *testing.T) {
+	mode := &core.InterlineMode{InkStart: "\x1b[I", InkEnd: "\x1b[B"}
+	data := []byte("ABC\r\n\x1b[IHello\x1b[B\r\n,D\r\n\f" + "1\n2\n3\n\x1b\f")
+	pv := previewPages(data, 2, mode)
+	want := [][]string{{"⠁⠃⠉", "⠠⠙"}, {"⠂", "⠆"}, {"⠒"}}
+	if pv.PageCount != 3 || !slices.EqualFunc(pv.Pages, want, slices.Equal) {
+		t.Fatalf("pages = %q, want %q", pv.Pages, want)
+	}
+	if pv.Print[0][0] != "Hello" || pv.Print[0][1] != "" {
+		t.Errorf("print = %q", pv.Print)
+	}
+	if pv.ControlBytes != len(mode.InkStart)+len(mode.InkEnd)+1 {
+		t.Errorf("control bytes = %d", pv.ControlBytes)
+	}
+	if pv := previewPages([]byte("A\n"), 25, nil); pv.Print != nil || pv.PageCount != 1 {
+		t.Errorf("plain job: %+v", pv)
+	}
+}
@@ -0,0 +1,22 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Printer status endpoint
+// ---------------------------------------------------------------------------
+
+// handlePrinterStatus serves GET /printers/{name}/status.
+func handlePrinterStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.DestinationState(r.Context(), r.PathValue("name")))
+}
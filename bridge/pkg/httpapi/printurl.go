@@ -0,0 +1,183 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/brf"
+)
+
+// ---------------------------------------------------------------------------
+// Print from URL
+//
+// POST /print-url {"printer":"Name","url":"https://…","student":"ID"} makes
+// the bridge download a .brf or .pef itself (a Google Drive export link, an
+// LMS attachment) so large files need not round-trip through the browser.
+// Only HTTPS is fetched, never from this machine's loopback or link-local
+// addresses, and downloads are capped at maxPrintBytes.
+// ---------------------------------------------------------------------------
+
+const (
+	maxPrintBytes = 5 * 1024 * 1024 // same limit as /print
+	fetchTimeout  = 60 * time.Second
+	maxRedirects  = 5
+)
+
+// printURLRequest is the JSON body for /print-url.
+type printURLRequest struct {
+	Printer string `json:"printer"`
+	URL     string `json:"url"`
+	Student string `json:"student,omitempty"`
+	Urgent  bool   `json:"urgent,omitempty"`
+	Media   string `json:"media,omitempty"`
+	Dots    int    `json:"dots,omitempty"` // 6 (default) or 8
+
+	LineSpacing string `json:"line_spacing,omitempty"`
+	Confirm     string `json:"confirm,omitempty"` // see confirm.go
+}
+
+// fetchableTypes are the Content-Types accepted from a download. Anything
+// else (HTML sign-in pages, PDFs) is refused before it reaches the sniffer.
+var fetchableTypes = map[string]bool{
+	"":                         true,
+	"text/plain":               true,
+	"application/octet-stream": true,
+	"binary/octet-stream":      true,
+	"application/x-brf":        true,
+	"application/x-pef+xml":    true,
+	"application/pef+xml":      true,
+	"application/xml":          true,
+	"text/xml":                 true,
+}
+
+var fetchClient = &http.Client{
+	Timeout: fetchTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{Timeout: 10 * time.Second, Control: refuseLocal}).DialContext,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return errors.New("too many redirects")
+		}
+		if req.URL.Scheme != "https" {
+			return errors.New("redirected to a non-HTTPS URL")
+		}
+		return nil
+	},
+}
+
+// refuseLocal stops downloads from reaching services on this machine.
+func refuseLocal(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("refusing to fetch from %s", host)
+	}
+	return nil
+}
+
+func handlePrintURL(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, 64*1024)
+	var req printURLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if !resolvePrinter(w, &req.Printer) {
+		return
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		http.Error(w, "url must be an https:// link", http.StatusBadRequest)
+		return
+	}
+
+	if !core.ValidDots(req.Dots) {
+		http.Error(w, "dots must be 6 or 8", http.StatusBadRequest)
+		return
+	}
+	if !core.ValidSpacing(req.LineSpacing) {
+		http.Error(w, "line_spacing must be single, double or interline", http.StatusBadRequest)
+		return
+	}
+
+	data, err := fetchBraille(r.Context(), u, req.Dots)
+	if err != nil {
+		log.Printf("print-url: %s: %v", u.Redacted(), err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	log.Printf("print-url: fetched %d bytes from %s", len(data), u.Host)
+	submitPrint(w, r, req.Printer, data, core.JobOptions{
+		Student: req.Student,
+		Urgent:  req.Urgent,
+		Media:   req.Media,
+		Dots:    req.Dots,
+		Spacing: req.LineSpacing,
+		Confirm: req.Confirm,
+	})
+}
+
+// fetchBraille downloads a BRF or PEF file, converting PEF to BRF for a
+// six- or eight-dot job.
+func fetchBraille(ctx context.Context, u *url.URL, dots int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain, application/x-brf, application/x-pef+xml, application/octet-stream;q=0.5")
+	resp, err := fetchClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+	if resp.ContentLength > maxPrintBytes {
+		return nil, fmt.Errorf("file is %d bytes; the limit is %d", resp.ContentLength, maxPrintBytes)
+	}
+	ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !fetchableTypes[ct] {
+		return nil, fmt.Errorf("link returned %s, not a braille file (is the link public, or does it need a sign-in?)", ct)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPrintBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("download failed: %w", err)
+	}
+	if len(data) > maxPrintBytes {
+		return nil, fmt.Errorf("file is larger than the %d-byte limit", maxPrintBytes)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("downloaded file is empty")
+	}
+
+	isPEF := strings.EqualFold(path.Ext(resp.Request.URL.Path), ".pef") ||
+		strings.Contains(ct, "xml") || brf.IsPEF(data)
+	if isPEF {
+		return brf.FromPEF(data, dots)
+	}
+	return data, nil
+}
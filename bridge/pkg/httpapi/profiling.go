@@ -0,0 +1,90 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Profiling
+//
+// Now and then a school reports the bridge using a whole CPU core or
+// growing without bound. Started with --debug-profiling, the bridge serves
+// Go's profiler and a runtime snapshot (admin scope) so a developer can see
+// why on the machine itself:
+//
+//	GET /debug/pprof/          → the standard net/http/pprof pages
+//	                             (go tool pprof http://127.0.0.1:8080/debug/pprof/profile)
+//	GET /debug/runtime         → goroutines, heap, GC and queue counts as JSON
+//	GET /debug/runtime?stacks=1 → every goroutine's stack as text
+//
+// Without the flag none of these exist. Profiles can include job data held
+// in memory, so they are never on by default.
+// ---------------------------------------------------------------------------
+
+var startTime = time.Now()
+
+// RuntimeSnapshot is the /debug/runtime response.
+type RuntimeSnapshot struct {
+	GoVersion     string  `json:"go_version"`
+	UptimeSeconds int     `json:"uptime_seconds"`
+	CPUs          int     `json:"cpus"`
+	Goroutines    int     `json:"goroutines"`
+	HeapAlloc     uint64  `json:"heap_alloc_bytes"`
+	HeapSys       uint64  `json:"heap_sys_bytes"`
+	HeapObjects   uint64  `json:"heap_objects"`
+	NumGC         uint32  `json:"gc_cycles"`
+	GCPauseMS     float64 `json:"gc_pause_total_ms"`
+	Workers       int     `json:"queue_workers"` // printers with a running queue worker
+	Jobs          int     `json:"jobs_in_memory"`
+}
+
+// ProfilingRoutes registers the profiling endpoints.
+func ProfilingRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", requireScope(core.ScopeAdmin, pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", requireScope(core.ScopeAdmin, pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", requireScope(core.ScopeAdmin, pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", requireScope(core.ScopeAdmin, pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", requireScope(core.ScopeAdmin, pprof.Trace))
+	mux.HandleFunc("/debug/runtime", requireScope(core.ScopeAdmin, handleRuntimeSnapshot))
+}
+
+// handleRuntimeSnapshot serves GET /debug/runtime.
+func handleRuntimeSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Query().Get("stacks") == "1" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		runtimepprof.Lookup("goroutine").WriteTo(w, 2)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runtimeSnapshot())
+}
+
+func runtimeSnapshot() RuntimeSnapshot {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	workers := core.JobQueue.Workers()
+	return RuntimeSnapshot{
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: int(time.Since(startTime).Seconds()),
+		CPUs:          runtime.NumCPU(),
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     m.HeapAlloc,
+		HeapSys:       m.HeapSys,
+		HeapObjects:   m.HeapObjects,
+		NumGC:         m.NumGC,
+		GCPauseMS:     float64(m.PauseTotalNs) / 1e6,
+		Workers:       workers,
+		Jobs:          len(core.Store.List()),
+	}
+}
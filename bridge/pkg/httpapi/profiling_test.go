@@ -0,0 +1,25 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProfilingRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	ProfilingRoutes(mux)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/runtime", nil))
+	var snap RuntimeSnapshot
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &snap) != nil || snap.Goroutines == 0 || snap.GoVersion == "" {
+		t.Errorf("snapshot %d %s", rec.Code, rec.Body)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/runtime?stacks=1", nil))
+	if !strings.Contains(rec.Body.String(), "goroutine") {
+		t.Errorf("stacks %q", rec.Body.String()[:min(200, rec.Body.Len())])
+	}
+}
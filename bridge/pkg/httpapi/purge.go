@@ -0,0 +1,125 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Data purge
+//
+// When a student leaves the district their records may have to be deleted
+// on request. DELETE /jobs (admin scope) removes every record of the
+// matching jobs:
+//
+//	student=<id>            exact student identifier (case-insensitive)
+//	before=<RFC 3339>       only jobs from before this time
+//
+// At least one is required. Matching jobs leave the job history with their
+// payloads and rendered PDFs, their lines leave the page ledger
+// (reports.go), and audit log entries for their /jobs/{id}/… URLs are
+// removed. Audit entries never name a student, so the rest of the audit
+// log is kept. Jobs that are queued or printing are left alone and counted
+// as "skipped"; purge again once they finish. Open dashboards drop the
+// purged jobs on the "purge" SSE event, and a dashboard filtered to the
+// purged student is cleared.
+// ---------------------------------------------------------------------------
+
+// PurgeResult is the response from DELETE /jobs.
+type PurgeResult struct {
+	Jobs    int `json:"jobs"`    // jobs removed from the history
+	Ledger  int `json:"ledger"`  // page ledger lines removed
+	Audit   int `json:"audit"`   // audit log entries removed
+	Skipped int `json:"skipped"` // matching jobs still queued or printing
+}
+
+// handlePurge serves DELETE /jobs.
+func (h jobsAPI) handlePurge(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := core.JobFilter{Student: strings.TrimSpace(q.Get("student"))}
+	if s := q.Get("before"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, "before must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		f.Until = t
+	}
+	if f.Student == "" && f.Until.IsZero() {
+		http.Error(w, "give student=, before= or both", http.StatusBadRequest)
+		return
+	}
+	res, err := purgeJobs(h.store, f)
+	if err != nil {
+		log.Printf("purge: %v", err)
+		http.Error(w, "purge incomplete: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("purge: removed %d job(s), %d ledger line(s), %d audit entries", res.Jobs, res.Ledger, res.Audit)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// purgeJobs removes the records of every finished job f matches.
+func purgeJobs(s core.JobStore, f core.JobFilter) (PurgeResult, error) {
+	var res PurgeResult
+	purged := s.Purge(func(e core.JobEvent) bool {
+		if !f.Match(e) {
+			return false
+		}
+		if e.Status == core.StatusQueued || e.Status == core.StatusPrinting {
+			res.Skipped++
+			return false
+		}
+		return true
+	})
+	res.Jobs = len(purged)
+
+	if f.Student != "" && core.SameStudent(core.CurrentUIState().StudentFilter, f.Student) {
+		core.UpdateUIState(func(s *core.UIState) {
+			if core.SameStudent(s.StudentFilter, f.Student) {
+				s.StudentFilter = ""
+			}
+		})
+	}
+
+	var err error
+	res.Ledger, err = core.PageLedger.Drop(func(line []byte) bool {
+		var rec core.PageRecord
+		if json.Unmarshal(line, &rec) != nil {
+			return false
+		}
+		return f.Match(core.JobEvent{Time: rec.Time, Printer: rec.Printer, Student: rec.Student, Status: rec.Status})
+	})
+	if err != nil || len(purged) == 0 {
+		return res, err
+	}
+	res.Audit, err = audit.Drop(func(line []byte) bool {
+		var a AuditEntry
+		return json.Unmarshal(line, &a) == nil && aboutJob(a, purged)
+	})
+	return res, err
+}
+
+// aboutJob reports whether an audit entry is a call to one of jobs' URLs.
+// Job IDs start again at 1 each run, so the entry must also be no older
+// than the job.
+func aboutJob(a AuditEntry, jobs []core.JobEvent) bool {
+	rest, ok := strings.CutPrefix(a.Path, "/jobs/")
+	if !ok {
+		return false
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	for _, e := range jobs {
+		if id == strconv.Itoa(e.ID) && !a.Time.Before(e.Time) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestStorePurge(t *testing.T) {
+	s := core.NewMemoryStore(3)
+	for _, st := range []string{"ana", "ben", "Ana", "ana"} { // the first is evicted
+		s.Append(core.JobEvent{Student: st, Status: core.StatusDone})
+	}
+	s.Update(4, func(e *core.JobEvent) { e.Status = core.StatusPrinting })
+	f := core.JobFilter{Student: "ANA"}
+	purged := s.Purge(func(e core.JobEvent) bool { return f.Match(e) && e.Status != core.StatusPrinting })
+	if len(purged) != 1 || purged[0].ID != 3 {
+		t.Fatalf("purged %v", purged)
+	}
+	var ids []int
+	for _, e := range s.List() {
+		ids = append(ids, e.ID)
+	}
+	if !slices.Equal(ids, []int{2, 4}) {
+		t.Errorf("left %v", ids)
+	}
+	if _, ok := s.Get(3); ok {
+		t.Error("purged job still found")
+	}
+	s.Append(core.JobEvent{Student: "cy"})
+	if e, ok := s.Get(5); !ok || len(s.List()) != 3 {
+		t.Errorf("append after purge: %v %v", e, s.List())
+	}
+
+	job := core.JobEvent{ID: 3, Time: time.Now()}
+	if !aboutJob(AuditEntry{Path: "/jobs/3/brf", Time: job.Time.Add(time.Second)}, []core.JobEvent{job}) {
+		t.Error("audit entry for the job not matched")
+	}
+	if aboutJob(AuditEntry{Path: "/jobs/3/brf", Time: job.Time.Add(-time.Hour)}, []core.JobEvent{job}) {
+		t.Error("older run's job 3 matched")
+	}
+}
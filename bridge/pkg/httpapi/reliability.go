@@ -0,0 +1,33 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Printer reliability endpoint
+// ---------------------------------------------------------------------------
+
+// handlePrinterReliability serves GET /printers/{name}/stats.
+func handlePrinterReliability(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	n := core.DefaultReliabilityWindow
+	if s := r.URL.Query().Get("last"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 || v > core.MaxReliabilityWindow {
+			http.Error(w, "last must be a number of jobs from 1 to 1000", http.StatusBadRequest)
+			return
+		}
+		n = v
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.ReliabilityFor(r.PathValue("name"), n, time.Now()))
+}
@@ -0,0 +1,71 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Page report endpoint
+// ---------------------------------------------------------------------------
+
+// handlePageReport totals the page ledger.
+func handlePageReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	by := r.URL.Query().Get("by")
+	switch by {
+	case "":
+		by = "student"
+	case "student", "printer", "month":
+	default:
+		http.Error(w, "by must be student, printer or month", http.StatusBadRequest)
+		return
+	}
+	f, err := parseJobFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.Status = core.StatusDone
+
+	groups := map[string]*core.ReportRow{}
+	core.EachRecord(f, func(rec core.PageRecord) {
+		key := core.ReportKey(rec, by)
+		row := groups[key]
+		if row == nil {
+			row = &core.ReportRow{Key: key}
+			groups[key] = row
+		}
+		row.Jobs++
+		row.Pages += rec.Pages
+	})
+	rows := make([]core.ReportRow, 0, len(groups))
+	for _, row := range groups {
+		rows = append(rows, *row)
+	}
+	slices.SortFunc(rows, func(a, b core.ReportRow) int { return strings.Compare(a.Key, b.Key) })
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="graham-bridge-pages-by-`+by+`.csv"`)
+		cw := csv.NewWriter(w)
+		cw.Write([]string{by, "jobs", "pages"})
+		for _, row := range rows {
+			cw.Write([]string{row.Key, strconv.Itoa(row.Jobs), strconv.Itoa(row.Pages)})
+		}
+		cw.Flush()
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"by": by, "rows": rows})
+}
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Request IDs
+//
+// Every request gets an ID, returned in the X-Request-ID header and logged
+// with its method, path, status and duration:
+//
+//	request 5c1e9a0b7f3d POST /print → 200 in 2.4s
+//
+// The ID is stored on the jobs the request makes ("request_id") and their
+// companions, written to the audit log, and repeated in the queue and
+// transport log lines of those jobs, so one job can be followed from the
+// web app's call through the queue to the embosser. A web app may send its
+// own X-Request-ID (up to 64 letters, digits, '.', '_' or '-') to tie the
+// bridge's logs to its own; anything else is replaced. Calls to /status,
+// which the web app makes every few seconds, are not logged.
+// ---------------------------------------------------------------------------
+
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// WithRequestLog assigns each request an ID and logs it when it finishes.
+func WithRequestLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+		if r.URL.Path == "/status" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		log.Printf("request %s %s %s → %d in %s", id, r.Method, r.URL.Path, rec.status,
+			time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// requestID returns the ID of the request ctx belongs to, or "".
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestRequestLog(t *testing.T) {
+	var seen string
+	h := WithRequestLog(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestID(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs", nil))
+	if seen == "" || rec.Header().Get(requestIDHeader) != seen || rec.Code != http.StatusTeapot {
+		t.Errorf("generated ID %q, header %q", seen, rec.Header().Get(requestIDHeader))
+	}
+	for id, keep := range map[string]bool{"app-42.x_y": true, "bad id": false, strings.Repeat("a", 65): false} {
+		r := httptest.NewRequest(http.MethodGet, "/jobs", nil)
+		r.Header.Set(requestIDHeader, id)
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		if (seen == id) != keep {
+			t.Errorf("client ID %q: used %q", id, seen)
+		}
+	}
+	if got := (&core.PrintJob{ID: 7, ReqID: "abc"}).Label(); got != "job 7 (request abc)" {
+		t.Errorf("label %q", got)
+	}
+}
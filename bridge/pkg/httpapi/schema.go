@@ -0,0 +1,75 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Job record schema versions
+//
+// Callers that still expect the version 1 records can ask for them with
+// ?schema=1 (see requestedSchema).
+// ---------------------------------------------------------------------------
+
+// jobSchemas lists the versions /log-stream can send, oldest first.
+var jobSchemas = []int{1, 2}
+
+// jobEventV1 is the version 1 job record.
+type jobEventV1 struct {
+	Schema  int       `json:"schema"`
+	ID      int       `json:"id"`
+	Time    time.Time `json:"time"`
+	Printer string    `json:"printer"`
+	Bytes   int       `json:"bytes"`
+	BRFText string    `json:"brf_text"`
+	HexDump string    `json:"hex_dump"`
+	ErrMsg  string    `json:"error"`
+}
+
+// requestedSchema reads ?schema= from r, defaulting to the current one.
+func requestedSchema(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("schema")
+	if s == "" {
+		return core.JobSchema, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < jobSchemas[0] || v > core.JobSchema {
+		return 0, fmt.Errorf("schema must be one of %v", jobSchemas)
+	}
+	return v, nil
+}
+
+// versionedData returns the data of ev as a consumer of version v expects
+// it, or false if that consumer should not get ev. Only job records change
+// between versions.
+func versionedData(ev core.StreamEvent, v int) (any, bool) {
+	e, ok := ev.Data.(core.JobEvent)
+	if !ok || ev.Name != "" || v == core.JobSchema {
+		return ev.Data, true
+	}
+	errMsg := e.ErrMsg
+	switch e.Status {
+	case core.StatusQueued, core.StatusPrinting, core.StatusHeld, core.StatusWaiting:
+		return nil, false
+	case core.StatusDone:
+	default:
+		if errMsg == "" {
+			errMsg = "job " + e.Status
+		}
+	}
+	return jobEventV1{
+		Schema:  1,
+		ID:      e.ID,
+		Time:    e.Time,
+		Printer: e.Printer,
+		Bytes:   e.Bytes,
+		BRFText: e.BRFText,
+		HexDump: e.HexDump,
+		ErrMsg:  errMsg,
+	}, true
+}
@@ -0,0 +1,34 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestVersionedJobEvents(t *testing.T) {
+	done := core.JobEvent{ID: 3, Printer: "Index", Status: core.StatusDone, Pages: 2}
+	data, _ := json.Marshal(done)
+	if !strings.Contains(string(data), `"schema":2`) || !strings.Contains(string(data), `"pages":2`) {
+		t.Errorf("current record %s", data)
+	}
+	if _, ok := versionedData(core.StreamEvent{Data: core.JobEvent{Status: core.StatusQueued}}, 1); ok {
+		t.Error("queued job sent to a version 1 consumer")
+	}
+	v1, ok := versionedData(core.StreamEvent{Data: core.JobEvent{ID: 4, Status: core.StatusCancelled}}, 1)
+	if !ok || v1.(jobEventV1).ErrMsg == "" {
+		t.Errorf("cancelled job as version 1: %+v", v1)
+	}
+	data, _ = json.Marshal(v1)
+	if strings.Contains(string(data), "status") || !strings.Contains(string(data), `"schema":1`) {
+		t.Errorf("version 1 record %s", data)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/log-stream?schema=9", nil)
+	if _, err := requestedSchema(r); err == nil {
+		t.Error("unknown schema accepted")
+	}
+}
@@ -0,0 +1,87 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// mockSpooler stands in for CUPS: it lists printers, refuses Check for
+// names in refuse and records what each queue was sent.
+type mockSpooler struct {
+	printers []string
+	refuse   map[string]error
+	sendErr  error
+
+	mu   sync.Mutex
+	sent map[string][][]byte
+}
+
+func (m *mockSpooler) Printers(context.Context) []string { return m.printers }
+
+func (m *mockSpooler) Check(_ context.Context, printer string) error {
+	if err := m.refuse[printer]; err != nil {
+		return err
+	}
+	if !slices.Contains(m.printers, printer) {
+		return core.Classified(core.ErrCodeNotFound, errors.New("no such queue"))
+	}
+	return nil
+}
+
+func (m *mockSpooler) Send(_ context.Context, printer string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sent == nil {
+		m.sent = map[string][][]byte{}
+	}
+	m.sent[printer] = append(m.sent[printer], bytes.Clone(data))
+	return m.sendErr
+}
+
+func (m *mockSpooler) Driver(context.Context, string) (string, string) { return "", "" }
+
+// withSpooler installs m for the rest of the test.
+func withSpooler(t *testing.T, m core.Spooler) {
+	prev := core.ActiveSpooler
+	core.ActiveSpooler = m
+	t.Cleanup(func() { core.ActiveSpooler = prev })
+}
+
+func TestPrintThroughMockSpooler(t *testing.T) {
+	m := &mockSpooler{
+		printers: []string{"Mock Everest", "Mock Paused"},
+		refuse:   map[string]error{"Mock Paused": core.Classified(core.ErrCodeNotAccepting, errors.New("paused"))},
+	}
+	withSpooler(t, m)
+	post := func(printer string) *httptest.ResponseRecorder {
+		body := `{"printer":"` + printer + `","data":"QUJDDA=="}` // "ABC\f"
+		rec := httptest.NewRecorder()
+		printHandler(rec, httptest.NewRequest(http.MethodPost, "/print", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := post("Mock Everest"); rec.Code != http.StatusOK {
+		t.Fatalf("print: %d %s", rec.Code, rec.Body)
+	}
+	m.mu.Lock()
+	sent := m.sent["Mock Everest"]
+	m.mu.Unlock()
+	if len(sent) != 1 || string(sent[0]) != "ABC\n\f" { // the last line ended before the eject
+		t.Errorf("spooler got %q", sent)
+	}
+	if rec := post("Mock Paused"); rec.Code != http.StatusConflict {
+		t.Errorf("paused queue: %d %s", rec.Code, rec.Body)
+	}
+	if rec := post("Mock Missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown queue: %d %s", rec.Code, rec.Body)
+	}
+}
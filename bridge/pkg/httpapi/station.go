@@ -0,0 +1,209 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Station mode
+//
+// The clients that have called the bridge and the host control
+// endpoints. Which addresses may connect is decided in pkg/core/station.go.
+// ---------------------------------------------------------------------------
+
+// ClientInfo describes one machine that has called the bridge.
+type ClientInfo struct {
+	IP        string    `json:"ip"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Requests  int       `json:"requests"`
+	Identity  string    `json:"identity"` // last authenticated principal
+	UserAgent string    `json:"user_agent,omitempty"`
+	Blocked   bool      `json:"blocked"`
+	Local     bool      `json:"local"` // this machine
+}
+
+var clients = struct {
+	sync.Mutex
+	m       map[string]*ClientInfo
+	blocked map[string]bool // blocked from the dashboard until restart
+}{m: make(map[string]*ClientInfo), blocked: make(map[string]bool)}
+
+// ServerAddr is the address the HTTP server binds to.
+func ServerAddr() string {
+	if core.StationEnabled() {
+		return core.StationListen(core.CurrentConfig().Station)
+	}
+	return core.ListenAddr
+}
+
+// StartStation advertises the bridge on the LAN when station mode is on.
+func StartStation() {
+	if !core.StationEnabled() {
+		return
+	}
+	st := core.CurrentConfig().Station
+	_, portStr, _ := net.SplitHostPort(core.StationListen(st))
+	port, _ := strconv.Atoi(portStr)
+	name := st.Name
+	if name == "" {
+		name = core.HostnameOr("Graham Bridge")
+	}
+	go advertise([]mdnsService{{
+		Instance: "Graham Bridge (" + name + ")",
+		Type:     core.StationServiceType,
+		Port:     port,
+		TXT:      []string{"version=" + core.BridgeVersion, "path=/print", "name=" + name},
+	}})
+	log.Printf("station mode: serving the LAN as %q", name)
+}
+
+func matchesAny(ip netip.Addr, rules []string) bool {
+	for _, r := range rules {
+		if p := core.ParseHostRule(r); p != nil && p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAllowed decides whether a remote address may use the bridge.
+// The local machine is always allowed so the dashboard stays reachable.
+func clientAllowed(ip netip.Addr) bool {
+	if ip.IsLoopback() {
+		return true
+	}
+	st := core.CurrentConfig().Station
+	if st == nil || !st.Enabled {
+		return false
+	}
+	clients.Lock()
+	blocked := clients.blocked[ip.String()]
+	clients.Unlock()
+	if blocked || matchesAny(ip, st.BlockedHosts) {
+		return false
+	}
+	if len(st.AllowedHosts) > 0 {
+		return matchesAny(ip, st.AllowedHosts)
+	}
+	return ip.IsPrivate() || ip.IsLinkLocalUnicast()
+}
+
+// WithHostControl refuses clients not permitted by the station settings
+// and records every caller for the client list. Only admitted callers that
+// authenticate have their identity and User-Agent recorded, so an unknown
+// machine cannot plant text on the dashboard.
+func WithHostControl(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := remoteAddr(r)
+		allowed := clientAllowed(ip)
+		trackClient(ip, r, !allowed)
+		if !allowed {
+			http.Error(w, "Forbidden: this machine may not use the bridge", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// remoteAddr returns the caller's IP address.
+func remoteAddr(r *http.Request) netip.Addr {
+	ap, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return ap.Addr().Unmap()
+}
+
+func trackClient(ip netip.Addr, r *http.Request, blocked bool) {
+	key := ip.String()
+	now := time.Now()
+	identity := anonymous.Name
+	if !blocked {
+		identity = identify(r)
+	}
+	clients.Lock()
+	defer clients.Unlock()
+	c := clients.m[key]
+	if c == nil {
+		c = &ClientInfo{IP: key, FirstSeen: now, Local: ip.IsLoopback()}
+		clients.m[key] = c
+	}
+	c.LastSeen = now
+	c.Requests++
+	c.Blocked = blocked
+	if identity != anonymous.Name || c.Identity == "" {
+		c.Identity = identity
+	}
+	if ua := r.UserAgent(); ua != "" && identity != anonymous.Name {
+		c.UserAgent = ua
+	}
+}
+
+// handleClients lists the machines that have called the bridge, most
+// recent first.
+func handleClients(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	clients.Lock()
+	list := make([]ClientInfo, 0, len(clients.m))
+	for _, c := range clients.m {
+		list = append(list, *c)
+	}
+	clients.Unlock()
+	slices.SortFunc(list, func(a, b ClientInfo) int { return b.LastSeen.Compare(a.LastSeen) })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"station": core.StationEnabled(),
+		"listen":  ServerAddr(),
+		"clients": list,
+	})
+}
+
+// handleClientBlock and handleClientUnblock toggle a runtime block on one
+// client address.
+func handleClientBlock(w http.ResponseWriter, r *http.Request)   { setClientBlocked(w, r, true) }
+func handleClientUnblock(w http.ResponseWriter, r *http.Request) { setClientBlocked(w, r, false) }
+
+func setClientBlocked(w http.ResponseWriter, r *http.Request, block bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ip, err := netip.ParseAddr(r.PathValue("ip"))
+	if err != nil {
+		http.Error(w, "invalid client address", http.StatusBadRequest)
+		return
+	}
+	if ip.IsLoopback() {
+		http.Error(w, "the local machine cannot be blocked", http.StatusBadRequest)
+		return
+	}
+	key := ip.Unmap().String()
+	clients.Lock()
+	if block {
+		clients.blocked[key] = true
+	} else {
+		delete(clients.blocked, key)
+	}
+	if c := clients.m[key]; c != nil {
+		c.Blocked = block
+	}
+	clients.Unlock()
+	log.Printf("client %s blocked=%v", key, block)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"ip": key, "blocked": block})
+}
@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestClientListRecordsOnlyAuthenticatedAgents(t *testing.T) {
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	core.Cfg.Store(&core.Config{
+		Station: &core.StationConfig{Enabled: true, BlockedHosts: []string{"10.0.0.9"}},
+		Tokens:  []core.APIToken{{Name: "web", Token: "web-token-0123456789", Scope: core.ScopePrint}},
+	})
+	clients.Lock()
+	clients.m = make(map[string]*ClientInfo)
+	clients.Unlock()
+	defer func() {
+		clients.Lock()
+		clients.m = make(map[string]*ClientInfo)
+		clients.Unlock()
+	}()
+
+	h := WithHostControl(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	call := func(ip, token string) int {
+		r := httptest.NewRequest(http.MethodGet, "/status", nil)
+		r.RemoteAddr = ip + ":5000"
+		r.Header.Set("User-Agent", `"><img src=x onerror=alert(1)>`)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, r)
+		return rec.Code
+	}
+	if code := call("10.0.0.9", "web-token-0123456789"); code != http.StatusForbidden {
+		t.Fatalf("blocked host: %d", code)
+	}
+	call("10.0.0.5", "")
+	call("10.0.0.6", "web-token-0123456789")
+
+	clients.Lock()
+	defer clients.Unlock()
+	for ip, want := range map[string]string{"10.0.0.9": "", "10.0.0.5": "", "10.0.0.6": `"><img src=x onerror=alert(1)>`} {
+		c := clients.m[ip]
+		if c == nil {
+			t.Errorf("%s not listed", ip)
+			continue
+		}
+		if c.UserAgent != want {
+			t.Errorf("%s user agent = %q, want %q", ip, c.UserAgent, want)
+		}
+	}
+	if c := clients.m["10.0.0.9"]; c != nil && c.Identity != anonymous.Name {
+		t.Errorf("refused client identity = %q", c.Identity)
+	}
+}
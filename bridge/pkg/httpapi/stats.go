@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Usage statistics endpoint
+// ---------------------------------------------------------------------------
+
+// handleUsageStats serves GET /stats.
+func handleUsageStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	f, err := parseJobFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.Until.IsZero() {
+		f.Until = time.Now()
+	}
+	if f.Since.IsZero() {
+		f.Since = f.Until.Add(-core.DefaultStatsRange)
+	}
+	if !f.Since.Before(f.Until) {
+		http.Error(w, "since must be before until", http.StatusBadRequest)
+		return
+	}
+	f.Student, f.Status = "", ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(core.StatsFor(f))
+}
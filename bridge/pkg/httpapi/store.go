@@ -0,0 +1,16 @@
+package httpapi
+
+import (
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Job history endpoints
+// ---------------------------------------------------------------------------
+
+// jobsAPI serves the endpoints that read the job history. It is given the
+// store to serve rather than using the package's, so tests can run the
+// handlers against a store of their own.
+type jobsAPI struct {
+	store core.JobStore
+}
@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestHeldJobOutlivesHistory(t *testing.T) {
+	m := &mockSpooler{printers: []string{"Mock Everest"}}
+	withSpooler(t, m)
+	oldStore := core.Store
+	defer func() { core.Store = oldStore }()
+	core.Store = core.NewMemoryStore(core.HistorySize)
+
+	held := core.HoldJob(core.JobEvent{Printer: "Mock Everest", Payload: []byte("ABC\f")})
+	for range core.HistorySize {
+		core.Store.Append(core.JobEvent{Printer: "Mock Everest", Status: core.StatusDone})
+	}
+	if _, ok := core.ReleaseJob(held.ID); !ok {
+		t.Fatal("the held job was evicted before it was released")
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		e, _ := core.Store.Get(held.ID)
+		if e.Status == core.StatusDone {
+			break
+		}
+		if core.FinishedStatus(e.Status) || time.Now().After(deadline) {
+			t.Fatalf("released job ended %s: %s", e.Status, e.ErrMsg)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if sent := m.sent["Mock Everest"]; len(sent) != 1 || string(sent[0]) != "ABC\f" {
+		t.Errorf("spooler got %q", sent)
+	}
+}
@@ -0,0 +1,55 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestPersistentStores(t *testing.T) {
+	for _, backend := range []string{core.BackendBolt, core.BackendSQLite} {
+		t.Run(backend, func(t *testing.T) {
+			c := &core.StoreConfig{Backend: backend, Path: filepath.Join(t.TempDir(), "jobs"), MaxJobs: 3}
+			s, err := core.OpenStore(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range 4 {
+				s.Append(core.JobEvent{Printer: "Index", Status: core.StatusDone, Student: "S" + string(rune('0'+i))})
+			}
+			s.Update(4, func(e *core.JobEvent) { e.Status = core.StatusPrinting })
+			s.Purge(func(e core.JobEvent) bool { return e.ID == 3 })
+			s.(*core.PersistentStore).Close()
+
+			s, err = core.OpenStore(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.(*core.PersistentStore).Close()
+			var ids []int
+			for _, e := range s.List() {
+				ids = append(ids, e.ID)
+			}
+			if !slices.Equal(ids, []int{2, 4}) {
+				t.Fatalf("reopened with jobs %v, want [2 4]", ids)
+			}
+			if e, _ := s.Get(4); e.Status != core.StatusFailed || e.Student != "S3" {
+				t.Errorf("interrupted job reloaded as %+v", e)
+			}
+			if e := s.Append(core.JobEvent{Printer: "Index"}); e.ID != 5 {
+				t.Errorf("next ID %d, want 5", e.ID)
+			}
+
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/jobs/2/preview", nil)
+			r.SetPathValue("id", "2")
+			if e, ok := (jobsAPI{store: s}).jobFromPath(rec, r); !ok || e.Student != "S1" {
+				t.Errorf("handler read %+v from the store it was given", e)
+			}
+		})
+	}
+}
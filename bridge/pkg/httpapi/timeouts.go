@@ -0,0 +1,101 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Request timeouts
+//
+// Every request gets a deadline for reading its body and one for the whole
+// call, set by the class of endpoint it is for ("timeouts" in the config):
+//
+//	print   /print, /print-url, resends, reprints, test and ruler pages:
+//	        upload_seconds to read the body, then up to response_seconds
+//	        waiting for the job (after which /print answers 202)
+//	upload  /lint: upload_seconds for the whole call
+//	export  job history, reports, statistics, the audit log and job
+//	        payloads: export_seconds
+//	stream  /log-stream and the profiler's profile and trace: no limit
+//	api     everything else: request_seconds
+//
+// The call's context is cancelled at its deadline or as soon as the client
+// goes away, which stops waits on the spooler, printer checks and URL
+// downloads; the connection is closed if the handler still has not
+// answered shortly after. A browser tab closed mid-upload or left waiting
+// on a long job therefore frees its connection and goroutine. A job that
+// was queued before the client left still prints.
+// ---------------------------------------------------------------------------
+
+const (
+	headerTimeout = 10 * time.Second  // reading request headers
+	idleTimeout   = 120 * time.Second // keep-alive connections between requests
+	writeGrace    = 10 * time.Second  // time to write a response after the deadline
+)
+
+// requestLimits are the deadlines of one endpoint class; zero is no limit.
+type requestLimits struct {
+	class   string
+	read    time.Duration // request body
+	handler time.Duration // the whole call
+}
+
+// limitsFor returns the deadlines for r.
+func limitsFor(r *http.Request) requestLimits {
+	p := r.URL.Path
+	switch {
+	case p == "/log-stream", p == "/debug/pprof/profile", p == "/debug/pprof/trace":
+		return requestLimits{class: "stream"}
+	case r.Method == http.MethodPost && (p == "/print" || p == "/print-url" || p == "/testprint" ||
+		strings.HasSuffix(p, "/ruler") || strings.HasSuffix(p, "/resend") ||
+		strings.HasSuffix(p, "/resume") || strings.HasPrefix(p, "/jobs/") && strings.HasSuffix(p, "/print")):
+		return requestLimits{class: "print", read: core.UploadTimeout(), handler: core.UploadTimeout() + core.ResponseTimeout()}
+	case r.Method == http.MethodPost && p == "/lint":
+		return requestLimits{class: "upload", read: core.UploadTimeout(), handler: core.UploadTimeout()}
+	case r.Method == http.MethodGet && (p == "/jobs" || p == "/stats" || p == "/audit" ||
+		strings.HasPrefix(p, "/reports/") || strings.HasSuffix(p, "/brf") ||
+		strings.HasSuffix(p, "/pdf") || strings.HasSuffix(p, "/preview")):
+		return requestLimits{class: "export", read: core.RequestTimeout(), handler: core.ExportTimeout()}
+	}
+	return requestLimits{class: "api", read: core.RequestTimeout(), handler: core.RequestTimeout()}
+}
+
+// withTimeouts applies the deadlines of each request's endpoint class.
+func withTimeouts(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lim := limitsFor(r)
+		// Deadlines carry over to the next request on a kept-alive
+		// connection, so they are always set, to zero for no limit.
+		var readBy, writeBy time.Time
+		if lim.handler > 0 {
+			now := time.Now()
+			readBy = now.Add(lim.read)
+			writeBy = now.Add(lim.handler + writeGrace)
+			ctx, cancel := context.WithTimeout(r.Context(), lim.handler)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+		// Errors mean the writer has no connection (native messaging).
+		rc := http.NewResponseController(w)
+		rc.SetReadDeadline(readBy)
+		rc.SetWriteDeadline(writeBy)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewServer returns the HTTP server for addr. Per-request read and write
+// deadlines are set by withTimeouts, so the server sets only those that
+// apply before a request is routed.
+func NewServer(addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           withTimeouts(h),
+		ReadHeaderTimeout: headerTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
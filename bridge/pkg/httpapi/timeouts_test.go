@@ -0,0 +1,41 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestRequestLimits(t *testing.T) {
+	for _, c := range []struct{ method, path, class string }{
+		{http.MethodPost, "/print", "print"},
+		{http.MethodPost, "/jobs/4/print", "print"},
+		{http.MethodPost, "/lint", "upload"},
+		{http.MethodGet, "/jobs", "export"},
+		{http.MethodGet, "/jobs/4/preview", "export"},
+		{http.MethodGet, "/log-stream", "stream"},
+		{http.MethodGet, "/printers", "api"},
+		{http.MethodPost, "/jobs/4/release", "api"},
+	} {
+		if lim := limitsFor(httptest.NewRequest(c.method, c.path, nil)); lim.class != c.class {
+			t.Errorf("%s %s: class %q, want %q", c.method, c.path, lim.class, c.class)
+		}
+	}
+
+	var deadline time.Time
+	h := withTimeouts(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ = r.Context().Deadline()
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/printers", nil))
+	if d := time.Until(deadline); d <= 0 || d > core.DefaultRequestTimeout {
+		t.Errorf("api deadline in %s", d)
+	}
+	deadline = time.Time{}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/log-stream", nil))
+	if !deadline.IsZero() {
+		t.Error("stream given a deadline")
+	}
+}
@@ -0,0 +1,157 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Dashboard assets
+//
+// The debug dashboard is a set of static files in ui/, embedded in the
+// binary. --ui-dir names a directory whose files are served in their place:
+// a school can brand the dashboard by dropping in its own dashboard.css (or
+// a whole replacement index.html) without rebuilding. Files missing from
+// the directory fall back to the embedded copies, so an override only needs
+// the files it changes.
+//
+//	GET /debug        → index.html
+//	GET /kiosk        → kiosk.html, the wall display (kiosk.go)
+//	GET /ui/{file...} → any other asset
+//	GET /client.js    → the JavaScript client for web apps (ui/client.js)
+//
+// /client.js needs no login: it is the module web apps and third-party web
+// tools import to find the bridge, pair with a token, send jobs and follow
+// the event stream, so they share one tested integration layer.
+//
+// Each asset is served with an ETag (a hash of its content) and
+// "Cache-Control: no-cache", so a reload on a slow network costs one 304
+// per file instead of the files themselves, yet a new bridge version or an
+// edited override file is picked up at once. API responses are sent with
+// "Cache-Control: no-store" (see withCORS) and are never cached.
+// ---------------------------------------------------------------------------
+
+//go:embed ui
+var embeddedUI embed.FS
+
+// uiFS serves dashboard assets; see SetUIDir.
+var uiFS fs.FS = mustSub(embeddedUI, "ui")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// SetUIDir layers the files in dir over the embedded dashboard.
+func SetUIDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	uiFS = overlayFS{top: os.DirFS(dir), base: uiFS}
+	return nil
+}
+
+// overlayFS opens files from top, falling back to base for any top lacks.
+type overlayFS struct {
+	top, base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.top.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.base.Open(name)
+	}
+	return f, err
+}
+
+// handleDebugPage serves the dashboard's index.html.
+func handleDebugPage(w http.ResponseWriter, r *http.Request) {
+	serveUIFile(w, r, "index.html")
+}
+
+// handleClientJS serves GET /client.js.
+func handleClientJS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	serveUIFile(w, r, "client.js")
+}
+
+// handleUIAsset serves GET /ui/{file...}.
+func handleUIAsset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	serveUIFile(w, r, r.PathValue("file"))
+}
+
+// serveUIFile serves one file from uiFS. Directories are not listed.
+func serveUIFile(w http.ResponseWriter, r *http.Request, name string) {
+	info, err := fs.Stat(uiFS, name)
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	a, err := loadUIAsset(name, info)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", a.etag)
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(a.data))
+}
+
+// uiAsset is a dashboard file read into memory with its ETag.
+type uiAsset struct {
+	data    []byte
+	etag    string
+	modTime time.Time
+	size    int64
+}
+
+// uiAssets caches assets by name. An entry is reread when the file's
+// modification time or size changes, which only happens to override files.
+var uiAssets = struct {
+	sync.Mutex
+	m map[string]uiAsset
+}{m: map[string]uiAsset{}}
+
+func loadUIAsset(name string, info fs.FileInfo) (uiAsset, error) {
+	uiAssets.Lock()
+	defer uiAssets.Unlock()
+	if a, ok := uiAssets.m[name]; ok && a.modTime.Equal(info.ModTime()) && a.size == info.Size() {
+		return a, nil
+	}
+	data, err := fs.ReadFile(uiFS, name)
+	if err != nil {
+		return uiAsset{}, err
+	}
+	sum := sha256.Sum256(data)
+	a := uiAsset{
+		data:    data,
+		etag:    `"` + hex.EncodeToString(sum[:8]) + `"`,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+	uiAssets.m[name] = a
+	return a, nil
+}
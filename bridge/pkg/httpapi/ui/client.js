@@ -0,0 +1,256 @@
+// Graham Bridge client — served by the bridge at /client.js.
+//
+// One integration layer for the web app and third-party web tools:
+// finding the bridge, pairing with an API token, sending jobs and
+// following them live. Load it as a module (a plain <script> from another
+// site is refused by the bridge's origin check):
+//
+//   const { BridgeClient } = await import('http://127.0.0.1:8080/client.js');
+//   const bridge = await BridgeClient.discover();
+//   if (bridge) {
+//     const res = await bridge.print({ printer: 'Tiger', data: brfText });
+//     const stop = bridge.subscribe(ev => console.log(ev.name, ev.data));
+//   }
+//
+// The page's origin must be trusted by the bridge (its defaults or the
+// config's "allowed_origins").
+
+const DEFAULT_PORTS = [8080, 8081, 8082];
+const TOKEN_KEY = 'graham-bridge-token:';
+const PROBE_MS = 1500;
+
+// BridgeError is a refused request or failed job. code is the bridge's
+// error_code (e.g. "printer_not_found"), empty for plain-text refusals.
+export class BridgeError extends Error {
+  constructor(status, body, requestId) {
+    super(body.error || body.message || ('bridge answered ' + status));
+    this.name = 'BridgeError';
+    this.status = status;
+    this.code = body.error_code || '';
+    this.guidance = body.guidance || '';
+    this.jobId = body.id || 0;
+    this.requestId = requestId || '';
+    this.estimate = this.code === 'confirmation_required' ? body : null;
+  }
+}
+
+// Tries each URL's /status and returns the first that answers as a
+// Graham bridge, or null.
+export async function findBridge(urls) {
+  const probes = urls.map(async url => {
+    const res = await fetch(url + '/status', { signal: AbortSignal.timeout(PROBE_MS) });
+    const s = await res.json();
+    if (!res.ok || s.status !== 'ok' || (s.app && s.app !== 'graham-bridge')) throw new Error('not a bridge');
+    return { url, status: s };
+  });
+  try {
+    return await Promise.any(probes);
+  } catch {
+    return null;
+  }
+}
+
+function candidateURLs(opts) {
+  const urls = [];
+  // The bridge this file was loaded from is the likeliest.
+  try {
+    const self = new URL(import.meta.url);
+    if (self.pathname.endsWith('/client.js')) urls.push(self.origin);
+  } catch { /* bundled copy */ }
+  for (const host of opts.hosts || ['127.0.0.1']) {
+    for (const port of opts.ports || DEFAULT_PORTS) {
+      const url = 'http://' + host + ':' + port;
+      if (!urls.includes(url)) urls.push(url);
+    }
+  }
+  return urls;
+}
+
+function toBase64(data) {
+  if (typeof data === 'string') data = new TextEncoder().encode(data);
+  let s = '';
+  for (let i = 0; i < data.length; i += 0x8000) {
+    s += String.fromCharCode.apply(null, data.subarray(i, i + 0x8000));
+  }
+  return btoa(s);
+}
+
+export class BridgeClient {
+  // url is the bridge's base URL; token an API token, if the bridge
+  // requires one (by default the token saved by pair() is used).
+  constructor(url, token) {
+    this.url = url.replace(/\/+$/, '');
+    this.token = token === undefined ? BridgeClient.savedToken(this.url) : token;
+    this.status = null;
+  }
+
+  // Finds a running bridge on this machine by probing opts.ports
+  // (default 8080–8082) on opts.hosts (default 127.0.0.1). Resolves to a
+  // client, or null if no bridge answered.
+  static async discover(opts = {}) {
+    const found = await findBridge(candidateURLs(opts));
+    if (!found) return null;
+    const c = new BridgeClient(found.url, opts.token);
+    c.status = found.status;
+    return c;
+  }
+
+  static savedToken(url) {
+    try { return localStorage.getItem(TOKEN_KEY + url) || ''; } catch { return ''; }
+  }
+
+  // Checks token against the bridge and, if it is accepted, remembers it
+  // for this bridge in localStorage. Rejects with a BridgeError (status
+  // 401 or 403) for a token the bridge does not accept.
+  async pair(token) {
+    const prev = this.token;
+    this.token = token;
+    try {
+      await this.printers();
+    } catch (e) {
+      this.token = prev;
+      throw e;
+    }
+    try { localStorage.setItem(TOKEN_KEY + this.url, token); } catch { /* private mode */ }
+  }
+
+  // Forgets the saved token.
+  unpair() {
+    this.token = '';
+    try { localStorage.removeItem(TOKEN_KEY + this.url); } catch { /* private mode */ }
+  }
+
+  headers(extra) {
+    const h = Object.assign({ 'Accept': 'application/json' }, extra);
+    if (this.token) h['Authorization'] = 'Bearer ' + this.token;
+    return h;
+  }
+
+  async request(method, path, body, timeoutMs) {
+    const res = await fetch(this.url + path, {
+      method,
+      headers: this.headers(body ? { 'Content-Type': 'application/json' } : {}),
+      body: body ? JSON.stringify(body) : undefined,
+      signal: timeoutMs ? AbortSignal.timeout(timeoutMs) : undefined,
+    });
+    const text = await res.text();
+    let json = null;
+    try { json = text ? JSON.parse(text) : null; } catch { /* plain text */ }
+    if (!res.ok) {
+      throw new BridgeError(res.status, json && typeof json === 'object' ? json : { message: text.trim() },
+        res.headers.get('X-Request-ID'));
+    }
+    return json;
+  }
+
+  // Resolves to the names of the printers and pools jobs can be sent to.
+  printers() {
+    return this.request('GET', '/printers', null, 15000);
+  }
+
+  // Resolves to the optional parts this bridge has ({version, features,
+  // printers}; see GET /features), so the page can hide what it lacks.
+  features() {
+    return this.request('GET', '/features', null, 5000);
+  }
+
+  // Sends a job. job.data is the BRF as a string or Uint8Array; printer,
+  // student, urgent, media, dots, line_spacing, print_text, also and
+  // confirm are passed through as /print takes them. Resolves to the
+  // bridge's answer ({status: "queued" | "accepted" | "held" | "waiting" |
+  // "stuck", id, …}) and rejects with a BridgeError for refused or failed
+  // jobs. A job over the bridge's confirm_pages is refused with code
+  // "confirmation_required" and err.estimate ({pages, sheets, interpoint,
+  // estimated_seconds, confirm}); send it again with confirm set to print.
+  print(job) {
+    const body = Object.assign({}, job, { data: toBase64(job.data) });
+    return this.request('POST', '/print', body);
+  }
+
+  // Adds a note to a finished job ("delivered to room 12"). Resolves to
+  // {time, by, text}; a job still printing is refused with status 409.
+  annotate(id, text) {
+    return this.request('POST', '/jobs/' + id + '/annotations', { text });
+  }
+
+  // Records that someone checked job id's embossed pages. Resolves to
+  // the job; jobs that did not emboss are refused with status 409.
+  verify(id) {
+    return this.request('POST', '/jobs/' + id + '/verify');
+  }
+
+  // Follows the live event stream, calling onEvent({name, id, data}) for
+  // each event: name is "" for job records, or progress, retry, printer,
+  // paper, purge, reset, ui or heartbeat. It reconnects by itself and
+  // resumes after the last event. onState(state), if given, is told
+  // "open", "closed" (retrying) or "error" (gave up: a BridgeError such
+  // as a bad token, passed as the second argument). Returns a function
+  // that stops the subscription.
+  subscribe(onEvent, onState = () => {}) {
+    const ctl = new AbortController();
+    let lastId = '', retryMs = 3000;
+    const run = async () => {
+      while (!ctl.signal.aborted) {
+        try {
+          const headers = this.headers({ 'Accept': 'text/event-stream' });
+          if (lastId) headers['Last-Event-ID'] = lastId;
+          const res = await fetch(this.url + '/log-stream?schema=2', { headers, signal: ctl.signal });
+          if (!res.ok) {
+            const text = await res.text();
+            onState('error', new BridgeError(res.status, { message: text.trim() }, res.headers.get('X-Request-ID')));
+            return;
+          }
+          onState('open');
+          await readEvents(res.body, ev => {
+            if (ev.retry) { retryMs = ev.retry; return; }
+            if (ev.id) lastId = ev.id;
+            onEvent(ev);
+          });
+        } catch (e) {
+          if (ctl.signal.aborted) return;
+        }
+        onState('closed');
+        await new Promise(r => setTimeout(r, retryMs));
+      }
+    };
+    run();
+    return () => ctl.abort();
+  }
+}
+
+// readEvents parses a text/event-stream body, calling fn for each event
+// ({name, id, data} with data parsed as JSON) and for each retry
+// directive ({retry: ms}).
+async function readEvents(body, fn) {
+  const reader = body.pipeThrough(new TextDecoderStream()).getReader();
+  let buf = '', name = '', id = '', data = [];
+  for (;;) {
+    const { value, done } = await reader.read();
+    if (done) return;
+    buf += value;
+    let nl;
+    while ((nl = buf.indexOf('\n')) >= 0) {
+      const line = buf.slice(0, nl).replace(/\r$/, '');
+      buf = buf.slice(nl + 1);
+      if (line === '') {
+        if (data.length) {
+          let parsed;
+          try { parsed = JSON.parse(data.join('\n')); } catch { parsed = data.join('\n'); }
+          fn({ name, id, data: parsed });
+        }
+        name = ''; id = ''; data = [];
+        continue;
+      }
+      const colon = line.indexOf(':');
+      if (colon === 0) continue; // comment
+      const field = colon < 0 ? line : line.slice(0, colon);
+      const val = colon < 0 ? '' : line.slice(colon + 1).replace(/^ /, '');
+      if (field === 'event') name = val;
+      else if (field === 'id') id = val;
+      else if (field === 'data') data.push(val);
+      else if (field === 'retry' && +val > 0) fn({ retry: +val });
+    }
+  }
+}
+
+export default BridgeClient;
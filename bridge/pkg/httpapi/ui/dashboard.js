@@ -0,0 +1,1336 @@
+(function themeInit(){
+  const THEME_KEY = 'graham-braille-theme';
+  const ORDER = ['dark','light','high-contrast'];
+  const LABEL = {dark:'Dark',light:'Light','high-contrast':'High contrast'};
+  function get(){ let t = localStorage.getItem(THEME_KEY) || 'dark'; return ORDER.indexOf(t) === -1 ? 'dark' : t; }
+  function apply(t){
+    if (t === 'dark') document.documentElement.removeAttribute('data-theme');
+    else document.documentElement.setAttribute('data-theme', t);
+    localStorage.setItem(THEME_KEY, t);
+    const btn = document.getElementById('theme-btn');
+    const next = ORDER[(ORDER.indexOf(t) + 1) % ORDER.length];
+    btn.textContent = LABEL[t];
+    btn.title = 'Theme (matches Graham Braille Editor). Next: ' + LABEL[next];
+  }
+  document.getElementById('theme-btn').addEventListener('click', function(){
+    apply(ORDER[(ORDER.indexOf(get()) + 1) % ORDER.length]);
+  });
+  apply(get());
+})();
+let selPrinter = null, selJob = null, selResume = '', uiState = {};
+const jobsById = {};
+
+// ── SSE stream ───────────────────────────────────────────────
+// The bridge sends a heartbeat every 15 s. If none arrives for
+// STALE_MS the connection is presumed dead (e.g. a proxy silently dropped
+// it) and is re-opened, resuming from the last event seen.
+const STALE_MS = 45000;
+let es = null, lastEventId = '', lastBeat = 0;
+
+function connect() {
+  if (es) es.close();
+  es = new EventSource('/log-stream' +
+    (lastEventId ? '?lastEventId=' + encodeURIComponent(lastEventId) : ''));
+  es.onopen = () => {
+    lastBeat = Date.now();
+    set('#badge','LIVE',['connecting','offline'],[]);
+    set('#dot','',['connecting','offline'],[]);
+    document.getElementById('status-txt').textContent =
+      'Connected — listening for print jobs on port 8080';
+  };
+  es.onerror = () => {
+    set('#badge','OFFLINE',[],['offline']);
+    set('#dot','',['connecting'],['offline']);
+    document.getElementById('status-txt').textContent =
+      'Connection lost — is the bridge still running?';
+  };
+  es.onmessage = ev => {
+    track(ev);
+    const job = JSON.parse(ev.data);
+    jobAlert(jobsById[job.id], job);
+    if (!addRow(job)) {
+      if (job.id === selJob) { showAnnotations(job); showVerified(job); }
+      return;
+    }
+    document.querySelectorAll('#log-body tr.sel').forEach(r => r.classList.remove('sel'));
+    updatePreview(job);
+  };
+
+  // Sent before a full replay (first connect, or a reconnect that could not
+  // resume from Last-Event-ID, e.g. after the bridge restarted).
+  es.addEventListener('reset', ev => {
+    track(ev);
+    Object.keys(jobsById).forEach(k => delete jobsById[k]);
+    document.getElementById('log-body').innerHTML = '';
+    updateCount();
+    document.getElementById('log-empty').style.display = '';
+    document.getElementById('log-tbl').style.display = 'none';
+  });
+
+  // Per-chunk progress, with the page and time left, from direct
+  // (serial/USB) transports.
+  es.addEventListener('progress', ev => {
+    track(ev);
+    const p = JSON.parse(ev.data);
+    const job = jobsById[p.job_id];
+    if (!job || job.status !== 'printing') return;
+    const cell = document.querySelector('#log-body tr[data-id="'+p.job_id+'"] td:last-child');
+    if (!cell) return;
+    let text = '🖨 Page ' + p.page + ' of ' + p.pages + ' (' + Math.floor(p.sent * 100 / p.total) + '%)';
+    if (p.remaining_seconds) text += ', ' + duration(p.remaining_seconds) + ' remaining';
+    cell.textContent = text;
+  });
+
+  // A transient failure (retry.go): the job is sent again after a pause.
+  es.addEventListener('retry', ev => {
+    track(ev);
+    const r = JSON.parse(ev.data);
+    const cell = document.querySelector('#log-body tr[data-id="'+r.job_id+'"] td:last-child');
+    if (!cell) return;
+    cell.textContent = '🔁 Attempt ' + r.attempt + ' of ' + r.attempts + ' failed, retrying in ' + duration(r.delay_seconds);
+    cell.title = r.error;
+  });
+
+  // Paper estimates change after every embossed job and refill.
+  es.addEventListener('paper', ev => {
+    track(ev);
+    loadPaper();
+  });
+
+  // State changes found by the bridge's printer health watchdog.
+  es.addEventListener('printer', ev => {
+    track(ev);
+    const s = JSON.parse(ev.data);
+    printerAlert(s);
+    document.querySelectorAll('#printer-ul li').forEach(li => {
+      if (li.dataset.printer === s.printer) showPrinterState(li, s);
+    });
+  });
+
+  // Printer, filter and pause changes from any open dashboard (uistate.go),
+  // and the current state after a replay.
+  es.addEventListener('ui', ev => {
+    track(ev);
+    applyUIState(JSON.parse(ev.data));
+  });
+
+  // Jobs deleted by DELETE /jobs (purge.go).
+  es.addEventListener('purge', ev => {
+    track(ev);
+    JSON.parse(ev.data).jobs.forEach(id => {
+      delete jobsById[id];
+      const tr = document.querySelector('#log-body tr[data-id="'+id+'"]');
+      if (tr) tr.remove();
+    });
+    updateCount();
+  });
+
+  es.addEventListener('heartbeat', () => {
+    lastBeat = Date.now();
+    document.getElementById('status-txt').textContent =
+      'Connected — no new jobs (last heartbeat ' + new Date().toLocaleTimeString([], {hour12:false}) + ')';
+  });
+}
+
+// track remembers the latest event id so a manual reconnect can resume.
+function track(ev) {
+  lastBeat = Date.now();
+  if (ev.lastEventId) lastEventId = ev.lastEventId;
+}
+
+setInterval(() => {
+  if (!es || es.readyState !== EventSource.OPEN) return;
+  if (Date.now() - lastBeat > STALE_MS) {
+    set('#badge','STALE',[],['connecting']);
+    document.getElementById('status-txt').textContent =
+      'No heartbeat from the bridge — reconnecting…';
+    connect();
+  }
+}, 5000);
+
+connect();
+
+function set(sel, txt, rem, add) {
+  const el = document.querySelector(sel);
+  if (txt !== '') el.textContent = txt;
+  rem.forEach(c => el.classList.remove(c));
+  add.forEach(c => el.classList.add(c));
+}
+
+function fmt(iso) {
+  return new Date(iso).toLocaleTimeString([], {hour12:false});
+}
+
+// addRow inserts a job row, or updates it in place when the job's status
+// changes. Returns true for jobs not seen before.
+function addRow(job) {
+  const known = jobsById[job.id] !== undefined;
+  jobsById[job.id] = job;
+  let tr = document.querySelector('#log-body tr[data-id="'+job.id+'"]');
+  if (!tr) {
+    document.getElementById('log-empty').style.display = 'none';
+    document.getElementById('log-tbl').style.display = '';
+    tr = document.createElement('tr');
+    tr.dataset.id = job.id;
+    tr.onclick = () => selectJob(job.id);
+    document.getElementById('log-body').prepend(tr);
+  }
+  tr.innerHTML =
+    '<td class="ts">#'+job.id+'</td>'+
+    '<td class="ts">'+fmt(job.time)+'</td>'+
+    '<td class="pc" title="'+esc(job.printer)+'">'+esc(job.printer)+'</td>'+
+    '<td class="ts">'+(job.student ? esc(job.student) : '—')+'</td>'+
+    '<td class="bc">'+job.bytes+' B</td>'+
+    resultCell(job);
+  tr.hidden = !matchesFilter(job);
+  updateCount();
+  return !known;
+}
+
+// ── Student filter ───────────────────────────────────────────
+// Filters the log to one student and totals their embossed pages (for
+// IEP records). GET /jobs?student= gives the same totals to scripts.
+function matchesFilter(job) {
+  const f = document.getElementById('student-filter').value.trim().toLowerCase();
+  if (document.getElementById('unverified-filter').checked && (job.status !== 'done' || job.verified)) return false;
+  return !f || (job.student || '').toLowerCase() === f;
+}
+
+// Shares the filter with other open dashboards once typing pauses.
+let filterTimer = null;
+function filterChanged() {
+  applyFilter();
+  clearTimeout(filterTimer);
+  filterTimer = setTimeout(() => shareUIState({student_filter: document.getElementById('student-filter').value.trim()}), 400);
+}
+
+function applyFilter() {
+  document.getElementById('purge-btn').hidden = !document.getElementById('student-filter').value.trim();
+  document.querySelectorAll('#log-body tr').forEach(tr =>
+    tr.hidden = !matchesFilter(jobsById[tr.dataset.id]));
+  updateCount();
+}
+
+function updateCount() {
+  const jobs = Object.values(jobsById).filter(matchesFilter);
+  let txt = jobs.length + ' job' + (jobs.length !== 1 ? 's' : '');
+  if (document.getElementById('student-filter').value.trim()) {
+    const pages = jobs.filter(j => j.status === 'done').reduce((n, j) => n + (j.pages || 0), 0);
+    txt += ' · ' + pages + ' page' + (pages !== 1 ? 's' : '') + ' embossed';
+  }
+  document.getElementById('job-count').textContent = txt;
+}
+
+// Short labels for the bridge's failure classes (failures.go); the full
+// guidance is shown with the job's BRF text.
+const errorLabels = {
+  timeout:               '⏱ Timed out',
+  printer_not_found:     '❓ Printer not found',
+  printer_not_accepting: '⏸ Printer paused',
+  spooler_unavailable:   '🛑 Print service down',
+  permission_denied:     '🔒 Access denied',
+  device_unavailable:    '🔌 Device not found',
+  job_stuck:             '⏸ Waiting in print queue',
+  hook_rejected:         '🚫 Stopped by a print rule',
+  printer_needs_attention: '⚠ Printer needs attention',
+};
+
+const stateLabels = {
+  ready:     'Ready',
+  printing:  'Printing',
+  paused:    'Paused',
+  offline:   'Offline',
+  paper_out: 'Out of paper',
+  paper_jam: 'Paper jam',
+  door_open: 'Door open',
+  error:     'Needs attention',
+  not_found: 'Not found',
+};
+
+function resultCell(job) {
+  switch (job.status) {
+    case 'queued':
+      return '<td class="ts">⏳ Queued '+
+        '<button class="ref-btn" data-scope="admin" title="Emboss this job next, after the one printing now" onclick="heldAction(event,'+job.id+',\'next\')">⏭ Next</button></td>';
+    case 'printing': return '<td class="ts">🖨 Printing…</td>';
+    case 'cancelled': return '<td class="ts">🚫 Discarded</td>';
+    case 'waiting':
+      return '<td class="ts" title="'+esc(job.error)+'">🔌 Waiting for printer '+
+        '<button class="ref-btn" data-scope="admin" onclick="heldAction(event,'+job.id+',\'release\')">Send now</button> '+
+        '<button class="ref-btn" data-scope="admin" onclick="heldAction(event,'+job.id+',\'discard\')">Discard</button></td>';
+    case 'held':
+      return '<td class="ts">'+(job.hold_reason === 'quiet_hours'
+        ? '🌙 Quiet until '+new Date(job.held_until).toLocaleTimeString([], {hour:'2-digit', minute:'2-digit'})+' '
+        : '✋ Held ')+
+        '<button class="ref-btn" data-scope="admin" onclick="heldAction(event,'+job.id+',\'release\')">Release</button> '+
+        '<button class="ref-btn" data-scope="admin" onclick="heldAction(event,'+job.id+',\'discard\')">Discard</button></td>';
+  }
+  const ok = !job.error;
+  const label = errorLabels[job.error_code];
+  if (!ok && label)
+    return '<td class="err" title="'+esc(job.error)+'">'+label+'</td>';
+  const checked = job.verified ? ' · ☑ Checked' : '';
+  return '<td class="'+(ok?'ok':'err')+'">'+(ok?'✅ OK'+checked:'❌ '+esc(job.error))+'</td>';
+}
+
+// heldAction releases or discards a held job (teacher approval or quiet
+// hours) or one waiting for its printer, or moves a queued job to the front.
+async function heldAction(ev, id, action) {
+  ev.stopPropagation();
+  ev.target.disabled = true;
+  try {
+    const r = await fetch('/jobs/' + id + '/' + action, {method: 'POST'});
+    if (!r.ok) throw new Error(await r.text());
+  } catch(e) {
+    alert('Job #' + id + ': ' + e.message);
+    ev.target.disabled = false;
+  }
+}
+
+// purgeStudent deletes every record of the filtered student, for a
+// student who has left the district.
+async function purgeStudent() {
+  const student = document.getElementById('student-filter').value.trim();
+  if (!student || !confirm('Delete every job, payload and page record for "' + student + '"? This cannot be undone.')) return;
+  try {
+    const r = await fetch('/jobs?student=' + encodeURIComponent(student), {method: 'DELETE'});
+    if (!r.ok) throw new Error(await r.text());
+    const res = await r.json();
+    alert('Purged ' + res.jobs + ' job(s) and ' + res.ledger + ' page record(s)' +
+      (res.skipped ? '; ' + res.skipped + ' still printing were kept' : '') + '.');
+  } catch(e) {
+    alert('Could not purge ' + student + ': ' + e.message);
+  }
+}
+
+// restoreBackup uploads a bundle from "Backup" (backup.go), replacing this
+// bridge's config, tokens, printer profiles and job history.
+async function restoreBackup(input) {
+  const file = input.files[0];
+  input.value = '';
+  if (!file || !confirm('Replace this bridge\'s config, tokens, printer profiles and job history with "' + file.name + '"? The current config is kept as a .bak file.')) return;
+  try {
+    const r = await fetch('/restore', {method: 'POST', headers: {'Content-Type': 'application/gzip'}, body: file});
+    if (!r.ok) throw new Error(await r.text());
+    const res = await r.json();
+    alert('Restored ' + res.files.length + ' file(s) and ' + res.jobs + ' job(s) from a backup made by bridge ' + res.from + '.' +
+      (res.restart_needed ? ' Restart the bridge to finish.' : ''));
+  } catch(e) {
+    alert('Could not restore ' + file.name + ': ' + e.message);
+  }
+}
+
+function selectJob(id) {
+  document.querySelectorAll('#log-body tr').forEach(r =>
+    r.classList.toggle('sel', r.dataset.id === String(id)));
+  const job = jobsById[id];
+  if (!job) return;
+  updatePreview(job);
+  document.getElementById('brf-title').textContent = 'BRF Text — job #' + id;
+}
+
+// jobNotes lists what the bridge's payload checks found or changed.
+// duration formats a time estimate: "~40 s", "~3 min".
+function duration(secs) {
+  return secs < 60 ? '~' + secs + ' s' : '~' + Math.ceil(secs / 60) + ' min';
+}
+
+function jobNotes(job) {
+  const notes = [];
+  if (job.guidance) notes.push(job.guidance);
+  if (job.estimated_seconds)
+    notes.push('Estimated embossing time: ' + duration(job.estimated_seconds) + '.');
+  if (job.linked_to) notes.push('Companion output of job #' + job.linked_to + '.');
+  if (job.app) notes.push('Sent by the ' + job.app + ' application.');
+  if (job.pool) notes.push('Sent to pool ' + job.pool + ', which gave it to this printer.');
+  if (job.linked) notes.push('Sent with companion job' + (job.linked.length > 1 ? 's' : '') + ' ' + job.linked.map(id => '#' + id).join(', ') + '.');
+  if (job.attempts > 1)
+    notes.push('Sent ' + job.attempts + ' times: earlier attempts failed before reaching the embosser.');
+  const nm = job.normalized;
+  if (nm) {
+    const list = m => Object.keys(m).map(c =>
+      '\u201C' + c + '\u201D (U+' + c.codePointAt(0).toString(16).toUpperCase().padStart(4, '0') +
+      ') \u00D7' + m[c]).join(', ');
+    if (nm.bom) notes.push('Removed a UTF-8 byte-order mark.');
+    if (nm.replaced) notes.push('Replaced with plain ASCII: ' + list(nm.replaced) + '.');
+    if (nm.unknown) notes.push('Non-braille characters left in place (will emboss as garbage): ' + list(nm.unknown) + '.');
+  }
+  const lc = job.line_check;
+  if (lc) {
+    const verb = lc.action === 'wrap' ? 'were wrapped to' : 'exceed';
+    notes.push('Line' + (lc.lines.length > 1 ? 's ' : ' ') + lc.lines.join(', ') +
+      ' ' + verb + ' ' + lc.limit + ' cells.');
+  }
+  if (job.pages_sent) notes.push(job.pages_sent + ' page(s) were sent before the job failed; resume after them if those came out.');
+  if (job.reversed) notes.push('Pages were sent last first for a face-down stacker.');
+  if (job.request_id) notes.push('Request ID ' + job.request_id + ': search the bridge log and audit log for it to follow this job.');
+  if (job.flow) {
+    const fl = job.flow;
+    notes.push('Flow check (' + fl.flow_control + '): written in ' + fl.seconds + ' s, held off for ' + fl.held_seconds + ' s.');
+    (fl.warnings || []).forEach(w => notes.push('Flow check: ' + w + '.'));
+  }
+  if (job.route) notes.push('Sent straight to ' + job.route + ' instead of through the AirPrint queue.');
+  if (job.page_range) notes.push('Pages ' + job.page_range + ' of job ' + job.resent_from + '.');
+  if (job.media) notes.push('Media setting: ' + job.media + '.');
+  if (job.dots === 8) notes.push('Embossed in eight-dot mode.');
+  if (job.line_spacing) notes.push('Line spacing: ' + job.line_spacing + '.');
+  if (job.eject_fixed) notes.push('End-of-job page eject was added or de-duplicated.');
+  if (job.tof_fixed) notes.push('Start of job adjusted for the printer\'s top-of-form setting.');
+  (job.hooks || []).forEach(h => notes.push('Hook “' + h.name + '” (' + h.stage + '): ' +
+    (h.error ? 'failed: ' + h.error : h.changed ? 'changed the document.' : 'ran, no changes.')));
+  if (job.print_text) notes.push(job.interlined
+    ? 'Print text was interlined in ink between the braille lines.'
+    : 'Print text attached for preview only (this printer has no interline profile).');
+  return notes;
+}
+
+// showAnnotations lists the notes added to a finished job after it came
+// out (annotations.go), with a box to add one.
+function showAnnotations(job) {
+  const done = !['queued', 'printing', 'held', 'waiting'].includes(job.status);
+  const list = job.annotations || [];
+  document.getElementById('job-ann').hidden = !done;
+  document.getElementById('ann-list').hidden = list.length === 0;
+  document.getElementById('ann-list').innerHTML = list.map(a =>
+    '<li>'+esc(a.text)+' <span class="ts">— '+esc(a.by)+', '+new Date(a.time).toLocaleString()+'</span></li>').join('');
+}
+
+async function addAnnotation() {
+  const input = document.getElementById('ann-text');
+  const text = input.value.trim();
+  if (!text || selJob === null) return;
+  try {
+    const r = await fetch('/jobs/' + selJob + '/annotations', {method:'POST',
+      headers:{'Content-Type':'application/json'}, body:JSON.stringify({text})});
+    if (!r.ok) throw new Error(await r.text());
+    input.value = '';
+  } catch(e) {
+    alert('Could not add the note: ' + e.message);
+  }
+}
+
+// showVerified offers to record that an embossed job's pages were checked
+// by hand before going out (verify.go).
+function showVerified(job) {
+  const b = document.getElementById('verify-btn');
+  b.hidden = job.status !== 'done';
+  b.textContent = job.verified ? '☐ Unmark Checked' : '☑ Mark Checked';
+  b.title = job.verified ? 'Checked by ' + job.verified.by + ', ' + new Date(job.verified.time).toLocaleString()
+    : 'Record that you checked the embossed pages';
+}
+
+async function toggleVerified() {
+  const job = jobsById[selJob];
+  if (!job) return;
+  const r = await fetch('/jobs/' + job.id + (job.verified ? '/unverify' : '/verify'), {method:'POST'});
+  if (!r.ok) alert('Could not update job #' + job.id + ': ' + await r.text());
+}
+
+// brfHighlight marks the structural parts of BRF text: number signs,
+// capital indicators, form feeds and any other control code (an escape
+// sequence injected by a profile or a stray byte), shown as its control
+// picture.
+function brfHighlight(text) {
+  // A form feed is shown ending its line, so drop a newline after one.
+  const src = text.replace(/\r\n/g, '\n').replace(/\f\n/g, '\f');
+  return src.split(/(#|,+(?=[A-Za-z])|[\x00-\x09\x0b-\x1f\x7f])/).map((t, i) => {
+    if (i % 2 === 0) return esc(t);
+    if (t === '#') return '<span class="brf-num" title="Number sign">#</span>';
+    if (t[0] === ',') return '<span class="brf-cap" title="Capital ' + (t.length > 1 ? 'word or passage ' : '') + 'indicator">' + t + '</span>';
+    if (t === '\f') return '<span class="brf-ff" title="Form feed (page break)">␌</span>\n';
+    if (t === '\t') return '<span class="brf-ctl" title="Tab (0x09)">␉</span>';
+    const code = t.charCodeAt(0), hex = '0x' + code.toString(16).toUpperCase().padStart(2, '0');
+    const pic = code === 0x7f ? '␡' : String.fromCharCode(0x2400 + code);
+    return '<span class="brf-ctl" title="' + (code === 0x1b ? 'Escape' : 'Control code') + ' (' + hex + ')">' + pic + '</span>';
+  }).join('');
+}
+
+// interlinePreview shows each braille line with its print line beneath,
+// the way an interline page reads.
+function interlinePreview(brf, ink) {
+  const pages = t => t.replace(/\r\n/g, '\n').split('\f').map(p => p.split('\n'));
+  const inkPages = pages(ink);
+  return pages(brf).map((lines, i) => lines.map((line, j) => {
+    const print = (inkPages[i] || [])[j];
+    return brfHighlight(line) + (print && print.trim() ? '\n<span class="ink">' + esc(print) + '</span>' : '');
+  }).join('\n')).join('\n<span class="ink">── page break ──</span>\n');
+}
+
+function updatePreview(job) {
+  selJob = job.id;
+  const notes = jobNotes(job), nl = document.getElementById('job-notes');
+  nl.hidden = notes.length === 0;
+  nl.innerHTML = notes.map(n => '<li>'+esc(n)+'</li>').join('');
+  showAnnotations(job);
+  showVerified(job);
+  document.getElementById('edit-btn').disabled = false;
+  // A failed job offers to carry on after the pages already written.
+  const sent = job.pages_sent || 0;
+  selResume = job.status !== 'failed' ? '' : job.reversed ? '1-' + (job.pages - sent) : (sent + 1) + '-';
+  const pb = document.getElementById('pages-btn');
+  pb.disabled = false;
+  pb.textContent = selResume ? '⏵ Resume…' : '⎙ Print Pages…';
+  const pdf = document.getElementById('pdf-link');
+  pdf.hidden = !job.rendered;
+  pdf.href = '/jobs/' + job.id + '/pdf';
+  document.getElementById('brf-title').textContent = 'BRF Text — last job';
+  if (job.brf_text) {
+    document.getElementById('brf-empty').style.display = 'none';
+    const b = document.getElementById('brf-box');
+    b.style.display = '';
+    if (job.print_text) b.innerHTML = interlinePreview(job.brf_text, job.print_text);
+    else b.innerHTML = brfHighlight(job.brf_text);
+  }
+  if (job.hex_dump) {
+    document.getElementById('hex-empty').style.display = 'none';
+    const h = document.getElementById('hex-box');
+    h.style.display = ''; h.textContent = job.hex_dump;
+  }
+}
+
+// ── Printer list ─────────────────────────────────────────────
+async function loadPrinters() {
+  document.getElementById('printer-empty').textContent = 'Loading…';
+  document.getElementById('printer-empty').style.display = '';
+  document.getElementById('printer-ul').style.display = 'none';
+  try {
+    const list = await fetch('/printers?details=1').then(r => r.json());
+    const ul = document.getElementById('printer-ul');
+    ul.innerHTML = '';
+    if (!list || list.length === 0) {
+      document.getElementById('printer-empty').textContent =
+        'No printers found on this machine.';
+      return;
+    }
+    document.getElementById('printer-empty').style.display = 'none';
+    ul.style.display = '';
+    list.forEach(p => {
+      const name = p.name;
+      const li = document.createElement('li');
+      li.innerHTML = p.setup
+        ? '<span>🔌</span><div class="usb-new">'+esc(p.model)+' (USB)<small>'+esc(p.setup)+'</small></div>'
+        : p.members
+        ? '<span>🖨🖨</span><div class="usb-new">'+esc(name)+' (pool)<small>'+esc(p.members.join(', '))+'</small></div>'
+        : '<span>🖨</span>'+esc(name);
+      if (p.warning) {
+        li.insertAdjacentHTML('beforeend', '<span class="pwarn" title="'+esc(p.warning)+'">⚠ driver</span>');
+      }
+      li.dataset.printer = name;
+      if (!p.members) loadPrinterState(li, name);
+      li.onclick = () => {
+        selectPrinter(name);
+        shareUIState({printer: name});
+      };
+      ul.appendChild(li);
+    });
+    if (uiState.printer) selectPrinter(uiState.printer);
+    markDefault();
+  } catch(e) {
+    document.getElementById('printer-empty').textContent =
+      'Failed: '+e.message;
+  }
+}
+
+function selectPrinter(name) {
+  let found = false;
+  document.querySelectorAll('#printer-ul li').forEach(l => {
+    l.classList.toggle('sel', l.dataset.printer === name);
+    found = found || l.dataset.printer === name;
+  });
+  if (!found) return;
+  selPrinter = name;
+  document.getElementById('test-btn').disabled = false;
+  document.getElementById('ruler-btn').disabled = false;
+  document.getElementById('default-btn').disabled = false;
+  markDefault();
+  loadIdentity(name);
+  loadReliability(name);
+}
+
+// Marks the default printer in the list and labels the Default button for
+// the selected one.
+function markDefault() {
+  const def = uiState.default_printer || '';
+  document.querySelectorAll('#printer-ul li').forEach(l => {
+    let star = l.querySelector('.pdefault');
+    if (l.dataset.printer === def && !star) {
+      star = document.createElement('span');
+      star.className = 'pdefault';
+      star.textContent = '★ default';
+      star.title = 'Print requests that name no printer come here';
+      l.insertBefore(star, l.querySelector('.pstate'));
+    } else if (l.dataset.printer !== def && star) {
+      star.remove();
+    }
+  });
+  const btn = document.getElementById('default-btn');
+  btn.textContent = selPrinter && selPrinter === def ? '☆ Clear Default' : '★ Default';
+}
+
+async function toggleDefault() {
+  if (!selPrinter) return;
+  const printer = selPrinter === uiState.default_printer ? '' : selPrinter;
+  const r = await fetch('/printers/default', {method:'PUT', headers:{'Content-Type':'application/json'}, body:JSON.stringify({printer})});
+  if (!r.ok) alert('Could not set the default printer: ' + await r.text());
+}
+
+// Shows the selected embosser's model and firmware from
+// /printers/{name}/identity, for support calls.
+async function loadIdentity(name) {
+  const el = document.getElementById('printer-identity');
+  el.hidden = true;
+  try {
+    const id = await fetch('/printers/'+encodeURIComponent(name)+'/identity').then(r => r.json());
+    if (name !== selPrinter || !(id.model || id.firmware)) return;
+    el.textContent = [id.model, id.firmware && 'firmware '+id.firmware, id.serial_number && 'S/N '+id.serial_number]
+      .filter(Boolean).join(' · ');
+    el.hidden = false;
+  } catch(e) {}
+}
+
+// Shows the selected printer's recent success rate from
+// /printers/{name}/stats, with a sparkline of the last 14 days: bar height
+// is the day's jobs, the red part its failures.
+async function loadReliability(name) {
+  const el = document.getElementById('printer-reliability');
+  el.hidden = true;
+  try {
+    const st = await fetch('/printers/'+encodeURIComponent(name)+'/stats').then(r => r.json());
+    const win = st.window;
+    if (name !== selPrinter || !win.jobs) return;
+    const parts = ['Last ' + win.jobs + ' job' + (win.jobs !== 1 ? 's' : '') + ': ' + Math.round(win.success_rate * 100) + '% succeeded'];
+    if (win.mean_seconds) parts.push('mean send ' + duration(Math.round(win.mean_seconds)).slice(1));
+    if (win.errors.length) parts.push('most common error ' + win.errors[0].code + ' (' + win.errors[0].count + '×)');
+    const max = Math.max(1, ...st.days.map(d => d.jobs)), bw = 8, h = 20;
+    const bars = st.days.map((d, i) => {
+      const x = i * bw, jh = Math.max(1, Math.round(d.jobs / max * h)), fh = Math.round(d.failed / max * h);
+      const tip = '<title>' + d.key + ': ' + d.jobs + ' jobs, ' + d.failed + ' failed</title>';
+      if (!d.jobs) return '<rect class="none" x="'+x+'" y="'+(h-1)+'" width="'+(bw-2)+'" height="1">'+tip+'</rect>';
+      return '<g>' + tip + '<rect class="ok" x="'+x+'" y="'+(h-jh)+'" width="'+(bw-2)+'" height="'+(jh-fh)+'"/>' +
+        (fh ? '<rect class="bad" x="'+x+'" y="'+(h-fh)+'" width="'+(bw-2)+'" height="'+fh+'"/>' : '') + '</g>';
+    }).join('');
+    el.innerHTML = esc(parts.join(' · ')) +
+      '<svg class="spark" width="'+(st.days.length*bw)+'" height="'+h+'" role="img" aria-label="Jobs and failures per day, last '+st.days.length+' days">'+bars+'</svg>';
+    el.hidden = false;
+  } catch(e) {}
+}
+
+// ── Shared state ─────────────────────────────────────────────
+// The selected printer, student filter and queue pause are kept by the
+// bridge so every open dashboard shows the same ones.
+async function shareUIState(change) {
+  if (scope === 'read') return;
+  try {
+    await fetch('/ui-state', {method:'POST', headers:{'Content-Type':'application/json'}, body:JSON.stringify(change)});
+  } catch(e) {}
+}
+
+function applyUIState(s) {
+  uiState = s;
+  if (s.printer && s.printer !== selPrinter) selectPrinter(s.printer);
+  const f = document.getElementById('student-filter');
+  if (f.value.trim() !== s.student_filter && document.activeElement !== f) {
+    f.value = s.student_filter;
+    applyFilter();
+  }
+  const btn = document.getElementById('pause-btn');
+  btn.textContent = s.paused ? '▶ Resume Queue' : '⏸ Pause Queue';
+  btn.classList.toggle('paused', s.paused);
+  btn.title = s.paused ? 'Queued jobs are held until the queue is resumed' : 'Hold queued jobs without refusing new ones';
+  markDefault();
+}
+
+async function togglePause() {
+  const r = await fetch(uiState.paused ? '/queue/resume' : '/queue/pause', {method:'POST'});
+  if (!r.ok) alert('Could not change the queue: ' + await r.text());
+}
+
+// Adds a state badge to a printer list entry from /printers/{name}/status.
+async function loadPrinterState(li, name) {
+  try {
+    showPrinterState(li, await fetch('/printers/'+encodeURIComponent(name)+'/status').then(r => r.json()));
+  } catch(e) {}
+}
+
+// The state each printer's badge shows, so alerts announce only changes.
+const shownStates = {};
+
+// Sets (or replaces) a printer list entry's state badge.
+function showPrinterState(li, s) {
+  const label = stateLabels[s.state];
+  if (!label) return;
+  let badge = li.querySelector('.pstate');
+  if (!badge) {
+    badge = document.createElement('span');
+    li.appendChild(badge);
+  }
+  shownStates[s.printer] = s.state;
+  badge.className = 'pstate '+s.state;
+  badge.textContent = label + (s.jobs ? ' · '+s.jobs+' queued' : '') + (s.held ? ' · queue held' : '');
+  badge.title = s.guidance || (s.reasons || []).join(', ');
+}
+
+// ── Test print ───────────────────────────────────────────────
+async function sendTest() {
+  if (!selPrinter) return;
+  const btn = document.getElementById('test-btn');
+  btn.disabled = true; btn.textContent = '⏳ Sending…';
+  try {
+    const r = await fetch('/testprint', {
+      method:'POST',
+      headers:{'Content-Type':'application/json'},
+      body:JSON.stringify({printer:selPrinter})
+    });
+    const res = r.ok ? await r.json() : {};
+    btn.textContent = !r.ok ? '❌ Send failed.' : res.status === 'held' ? '⏸ Held; see the job list.' : '✅ Sent! Check the embosser.';
+  } catch(e) {
+    btn.textContent = '❌ Error: '+e.message;
+  }
+  setTimeout(() => {
+    btn.textContent = '🧪 Send Test Page to Selected Printer';
+    btn.disabled = false;
+  }, 4000);
+}
+
+// sendRuler embosses a ruler page for measuring the printer's cell and
+// line pitch (see the profile's *_pitch_mm settings).
+async function sendRuler() {
+  if (!selPrinter) return;
+  const btn = document.getElementById('ruler-btn');
+  btn.disabled = true;
+  try {
+    const r = await fetch('/printers/' + encodeURIComponent(selPrinter) + '/ruler', {method:'POST'});
+    if (!r.ok) throw new Error(await r.text());
+    btn.textContent = (await r.json()).status === 'held' ? '⏸ Ruler held' : '✅ Ruler sent';
+  } catch(e) {
+    btn.textContent = '❌ Ruler failed';
+    btn.title = e.message;
+  }
+  setTimeout(() => { btn.textContent = '📏 Ruler'; btn.disabled = false; }, 4000);
+}
+
+// ── BRF editor ───────────────────────────────────────────────
+// BRF bytes are mapped 1:1 onto char codes so escape sequences and
+// form feeds survive the round trip through the textarea.
+function bytesToText(buf) {
+  let s = '';
+  new Uint8Array(buf).forEach(b => { s += String.fromCharCode(b); });
+  return s;
+}
+function textToBase64(text) {
+  let bin = '';
+  for (const ch of text) {
+    const c = ch.charCodeAt(0);
+    bin += c < 256 ? ch : '?';
+  }
+  return btoa(bin);
+}
+
+async function openEditor() {
+  if (selJob === null) return;
+  const job = jobsById[selJob];
+  try {
+    const r = await fetch('/jobs/' + selJob + '/brf');
+    if (!r.ok) throw new Error(await r.text());
+    document.getElementById('ed-text').value = bytesToText(await r.arrayBuffer());
+  } catch(e) {
+    alert('Could not load job #' + selJob + ': ' + e.message);
+    return;
+  }
+  document.getElementById('ed-title').textContent = 'Edit job #' + selJob;
+  document.getElementById('ed-printer').value = job ? job.printer : '';
+  document.getElementById('editor').hidden = false;
+  validateEditor();
+  document.getElementById('ed-text').focus();
+}
+
+function closeEditor() {
+  document.getElementById('editor').hidden = true;
+}
+
+function insertFormFeed() {
+  const ta = document.getElementById('ed-text');
+  const at = ta.selectionStart;
+  ta.setRangeText('\f', at, ta.selectionEnd, 'end');
+  ta.focus();
+  validateEditor();
+}
+
+// validateEditor reports lines longer than the configured cell width.
+function validateEditor() {
+  const text = document.getElementById('ed-text').value;
+  const width = parseInt(document.getElementById('ed-width').value, 10) || 40;
+  const pages = text.split('\f').length;
+  const lines = text.split(/\r?\n/);
+  const long = [];
+  lines.forEach((l, i) => {
+    const len = l.replace(/[\r\f]/g, '').length;
+    if (len > width) long.push((i + 1) + ' (' + len + ')');
+  });
+  const rep = document.getElementById('ed-report');
+  const summary = lines.length + ' lines, ' + pages + ' page' + (pages !== 1 ? 's' : '') + '. ';
+  if (long.length) {
+    rep.className = 'err';
+    rep.textContent = summary + long.length + ' line' + (long.length !== 1 ? 's' : '') +
+      ' over ' + width + ' cells: ' + long.join(', ');
+  } else {
+    rep.className = 'ok';
+    rep.textContent = summary + 'All lines fit in ' + width + ' cells.';
+  }
+}
+document.getElementById('ed-text').addEventListener('input', validateEditor);
+document.getElementById('ed-width').addEventListener('input', validateEditor);
+// ── Page reports ─────────────────────────────────────────────
+function openReports() {
+  document.getElementById('reports').hidden = false;
+  loadReport();
+}
+
+function closeReports() {
+  document.getElementById('reports').hidden = true;
+}
+
+async function loadReport() {
+  const by = document.getElementById('rp-by').value;
+  const q = new URLSearchParams({by});
+  const since = document.getElementById('rp-since').value;
+  const until = document.getElementById('rp-until').value;
+  if (since) q.set('since', new Date(since + 'T00:00:00').toISOString());
+  if (until) q.set('until', new Date(new Date(until + 'T00:00:00').getTime() + 864e5).toISOString());
+  document.getElementById('rp-csv').href = '/reports/pages?' + q + '&format=csv';
+  document.getElementById('rp-key').textContent =
+    document.getElementById('rp-by').selectedOptions[0].textContent;
+  const body = document.getElementById('rp-body');
+  try {
+    const r = await fetch('/reports/pages?' + q);
+    if (!r.ok) throw new Error(await r.text());
+    const rows = (await r.json()).rows;
+    let jobs = 0, pages = 0;
+    body.innerHTML = rows.map(row => {
+      jobs += row.jobs; pages += row.pages;
+      return '<tr><td>'+esc(row.key)+'</td><td class="bc">'+row.jobs+'</td><td class="bc">'+row.pages+'</td></tr>';
+    }).join('') + (rows.length ? '<tr><td class="ok">Total</td><td class="bc">'+jobs+'</td><td class="bc">'+pages+'</td></tr>' : '');
+    document.getElementById('rp-empty').hidden = rows.length > 0;
+  } catch(e) {
+    body.innerHTML = '<tr><td class="err" colspan="3">Could not load report: '+esc(e.message)+'</td></tr>';
+  }
+}
+
+// ── Usage statistics ─────────────────────────────────────────
+function openStats() {
+  document.getElementById('stats').hidden = false;
+  loadStats();
+}
+
+function closeStats() {
+  document.getElementById('stats').hidden = true;
+}
+
+// barChart draws one bar per count, scaled to the largest, with failures
+// stacked in red.
+function barChart(el, counts, label) {
+  const top = Math.max(1, ...counts.map(c => c.jobs));
+  el.innerHTML = counts.map(c =>
+    '<div style="height:'+(100*c.jobs/top)+'%;display:flex;flex-direction:column;justify-content:flex-end" title="'+
+      esc(label(c))+': '+c.jobs+' jobs, '+c.pages+' pages, '+c.failed+' failed">'+
+      (c.failed ? '<div class="fail" style="height:'+(100*c.failed/c.jobs)+'%"></div>' : '')+'</div>'
+  ).join('');
+  el.setAttribute('aria-label', counts.filter(c => c.jobs).map(c => label(c)+': '+c.jobs+' jobs').join(', ') || 'No jobs');
+}
+
+async function loadStats() {
+  const days = +document.getElementById('st-days').value;
+  const since = new Date(Date.now() - days * 864e5).toISOString();
+  try {
+    const r = await fetch('/stats?' + new URLSearchParams({since}));
+    if (!r.ok) throw new Error(await r.text());
+    const st = await r.json();
+    const t = st.total;
+    document.getElementById('st-total').textContent = t.jobs + ' jobs, ' + t.pages + ' pages, ' +
+      (100 * t.failure_rate).toFixed(1) + '% failed';
+    barChart(document.getElementById('st-days-chart'), st.days, c => c.key);
+    barChart(document.getElementById('st-hours-chart'), st.hours, c => c.key + ':00');
+    const busiest = st.hours.filter(h => h.jobs).sort((a, b) => b.jobs - a.jobs).slice(0, 3);
+    document.getElementById('st-busiest').textContent = busiest.length
+      ? 'Busiest hours: ' + busiest.map(h => h.key + ':00 (' + h.jobs + ')').join(', ') : '';
+    document.getElementById('st-printers').innerHTML = st.printers.map(p =>
+      '<tr><td>'+esc(p.key)+'</td><td class="bc">'+p.jobs+'</td><td class="bc">'+p.pages+'</td>'+
+      '<td class="bc">'+p.failed+'</td><td class="'+(p.failure_rate > 0.1 ? 'err' : 'bc')+'">'+
+      (100 * p.failure_rate).toFixed(1)+'%</td></tr>'
+    ).join('') || '<tr><td colspan="5" class="empty">No jobs in this range.</td></tr>';
+  } catch(e) {
+    document.getElementById('st-total').textContent = 'Could not load statistics: ' + e.message;
+  }
+}
+
+// ── Paper ────────────────────────────────────────────────────
+let paperLevels = [];
+
+function openPaper() {
+  document.getElementById('paper').hidden = false;
+  loadPaper();
+}
+
+function closePaper() {
+  document.getElementById('paper').hidden = true;
+}
+
+// loadPaper refreshes the low-paper banner and, when open, the paper dialog.
+async function loadPaper() {
+  let levels;
+  try {
+    const r = await fetch('/paper');
+    if (!r.ok) return;
+    levels = await r.json();
+  } catch(e) { return; }
+  const low = levels.filter(l => l.low);
+  document.getElementById('paper-bar').hidden = low.length === 0;
+  document.getElementById('paper-msg').textContent = '📄 Paper low: ' +
+    low.map(l => l.printer + ' (about ' + l.remaining + ' sheets left)').join(', ');
+  document.getElementById('pp-empty').hidden = levels.length > 0;
+  document.getElementById('pp-body').innerHTML = levels.map((l, i) =>
+    '<tr><td>'+esc(l.printer)+'</td><td class="bc">'+l.loaded+'</td><td class="bc">'+l.used+'</td>'+
+    '<td class="'+(l.low ? 'err' : 'ok')+'">'+l.remaining+'</td>'+
+    '<td class="ts">'+(l.refilled_at ? new Date(l.refilled_at).toLocaleDateString() : '—')+'</td>'+
+    '<td class="ed-tools" data-scope="print"><input type="number" min="1" id="pp-n'+i+'" value="'+l.loaded+'" aria-label="Sheets loaded in '+esc(l.printer)+'">'+
+    '<button class="ref-btn" onclick="refillPaper('+i+')">Refilled</button></td></tr>'
+  ).join('');
+  paperLevels = levels;
+}
+
+async function refillPaper(i) {
+  const l = paperLevels[i];
+  const loaded = +document.getElementById('pp-n'+i).value;
+  const r = await fetch('/paper/' + encodeURIComponent(l.printer) + '/reset', {
+    method:'POST',
+    headers:{'Content-Type':'application/json'},
+    body:JSON.stringify({loaded})
+  });
+  if (!r.ok) alert('Could not reset paper count: ' + await r.text());
+  loadPaper();
+}
+loadPaper();
+
+// ── Log level ────────────────────────────────────────────────
+// Debug and trace logging for troubleshooting; the bridge drops back to
+// info by itself after 30 minutes.
+async function loadLogLevel() {
+  try {
+    const r = await fetch('/log-level');
+    if (r.ok) showLogLevel(await r.json());
+  } catch(e) {}
+}
+
+function showLogLevel(s) {
+  const sel = document.getElementById('log-level');
+  sel.value = s.level;
+  sel.title = s.until ? 'Logging at ' + s.level + ' until ' + new Date(s.until).toLocaleTimeString()
+    : 'Log level; debug and trace drop back to info after 30 minutes';
+}
+
+async function changeLogLevel(level) {
+  const r = await fetch('/log-level', {method:'POST', headers:{'Content-Type':'application/json'}, body:JSON.stringify({level})});
+  if (!r.ok) alert('Could not change the log level: ' + await r.text());
+  loadLogLevel();
+}
+loadLogLevel();
+
+// ── Connected clients ────────────────────────────────────────
+function openClients() {
+  document.getElementById('clients').hidden = false;
+  loadClients();
+}
+
+function closeClients() {
+  document.getElementById('clients').hidden = true;
+}
+
+async function loadClients() {
+  const body = document.getElementById('cl-body');
+  try {
+    const r = await fetch('/clients');
+    if (!r.ok) throw new Error(await r.text());
+    const d = await r.json();
+    document.getElementById('cl-mode').textContent = d.station
+      ? 'Station mode: serving the LAN on ' + d.listen
+      : 'Local only (' + d.listen + '). Enable station mode in the config to serve other machines.';
+    body.innerHTML = d.clients.map(c =>
+      '<tr><td>'+esc(c.ip)+(c.local ? ' (this machine)' : '')+'</td>'+
+      '<td class="cl-id">'+esc(c.identity)+'</td>'+
+      '<td class="bc">'+c.requests+'</td>'+
+      '<td>'+new Date(c.last_seen).toLocaleTimeString()+'</td>'+
+      '<td>'+(c.local ? '' : '<button class="ref-btn" data-scope="admin" onclick="clientAction(\''+esc(c.ip)+'\','+!c.blocked+')">'+
+        (c.blocked ? 'Unblock' : 'Block')+'</button>')+'</td></tr>'
+    ).join('');
+    // The User-Agent is whatever the caller sent; set it as a property so
+    // it is never parsed as markup.
+    body.querySelectorAll('.cl-id').forEach((td, i) => { td.title = d.clients[i].user_agent || ''; });
+  } catch(e) {
+    body.innerHTML = '<tr><td class="err" colspan="5">Could not load clients: '+esc(e.message)+'</td></tr>';
+  }
+}
+
+async function clientAction(ip, block) {
+  const r = await fetch('/clients/' + encodeURIComponent(ip) + (block ? '/block' : '/unblock'), {method:'POST'});
+  if (!r.ok) alert('Could not update client: ' + await r.text());
+  loadClients();
+}
+
+// ── Session and guests ───────────────────────────────────────
+// A guest or kiosk session is read-only: the buttons marked data-scope
+// are hidden (dashboard.css) rather than left to fail with 403.
+let scope = 'admin';
+
+async function loadSession() {
+  try {
+    const r = await fetch('/session');
+    if (!r.ok) return;
+    const s = await r.json();
+    scope = s.scope;
+    document.body.dataset.scope = s.scope;
+    if (s.name.startsWith('guest:')) document.title += ' (guest: ' + s.name.slice(6) + ')';
+  } catch(e) {}
+}
+loadSession();
+
+function openGuests() {
+  document.getElementById('guests').hidden = false;
+  document.getElementById('gu-issued').hidden = true;
+  loadGuests();
+}
+
+function closeGuests() {
+  document.getElementById('guests').hidden = true;
+}
+
+async function loadGuests() {
+  const body = document.getElementById('gu-body');
+  try {
+    const r = await fetch('/guests');
+    if (!r.ok) throw new Error(await r.text());
+    const list = await r.json();
+    body.innerHTML = list.length ? list.map(g =>
+      '<tr><td>'+esc(g.name)+'</td>'+
+      '<td>'+new Date(g.created).toLocaleDateString()+'</td>'+
+      '<td>'+new Date(g.expires).toLocaleDateString()+'</td>'+
+      '<td><button class="ref-btn" onclick="revokeGuest(\''+encodeURIComponent(g.name)+'\')">Revoke</button></td></tr>'
+    ).join('') : '<tr><td colspan="4">No guests.</td></tr>';
+  } catch(e) {
+    body.innerHTML = '<tr><td class="err" colspan="4">Could not load guests: '+esc(e.message)+'</td></tr>';
+  }
+}
+
+async function issueGuest() {
+  const name = document.getElementById('gu-name').value.trim();
+  if (!name) return;
+  try {
+    const r = await fetch('/guests', {method:'POST', headers:{'Content-Type':'application/json'},
+      body:JSON.stringify({name, days: +document.getElementById('gu-days').value})});
+    if (!r.ok) throw new Error(await r.text());
+    const g = await r.json();
+    document.getElementById('gu-who').textContent = g.name;
+    document.getElementById('gu-link').value = location.origin + g.link;
+    document.getElementById('gu-issued').hidden = false;
+    document.getElementById('gu-name').value = '';
+  } catch(e) {
+    alert('Could not issue a guest link: ' + e.message);
+  }
+  loadGuests();
+}
+
+async function revokeGuest(name) {
+  if (!confirm('Revoke ' + decodeURIComponent(name) + '\'s access?')) return;
+  const r = await fetch('/guests/' + name, {method:'DELETE'});
+  if (!r.ok) alert('Could not revoke: ' + await r.text());
+  loadGuests();
+}
+
+// ── Alerts ───────────────────────────────────────────────────
+// Chimes and spoken announcements ("Job for Maya finished on Everest")
+// for staff who cannot see a toast. Settings are per browser, since they
+// suit whoever sits at this computer; nothing is announced for the jobs
+// replayed when the dashboard connects.
+const ALERTS_KEY = 'graham-braille-alerts';
+const alertDefaults = {mode:'off', done:true, failed:true, printer:true, voice:'', rate:1, only:''};
+let alerts = loadAlerts(), audioCtx = null;
+
+function loadAlerts() {
+  try { return Object.assign({}, alertDefaults, JSON.parse(localStorage.getItem(ALERTS_KEY) || '{}')); }
+  catch { return Object.assign({}, alertDefaults); }
+}
+
+function openAlerts() {
+  document.getElementById('al-mode').value = alerts.mode;
+  document.getElementById('al-done').checked = alerts.done;
+  document.getElementById('al-failed').checked = alerts.failed;
+  document.getElementById('al-printer').checked = alerts.printer;
+  document.getElementById('al-rate').value = alerts.rate;
+  document.getElementById('al-only').value = alerts.only;
+  listVoices();
+  document.getElementById('alerts').hidden = false;
+}
+
+function closeAlerts() {
+  document.getElementById('alerts').hidden = true;
+}
+
+// listVoices fills the voice menu; browsers load their voices late.
+function listVoices() {
+  const sel = document.getElementById('al-voice');
+  if (!window.speechSynthesis) { sel.disabled = true; return; }
+  sel.innerHTML = '<option value="">Default</option>' + speechSynthesis.getVoices().map(v =>
+    '<option value="'+esc(v.name)+'">'+esc(v.name)+' ('+esc(v.lang)+')</option>').join('');
+  sel.value = alerts.voice;
+}
+if (window.speechSynthesis) speechSynthesis.onvoiceschanged = listVoices;
+
+function saveAlerts() {
+  alerts = {
+    mode: document.getElementById('al-mode').value,
+    done: document.getElementById('al-done').checked,
+    failed: document.getElementById('al-failed').checked,
+    printer: document.getElementById('al-printer').checked,
+    voice: document.getElementById('al-voice').value,
+    rate: Number(document.getElementById('al-rate').value) || 1,
+    only: document.getElementById('al-only').value.trim(),
+  };
+  try { localStorage.setItem(ALERTS_KEY, JSON.stringify(alerts)); } catch { /* private mode */ }
+}
+
+function testAlert() {
+  announce('done', selPrinter || 'the embosser', 'Job for Maya finished on ' + (selPrinter || 'the embosser'));
+}
+
+// jobAlert announces a job that has just finished. prev is the job as the
+// dashboard last saw it, so replayed and already-finished jobs stay quiet.
+function jobAlert(prev, job) {
+  if (!prev || (prev.status !== 'queued' && prev.status !== 'printing') || prev.status === job.status) return;
+  const who = job.student ? 'Job for ' + job.student : 'Job ' + job.id;
+  if (job.status === 'done') announce('done', job.printer, who + ' finished on ' + job.printer);
+  else if (job.status === 'failed' || job.status === 'stuck') announce('failed', job.printer, who + ' failed on ' + job.printer);
+}
+
+// printerAlert announces a printer that has started needing attention,
+// judged against the state its badge last showed.
+function printerAlert(s) {
+  const was = shownStates[s.printer];
+  if (was === undefined || was === s.state) return;
+  if (!['paper_out','paper_jam','door_open','error','offline'].includes(s.state)) return;
+  announce('printer', s.printer, s.printer + ': ' + stateLabels[s.state].toLowerCase() + (s.held ? ', jobs are held' : ''));
+}
+
+// announce chimes and/or speaks text for an event of kind on printer, if
+// this browser's settings ask for it.
+function announce(kind, printer, text) {
+  if (alerts.mode === 'off' || !alerts[kind]) return;
+  if (alerts.only && alerts.only.toLowerCase() !== (printer || '').toLowerCase()) return;
+  if (alerts.mode === 'chime' || alerts.mode === 'both') chime(kind === 'done');
+  if ((alerts.mode === 'speech' || alerts.mode === 'both') && window.speechSynthesis) {
+    const u = new SpeechSynthesisUtterance(text);
+    const v = speechSynthesis.getVoices().find(v => v.name === alerts.voice);
+    if (v) u.voice = v;
+    u.rate = alerts.rate;
+    setTimeout(() => speechSynthesis.speak(u), alerts.mode === 'both' ? 600 : 0);
+  }
+}
+
+// chime plays two rising notes for good news and two falling ones for bad.
+function chime(good) {
+  const AC = window.AudioContext || window.webkitAudioContext;
+  if (!AC) return;
+  audioCtx = audioCtx || new AC();
+  const notes = good ? [660, 880] : [440, 294];
+  notes.forEach((f, i) => {
+    const o = audioCtx.createOscillator(), g = audioCtx.createGain();
+    const t = audioCtx.currentTime + i * 0.22;
+    o.frequency.value = f;
+    g.gain.setValueAtTime(0.25, t);
+    g.gain.exponentialRampToValueAtTime(0.001, t + 0.2);
+    o.connect(g).connect(audioCtx.destination);
+    o.start(t);
+    o.stop(t + 0.2);
+  });
+}
+
+// Browsers only let a page make sound after someone has used it.
+document.addEventListener('pointerdown', () => { if (audioCtx) audioCtx.resume(); });
+document.addEventListener('keydown', () => { if (audioCtx) audioCtx.resume(); });
+
+// ── Keyboard shortcuts ───────────────────────────────────────
+// Single keys, listed by "?", for repetitive troubleshooting and
+// keyboard-only use. They are ignored while typing in a field or with a
+// modifier held, so they never take over the browser's own shortcuts.
+const shortcuts = {
+  'r': () => loadPrinters(),
+  't': () => { if (selPrinter && scope !== 'read') sendTest(); },
+  '/': () => document.getElementById('student-filter').focus(),
+  'l': () => openLatestJob(),
+  '?': () => openShortcuts(),
+};
+
+function openShortcuts() {
+  document.getElementById('shortcuts').hidden = false;
+}
+
+function closeShortcuts() {
+  document.getElementById('shortcuts').hidden = true;
+}
+
+// openLatestJob selects the newest job shown in the log and moves focus
+// to its BRF text.
+function openLatestJob() {
+  const tr = [...document.querySelectorAll('#log-body tr')].find(tr => !tr.hidden);
+  if (!tr) return;
+  selectJob(Number(tr.dataset.id));
+  tr.scrollIntoView({block:'nearest'});
+  const b = document.getElementById('brf-box');
+  if (b.style.display !== 'none') b.focus();
+}
+
+document.addEventListener('keydown', e => {
+  const typing = e.target.matches('input, textarea, select, [contenteditable]');
+  if (typing && e.key === 'Escape' && e.target.id === 'student-filter') e.target.blur();
+  const run = shortcuts[e.key.toLowerCase()];
+  if (run && !typing && !e.ctrlKey && !e.metaKey && !e.altKey && document.querySelector('.overlay:not([hidden])') === null) {
+    e.preventDefault();
+    run();
+    return;
+  }
+  if (e.key === 'Escape' && !document.getElementById('shortcuts').hidden) closeShortcuts();
+  if (e.key === 'Escape' && !document.getElementById('clients').hidden) closeClients();
+  if (e.key === 'Escape' && !document.getElementById('guests').hidden) closeGuests();
+  if (e.key === 'Escape' && !document.getElementById('alerts').hidden) closeAlerts();
+  if (e.key === 'Escape' && !document.getElementById('stats').hidden) closeStats();
+  if (e.key === 'Escape' && !document.getElementById('paper').hidden) closePaper();
+  if (e.key === 'Escape' && !document.getElementById('reports').hidden) closeReports();
+  if (e.key === 'Escape' && !document.getElementById('editor').hidden) closeEditor();
+  if (e.key === 'Escape' && !document.getElementById('picker').hidden) closePagePicker();
+});
+
+// sendConfirmed makes a request that may be a job over the bridge's
+// confirm_pages; send(confirm) makes it, with the confirm value once the
+// teacher has agreed to the estimate. Resolves to the response, or null
+// if the teacher declined.
+async function sendConfirmed(send) {
+  let r = await send('');
+  if (r.status !== 409) return r;
+  const est = await r.json();
+  if (est.error_code !== 'confirmation_required') throw new Error(est.error || 'refused');
+  const time = est.estimated_seconds ? ', about ' + Math.ceil(est.estimated_seconds / 60) + ' min' : '';
+  if (!confirm('This is ' + est.pages + ' pages (' + est.sheets + ' sheets' + time + ') on ' + est.printer + '. Emboss it?')) return null;
+  return send(est.confirm);
+}
+
+async function resendEdited() {
+  const btn = document.getElementById('ed-send');
+  btn.disabled = true; btn.textContent = '⏳ Sending…';
+  try {
+    const r = await sendConfirmed(c => fetch('/jobs/' + selJob + '/resend', {
+      method:'POST',
+      headers:{'Content-Type':'application/json'},
+      body:JSON.stringify({
+        printer: document.getElementById('ed-printer').value.trim(),
+        data: textToBase64(document.getElementById('ed-text').value),
+        confirm: c
+      })
+    }));
+    if (r && !r.ok) throw new Error(await r.text());
+    if (r) closeEditor();
+  } catch(e) {
+    alert('Resend failed: ' + e.message);
+  }
+  btn.disabled = false; btn.textContent = '↻ Resend as New Job';
+}
+
+// ── Page picker ──────────────────────────────────────────────
+// Thumbnails of a stored job's pages, from /jobs/{id}/preview; the ticked
+// ones are embossed again as a new job linked to this one, the usual fix
+// after a page creased or jammed. A failed job starts with the pages it
+// did not finish ticked.
+async function openPagePicker() {
+  const job = jobsById[selJob];
+  if (!job) return;
+  const box = document.getElementById('pk-thumbs');
+  document.getElementById('pk-title').textContent = 'Print pages of job #' + job.id + ' again';
+  box.innerHTML = '<div class="empty">Loading pages…</div>';
+  document.getElementById('picker').hidden = false;
+  try {
+    const r = await fetch('/jobs/' + job.id + '/preview');
+    if (!r.ok) throw new Error(await r.text());
+    const pv = await r.json();
+    const n = pv.pages.length;
+    // Pages are in the order sent, last first for a reversed job.
+    const pages = pv.pages.map((rows, i) => ({page: job.reversed ? n - i : i + 1, rows}))
+      .sort((a, b) => a.page - b.page);
+    const resume = rangePages(selResume, n);
+    box.innerHTML = pages.map(p =>
+      '<label class="thumb"><input type="checkbox" value="'+p.page+'"'+(resume.has(p.page) ? ' checked' : '')+
+      ' onchange="pickChanged()"><pre aria-hidden="true">'+esc(p.rows.join('\n'))+'</pre>'+
+      '<span>Page '+p.page+'</span></label>').join('');
+  } catch(e) {
+    box.innerHTML = '<div class="empty err">Could not load the pages: '+esc(e.message)+'</div>';
+  }
+  pickChanged();
+}
+
+function closePagePicker() {
+  document.getElementById('picker').hidden = true;
+}
+
+// rangePages expands a range such as "5-" or "1-3" to page numbers.
+function rangePages(range, count) {
+  const m = /^(\d+)-(\d*)$/.exec(range || '');
+  const set = new Set();
+  if (m) for (let p = +m[1]; p <= (m[2] ? +m[2] : count); p++) set.add(p);
+  return set;
+}
+
+function pickAll(on) {
+  document.querySelectorAll('#pk-thumbs input').forEach(c => c.checked = on);
+  pickChanged();
+}
+
+// pickedPages returns the ticked pages as a page list, e.g. "3,7,9-12".
+function pickedPages() {
+  const pages = [...document.querySelectorAll('#pk-thumbs input:checked')].map(c => +c.value);
+  const parts = [];
+  for (let i = 0; i < pages.length; i++) {
+    let j = i;
+    while (j + 1 < pages.length && pages[j + 1] === pages[j] + 1) j++;
+    parts.push(j > i ? pages[i] + '-' + pages[j] : String(pages[i]));
+    i = j;
+  }
+  return parts.join(',');
+}
+
+function pickChanged() {
+  const pages = pickedPages();
+  document.getElementById('pk-sel').textContent = pages ? 'Pages ' + pages.replace(/,/g, ', ') : 'No pages ticked';
+  document.getElementById('pk-send').disabled = !pages;
+}
+
+async function printPickedPages() {
+  const pages = pickedPages();
+  if (!pages) return;
+  try {
+    const r = await sendConfirmed(c => fetch('/jobs/' + selJob + '/print?pages=' + encodeURIComponent(pages) +
+      (c ? '&confirm=' + encodeURIComponent(c) : ''), {method:'POST'}));
+    if (!r) return;
+    if (!r.ok) throw new Error(await r.text());
+    closePagePicker();
+  } catch(e) {
+    alert('Printing pages failed: ' + e.message);
+  }
+}
+
+// esc makes text safe in element content and in quoted attributes.
+function esc(s) {
+  return String(s)
+    .replace(/&/g,'&amp;')
+    .replace(/</g,'&lt;')
+    .replace(/>/g,'&gt;')
+    .replace(/"/g,'&quot;')
+    .replace(/'/g,'&#39;');
+}
+
+loadPrinters();
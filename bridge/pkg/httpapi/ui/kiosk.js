@@ -0,0 +1,130 @@
+// Kiosk display (kiosk.go): printers and the live queue, read-only.
+// ?theme=light|high-contrast picks the theme; ?students=1 shows student
+// identifiers, which are hidden by default because the screen is public.
+const params = new URLSearchParams(location.search);
+if (params.get('theme')) document.documentElement.setAttribute('data-theme', params.get('theme'));
+const showStudents = params.get('students') === '1';
+
+const FINISHED_SHOWN = 6;
+const STALE_MS = 45000;
+const stateLabels = {
+  ready: 'Ready', printing: 'Printing', paused: 'Paused', offline: 'Offline',
+  paper_out: 'Out of paper', paper_jam: 'Paper jam', door_open: 'Door open',
+  error: 'Needs attention', not_found: 'Not found',
+};
+const statusLabels = {
+  queued: 'Waiting', printing: 'Embossing', held: 'On hold', waiting: 'Printer unavailable',
+  stuck: 'Stuck in print queue', done: 'Finished', failed: 'Failed', cancelled: 'Cancelled',
+};
+const active = ['printing', 'queued', 'waiting', 'held', 'stuck'];
+
+let jobs = {}, progress = {}, printers = {}, es = null, lastBeat = 0;
+
+function esc(s) {
+  return String(s).replace(/&/g, '&amp;').replace(/</g, '&lt;').replace(/>/g, '&gt;')
+    .replace(/"/g, '&quot;').replace(/'/g, '&#39;');
+}
+
+function connect() {
+  if (es) es.close();
+  es = new EventSource('/log-stream');
+  es.onopen = () => { lastBeat = Date.now(); badge('LIVE', ''); };
+  es.onerror = () => {
+    badge('OFFLINE', 'offline');
+    // A refused stream (the bridge restarted and forgot this kiosk's
+    // session) is not retried by the browser: reload to log in again.
+    if (es.readyState === EventSource.CLOSED) setTimeout(() => location.reload(), 10000);
+  };
+  es.onmessage = ev => {
+    lastBeat = Date.now();
+    const job = JSON.parse(ev.data);
+    jobs[job.id] = job;
+    if (job.status !== 'printing') delete progress[job.id];
+    renderJobs();
+  };
+  es.addEventListener('reset', () => { jobs = {}; progress = {}; renderJobs(); });
+  es.addEventListener('purge', ev => {
+    JSON.parse(ev.data).jobs.forEach(id => delete jobs[id]);
+    renderJobs();
+  });
+  es.addEventListener('progress', ev => {
+    const p = JSON.parse(ev.data);
+    progress[p.job_id] = p;
+    renderJobs();
+  });
+  es.addEventListener('printer', ev => {
+    const s = JSON.parse(ev.data);
+    printers[s.printer] = s;
+    renderPrinters();
+  });
+  es.addEventListener('heartbeat', () => { lastBeat = Date.now(); });
+}
+
+function badge(text, cls) {
+  const b = document.getElementById('badge');
+  b.textContent = text;
+  b.className = 'badge ' + cls;
+}
+
+async function loadPrinters() {
+  try {
+    const list = await fetch('/printers?details=1').then(r => r.json());
+    const next = {};
+    await Promise.all(list.filter(p => !p.members && !p.setup).map(async p => {
+      try {
+        next[p.name] = await fetch('/printers/' + encodeURIComponent(p.name) + '/status').then(r => r.json());
+      } catch (e) {
+        next[p.name] = {printer: p.name};
+      }
+    }));
+    printers = next;
+    renderPrinters();
+  } catch (e) { /* shown as offline by the stream */ }
+}
+
+function renderPrinters() {
+  const ul = document.getElementById('printers');
+  ul.innerHTML = Object.keys(printers).sort().map(name => {
+    const s = printers[name];
+    const label = stateLabels[s.state] || 'Unknown';
+    return '<li><span class="name">' + esc(name) + '</span>' +
+      '<span class="pstate ' + esc(s.state || '') + '">' + label + (s.jobs ? ' · ' + s.jobs + ' queued' : '') + '</span>' +
+      (s.guidance && s.state !== 'ready' && s.state !== 'printing' ? '<span class="hint">' + esc(s.guidance) + '</span>' : '') +
+      '</li>';
+  }).join('');
+}
+
+function jobItem(job) {
+  let state = statusLabels[job.status] || job.status;
+  const p = progress[job.id];
+  if (job.status === 'printing' && p && p.total) {
+    state = 'Page ' + p.page + ' of ' + p.pages + ' (' + Math.floor(p.sent * 100 / p.total) + '%)';
+  }
+  const what = [esc(job.printer), job.pages ? job.pages + ' page' + (job.pages === 1 ? '' : 's') : '',
+    showStudents && job.student ? esc(job.student) : ''].filter(Boolean).join(' · ');
+  return '<li class="' + esc(job.status) + '"><span class="id">#' + job.id + '</span>' +
+    '<span class="what">' + what + '</span><span class="state">' + esc(state) + '</span></li>';
+}
+
+function renderJobs() {
+  const all = Object.values(jobs);
+  const queue = all.filter(j => active.includes(j.status))
+    .sort((a, b) => active.indexOf(a.status) - active.indexOf(b.status) || a.id - b.id);
+  const done = all.filter(j => !active.includes(j.status) && j.status !== 'rejected')
+    .sort((a, b) => b.id - a.id).slice(0, FINISHED_SHOWN);
+  document.getElementById('queue').innerHTML = queue.map(jobItem).join('');
+  document.getElementById('queue-empty').hidden = queue.length > 0;
+  document.getElementById('done').innerHTML = done.map(jobItem).join('');
+}
+
+function tick() {
+  document.getElementById('clock').textContent =
+    new Date().toLocaleTimeString([], {hour: 'numeric', minute: '2-digit'});
+  if (es && es.readyState === EventSource.OPEN && Date.now() - lastBeat > STALE_MS) connect();
+}
+
+connect();
+loadPrinters();
+setInterval(loadPrinters, 5 * 60 * 1000);
+setInterval(tick, 1000);
+tick();
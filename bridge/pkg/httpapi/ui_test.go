@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestUIAssetETag(t *testing.T) {
+	rec := httptest.NewRecorder()
+	serveUIFile(rec, httptest.NewRequest(http.MethodGet, "/ui/dashboard.css", nil), "dashboard.css")
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" || rec.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("first load %d %v", rec.Code, rec.Header())
+	}
+	r := httptest.NewRequest(http.MethodGet, "/ui/dashboard.css", nil)
+	r.Header.Set("If-None-Match", "W/"+etag)
+	rec = httptest.NewRecorder()
+	serveUIFile(rec, r, "dashboard.css")
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("revalidation %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+}
+
+func TestClientJS(t *testing.T) {
+	mux := http.NewServeMux()
+	APIRoutes(mux, core.Store)
+	req := httptest.NewRequest(http.MethodGet, "/client.js", nil)
+	req.Header.Set("Origin", "https://grahamthetvi.github.io") // a module import is a CORS request
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Header().Get("Content-Type"), "javascript") ||
+		rec.Header().Get("Access-Control-Allow-Origin") != "https://grahamthetvi.github.io" {
+		t.Fatalf("GET /client.js: %d %v", rec.Code, rec.Header())
+	}
+	if !strings.Contains(rec.Body.String(), "export class BridgeClient") || rec.Header().Get("ETag") == "" {
+		t.Error("client.js is not the module, or has no ETag")
+	}
+}
@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Shared dashboard state endpoints
+// ---------------------------------------------------------------------------
+
+// handleUIState serves GET and POST /ui-state. A POST changes only the
+// fields it includes.
+func handleUIState(w http.ResponseWriter, r *http.Request) {
+	s := core.CurrentUIState()
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			Printer       *string `json:"printer"`
+			StudentFilter *string `json:"student_filter"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+			http.Error(w, "body must be {\"printer\": …, \"student_filter\": …}", http.StatusBadRequest)
+			return
+		}
+		s = core.UpdateUIState(func(s *core.UIState) {
+			if req.Printer != nil {
+				s.Printer = *req.Printer
+			}
+			if req.StudentFilter != nil {
+				s.StudentFilter = core.ShownStudent(strings.TrimSpace(*req.StudentFilter))
+			}
+		})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+func handleQueuePause(w http.ResponseWriter, r *http.Request)  { setQueuePaused(w, r, true) }
+func handleQueueResume(w http.ResponseWriter, r *http.Request) { setQueuePaused(w, r, false) }
+
+func setQueuePaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s := core.UpdateUIState(func(s *core.UIState) { s.Paused = paused })
+	if paused {
+		log.Printf("job queue paused")
+	} else {
+		log.Printf("job queue resumed")
+		core.JobQueue.WakeAll()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestUIStateChangesNeedPrintScope(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("AppData", home)
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	core.Cfg.Store(&core.Config{Tokens: []core.APIToken{
+		{Name: "para", Token: "read-token", Scope: core.ScopeRead},
+		{Name: "teacher", Token: "print-token", Scope: core.ScopePrint},
+	}})
+	mux := http.NewServeMux()
+	DashboardRoutes(mux, core.NewMemoryStore(10))
+
+	call := func(method, token string) int {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/ui-state", strings.NewReader(`{"student_filter": "ana"}`))
+		r.Header.Set("Authorization", "Bearer "+token)
+		mux.ServeHTTP(rec, r)
+		return rec.Code
+	}
+	if code := call(http.MethodGet, "read-token"); code != http.StatusOK {
+		t.Errorf("reading with a read token: %d", code)
+	}
+	if code := call(http.MethodPost, "read-token"); code != http.StatusForbidden {
+		t.Errorf("changing with a read token: %d", code)
+	}
+	if code := call(http.MethodPost, "print-token"); code != http.StatusOK {
+		t.Errorf("changing with a print token: %d", code)
+	}
+}
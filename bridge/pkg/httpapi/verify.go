@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+// ---------------------------------------------------------------------------
+// Physical verification endpoints
+// ---------------------------------------------------------------------------
+
+func (h jobsAPI) handleJobVerify(w http.ResponseWriter, r *http.Request) {
+	h.setVerified(w, r, true)
+}
+
+func (h jobsAPI) handleJobUnverify(w http.ResponseWriter, r *http.Request) {
+	h.setVerified(w, r, false)
+}
+
+func (h jobsAPI) setVerified(w http.ResponseWriter, r *http.Request, verified bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	src, ok := h.jobFromPath(w, r)
+	if !ok {
+		return
+	}
+	by := identify(r)
+	changed := false
+	e, _ := h.store.Update(src.ID, func(e *core.JobEvent) {
+		switch {
+		case e.Status != core.StatusDone:
+		case verified && e.Verified == nil:
+			e.Verified = &core.Verification{Time: time.Now().UTC(), By: by}
+			changed = true
+		case !verified && e.Verified != nil:
+			e.Verified = nil
+			changed = true
+		}
+	})
+	if e.Status != core.StatusDone {
+		http.Error(w, fmt.Sprintf("job %d is %s; only jobs that embossed can be verified", e.ID, e.Status), http.StatusConflict)
+		return
+	}
+	if changed {
+		if verified {
+			log.Printf("job %d verified by %s", e.ID, by)
+		} else {
+			log.Printf("job %d verification cleared by %s", e.ID, by)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
@@ -0,0 +1,56 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/grahamthetvi/GrahamBrailleWriter/bridge/pkg/core"
+)
+
+func TestJobVerification(t *testing.T) {
+	old := core.Cfg.Load()
+	defer core.Cfg.Store(old)
+	core.Cfg.Store(&core.Config{})
+	s := core.NewMemoryStore(10)
+	done := s.Append(core.JobEvent{Printer: "Tiger", Status: core.StatusDone, Time: time.Now()})
+	failed := s.Append(core.JobEvent{Printer: "Tiger", Status: core.StatusFailed, Time: time.Now()})
+	s.Append(core.JobEvent{Printer: "Tiger", Status: core.StatusDone, Time: time.Now()})
+
+	post := func(id int, action string) int {
+		rec := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/jobs/x/"+action, nil)
+		r.SetPathValue("id", strconv.Itoa(id))
+		jobsAPI{store: s}.setVerified(rec, r, action == "verify")
+		return rec.Code
+	}
+	unverified := func() int {
+		rec := httptest.NewRecorder()
+		jobsAPI{store: s}.handleJobs(rec, httptest.NewRequest(http.MethodGet, "/jobs?verified=no", nil))
+		var resp jobsResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		return len(resp.Jobs)
+	}
+	if n := unverified(); n != 2 {
+		t.Errorf("%d unverified jobs before checking, want 2", n)
+	}
+	if code := post(done.ID, "verify"); code != http.StatusOK {
+		t.Fatalf("verify: %d", code)
+	}
+	if code := post(failed.ID, "verify"); code != http.StatusConflict {
+		t.Errorf("verifying a failed job: %d", code)
+	}
+	if e, _ := s.Get(done.ID); e.Verified == nil || e.Verified.By != "anonymous" {
+		t.Errorf("verified = %+v", e.Verified)
+	}
+	if n := unverified(); n != 1 {
+		t.Errorf("%d unverified jobs after checking one, want 1", n)
+	}
+	post(done.ID, "unverify")
+	if n := unverified(); n != 2 {
+		t.Errorf("%d unverified jobs after unmarking, want 2", n)
+	}
+}
@@ -187,6 +187,23 @@ export async function printBrf(printer: string, rawData: Uint8Array): Promise<vo
 
   if (!res.ok) {
     const body = await res.text().catch(() => '');
-    throw new Error(`Bridge returned ${res.status}: ${body}`);
+    throw new Error(bridgeErrorMessage(res.status, body));
   }
 }
+
+/**
+ * Turn a bridge error response into a message for the user. Failed jobs come
+ * back as JSON with an `error_code` and plain-language `guidance`; other
+ * errors are plain text.
+ */
+function bridgeErrorMessage(status: number, body: string): string {
+  try {
+    const data = JSON.parse(body);
+    if (data && typeof data.guidance === 'string' && data.guidance) {
+      return `${data.guidance} (${data.error_code})`;
+    }
+  } catch {
+    // plain-text error
+  }
+  return `Bridge returned ${status}: ${body}`;
+}